@@ -0,0 +1,527 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"math"
+	"net/http"
+	"sort"
+	"strconv"
+	"time"
+
+	"HMSBackend/hms"
+	"HMSBackend/sqlcdb"
+
+	"github.com/labstack/echo/v4"
+)
+
+// Calibration objectives. NSE and KGE are maximised, the absolute percent
+// bias and peak error minimised.
+const (
+	objectiveNSE         = "nse"
+	objectiveKGE         = "kge"
+	objectivePercentBias = "percent_bias"
+	objectivePeakError   = "peak_error"
+
+	calibrationMultiply = "multiply"
+	calibrationSet      = "set"
+)
+
+// calibrationTrialParams is stored as the parameters of a trial
+type calibrationTrialParams struct {
+	Values    []float64               `json:"values"`
+	Overrides []hms.ParameterOverride `json:"overrides"`
+}
+
+// calibrationTarget is a parameter dimension resolved against the basin: the
+// elements it changes and their current values
+type calibrationTarget struct {
+	CalibrationParameter
+	base map[string]float64
+}
+
+// resolveCalibration checks a calibration request against the model's basin
+// and fills in its defaults and parameter values
+func resolveCalibration(model ModelConfig, req *CalibrationRequest) ([]calibrationTarget, error) {
+	if req.Name == "" {
+		return nil, errors.New("name is required")
+	}
+	switch req.Objective {
+	case "":
+		req.Objective = objectiveNSE
+	case objectiveNSE, objectiveKGE, objectivePercentBias, objectivePeakError:
+	default:
+		return nil, fmt.Errorf("objective must be %s, %s, %s or %s", objectiveNSE, objectiveKGE, objectivePercentBias, objectivePeakError)
+	}
+	if len(req.Observed) == 0 {
+		return nil, errors.New("at least one observed series is required")
+	}
+	if len(req.Parameters) == 0 {
+		return nil, errors.New("at least one parameter is required")
+	}
+
+	basin, err := loadBasinModel(model.ID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load basin model: %w", err)
+	}
+
+	var first, last time.Time
+	for _, obs := range req.Observed {
+		if _, ok := basin.Element(obs.Junction); !ok {
+			return nil, fmt.Errorf("observed: element %q is not in model %s", obs.Junction, model.ID)
+		}
+		if len(obs.Data) < 2 {
+			return nil, fmt.Errorf("observed: %s needs at least two values", obs.Junction)
+		}
+		for _, p := range obs.Data {
+			t, err := parseQueryTime(p.Time)
+			if err != nil {
+				return nil, fmt.Errorf("observed: %s: %w", obs.Junction, err)
+			}
+			if first.IsZero() || t.Before(first) {
+				first = t
+			}
+			if t.After(last) {
+				last = t
+			}
+		}
+	}
+	if req.Start.IsZero() {
+		req.Start = first
+	}
+	if req.End.IsZero() {
+		req.End = last
+	}
+	req.Start, req.End = req.Start.UTC(), req.End.UTC()
+	if !req.End.After(req.Start) {
+		return nil, errors.New("end must be after start")
+	}
+
+	targets := make([]calibrationTarget, len(req.Parameters))
+	trials := 1
+	for i := range req.Parameters {
+		p := &req.Parameters[i]
+		if p.Mode == "" {
+			p.Mode = calibrationMultiply
+		}
+		if p.Mode != calibrationMultiply && p.Mode != calibrationSet {
+			return nil, fmt.Errorf("parameter %s: mode must be %s or %s", p.Parameter, calibrationMultiply, calibrationSet)
+		}
+		if len(p.Values) == 0 {
+			if p.Steps < 2 || p.Max <= p.Min {
+				return nil, fmt.Errorf("parameter %s: values or min < max with at least 2 steps are required", p.Parameter)
+			}
+			for k := 0; k < p.Steps; k++ {
+				p.Values = append(p.Values, p.Min+(p.Max-p.Min)*float64(k)/float64(p.Steps-1))
+			}
+		}
+
+		t := calibrationTarget{CalibrationParameter: *p, base: map[string]float64{}}
+		elements := p.Elements
+		if len(elements) == 0 {
+			for _, e := range basin.Elements {
+				if _, ok := e.Parameters[p.Parameter]; ok {
+					elements = append(elements, e.Name)
+				}
+			}
+		}
+		if len(elements) == 0 {
+			return nil, fmt.Errorf("parameter %s: no element of model %s has it", p.Parameter, model.ID)
+		}
+		for _, name := range elements {
+			e, ok := basin.Element(name)
+			if !ok {
+				return nil, fmt.Errorf("parameter %s: element %q is not in model %s", p.Parameter, name, model.ID)
+			}
+			v, ok := e.Parameters[p.Parameter]
+			if !ok {
+				return nil, fmt.Errorf("parameter %s: %s %s has no such field", p.Parameter, e.Kind, e.Name)
+			}
+			f, err := strconv.ParseFloat(v, 64)
+			if err != nil {
+				return nil, fmt.Errorf("parameter %s: %s has the non-numeric value %q", p.Parameter, e.Name, v)
+			}
+			t.base[e.Name] = f
+		}
+		targets[i] = t
+		trials *= len(p.Values)
+	}
+	if limit := AppConfig.Calibration.MaxTrials; limit > 0 && trials > limit {
+		return nil, fmt.Errorf("%d trials requested, at most %d allowed", trials, limit)
+	}
+	return targets, nil
+}
+
+// calibrationTrials lists every combination of the targets' values, the
+// last parameter varying fastest
+func calibrationTrials(targets []calibrationTarget) [][]float64 {
+	trials := [][]float64{nil}
+	for _, t := range targets {
+		var next [][]float64
+		for _, prefix := range trials {
+			for _, v := range t.Values {
+				next = append(next, append(append([]float64(nil), prefix...), v))
+			}
+		}
+		trials = next
+	}
+	return trials
+}
+
+// trialOverrides turns a trial's values into basin overrides. Every trial
+// sets every calibrated field, so trials computed one after another in the
+// same workspace do not depend on each other.
+func trialOverrides(targets []calibrationTarget, values []float64) []hms.ParameterOverride {
+	var overrides []hms.ParameterOverride
+	for i, t := range targets {
+		elements := make([]string, 0, len(t.base))
+		for name := range t.base {
+			elements = append(elements, name)
+		}
+		sort.Strings(elements)
+		for _, name := range elements {
+			v := values[i]
+			if t.Mode == calibrationMultiply {
+				v *= t.base[name]
+			}
+			overrides = append(overrides, hms.ParameterOverride{
+				Element:   name,
+				Parameter: t.Parameter,
+				Value:     strconv.FormatFloat(math.Round(v*1e4)/1e4, 'f', -1, 64),
+			})
+		}
+	}
+	return overrides
+}
+
+// pairFlows returns the observed values between start and end with the
+// simulated flows linearly interpolated at their times
+func pairFlows(observed ObservedFlowSeries, simulated JunctionFlowSeries, start, end time.Time) ([]float64, []float64, error) {
+	times := make([]time.Time, len(simulated.Data))
+	for i, p := range simulated.Data {
+		t, err := parseTimeString(p.Time)
+		if err != nil {
+			return nil, nil, err
+		}
+		times[i] = t
+	}
+
+	var obs, sim []float64
+	for _, p := range observed.Data {
+		t, err := parseQueryTime(p.Time)
+		if err != nil {
+			return nil, nil, err
+		}
+		if t.Before(start) || t.After(end) {
+			continue
+		}
+		i := sort.Search(len(times), func(i int) bool { return !times[i].Before(t) })
+		if i == len(times) || (i == 0 && !times[0].Equal(t)) {
+			continue // outside the simulation
+		}
+		v := simulated.Data[i].Value
+		if !times[i].Equal(t) {
+			a, b := simulated.Data[i-1], simulated.Data[i]
+			f := float64(t.Sub(times[i-1])) / float64(times[i].Sub(times[i-1]))
+			v = a.Value + (b.Value-a.Value)*f
+		}
+		obs = append(obs, p.Value)
+		sim = append(sim, v)
+	}
+	return obs, sim, nil
+}
+
+// scoreTrial fits the simulated flows to every observed series and returns
+// the fits by junction and the objective averaged over them
+func scoreTrial(req CalibrationRequest, flows *JunctionFlowsFile) (map[string]hms.Fit, float64, error) {
+	byName := make(map[string]JunctionFlowSeries, len(flows.Series))
+	for _, s := range flows.Series {
+		byName[s.Name] = s
+	}
+	fits := make(map[string]hms.Fit, len(req.Observed))
+	var total float64
+	for _, obs := range req.Observed {
+		s, ok := byName[obs.Junction]
+		if !ok {
+			return nil, 0, fmt.Errorf("no simulated flows for %s", obs.Junction)
+		}
+		o, sim, err := pairFlows(obs, s, req.Start, req.End)
+		if err != nil {
+			return nil, 0, fmt.Errorf("%s: %w", obs.Junction, err)
+		}
+		fit, err := hms.GoodnessOfFit(o, sim)
+		if err != nil {
+			return nil, 0, fmt.Errorf("%s: %w", obs.Junction, err)
+		}
+		fits[obs.Junction] = fit
+		switch req.Objective {
+		case objectiveNSE:
+			total += fit.NSE
+		case objectiveKGE:
+			total += fit.KGE
+		case objectivePercentBias:
+			total += math.Abs(fit.PercentBias)
+		case objectivePeakError:
+			total += math.Abs(fit.PeakError)
+		}
+	}
+	return fits, total / float64(len(req.Observed)), nil
+}
+
+// betterScore reports whether score a beats b under the objective
+func betterScore(objective string, a, b float64) bool {
+	if objective == objectiveNSE || objective == objectiveKGE {
+		return a > b
+	}
+	return a < b
+}
+
+// RunCalibration computes every trial of a calibration one after another in
+// a single workspace of the model's historical project, stores each trial's
+// fits and marks the best one
+func RunCalibration(ctx context.Context, queries *sqlcdb.Queries, calibration sqlcdb.Calibration, model ModelConfig, req CalibrationRequest, targets []calibrationTarget) (err error) {
+	bestTrial := sql.NullInt32{}
+	runID := startPipelineRun(ctx, queries, pipelineRunCalibration, map[string]interface{}{
+		"model":          model.ID,
+		"calibration_id": calibration.ID,
+		"objective":      req.Objective,
+	})
+	defer func() {
+		finishPipelineRun(queries, runID, err)
+		finishCalibration(queries, calibration.ID, bestTrial, err)
+	}()
+	if runID != 0 {
+		if err := queries.SetCalibrationRun(ctx, sqlcdb.SetCalibrationRunParams{
+			ID:    calibration.ID,
+			RunID: sql.NullInt32{Int32: runID, Valid: true},
+		}); err != nil {
+			log.Printf("Warning: Failed to link calibration %d to run %d: %v", calibration.ID, runID, err)
+		}
+	}
+
+	ws, err := newHMSWorkspaceIn(hmsJobDir(model, pipelineRunCalibration, runID), model, pipelineRunHistorical)
+	if err != nil {
+		return fmt.Errorf("failed to create workspace: %w", err)
+	}
+	defer func() { ws.close(err != nil, req.KeepWorkspace) }()
+	if err = setControlWindow(ws.Model.ControlFile(pipelineRunHistorical), req.Start, req.End); err != nil {
+		return err
+	}
+
+	trials := calibrationTrials(targets)
+	var best float64
+	for i, values := range trials {
+		trial := i + 1
+		overrides := trialOverrides(targets, values)
+		log.Printf("INFO: Calibration %d: computing trial %d of %d %v", calibration.ID, trial, len(trials), values)
+
+		fits, score, trialErr := runCalibrationTrial(ctx, ws, req, overrides)
+		if ctx.Err() != nil {
+			return fmt.Errorf("calibration stopped after %d of %d trials: %w", i, len(trials), ctx.Err())
+		}
+		params, _ := json.Marshal(calibrationTrialParams{Values: values, Overrides: overrides})
+		row := sqlcdb.InsertCalibrationTrialParams{
+			CalibrationID: calibration.ID,
+			Trial:         int32(trial),
+			Parameters:    params,
+			Status:        pipelineStatusSucceeded,
+			Fits:          json.RawMessage("{}"),
+		}
+		if trialErr != nil {
+			log.Printf("Warning: Calibration %d trial %d failed: %v", calibration.ID, trial, trialErr)
+			row.Status, row.ErrorMessage = pipelineStatusFailed, trialErr.Error()
+		} else {
+			row.Score = sql.NullFloat64{Float64: score, Valid: true}
+			row.Fits, _ = json.Marshal(fits)
+			if !bestTrial.Valid || betterScore(req.Objective, score, best) {
+				bestTrial, best = sql.NullInt32{Int32: int32(trial), Valid: true}, score
+			}
+		}
+		if err := queries.InsertCalibrationTrial(ctx, row); err != nil {
+			log.Printf("Warning: Failed to store calibration %d trial %d: %v", calibration.ID, trial, err)
+		}
+	}
+
+	if !bestTrial.Valid {
+		return fmt.Errorf("all %d trials failed", len(trials))
+	}
+	log.Printf("INFO: Calibration %d completed: best trial %d with %s %.4f", calibration.ID, bestTrial.Int32, req.Objective, best)
+	return nil
+}
+
+// runCalibrationTrial computes one parameter set and scores its flows
+func runCalibrationTrial(ctx context.Context, ws *hmsWorkspace, req CalibrationRequest, overrides []hms.ParameterOverride) (map[string]hms.Fit, float64, error) {
+	if err := ws.compute(ctx, overrides); err != nil {
+		return nil, 0, err
+	}
+	flows, err := ws.extractFlows(ctx)
+	if err != nil {
+		return nil, 0, err
+	}
+	return scoreTrial(req, flows)
+}
+
+// finishCalibration stores the outcome of a calibration
+func finishCalibration(queries *sqlcdb.Queries, id int32, bestTrial sql.NullInt32, runErr error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	params := sqlcdb.FinishCalibrationParams{ID: id, Status: pipelineStatusSucceeded, BestTrial: bestTrial}
+	if runErr != nil {
+		params.Status = pipelineStatusFailed
+		params.ErrorMessage = runErr.Error()
+	}
+	if err := queries.FinishCalibration(ctx, params); err != nil {
+		log.Printf("Warning: Failed to update calibration %d: %v", id, err)
+	}
+}
+
+func calibrationSummary(id int32, name, model, objective, status, errorMessage string, runID, bestTrial sql.NullInt32, createdAt time.Time, finishedAt sql.NullTime) CalibrationSummary {
+	s := CalibrationSummary{
+		ID:        id,
+		Name:      name,
+		Model:     model,
+		Objective: objective,
+		Status:    status,
+		Error:     errorMessage,
+		CreatedAt: createdAt,
+	}
+	if runID.Valid {
+		s.RunID = runID.Int32
+	}
+	if bestTrial.Valid {
+		b := int(bestTrial.Int32)
+		s.BestTrial = &b
+	}
+	if finishedAt.Valid {
+		t := finishedAt.Time
+		s.FinishedAt = &t
+	}
+	return s
+}
+
+// handleStartCalibration validates a calibration request and computes its
+// trials in the background
+func handleStartCalibration(queries *sqlcdb.Queries) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		var req CalibrationRequest
+		if err := c.Bind(&req); err != nil {
+			log.Printf("Error parsing calibration request body: %v", err)
+			return respondWithError(c, http.StatusBadRequest, "Invalid request format")
+		}
+		model, err := GetModel(req.Model)
+		if err != nil {
+			return respondWithError(c, http.StatusBadRequest, err.Error())
+		}
+		targets, err := resolveCalibration(model, &req)
+		if err != nil {
+			return respondWithError(c, http.StatusBadRequest, err.Error())
+		}
+		trials := len(calibrationTrials(targets))
+
+		definition, err := json.Marshal(req)
+		if err != nil {
+			return respondWithError(c, http.StatusInternalServerError, "Failed to encode calibration")
+		}
+		calibration, err := queries.CreateCalibration(c.Request().Context(), sqlcdb.CreateCalibrationParams{
+			Name:       req.Name,
+			Model:      model.ID,
+			Objective:  req.Objective,
+			Definition: definition,
+		})
+		if err != nil {
+			log.Printf("Error recording calibration %s: %v", req.Name, err)
+			return respondWithError(c, http.StatusInternalServerError, "Failed to start calibration")
+		}
+
+		log.Printf("Received calibration request: id=%d, name=%s, model=%s, %d trials by %s",
+			calibration.ID, req.Name, model.ID, trials, req.Objective)
+
+		go func() {
+			// Trials compute one after another, so allow far more than a single run
+			ctx, cancel := context.WithTimeout(context.Background(), 12*time.Hour)
+			defer cancel()
+
+			if err := RunCalibration(ctx, queries, calibration, model, req, targets); err != nil {
+				log.Printf("Calibration %d failed: %v", calibration.ID, err)
+			}
+		}()
+
+		return respondWithJSON(c, http.StatusAccepted, map[string]interface{}{
+			"message": "Calibration started",
+			"status":  "accepted",
+			"id":      calibration.ID,
+			"trials":  trials,
+		})
+	}
+}
+
+// handleListCalibrations lists the calibrations, newest first, optionally of
+// one model (?model=)
+func handleListCalibrations(queries *sqlcdb.Queries) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		rows, err := queries.ListCalibrations(c.Request().Context(), c.QueryParam("model"))
+		if err != nil {
+			log.Printf("Error listing calibrations: %v", err)
+			return respondWithError(c, http.StatusInternalServerError, "Failed to list calibrations")
+		}
+		out := make([]CalibrationSummary, len(rows))
+		for i, r := range rows {
+			out[i] = calibrationSummary(r.ID, r.Name, r.Model, r.Objective, r.Status, r.ErrorMessage, r.RunID, r.BestTrial, r.CreatedAt, r.FinishedAt)
+		}
+		return respondWithJSON(c, http.StatusOK, out)
+	}
+}
+
+// handleGetCalibration returns a calibration with every trial and the best one
+func handleGetCalibration(queries *sqlcdb.Queries) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		id, err := strconv.Atoi(c.Param("id"))
+		if err != nil || id <= 0 {
+			return respondWithError(c, http.StatusBadRequest, "id must be a positive integer")
+		}
+		ctx := c.Request().Context()
+		cal, err := queries.GetCalibration(ctx, int32(id))
+		if errors.Is(err, sql.ErrNoRows) {
+			return respondWithError(c, http.StatusNotFound, "Calibration not found")
+		}
+		if err != nil {
+			log.Printf("Error getting calibration %d: %v", id, err)
+			return respondWithError(c, http.StatusInternalServerError, "Failed to get calibration")
+		}
+		rows, err := queries.ListCalibrationTrials(ctx, cal.ID)
+		if err != nil {
+			log.Printf("Error listing trials of calibration %d: %v", id, err)
+			return respondWithError(c, http.StatusInternalServerError, "Failed to get calibration")
+		}
+
+		out := CalibrationResponse{
+			CalibrationSummary: calibrationSummary(cal.ID, cal.Name, cal.Model, cal.Objective, cal.Status, cal.ErrorMessage, cal.RunID, cal.BestTrial, cal.CreatedAt, cal.FinishedAt),
+			Definition:         cal.Definition,
+			Trials:             make([]CalibrationTrialResult, len(rows)),
+		}
+		for i, r := range rows {
+			t := CalibrationTrialResult{Trial: int(r.Trial), Status: r.Status, Error: r.ErrorMessage}
+			var params calibrationTrialParams
+			if err := json.Unmarshal(r.Parameters, &params); err == nil {
+				t.Values, t.Overrides = params.Values, params.Overrides
+			}
+			if r.Score.Valid {
+				score := r.Score.Float64
+				t.Score = &score
+				if err := json.Unmarshal(r.Fits, &t.Fits); err != nil {
+					log.Printf("Warning: Failed to decode fits of calibration %d trial %d: %v", id, r.Trial, err)
+				}
+			}
+			out.Trials[i] = t
+			if cal.BestTrial.Valid && r.Trial == cal.BestTrial.Int32 {
+				out.Best = &out.Trials[i]
+			}
+		}
+		return respondWithJSON(c, http.StatusOK, out)
+	}
+}
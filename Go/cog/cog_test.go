@@ -0,0 +1,104 @@
+package cog
+
+import (
+	"bytes"
+	"math"
+	"testing"
+)
+
+func testRaster(w, h int) []float32 {
+	values := make([]float32, w*h)
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			values[y*w+x] = float32(x) + float32(y)/1000
+		}
+	}
+	return values
+}
+
+func TestWriteReadRoundTrip(t *testing.T) {
+	const w, h = 700, 350
+	values := testRaster(w, h)
+	values[5] = float32(math.NaN())
+	bounds := [4]float64{-106, 25, -99, 28.5}
+
+	var buf bytes.Buffer
+	if err := Write(&buf, values, w, h, bounds, Options{TileSize: 256, NoData: -9999, Units: "inches"}); err != nil {
+		t.Fatal(err)
+	}
+	f, err := NewReader(bytes.NewReader(buf.Bytes()))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(f.Levels) != 3 {
+		t.Fatalf("got %d levels, want 3 (700x350, 350x175, 175x88)", len(f.Levels))
+	}
+	for i, want := range [][2]int{{700, 350}, {350, 175}, {175, 88}} {
+		if l := f.Levels[i]; l.Width != want[0] || l.Height != want[1] {
+			t.Errorf("level %d is %dx%d, want %dx%d", i, l.Width, l.Height, want[0], want[1])
+		}
+	}
+	for i := range bounds {
+		if math.Abs(f.Bounds[i]-bounds[i]) > 1e-9 {
+			t.Errorf("bounds = %v, want %v", f.Bounds, bounds)
+			break
+		}
+	}
+	if !f.HasNoData || f.NoData != -9999 {
+		t.Errorf("nodata = %v (%v), want -9999", f.NoData, f.HasNoData)
+	}
+	if f.Metadata["units"] != "inches" {
+		t.Errorf("units = %q, want inches", f.Metadata["units"])
+	}
+
+	got, err := f.ReadLevel(0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i, v := range values {
+		if math.IsNaN(float64(v)) {
+			if got[i] != -9999 {
+				t.Fatalf("cell %d = %v, want nodata", i, got[i])
+			}
+			continue
+		}
+		if float32(got[i]) != v {
+			t.Fatalf("cell %d = %v, want %v", i, got[i], v)
+		}
+	}
+
+	// Cell (x=123, y=45) centre
+	lon := bounds[0] + (123.5)*0.01
+	lat := bounds[3] - (45.5)*0.01
+	v, ok, err := f.At(0, lon, lat)
+	if err != nil || !ok || float32(v) != values[45*w+123] {
+		t.Errorf("At = %v, %v, %v; want %v", v, ok, err, values[45*w+123])
+	}
+	if _, ok, _ := f.At(0, bounds[0]+5*0.01+0.005, bounds[3]-0.005); ok {
+		t.Error("At on a nodata cell reported a value")
+	}
+	if _, ok, _ := f.At(0, -120, 27); ok {
+		t.Error("At outside the bounds reported a value")
+	}
+	if lvl := f.LevelFor(0.05); lvl != 2 {
+		t.Errorf("LevelFor(0.05) = %d, want 2", lvl)
+	}
+}
+
+func TestReadWrappedLongitudes(t *testing.T) {
+	// Files written with 0-360 longitudes must answer -180..180 queries
+	values := testRaster(10, 10)
+	var buf bytes.Buffer
+	if err := Write(&buf, values, 10, 10, [4]float64{250, 30, 260, 40}, Options{TileSize: 16}); err != nil {
+		t.Fatal(err)
+	}
+	f, err := NewReader(bytes.NewReader(buf.Bytes()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	v, ok, err := f.At(0, -105.5, 35.5) // 254.5E
+	if err != nil || !ok || float32(v) != values[4*10+4] {
+		t.Errorf("At = %v, %v, %v; want %v", v, ok, err, values[44])
+	}
+}
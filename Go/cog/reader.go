@@ -0,0 +1,617 @@
+// Package cog reads and writes the single-band, georeferenced (EPSG:4326)
+// Cloud Optimized GeoTIFFs used for the precipitation rasters.
+//
+// The reader handles classic and BigTIFF files, tiled or stripped layouts,
+// no/deflate compression, horizontal and floating point predictors and the
+// usual integer and float sample formats. Overviews are exposed as extra levels.
+package cog
+
+import (
+	"bytes"
+	"compress/zlib"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"strings"
+	"sync"
+)
+
+// TIFF tags used by the reader and writer.
+const (
+	tagNewSubfileType  = 254
+	tagImageWidth      = 256
+	tagImageLength     = 257
+	tagBitsPerSample   = 258
+	tagCompression     = 259
+	tagPhotometric     = 262
+	tagStripOffsets    = 273
+	tagSamplesPerPixel = 277
+	tagRowsPerStrip    = 278
+	tagStripByteCounts = 279
+	tagPlanarConfig    = 284
+	tagPredictor       = 317
+	tagTileWidth       = 322
+	tagTileLength      = 323
+	tagTileOffsets     = 324
+	tagTileByteCounts  = 325
+	tagSampleFormat    = 339
+	tagPixelScale      = 33550
+	tagTiepoint        = 33922
+	tagGeoKeyDirectory = 34735
+	tagGeoDoubleParams = 34736
+	tagGeoASCIIParams  = 34737
+	tagGDALMetadata    = 42112
+	tagGDALNoData      = 42113
+)
+
+const (
+	compressionNone       = 1
+	compressionDeflate    = 8
+	compressionDeflateOld = 32946
+
+	sampleUint  = 1
+	sampleInt   = 2
+	sampleFloat = 3
+)
+
+// Level is one resolution of the raster: the full image or an overview.
+type Level struct {
+	Width, Height         int
+	TileWidth, TileHeight int // strips are treated as full-width tiles
+
+	offsets, counts []uint64
+	tilesAcross     int
+}
+
+// File is an open GeoTIFF. It is safe for concurrent use.
+type File struct {
+	Levels []Level
+
+	// Bounds is west, south, east, north of the full image in degrees.
+	Bounds    [4]float64
+	NoData    float64
+	HasNoData bool
+	// Metadata holds GDAL metadata items (e.g. "units") when present.
+	Metadata map[string]string
+
+	r            io.ReaderAt
+	closer       io.Closer
+	order        binary.ByteOrder
+	bits         int
+	sampleFormat int
+	compression  int
+	predictor    int
+
+	mu    sync.Mutex
+	cache map[tileKey][]float64
+	lru   []tileKey
+}
+
+type tileKey struct{ level, tile int }
+
+// maxCachedTiles bounds the decoded tile cache per file.
+const maxCachedTiles = 64
+
+// Open opens a GeoTIFF file. Close it when done.
+func Open(path string) (*File, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	c, err := NewReader(f)
+	if err != nil {
+		f.Close()
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	c.closer = f
+	return c, nil
+}
+
+// Close releases the underlying file.
+func (f *File) Close() error {
+	if f.closer != nil {
+		return f.closer.Close()
+	}
+	return nil
+}
+
+// NewReader parses the TIFF structure from r.
+func NewReader(r io.ReaderAt) (*File, error) {
+	var hdr [16]byte
+	if _, err := r.ReadAt(hdr[:8], 0); err != nil {
+		return nil, fmt.Errorf("cog: reading header: %w", err)
+	}
+	f := &File{r: r, cache: map[tileKey][]float64{}}
+	switch string(hdr[:2]) {
+	case "II":
+		f.order = binary.LittleEndian
+	case "MM":
+		f.order = binary.BigEndian
+	default:
+		return nil, errors.New("cog: not a TIFF file")
+	}
+
+	big := false
+	var next uint64
+	switch f.order.Uint16(hdr[2:]) {
+	case 42:
+		next = uint64(f.order.Uint32(hdr[4:]))
+	case 43:
+		big = true
+		if _, err := r.ReadAt(hdr[:16], 0); err != nil {
+			return nil, fmt.Errorf("cog: reading BigTIFF header: %w", err)
+		}
+		next = f.order.Uint64(hdr[8:])
+	default:
+		return nil, errors.New("cog: unsupported TIFF version")
+	}
+
+	first := true
+	for next != 0 {
+		tags, n, err := f.readIFD(next, big)
+		if err != nil {
+			return nil, err
+		}
+		next = n
+		if first {
+			if err := f.parseMain(tags); err != nil {
+				return nil, err
+			}
+			first = false
+		} else if len(tags[tagNewSubfileType]) > 0 && tags[tagNewSubfileType][0]&4 != 0 {
+			continue // transparency mask
+		}
+		lvl, err := parseLevel(tags)
+		if err != nil {
+			return nil, err
+		}
+		f.Levels = append(f.Levels, lvl)
+	}
+	if len(f.Levels) == 0 {
+		return nil, errors.New("cog: no images in file")
+	}
+	return f, nil
+}
+
+// ifdTags maps a tag to its values as decoded by decodeValues.
+type ifdTags map[uint16][]uint64
+
+// readIFD reads one image file directory, returning its tags and the next IFD offset.
+func (f *File) readIFD(off uint64, big bool) (ifdTags, uint64, error) {
+	countSize, entrySize, offSize := 2, 12, 4
+	if big {
+		countSize, entrySize, offSize = 8, 20, 8
+	}
+	buf := make([]byte, countSize)
+	if _, err := f.r.ReadAt(buf, int64(off)); err != nil {
+		return nil, 0, fmt.Errorf("cog: reading IFD: %w", err)
+	}
+	var n uint64
+	if big {
+		n = f.order.Uint64(buf)
+	} else {
+		n = uint64(f.order.Uint16(buf))
+	}
+	if n > 4096 {
+		return nil, 0, errors.New("cog: implausible IFD entry count")
+	}
+	body := make([]byte, int(n)*entrySize+offSize)
+	if _, err := f.r.ReadAt(body, int64(off)+int64(countSize)); err != nil {
+		return nil, 0, fmt.Errorf("cog: reading IFD entries: %w", err)
+	}
+
+	tags := ifdTags{}
+	for i := 0; i < int(n); i++ {
+		e := body[i*entrySize:]
+		tag := f.order.Uint16(e[0:])
+		typ := f.order.Uint16(e[2:])
+		var count uint64
+		var valField []byte
+		if big {
+			count = f.order.Uint64(e[4:])
+			valField = e[12:20]
+		} else {
+			count = uint64(f.order.Uint32(e[4:]))
+			valField = e[8:12]
+		}
+		size := typeSize(typ)
+		if size == 0 {
+			continue
+		}
+		raw := valField[:0]
+		total := uint64(size) * count
+		if total <= uint64(len(valField)) {
+			raw = valField[:total]
+		} else {
+			var dataOff uint64
+			if big {
+				dataOff = f.order.Uint64(valField)
+			} else {
+				dataOff = uint64(f.order.Uint32(valField))
+			}
+			raw = make([]byte, total)
+			if _, err := f.r.ReadAt(raw, int64(dataOff)); err != nil {
+				return nil, 0, fmt.Errorf("cog: reading tag %d: %w", tag, err)
+			}
+		}
+		tags[tag] = decodeValues(f.order, typ, raw, count)
+	}
+
+	tail := body[int(n)*entrySize:]
+	var next uint64
+	if big {
+		next = f.order.Uint64(tail)
+	} else {
+		next = uint64(f.order.Uint32(tail))
+	}
+	return tags, next, nil
+}
+
+// typeSize returns the byte size of a TIFF field type.
+func typeSize(typ uint16) int {
+	switch typ {
+	case 1, 2, 6, 7: // BYTE, ASCII, SBYTE, UNDEFINED
+		return 1
+	case 3, 8: // SHORT, SSHORT
+		return 2
+	case 4, 9, 11: // LONG, SLONG, FLOAT
+		return 4
+	case 5, 10, 12, 16, 17, 18: // RATIONAL, SRATIONAL, DOUBLE, LONG8, SLONG8, IFD8
+		return 8
+	}
+	return 0
+}
+
+// decodeValues converts raw tag bytes to uint64s. DOUBLE values are stored as
+// their IEEE bits; BYTE/ASCII values are stored one per byte.
+func decodeValues(order binary.ByteOrder, typ uint16, raw []byte, count uint64) []uint64 {
+	out := make([]uint64, count)
+	for i := range out {
+		switch typ {
+		case 1, 2, 6, 7:
+			out[i] = uint64(raw[i])
+		case 3, 8:
+			out[i] = uint64(order.Uint16(raw[i*2:]))
+		case 4, 9, 11:
+			out[i] = uint64(order.Uint32(raw[i*4:]))
+		default:
+			out[i] = order.Uint64(raw[i*8:])
+		}
+	}
+	return out
+}
+
+func tagString(v []uint64) string {
+	b := make([]byte, 0, len(v))
+	for _, c := range v {
+		if c == 0 {
+			break
+		}
+		b = append(b, byte(c))
+	}
+	return string(b)
+}
+
+func tagFloats(v []uint64) []float64 {
+	out := make([]float64, len(v))
+	for i, bits := range v {
+		out[i] = math.Float64frombits(bits)
+	}
+	return out
+}
+
+func first(v []uint64, def uint64) uint64 {
+	if len(v) == 0 {
+		return def
+	}
+	return v[0]
+}
+
+// parseMain reads the sample layout and georeferencing from the first IFD.
+func (f *File) parseMain(t ifdTags) error {
+	if spp := first(t[tagSamplesPerPixel], 1); spp != 1 {
+		return fmt.Errorf("cog: %d samples per pixel, only single-band images are supported", spp)
+	}
+	f.bits = int(first(t[tagBitsPerSample], 1))
+	f.sampleFormat = int(first(t[tagSampleFormat], sampleUint))
+	f.compression = int(first(t[tagCompression], compressionNone))
+	f.predictor = int(first(t[tagPredictor], 1))
+	switch f.compression {
+	case compressionNone, compressionDeflate, compressionDeflateOld:
+	default:
+		return fmt.Errorf("cog: unsupported compression %d", f.compression)
+	}
+	switch {
+	case f.sampleFormat == sampleFloat && (f.bits == 32 || f.bits == 64):
+	case f.sampleFormat != sampleFloat && (f.bits == 8 || f.bits == 16 || f.bits == 32):
+	default:
+		return fmt.Errorf("cog: unsupported sample format %d with %d bits", f.sampleFormat, f.bits)
+	}
+
+	scale := tagFloats(t[tagPixelScale])
+	tie := tagFloats(t[tagTiepoint])
+	if len(scale) < 2 || len(tie) < 6 {
+		return errors.New("cog: file is not georeferenced (missing pixel scale or tiepoint)")
+	}
+	w := float64(first(t[tagImageWidth], 0))
+	h := float64(first(t[tagImageLength], 0))
+	west := tie[3] - tie[0]*scale[0]
+	north := tie[4] + tie[1]*scale[1]
+	f.Bounds = [4]float64{west, north - h*scale[1], west + w*scale[0], north}
+
+	if nd := tagString(t[tagGDALNoData]); nd != "" {
+		var v float64
+		if _, err := fmt.Sscan(nd, &v); err == nil {
+			f.NoData, f.HasNoData = v, true
+		}
+	}
+	f.Metadata = parseGDALMetadata(tagString(t[tagGDALMetadata]))
+	return nil
+}
+
+func parseLevel(t ifdTags) (Level, error) {
+	l := Level{
+		Width:  int(first(t[tagImageWidth], 0)),
+		Height: int(first(t[tagImageLength], 0)),
+	}
+	if l.Width == 0 || l.Height == 0 {
+		return l, errors.New("cog: image has no size")
+	}
+	if len(t[tagTileOffsets]) > 0 {
+		l.TileWidth = int(first(t[tagTileWidth], 0))
+		l.TileHeight = int(first(t[tagTileLength], 0))
+		l.offsets, l.counts = t[tagTileOffsets], t[tagTileByteCounts]
+	} else {
+		l.TileWidth = l.Width
+		l.TileHeight = int(first(t[tagRowsPerStrip], uint64(l.Height)))
+		if l.TileHeight > l.Height {
+			l.TileHeight = l.Height
+		}
+		l.offsets, l.counts = t[tagStripOffsets], t[tagStripByteCounts]
+	}
+	if l.TileWidth == 0 || l.TileHeight == 0 || len(l.offsets) == 0 || len(l.offsets) != len(l.counts) {
+		return l, errors.New("cog: missing tile or strip layout")
+	}
+	l.tilesAcross = (l.Width + l.TileWidth - 1) / l.TileWidth
+	tilesDown := (l.Height + l.TileHeight - 1) / l.TileHeight
+	if len(l.offsets) < l.tilesAcross*tilesDown {
+		return l, errors.New("cog: too few tile offsets for image size")
+	}
+	return l, nil
+}
+
+// parseGDALMetadata extracts <Item name="x">value</Item> pairs for band or
+// dataset scope. It is deliberately forgiving; unknown markup is ignored.
+func parseGDALMetadata(s string) map[string]string {
+	out := map[string]string{}
+	for {
+		i := strings.Index(s, `<Item name="`)
+		if i < 0 {
+			return out
+		}
+		s = s[i+len(`<Item name="`):]
+		q := strings.IndexByte(s, '"')
+		if q < 0 {
+			return out
+		}
+		name := s[:q]
+		gt := strings.IndexByte(s, '>')
+		end := strings.Index(s, "</Item>")
+		if gt < 0 || end < gt {
+			return out
+		}
+		out[name] = s[gt+1 : end]
+		s = s[end:]
+	}
+}
+
+// PixelSize returns the size of one pixel of level i in degrees (x, y).
+func (f *File) PixelSize(i int) (float64, float64) {
+	l := f.Levels[i]
+	return (f.Bounds[2] - f.Bounds[0]) / float64(l.Width), (f.Bounds[3] - f.Bounds[1]) / float64(l.Height)
+}
+
+// LevelFor returns the coarsest level whose pixels are no larger than res
+// degrees, falling back to the full resolution image.
+func (f *File) LevelFor(res float64) int {
+	best := 0
+	for i := range f.Levels {
+		if dx, _ := f.PixelSize(i); dx <= res {
+			best = i
+		}
+	}
+	return best
+}
+
+// At returns the value of level i at lon/lat and false when the point is off
+// the image or nodata. Longitudes are wrapped to the image's range so files
+// written with 0-360 longitudes work with -180..180 queries.
+func (f *File) At(i int, lon, lat float64) (float64, bool, error) {
+	for lon < f.Bounds[0] {
+		lon += 360
+	}
+	for lon >= f.Bounds[0]+360 {
+		lon -= 360
+	}
+	l := &f.Levels[i]
+	dx, dy := f.PixelSize(i)
+	col := int(math.Floor((lon - f.Bounds[0]) / dx))
+	row := int(math.Floor((f.Bounds[3] - lat) / dy))
+	if col < 0 || row < 0 || col >= l.Width || row >= l.Height {
+		return 0, false, nil
+	}
+	tile, err := f.tile(i, (row/l.TileHeight)*l.tilesAcross+col/l.TileWidth)
+	if err != nil {
+		return 0, false, err
+	}
+	v := tile[(row%l.TileHeight)*l.TileWidth+col%l.TileWidth]
+	if math.IsNaN(v) || (f.HasNoData && v == f.NoData) {
+		return 0, false, nil
+	}
+	return v, true, nil
+}
+
+// ReadLevel decodes a whole level into a row-major slice (north-up).
+func (f *File) ReadLevel(i int) ([]float64, error) {
+	l := &f.Levels[i]
+	out := make([]float64, l.Width*l.Height)
+	for ty := 0; ty*l.TileHeight < l.Height; ty++ {
+		for tx := 0; tx < l.tilesAcross; tx++ {
+			tile, err := f.decodeTile(i, ty*l.tilesAcross+tx)
+			if err != nil {
+				return nil, err
+			}
+			for r := 0; r < l.TileHeight && ty*l.TileHeight+r < l.Height; r++ {
+				for c := 0; c < l.TileWidth && tx*l.TileWidth+c < l.Width; c++ {
+					out[(ty*l.TileHeight+r)*l.Width+tx*l.TileWidth+c] = tile[r*l.TileWidth+c]
+				}
+			}
+		}
+	}
+	return out, nil
+}
+
+// tile returns a decoded tile from the cache, decoding it on a miss.
+func (f *File) tile(level, index int) ([]float64, error) {
+	key := tileKey{level, index}
+	f.mu.Lock()
+	if t, ok := f.cache[key]; ok {
+		f.mu.Unlock()
+		return t, nil
+	}
+	f.mu.Unlock()
+
+	t, err := f.decodeTile(level, index)
+	if err != nil {
+		return nil, err
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if _, ok := f.cache[key]; !ok {
+		if len(f.lru) >= maxCachedTiles {
+			delete(f.cache, f.lru[0])
+			f.lru = f.lru[1:]
+		}
+		f.cache[key] = t
+		f.lru = append(f.lru, key)
+	}
+	return t, nil
+}
+
+// decodeTile reads, decompresses and unpredicts one tile (or strip).
+func (f *File) decodeTile(level, index int) ([]float64, error) {
+	l := &f.Levels[level]
+	n := l.TileWidth * l.TileHeight
+	bps := f.bits / 8
+	out := make([]float64, n)
+	if l.counts[index] == 0 {
+		// Sparse tile: GDAL leaves empty tiles unwritten
+		fill := 0.0
+		if f.HasNoData {
+			fill = f.NoData
+		}
+		for k := range out {
+			out[k] = fill
+		}
+		return out, nil
+	}
+
+	raw := make([]byte, l.counts[index])
+	if _, err := f.r.ReadAt(raw, int64(l.offsets[index])); err != nil {
+		return nil, fmt.Errorf("cog: reading tile %d: %w", index, err)
+	}
+	data := raw
+	if f.compression != compressionNone {
+		zr, err := zlib.NewReader(bytes.NewReader(raw))
+		if err != nil {
+			return nil, fmt.Errorf("cog: tile %d: %w", index, err)
+		}
+		data = make([]byte, n*bps)
+		if _, err := io.ReadFull(zr, data); err != nil {
+			return nil, fmt.Errorf("cog: inflating tile %d: %w", index, err)
+		}
+	}
+	if len(data) < n*bps {
+		return nil, fmt.Errorf("cog: tile %d holds %d bytes, want %d", index, len(data), n*bps)
+	}
+
+	switch f.predictor {
+	case 1:
+	case 2:
+		undoHorizontal(data, l.TileWidth, l.TileHeight, bps, f.order)
+	case 3:
+		data = undoFloatPredictor(data, l.TileWidth, l.TileHeight, bps)
+	default:
+		return nil, fmt.Errorf("cog: unsupported predictor %d", f.predictor)
+	}
+	// The floating point predictor leaves samples big-endian.
+	order := f.order
+	if f.predictor == 3 {
+		order = binary.BigEndian
+	}
+
+	for k := range out {
+		b := data[k*bps:]
+		switch {
+		case f.sampleFormat == sampleFloat && bps == 4:
+			out[k] = float64(math.Float32frombits(order.Uint32(b)))
+		case f.sampleFormat == sampleFloat:
+			out[k] = math.Float64frombits(order.Uint64(b))
+		case bps == 1 && f.sampleFormat == sampleInt:
+			out[k] = float64(int8(b[0]))
+		case bps == 1:
+			out[k] = float64(b[0])
+		case bps == 2 && f.sampleFormat == sampleInt:
+			out[k] = float64(int16(order.Uint16(b)))
+		case bps == 2:
+			out[k] = float64(order.Uint16(b))
+		case f.sampleFormat == sampleInt:
+			out[k] = float64(int32(order.Uint32(b)))
+		default:
+			out[k] = float64(order.Uint32(b))
+		}
+	}
+	return out, nil
+}
+
+// undoHorizontal reverses TIFF predictor 2 (integer horizontal differencing).
+func undoHorizontal(data []byte, w, h, bps int, order binary.ByteOrder) {
+	for r := 0; r < h; r++ {
+		row := data[r*w*bps : (r+1)*w*bps]
+		for c := 1; c < w; c++ {
+			p, q := row[(c-1)*bps:], row[c*bps:]
+			switch bps {
+			case 1:
+				q[0] += p[0]
+			case 2:
+				order.PutUint16(q, order.Uint16(q)+order.Uint16(p))
+			case 4:
+				order.PutUint32(q, order.Uint32(q)+order.Uint32(p))
+			}
+		}
+	}
+}
+
+// undoFloatPredictor reverses TIFF predictor 3: bytes of each row are
+// differenced and then split into planes, most significant byte first.
+func undoFloatPredictor(data []byte, w, h, bps int) []byte {
+	out := make([]byte, len(data))
+	rowLen := w * bps
+	for r := 0; r < h; r++ {
+		row := data[r*rowLen : (r+1)*rowLen]
+		for i := 1; i < rowLen; i++ {
+			row[i] += row[i-1]
+		}
+		dst := out[r*rowLen : (r+1)*rowLen]
+		for c := 0; c < w; c++ {
+			for b := 0; b < bps; b++ {
+				dst[c*bps+b] = row[b*w+c]
+			}
+		}
+	}
+	return out
+}
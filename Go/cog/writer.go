@@ -0,0 +1,343 @@
+package cog
+
+import (
+	"bytes"
+	"compress/zlib"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"html"
+	"io"
+	"math"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+)
+
+// Options controls how a raster is written.
+type Options struct {
+	TileSize int     // tile width and height, default 512
+	NoData   float64 // value written for NaN cells
+	Units    string  // stored as GDAL band metadata, e.g. "inches"
+}
+
+// Write encodes a single-band float32 raster as a Cloud Optimized GeoTIFF.
+// values are row-major, north-up, with NaN marking missing cells. bounds is
+// west, south, east, north in EPSG:4326 degrees.
+//
+// The file holds the full image plus averaged overviews (halving until the
+// image fits in one tile). All IFDs come first and tile data is ordered from
+// the smallest overview to the full image, as the COG layout expects.
+func Write(w io.Writer, values []float32, width, height int, bounds [4]float64, opts Options) error {
+	if width <= 0 || height <= 0 || len(values) != width*height {
+		return errors.New("cog: values do not match width x height")
+	}
+	if opts.TileSize <= 0 {
+		opts.TileSize = 512
+	}
+	if opts.TileSize%16 != 0 {
+		return errors.New("cog: tile size must be a multiple of 16")
+	}
+
+	nodata := float32(opts.NoData)
+	full := make([]float32, len(values))
+	for i, v := range values {
+		if math.IsNaN(float64(v)) {
+			v = nodata
+		}
+		full[i] = v
+	}
+
+	type level struct {
+		w, h  int
+		tiles [][]byte
+	}
+	var levels []level
+	data, lw, lh := full, width, height
+	for {
+		tiles, err := encodeTiles(data, lw, lh, opts.TileSize)
+		if err != nil {
+			return err
+		}
+		levels = append(levels, level{lw, lh, tiles})
+		if lw <= opts.TileSize && lh <= opts.TileSize {
+			break
+		}
+		data, lw, lh = downsample(data, lw, lh, nodata)
+	}
+
+	// Build IFDs with placeholder tile offsets to learn their sizes.
+	pixelX := (bounds[2] - bounds[0]) / float64(width)
+	pixelY := (bounds[3] - bounds[1]) / float64(height)
+	ifds := make([]*ifdBuilder, len(levels))
+	for i, l := range levels {
+		b := &ifdBuilder{}
+		subfile := uint32(0)
+		if i > 0 {
+			subfile = 1 // reduced resolution image
+		}
+		counts := make([]uint32, len(l.tiles))
+		for t, tile := range l.tiles {
+			counts[t] = uint32(len(tile))
+		}
+		b.long(tagNewSubfileType, subfile)
+		b.long(tagImageWidth, uint32(l.w))
+		b.long(tagImageLength, uint32(l.h))
+		b.short(tagBitsPerSample, 32)
+		b.short(tagCompression, compressionDeflate)
+		b.short(tagPhotometric, 1) // BlackIsZero
+		b.short(tagSamplesPerPixel, 1)
+		b.short(tagPlanarConfig, 1)
+		b.short(tagPredictor, 1)
+		b.short(tagTileWidth, uint16(opts.TileSize))
+		b.short(tagTileLength, uint16(opts.TileSize))
+		b.longs(tagTileOffsets, make([]uint32, len(l.tiles)))
+		b.longs(tagTileByteCounts, counts)
+		b.short(tagSampleFormat, sampleFloat)
+		if i == 0 {
+			b.doubles(tagPixelScale, []float64{pixelX, pixelY, 0})
+			b.doubles(tagTiepoint, []float64{0, 0, 0, bounds[0], bounds[3], 0})
+			b.shorts(tagGeoKeyDirectory, []uint16{
+				1, 1, 0, 4, // version 1.1.0, 4 keys
+				1024, 0, 1, 2, // GTModelType = geographic
+				1025, 0, 1, 1, // GTRasterType = PixelIsArea
+				2048, 0, 1, 4326, // GeographicType = WGS 84
+				2054, 0, 1, 9102, // GeogAngularUnits = degree
+			})
+			if opts.Units != "" {
+				b.ascii(tagGDALMetadata, `<GDALMetadata><Item name="units" sample="0" role="unittype">`+
+					html.EscapeString(opts.Units)+`</Item></GDALMetadata>`)
+			}
+			b.ascii(tagGDALNoData, strconv.FormatFloat(float64(nodata), 'g', -1, 32))
+		}
+		ifds[i] = b
+	}
+
+	// Lay out: header, IFDs (each followed by its out-of-line values), tile data.
+	offset := uint32(8)
+	ifdOffsets := make([]uint32, len(ifds))
+	for i, b := range ifds {
+		ifdOffsets[i] = offset
+		offset += uint32(b.size())
+	}
+	tileOffsets := make([][]uint32, len(levels))
+	for i := len(levels) - 1; i >= 0; i-- {
+		tileOffsets[i] = make([]uint32, len(levels[i].tiles))
+		for t, tile := range levels[i].tiles {
+			tileOffsets[i][t] = offset
+			offset += uint32(len(tile))
+		}
+	}
+	if uint64(offset) > math.MaxUint32 {
+		return errors.New("cog: raster too large for classic TIFF")
+	}
+
+	var buf bytes.Buffer
+	buf.WriteString("II")
+	binary.Write(&buf, binary.LittleEndian, uint16(42))
+	binary.Write(&buf, binary.LittleEndian, ifdOffsets[0])
+	for i, b := range ifds {
+		b.longs(tagTileOffsets, tileOffsets[i])
+		next := uint32(0)
+		if i+1 < len(ifds) {
+			next = ifdOffsets[i+1]
+		}
+		buf.Write(b.encode(ifdOffsets[i], next))
+	}
+	if _, err := w.Write(buf.Bytes()); err != nil {
+		return err
+	}
+	for i := len(levels) - 1; i >= 0; i-- {
+		for _, tile := range levels[i].tiles {
+			if _, err := w.Write(tile); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// WriteFile writes the raster to path atomically (via a temporary file in the
+// same directory), so readers never observe a partially written COG.
+func WriteFile(path string, values []float32, width, height int, bounds [4]float64, opts Options) error {
+	tmp, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".*.tmp")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if err := Write(tmp, values, width, height, bounds, opts); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
+
+// encodeTiles splits a level into deflated tiles, padding edge tiles.
+func encodeTiles(values []float32, w, h, size int) ([][]byte, error) {
+	across := (w + size - 1) / size
+	down := (h + size - 1) / size
+	tiles := make([][]byte, 0, across*down)
+	raw := make([]byte, size*size*4)
+	for ty := 0; ty < down; ty++ {
+		for tx := 0; tx < across; tx++ {
+			for i := range raw {
+				raw[i] = 0
+			}
+			for r := 0; r < size; r++ {
+				y := ty*size + r
+				if y >= h {
+					break
+				}
+				for c := 0; c < size; c++ {
+					x := tx*size + c
+					if x >= w {
+						break
+					}
+					binary.LittleEndian.PutUint32(raw[(r*size+c)*4:], math.Float32bits(values[y*w+x]))
+				}
+			}
+			var out bytes.Buffer
+			zw, err := zlib.NewWriterLevel(&out, zlib.DefaultCompression)
+			if err != nil {
+				return nil, err
+			}
+			if _, err := zw.Write(raw); err != nil {
+				return nil, err
+			}
+			if err := zw.Close(); err != nil {
+				return nil, fmt.Errorf("cog: compressing tile: %w", err)
+			}
+			tiles = append(tiles, out.Bytes())
+		}
+	}
+	return tiles, nil
+}
+
+// downsample halves a level, averaging the valid cells of each 2x2 block.
+func downsample(values []float32, w, h int, nodata float32) ([]float32, int, int) {
+	nw, nh := (w+1)/2, (h+1)/2
+	out := make([]float32, nw*nh)
+	for y := 0; y < nh; y++ {
+		for x := 0; x < nw; x++ {
+			var sum float64
+			n := 0
+			for dy := 0; dy < 2; dy++ {
+				for dx := 0; dx < 2; dx++ {
+					sx, sy := 2*x+dx, 2*y+dy
+					if sx >= w || sy >= h {
+						continue
+					}
+					v := values[sy*w+sx]
+					if v == nodata {
+						continue
+					}
+					sum += float64(v)
+					n++
+				}
+			}
+			if n == 0 {
+				out[y*nw+x] = nodata
+			} else {
+				out[y*nw+x] = float32(sum / float64(n))
+			}
+		}
+	}
+	return out, nw, nh
+}
+
+// ifdBuilder collects little-endian TIFF entries for one IFD.
+type ifdBuilder struct {
+	entries map[uint16]ifdEntry
+}
+
+type ifdEntry struct {
+	typ   uint16
+	count uint32
+	data  []byte
+}
+
+func (b *ifdBuilder) set(tag, typ uint16, count int, data []byte) {
+	if b.entries == nil {
+		b.entries = map[uint16]ifdEntry{}
+	}
+	b.entries[tag] = ifdEntry{typ, uint32(count), data}
+}
+
+func (b *ifdBuilder) short(tag uint16, v uint16) { b.shorts(tag, []uint16{v}) }
+func (b *ifdBuilder) long(tag uint16, v uint32)  { b.longs(tag, []uint32{v}) }
+
+func (b *ifdBuilder) shorts(tag uint16, v []uint16) {
+	d := make([]byte, 2*len(v))
+	for i, x := range v {
+		binary.LittleEndian.PutUint16(d[2*i:], x)
+	}
+	b.set(tag, 3, len(v), d)
+}
+
+func (b *ifdBuilder) longs(tag uint16, v []uint32) {
+	d := make([]byte, 4*len(v))
+	for i, x := range v {
+		binary.LittleEndian.PutUint32(d[4*i:], x)
+	}
+	b.set(tag, 4, len(v), d)
+}
+
+func (b *ifdBuilder) doubles(tag uint16, v []float64) {
+	d := make([]byte, 8*len(v))
+	for i, x := range v {
+		binary.LittleEndian.PutUint64(d[8*i:], math.Float64bits(x))
+	}
+	b.set(tag, 12, len(v), d)
+}
+
+func (b *ifdBuilder) ascii(tag uint16, s string) {
+	d := append([]byte(s), 0)
+	b.set(tag, 2, len(d), d)
+}
+
+// size returns the encoded size of the IFD and its out-of-line values.
+func (b *ifdBuilder) size() int {
+	n := 2 + 12*len(b.entries) + 4
+	for _, e := range b.entries {
+		if len(e.data) > 4 {
+			n += (len(e.data) + 1) &^ 1 // word aligned
+		}
+	}
+	return n
+}
+
+// encode serialises the IFD located at offset, pointing to the next IFD.
+func (b *ifdBuilder) encode(offset, next uint32) []byte {
+	tags := make([]int, 0, len(b.entries))
+	for t := range b.entries {
+		tags = append(tags, int(t))
+	}
+	sort.Ints(tags)
+
+	head := make([]byte, 2+12*len(tags)+4)
+	binary.LittleEndian.PutUint16(head, uint16(len(tags)))
+	var extra []byte
+	extraOff := offset + uint32(len(head))
+	for i, t := range tags {
+		e := b.entries[uint16(t)]
+		p := head[2+12*i:]
+		binary.LittleEndian.PutUint16(p[0:], uint16(t))
+		binary.LittleEndian.PutUint16(p[2:], e.typ)
+		binary.LittleEndian.PutUint32(p[4:], e.count)
+		if len(e.data) <= 4 {
+			copy(p[8:12], e.data)
+			continue
+		}
+		binary.LittleEndian.PutUint32(p[8:], extraOff+uint32(len(extra)))
+		extra = append(extra, e.data...)
+		if len(extra)%2 == 1 {
+			extra = append(extra, 0)
+		}
+	}
+	binary.LittleEndian.PutUint32(head[len(head)-4:], next)
+	return append(head, extra...)
+}
@@ -18,6 +18,21 @@ type Config struct {
 	Jython   JythonConfig   `mapstructure:"jython"`
 	HMS      HMSConfig      `mapstructure:"hms"`
 	CORS     CORSConfig     `mapstructure:"cors"`
+	Precip   PrecipConfig   `mapstructure:"precip"`
+	Tiles    TilesConfig    `mapstructure:"tiles"`
+	GIS      GISConfig      `mapstructure:"gis"`
+	Gauges   GaugesConfig   `mapstructure:"gauges"`
+	Ensemble EnsembleConfig `mapstructure:"ensemble"`
+
+	DesignStorms DesignStormConfig  `mapstructure:"design_storms"`
+	Calibration  CalibrationConfig  `mapstructure:"calibration"`
+	Historical   HistoricalConfig   `mapstructure:"historical"`
+	Verification VerificationConfig `mapstructure:"verification"`
+
+	// HEC-HMS projects keyed by model ID. When empty a "leon_creek" model
+	// is built from the hms and paths settings (see buildModelRegistry).
+	Models       map[string]ModelConfig `mapstructure:"models"`
+	DefaultModel string                 `mapstructure:"default_model"`
 }
 
 type ServerConfig struct {
@@ -58,14 +73,14 @@ type URLsConfig struct {
 	MRMSDataSource      string `mapstructure:"mrms_data_source"`
 	MRMSArchive         string `mapstructure:"mrms_archive"`
 	MRMSPass1           string `mapstructure:"mrms_pass1"`
+	MRMSProducts        string `mapstructure:"mrms_products"`
 	HRRRDataSource      string `mapstructure:"hrrr_data_source"`
 	ArcGISTokenEndpoint string `mapstructure:"arcgis_token_endpoint"`
 	ArcGISSelfEndpoint  string `mapstructure:"arcgis_self_endpoint"`
 }
 
 type PythonConfig struct {
-	HMSEnvPath      string `mapstructure:"hms_env_path"`
-	Grib2CogEnvPath string `mapstructure:"grib2cog_env_path"`
+	HMSEnvPath string `mapstructure:"hms_env_path"`
 }
 
 type JythonConfig struct {
@@ -73,13 +88,17 @@ type JythonConfig struct {
 	BatchScriptsDir string `mapstructure:"batch_scripts_dir"`
 }
 
+// HMSConfig holds the HEC-HMS installation. The control file and
+// leon_creek_model settings predate the models registry and are only used
+// when no models are configured.
 type HMSConfig struct {
 	ExecutablePath        string          `mapstructure:"executable_path"`
 	Version               string          `mapstructure:"version"`
 	RealTimeControlFile   string          `mapstructure:"realtime_control_file"`
 	HistoricalControlFile string          `mapstructure:"historical_control_file"`
-	RealTimeScript        string          `mapstructure:"realtime_script"`
-	HistoricalScript      string          `mapstructure:"historical_script"`
+	JobsDir               string          `mapstructure:"jobs_dir"`           // one workspace per run: project copy, compute script, results
+	KeepWorkspaces        string          `mapstructure:"keep_workspaces"`    // never, on_failure or always
+	EventsDir             string          `mapstructure:"events_dir"`         // saved historical events: rainfall, results and flows
 	LeonCreekModel        LeonCreekConfig `mapstructure:"leon_creek_model"`
 }
 
@@ -87,6 +106,7 @@ type LeonCreekConfig struct {
 	RainfallDir   string   `mapstructure:"rainfall_dir"`
 	RealTimeDSS   string   `mapstructure:"realtime_dss"`
 	HistoricalDSS string   `mapstructure:"historical_dss"`
+	BasinFile     string   `mapstructure:"basin_file"` // defaults to LeonCreek/Leon_Watershed.basin in hms_models_dir
 	FilesToDelete []string `mapstructure:"files_to_delete"`
 }
 
@@ -95,6 +115,128 @@ type CORSConfig struct {
 	AllowedIPRanges []string `mapstructure:"allowed_ip_ranges"`
 }
 
+// ModelConfig describes one HEC-HMS project. Relative file names are
+// resolved against Dir, or HistoricalDir for the historical files.
+type ModelConfig struct {
+	ID          string `mapstructure:"-"` // the key in the models map
+	Name        string `mapstructure:"name"`
+	ProjectName string `mapstructure:"project_name"` // as opened by OpenProject
+	Dir         string `mapstructure:"dir"`
+	// Copy of the project used by historical runs; defaults to Dir
+	HistoricalDir string `mapstructure:"historical_dir"`
+
+	BasinFile             string `mapstructure:"basin_file"`
+	MetModel              string `mapstructure:"met_model"`
+	RealTimeRun           string `mapstructure:"realtime_run"`
+	HistoricalRun         string `mapstructure:"historical_run"`
+	RealTimeControlFile   string `mapstructure:"realtime_control_file"`
+	HistoricalControlFile string `mapstructure:"historical_control_file"`
+
+	// Polygon the gridded precipitation is clipped to; empty uses the merge
+	// scripts' default (Bexar County)
+	ClipShapefile string `mapstructure:"clip_shapefile"`
+
+	RainfallDir           string   `mapstructure:"rainfall_dir"` // precipitation DSS files, default "Rainfall"
+	HistoricalRainfallDir string   `mapstructure:"historical_rainfall_dir"`
+	RealTimeDSS           string   `mapstructure:"realtime_dss"`    // HMS results of the realtime run
+	HistoricalDSS         string   `mapstructure:"historical_dss"`  // HMS results of the historical run
+	FilesToDelete         []string `mapstructure:"files_to_delete"` // left out of run workspaces
+
+	ArchiveDir           string `mapstructure:"archive_dir"`            // default <paths.dss_archive_dir>/<id>
+	JSONOutput           string `mapstructure:"json_output"`            // default output_<id>.json in paths.json_output_dir
+	HistoricalJSONOutput string `mapstructure:"historical_json_output"` // default outputHistorical_<id>.json
+	EnsembleJSONOutput   string `mapstructure:"ensemble_json_output"`   // default outputEnsemble_<id>.json
+}
+
+// PrecipConfig controls the precipitation accumulation products published as COGs
+type PrecipConfig struct {
+	ObservedProduct     string    `mapstructure:"observed_product"`
+	ObservedHours       []int     `mapstructure:"observed_hours"`
+	ForecastHours       []int     `mapstructure:"forecast_hours"`
+	ForecastBounds      []float64 `mapstructure:"forecast_bounds"`
+	ForecastResolution  float64   `mapstructure:"forecast_resolution"`
+	AccumulationGribDir string    `mapstructure:"accumulation_grib_dir"`
+
+	// Catalogued COGs older than this many days are deleted; 0 keeps them.
+	// ProductRetentionDays overrides the default per product (e.g. qpe_72h).
+	RetentionDays        int            `mapstructure:"retention_days"`
+	ProductRetentionDays map[string]int `mapstructure:"product_retention_days"`
+
+	// Depths (inches) reported as "area above" in the per-run summaries
+	SummaryThresholds []float64 `mapstructure:"summary_thresholds"`
+}
+
+// TilesConfig controls the XYZ precipitation tile endpoint
+type TilesConfig struct {
+	CacheDir  string      `mapstructure:"cache_dir"`
+	MaxZoom   int         `mapstructure:"max_zoom"`
+	ColorRamp []ColorStop `mapstructure:"color_ramp"` // empty uses the NWS legend
+}
+
+// GISConfig points at the polygons used to summarise precipitation per run
+type GISConfig struct {
+	CountyShapefile   string `mapstructure:"county_shapefile"`
+	CountyName        string `mapstructure:"county_name"`
+	SubbasinShapefile string `mapstructure:"subbasin_shapefile"`
+	SubbasinNameField string `mapstructure:"subbasin_name_field"`
+}
+
+// GaugesConfig controls rain gauge ingestion and the radar bias correction
+type GaugesConfig struct {
+	Source   string `mapstructure:"source"`    // "", "local" or "http"
+	HTTPURL  string `mapstructure:"http_url"`  // queried with ?start=&end= (RFC3339)
+	LocalDir string `mapstructure:"local_dir"` // CSV files read by the local source
+
+	BiasMethod    string  `mapstructure:"bias_method"` // none, mean_field or local
+	ApplyBias     bool    `mapstructure:"apply_bias"`
+	MinGauges     int     `mapstructure:"min_gauges"`
+	MinTotal      float64 `mapstructure:"min_total"` // inches; gauge and radar must both reach it
+	MinFactor     float64 `mapstructure:"min_factor"`
+	MaxFactor     float64 `mapstructure:"max_factor"`
+	LocalRadiusKm float64 `mapstructure:"local_radius_km"`
+}
+
+// EnsembleConfig sets the default members of ensemble forecast runs: every
+// HRRR cycle of the time-lagged ensemble is run at every precipitation scale
+type EnsembleConfig struct {
+	Cycles     int       `mapstructure:"cycles"` // HRRR cycles ending at the run hour
+	Scales     []float64 `mapstructure:"scales"` // forecast precipitation multipliers
+	MaxMembers int       `mapstructure:"max_members"`
+}
+
+// DesignStormConfig holds the precipitation frequency tables of design storm
+// scenarios and the grid their rainfall is written on
+type DesignStormConfig struct {
+	// Return period in years -> duration ("15m", "1h", "24h") -> depth in
+	// inches, e.g. NOAA Atlas 14 point estimates for the watershed
+	Depths          map[string]map[string]float64 `mapstructure:"depths"`
+	IntervalMinutes int                           `mapstructure:"interval_minutes"`
+	RecessionHours  int                           `mapstructure:"recession_hours"` // simulated after the rain ends
+	Bounds          []float64                     `mapstructure:"bounds"`          // west, south, east, north
+	Resolution      float64                       `mapstructure:"resolution"`      // degrees
+}
+
+// CalibrationConfig limits parameter calibrations
+type CalibrationConfig struct {
+	MaxTrials int `mapstructure:"max_trials"` // HMS computes per calibration
+}
+
+// HistoricalConfig limits historical runs. Role keys are matched case
+// insensitively since viper lowercases map keys.
+type HistoricalConfig struct {
+	EarliestDate  string         `mapstructure:"earliest_date"`    // first day of the MRMS Pass2 archive, YYYYMMDD
+	ChunkDays     int            `mapstructure:"chunk_days"`       // days downloaded and merged at a time
+	MaxDays       int            `mapstructure:"max_days"`         // longest range without a signed-in user or a role limit
+	MaxDaysByRole map[string]int `mapstructure:"max_days_by_role"` // longest range by user role
+}
+
+// VerificationConfig sets the daily forecast verification job
+type VerificationConfig struct {
+	Hour       int `mapstructure:"hour"`        // UTC hour of the scheduler run that verifies; -1 disables the job
+	WindowDays int `mapstructure:"window_days"` // forecasts issued in the last days are verified
+	BinHours   int `mapstructure:"bin_hours"`   // lead time bins
+}
+
 var AppConfig Config
 
 func LoadConfig(configPath string) error {
@@ -130,6 +272,10 @@ func LoadConfig(configPath string) error {
 	// Process paths for OS compatibility
 	processPathsForOS()
 
+	if err := buildModelRegistry(); err != nil {
+		return err
+	}
+
 	return nil
 }
 
@@ -150,6 +296,64 @@ func setDefaults() {
 	viper.SetDefault("paths.grib_files_dir", "gribFiles")
 	viper.SetDefault("paths.json_output_dir", "../JSON")
 	viper.SetDefault("paths.csv_dir", "../CSV")
+
+	// Precipitation product defaults
+	viper.SetDefault("urls.mrms_products", "https://mrms.ncep.noaa.gov/2D/")
+	viper.SetDefault("precip.observed_product", "RadarOnly_QPE")
+	viper.SetDefault("precip.observed_hours", []int{1, 3, 6, 12, 24, 48, 72})
+	viper.SetDefault("precip.forecast_hours", []int{6, 12})
+	viper.SetDefault("precip.forecast_bounds", []float64{-134.1, 21.1, -60.9, 52.6})
+	viper.SetDefault("precip.forecast_resolution", 0.03)
+	viper.SetDefault("precip.accumulation_grib_dir", "gribFiles/accumulations")
+	viper.SetDefault("precip.retention_days", 30)
+	viper.SetDefault("precip.summary_thresholds", []float64{1, 2, 4})
+
+	// Tile server defaults
+	viper.SetDefault("tiles.cache_dir", "data/tile_cache")
+	viper.SetDefault("tiles.max_zoom", 12)
+
+	// GIS defaults
+	viper.SetDefault("gis.county_name", "Bexar County")
+	viper.SetDefault("gis.subbasin_name_field", "Name")
+
+	// HMS defaults
+	viper.SetDefault("hms.jobs_dir", "data/hms_jobs")
+	viper.SetDefault("hms.keep_workspaces", "on_failure")
+	viper.SetDefault("hms.events_dir", "data/hms_events")
+
+	// Ensemble defaults
+	viper.SetDefault("ensemble.cycles", 3)
+	viper.SetDefault("ensemble.scales", []float64{0.5, 1, 1.5})
+	viper.SetDefault("ensemble.max_members", 12)
+
+	// Design storm defaults
+	viper.SetDefault("design_storms.interval_minutes", 15)
+	viper.SetDefault("design_storms.recession_hours", 24)
+	viper.SetDefault("design_storms.bounds", []float64{-98.85, 29.05, -98.05, 29.8})
+	viper.SetDefault("design_storms.resolution", 0.01)
+
+	// Calibration defaults
+	viper.SetDefault("calibration.max_trials", 50)
+
+	// Historical run defaults
+	viper.SetDefault("historical.earliest_date", "20201015")
+	viper.SetDefault("historical.chunk_days", 7)
+	viper.SetDefault("historical.max_days", 5)
+	viper.SetDefault("historical.max_days_by_role", map[string]int{"superuser": 366, "admin": 92, "editor": 31})
+
+	// Forecast verification defaults
+	viper.SetDefault("verification.hour", 6)
+	viper.SetDefault("verification.window_days", 7)
+	viper.SetDefault("verification.bin_hours", 3)
+
+	// Gauge bias correction defaults
+	viper.SetDefault("gauges.local_dir", "data/gauges")
+	viper.SetDefault("gauges.bias_method", "none")
+	viper.SetDefault("gauges.min_gauges", 3)
+	viper.SetDefault("gauges.min_total", 0.1)
+	viper.SetDefault("gauges.min_factor", 0.25)
+	viper.SetDefault("gauges.max_factor", 4.0)
+	viper.SetDefault("gauges.local_radius_km", 25.0)
 }
 
 func processPathsForOS() {
@@ -158,9 +362,15 @@ func processPathsForOS() {
 		// Convert Windows paths to Unix paths
 		AppConfig.Paths = convertPathsToUnix(AppConfig.Paths)
 		AppConfig.Python.HMSEnvPath = filepath.ToSlash(AppConfig.Python.HMSEnvPath)
-		AppConfig.Python.Grib2CogEnvPath = filepath.ToSlash(AppConfig.Python.Grib2CogEnvPath)
 		AppConfig.Jython.ExecutablePath = filepath.ToSlash(AppConfig.Jython.ExecutablePath)
 		AppConfig.HMS.ExecutablePath = filepath.ToSlash(AppConfig.HMS.ExecutablePath)
+		AppConfig.Precip.AccumulationGribDir = filepath.ToSlash(AppConfig.Precip.AccumulationGribDir)
+		AppConfig.Tiles.CacheDir = filepath.ToSlash(AppConfig.Tiles.CacheDir)
+		AppConfig.GIS.CountyShapefile = filepath.ToSlash(AppConfig.GIS.CountyShapefile)
+		AppConfig.GIS.SubbasinShapefile = filepath.ToSlash(AppConfig.GIS.SubbasinShapefile)
+		AppConfig.Gauges.LocalDir = filepath.ToSlash(AppConfig.Gauges.LocalDir)
+		AppConfig.HMS.LeonCreekModel.BasinFile = filepath.ToSlash(AppConfig.HMS.LeonCreekModel.BasinFile)
+		AppConfig.HMS.JobsDir = filepath.ToSlash(AppConfig.HMS.JobsDir)
 	}
 }
 
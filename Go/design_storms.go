@@ -0,0 +1,245 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"math"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"time"
+
+	"HMSBackend/grib2"
+	"HMSBackend/hms"
+	"HMSBackend/sqlcdb"
+
+	"github.com/labstack/echo/v4"
+)
+
+const scenarioKindDesignStorm = "design_storm"
+
+// designStormTable returns the depth-duration table of a return period from
+// design_storms.depths
+func designStormTable(returnPeriod int) (hms.DepthDuration, error) {
+	depths, ok := AppConfig.DesignStorms.Depths[strconv.Itoa(returnPeriod)]
+	if !ok {
+		return nil, fmt.Errorf("no design_storms.depths table for the %d-year return period", returnPeriod)
+	}
+	table := hms.DepthDuration{}
+	for key, depth := range depths {
+		d, err := time.ParseDuration(key)
+		if err != nil {
+			return nil, fmt.Errorf("invalid duration %q in the %d-year depth table: %w", key, returnPeriod, err)
+		}
+		table[d.Hours()] = depth
+	}
+	return table, nil
+}
+
+// resolveDesignStorm fills in a request's defaults and builds its hyetograph
+func resolveDesignStorm(req DesignStormRequest) (DesignStorm, error) {
+	storm := DesignStorm{
+		Distribution:    req.Distribution,
+		ReturnPeriod:    req.ReturnPeriod,
+		DurationHours:   req.DurationHours,
+		IntervalMinutes: req.IntervalMinutes,
+		Depth:           req.Depth,
+		Start:           req.Start.UTC(),
+	}
+	if storm.Distribution == "" {
+		// San Antonio lies in the NRCS Type III region
+		storm.Distribution = hms.SCSTypeIII
+	}
+	if storm.DurationHours == 0 {
+		storm.DurationHours = 24
+	}
+	if storm.IntervalMinutes == 0 {
+		storm.IntervalMinutes = AppConfig.DesignStorms.IntervalMinutes
+	}
+	if storm.DurationHours < 0 || storm.IntervalMinutes <= 0 || storm.Depth < 0 || storm.ReturnPeriod < 0 {
+		return storm, errors.New("duration, interval, depth and return period must be positive")
+	}
+	if req.Start.IsZero() {
+		storm.Start = time.Now().UTC().Truncate(24 * time.Hour)
+	}
+
+	var table hms.DepthDuration
+	if storm.ReturnPeriod > 0 {
+		var err error
+		if table, err = designStormTable(storm.ReturnPeriod); err != nil {
+			return storm, err
+		}
+	}
+	if storm.Distribution == hms.AlternatingBlock && table == nil {
+		return storm, errors.New("the atlas14 distribution needs a return_period")
+	}
+	if storm.Depth == 0 {
+		if table == nil {
+			return storm, errors.New("depth or return_period is required")
+		}
+		depth, err := table.Depth(float64(storm.DurationHours))
+		if err != nil {
+			return storm, err
+		}
+		storm.Depth = depth
+	}
+
+	duration := time.Duration(storm.DurationHours) * time.Hour
+	hyetograph, err := hms.Storm{
+		Distribution: storm.Distribution,
+		Depth:        storm.Depth,
+		Duration:     duration,
+		Interval:     time.Duration(storm.IntervalMinutes) * time.Minute,
+		Weights:      req.Weights,
+		Table:        table,
+	}.Hyetograph()
+	if err != nil {
+		return storm, err
+	}
+	for i, v := range hyetograph {
+		hyetograph[i] = math.Round(v*1e4) / 1e4
+	}
+	storm.Hyetograph = hyetograph
+	storm.End = storm.Start.Add(duration + time.Duration(AppConfig.DesignStorms.RecessionHours)*time.Hour)
+	return storm, nil
+}
+
+// designStormVariable is the name Vortex gives the accumulation of the grids
+// written by writeDesignStormGrids
+func designStormVariable(intervalMinutes int) string {
+	if intervalMinutes%60 == 0 {
+		return fmt.Sprintf("Total_precipitation_surface_%d_Hour_Accumulation", intervalMinutes/60)
+	}
+	return fmt.Sprintf("Total_precipitation_surface_%d_Minute_Accumulation", intervalMinutes)
+}
+
+// writeDesignStormGrids writes one uniform APCP grid per interval of the
+// storm, zero after the rain ends, named like HRRR files so the HRRR merge
+// batch picks them up. It returns the number of files written.
+func writeDesignStormGrids(storm DesignStorm, dstDir string) (int, error) {
+	cfg := AppConfig.DesignStorms
+	if len(cfg.Bounds) != 4 {
+		return 0, fmt.Errorf("design_storms.bounds must have 4 values, got %d", len(cfg.Bounds))
+	}
+	if err := os.MkdirAll(dstDir, 0755); err != nil {
+		return 0, err
+	}
+	grid := grib2.NewLatLonGrid([4]float64{cfg.Bounds[0], cfg.Bounds[1], cfg.Bounds[2], cfg.Bounds[3]}, cfg.Resolution)
+	nx, ny := grid.Size()
+	values := make([]float32, nx*ny)
+
+	timeUnit, length := uint8(0), storm.IntervalMinutes // minutes
+	if storm.IntervalMinutes%60 == 0 {
+		timeUnit, length = 1, storm.IntervalMinutes/60 // hours
+	}
+	interval := time.Duration(storm.IntervalMinutes) * time.Minute
+	n := int(storm.End.Sub(storm.Start) / interval)
+	for k := 0; k < n; k++ {
+		depth := 0.0
+		if k < len(storm.Hyetograph) {
+			depth = storm.Hyetograph[k] * 25.4 // kg/m2
+		}
+		for i := range values {
+			values[i] = float32(depth)
+		}
+		msg := grib2.NewMessage(0, 7, storm.Start, grid, grib2.Product{
+			Template:           8,
+			Category:           1,
+			Number:             8,
+			TimeUnit:           timeUnit,
+			ForecastTime:       k * length,
+			SurfaceType:        1,
+			StatisticalProcess: 1,
+			IntervalUnit:       timeUnit,
+			IntervalLength:     length,
+			IntervalEnd:        storm.Start.Add(time.Duration(k+1) * interval),
+		})
+		if err := msg.Pack(values, 2); err != nil {
+			return k, err
+		}
+		path := filepath.Join(dstDir, fmt.Sprintf("designstorm.wrfsfcf%04d.grib2", k+1))
+		if err := grib2.WriteFile(path, msg); err != nil {
+			return k, fmt.Errorf("failed to write %s: %w", path, err)
+		}
+	}
+	return n, nil
+}
+
+// runDesignStorm computes a design storm in its own workspace and returns the
+// junction flows. The storm replaces the realtime model's combined rainfall.
+func runDesignStorm(ctx context.Context, model ModelConfig, storm DesignStorm, runID int32, keep bool) (flows *JunctionFlowsFile, err error) {
+	// The storm grids are merged into the rainfall DSS, so the copy must not
+	// start from the realtime rainfall, which overlaps the storm window
+	ws, err := newHMSWorkspaceIn(hmsJobDir(model, scenarioKindDesignStorm, runID), model, pipelineRunRealtime,
+		model.RainfallPath("RainfallRealTimeAndForcast.dss"))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create workspace: %w", err)
+	}
+	defer func() { ws.close(err != nil, keep) }()
+	m := ws.Model
+
+	gridDir := filepath.Join(ws.Dir, "design_storm")
+	n, err := writeDesignStormGrids(storm, gridDir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to write storm grids: %w", err)
+	}
+	log.Printf("INFO: Wrote %d design storm grids to %s", n, gridDir)
+
+	if err = executeBatchFile(ctx, GetJythonBatchScriptPath("MergeGRIBFilesRealTimeHRRBatch.bat"),
+		gridDir, m.ClipShapefile, m.RainfallPath("RainfallRealTimeAndForcast.dss"),
+		designStormVariable(storm.IntervalMinutes)); err != nil {
+		return nil, fmt.Errorf("failed to merge storm grids: %w", err)
+	}
+	if err = setControlWindow(m.ControlFile(pipelineRunRealtime), storm.Start, storm.End); err != nil {
+		return nil, err
+	}
+	if err = ws.compute(ctx, nil); err != nil {
+		return nil, err
+	}
+	return ws.extractFlows(ctx)
+}
+
+// handleRunDesignStorm builds a design storm and computes it as a named
+// scenario in the background
+func handleRunDesignStorm(queries *sqlcdb.Queries) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		var req DesignStormRequest
+		if err := c.Bind(&req); err != nil {
+			log.Printf("Error parsing design storm request body: %v", err)
+			return respondWithError(c, http.StatusBadRequest, "Invalid request format")
+		}
+		if err := validateScenarioName(req.Name); err != nil {
+			return respondWithError(c, http.StatusBadRequest, err.Error())
+		}
+		model, err := GetModel(req.Model)
+		if err != nil {
+			return respondWithError(c, http.StatusBadRequest, err.Error())
+		}
+		storm, err := resolveDesignStorm(req)
+		if err != nil {
+			return respondWithError(c, http.StatusBadRequest, err.Error())
+		}
+
+		log.Printf("Received design storm request: name=%s, model=%s, %s %.2f in over %d h",
+			req.Name, model.ID, storm.Distribution, storm.Depth, storm.DurationHours)
+
+		scenario, err := startScenario(queries, model, req.Name, scenarioKindDesignStorm, storm,
+			func(ctx context.Context, runID int32) (*JunctionFlowsFile, error) {
+				return runDesignStorm(ctx, model, storm, runID, req.KeepWorkspace)
+			})
+		if err != nil {
+			log.Printf("Error starting design storm %s: %v", req.Name, err)
+			return respondWithError(c, http.StatusInternalServerError, "Failed to start scenario")
+		}
+
+		return respondWithJSON(c, http.StatusAccepted, map[string]interface{}{
+			"message":  "Design storm scenario started",
+			"status":   "accepted",
+			"scenario": scenarioSummary(scenario.Name, scenario.Model, scenario.Kind, scenario.Status, "", scenario.RunID, scenario.CreatedAt, scenario.FinishedAt),
+			"storm":    storm,
+		})
+	}
+}
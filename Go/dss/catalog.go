@@ -0,0 +1,148 @@
+package dss
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Record types stored in the info block and the pathname bins
+const (
+	TypeRegularFloat    = 100
+	TypeRegularDouble   = 105
+	TypeIrregularFloat  = 110
+	TypeIrregularDouble = 115
+	TypePairedFloat     = 200
+	TypePairedDouble    = 205
+	TypeText            = 300
+)
+
+// TypeName returns the short name HEC-DSSVue shows for a record type.
+func TypeName(recordType int) string {
+	switch recordType {
+	case TypeRegularFloat:
+		return "RTS"
+	case TypeRegularDouble:
+		return "RTD"
+	case TypeIrregularFloat:
+		return "ITS"
+	case TypeIrregularDouble:
+		return "ITD"
+	case TypePairedFloat:
+		return "PD"
+	case TypePairedDouble:
+		return "PDD"
+	case TypeText:
+		return "TXT"
+	}
+	if recordType >= 400 && recordType < 500 {
+		return "GRID"
+	}
+	return fmt.Sprintf("type %d", recordType)
+}
+
+// Pathname is a DSS pathname split into its six parts:
+// /A (basin)/B (location)/C (parameter)/D (block start date)/E (interval)/F (version)/
+type Pathname struct {
+	A, B, C, D, E, F string
+}
+
+// ParsePathname splits a pathname of the form /A/B/C/D/E/F/.
+func ParsePathname(s string) (Pathname, error) {
+	parts := strings.Split(s, "/")
+	if len(parts) != 8 || parts[0] != "" || parts[7] != "" {
+		return Pathname{}, fmt.Errorf("dss: invalid pathname %q", s)
+	}
+	return Pathname{A: parts[1], B: parts[2], C: parts[3], D: parts[4], E: parts[5], F: parts[6]}, nil
+}
+
+// String returns the pathname in /A/B/C/D/E/F/ form.
+func (p Pathname) String() string {
+	return "/" + strings.Join([]string{p.A, p.B, p.C, p.D, p.E, p.F}, "/") + "/"
+}
+
+// sameSeries reports whether p and q name blocks of the same time series,
+// ignoring the D part and case
+func (p Pathname) sameSeries(q Pathname) bool {
+	return strings.EqualFold(p.A, q.A) && strings.EqualFold(p.B, q.B) && strings.EqualFold(p.C, q.C) &&
+		strings.EqualFold(p.E, q.E) && strings.EqualFold(p.F, q.F)
+}
+
+// CatalogEntry is one record of a file's catalog.
+type CatalogEntry struct {
+	Pathname  string
+	Type      int
+	LastWrite time.Time
+}
+
+// Catalog lists the live records of the file sorted by pathname.
+func (f *File) Catalog() ([]CatalogEntry, error) {
+	if f.catalog == nil {
+		if err := f.readCatalog(); err != nil {
+			return nil, err
+		}
+	}
+	return append([]CatalogEntry(nil), f.catalog...), nil
+}
+
+// readCatalog walks every bin block and indexes the live records
+func (f *File) readCatalog() error {
+	binsPerBlock, binSize := f.header[keyBinsPerBlock], f.header[keyBinSize]
+	catalog := []CatalogEntry{}
+	index := map[string]int64{}
+
+	seen := map[int64]bool{}
+	for block := f.header[keyFirstBin]; block != 0; {
+		if seen[block] {
+			return fmt.Errorf("dss: bin block %d chains to itself", block)
+		}
+		seen[block] = true
+
+		words, err := f.words(block, binsPerBlock*binSize+1)
+		if err != nil {
+			return err
+		}
+		for b := int64(0); b < binsPerBlock; b++ {
+			bin := words[b*binSize : (b+1)*binSize]
+			// The last word of a bin is its overflow address, visited as part of its own block
+			for i := int64(0); i+binPathname < binSize-1 && bin[i+binHash] != 0; {
+				pathLen := int(bin[i+binPathLength])
+				end := i + binPathname + int64(numWords(pathLen))
+				if pathLen <= 0 || end > binSize-1 {
+					return fmt.Errorf("dss: corrupt pathname bin at %d", block+b*binSize)
+				}
+				if bin[i+binStatus] == statusPrimary {
+					path := wordString(bin[i+binPathname:end], pathLen)
+					catalog = append(catalog, CatalogEntry{
+						Pathname:  path,
+						Type:      int(wordInt(bin[i+binTypeAndSort], 0)),
+						LastWrite: time.UnixMilli(bin[i+binLastWrite]).UTC(),
+					})
+					index[strings.ToUpper(path)] = bin[i+binInfoAddress]
+				}
+				i = end
+			}
+		}
+		block = words[binsPerBlock*binSize]
+	}
+
+	sort.Slice(catalog, func(i, j int) bool { return catalog[i].Pathname < catalog[j].Pathname })
+	f.catalog, f.pathInfo = catalog, index
+	return nil
+}
+
+// infoAddress returns the info block address of a record, ErrNotFound when
+// the file has no live record of that pathname
+func (f *File) infoAddress(pathname string) (int64, error) {
+	if f.pathInfo == nil {
+		if err := f.readCatalog(); err != nil {
+			return 0, err
+		}
+	}
+	addr, ok := f.pathInfo[strings.ToUpper(pathname)]
+	if !ok {
+		return 0, fmt.Errorf("%w: %s", ErrNotFound, pathname)
+	}
+	return addr, nil
+}
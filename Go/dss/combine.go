@@ -0,0 +1,167 @@
+package dss
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// CombineSource is one input file of Combine.
+type CombineSource struct {
+	Name string // reported as the supplier of its records
+	Path string
+
+	// AfterOthers limits the source to records starting at or after the end
+	// of the latest record of the same series taken from the sources before
+	// it, e.g. a forecast used only beyond the last observed hour.
+	AfterOthers bool
+}
+
+// CombineReport tells which source supplies each record of a combined file.
+type CombineReport struct {
+	Output  string            `json:"output"`
+	Files   map[string]string `json:"files"` // path of each source by name
+	Records int               `json:"records"`
+	Sources map[string]int    `json:"sources"` // records taken from each source
+	Series  []CombinedSeries  `json:"series"`
+}
+
+// CombinedSeries lists the records of one series (pathname without D and E
+// parts) in time order.
+type CombinedSeries struct {
+	Pathname string           `json:"pathname"`
+	Records  []CombinedRecord `json:"records"`
+}
+
+// CombinedRecord is one record of a combined file and its source.
+type CombinedRecord struct {
+	Pathname string     `json:"pathname"`
+	Start    *time.Time `json:"start,omitempty"` // D part, nil when not a date
+	End      *time.Time `json:"end,omitempty"`   // E part of grids, nil otherwise
+	Source   string     `json:"source"`
+}
+
+// ParseRecordTime parses a date-time pathname part such as 01JAN2024:0100,
+// 01JAN2024:2400 (midnight ending the day) or 01JAN2024.
+func ParseRecordTime(part string) (time.Time, bool) {
+	date, clock, hasClock := strings.Cut(strings.TrimSpace(part), ":")
+	t, err := time.Parse("02Jan2006", date)
+	if err != nil {
+		return t, false
+	}
+	if !hasClock {
+		return t, true
+	}
+	hhmm, err := strconv.Atoi(clock)
+	if err != nil || len(clock) != 4 || hhmm%100 > 59 || hhmm > 2400 {
+		return t, false
+	}
+	return t.Add(time.Duration(hhmm/100)*time.Hour + time.Duration(hhmm%100)*time.Minute), true
+}
+
+// seriesKey returns the pathname of a record's series, without D and E parts
+func seriesKey(p Pathname) string {
+	p.D, p.E = "", ""
+	return strings.ToUpper(p.String())
+}
+
+// PlanCombine chooses the records of sources that make up the combined
+// file dst, which HEC-DSS then writes by copying them. Sources are in
+// precedence order: a pathname present in several sources is taken from the
+// first, and AfterOthers sources only extend the series of the sources
+// before them.
+func PlanCombine(dst string, sources ...CombineSource) (*CombineReport, error) {
+	type candidate struct {
+		source   int
+		pathname string
+		series   string
+		start    *time.Time
+		end      *time.Time
+	}
+
+	chosen := map[string]*candidate{}
+	var order []*candidate
+	for i, src := range sources {
+		f, err := Open(src.Path)
+		if err != nil {
+			return nil, fmt.Errorf("dss: opening %s: %w", src.Name, err)
+		}
+		catalog, err := f.Catalog()
+		f.Close()
+		if err != nil {
+			return nil, fmt.Errorf("dss: reading the catalog of %s: %w", src.Name, err)
+		}
+
+		// Where each series taken so far ends
+		var seriesEnd map[string]time.Time
+		if src.AfterOthers {
+			seriesEnd = map[string]time.Time{}
+			for _, c := range order {
+				end := c.end
+				if end == nil {
+					end = c.start
+				}
+				if end != nil && end.After(seriesEnd[c.series]) {
+					seriesEnd[c.series] = *end
+				}
+			}
+		}
+
+		for _, entry := range catalog {
+			key := strings.ToUpper(entry.Pathname)
+			if chosen[key] != nil {
+				continue
+			}
+			p, err := ParsePathname(entry.Pathname)
+			if err != nil {
+				continue
+			}
+			c := &candidate{source: i, pathname: entry.Pathname, series: seriesKey(p)}
+			if t, ok := ParseRecordTime(p.D); ok {
+				c.start = &t
+			}
+			if t, ok := ParseRecordTime(p.E); ok {
+				c.end = &t
+			}
+			if src.AfterOthers {
+				if last, ok := seriesEnd[c.series]; ok && (c.start == nil || c.start.Before(last)) {
+					continue
+				}
+			}
+			chosen[key] = c
+			order = append(order, c)
+		}
+	}
+
+	sort.SliceStable(order, func(i, j int) bool {
+		a, b := order[i], order[j]
+		if a.series != b.series {
+			return a.series < b.series
+		}
+		if a.start != nil && b.start != nil && !a.start.Equal(*b.start) {
+			return a.start.Before(*b.start)
+		}
+		return a.pathname < b.pathname
+	})
+
+	report := &CombineReport{Output: dst, Files: map[string]string{}, Sources: map[string]int{}, Series: []CombinedSeries{}}
+	for _, src := range sources {
+		report.Files[src.Name] = src.Path
+		report.Sources[src.Name] = 0
+	}
+	for _, c := range order {
+		name := sources[c.source].Name
+		report.Records++
+		report.Sources[name]++
+		if n := len(report.Series); n == 0 || strings.ToUpper(report.Series[n-1].Pathname) != c.series {
+			p, _ := ParsePathname(c.pathname)
+			p.D, p.E = "", ""
+			report.Series = append(report.Series, CombinedSeries{Pathname: p.String()})
+		}
+		s := &report.Series[len(report.Series)-1]
+		s.Records = append(s.Records, CombinedRecord{Pathname: c.pathname, Start: c.start, End: c.end, Source: name})
+	}
+	return report, nil
+}
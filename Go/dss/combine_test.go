@@ -0,0 +1,103 @@
+package dss
+
+import (
+	"testing"
+	"time"
+)
+
+// gridRecord is an hourly precipitation grid as Vortex writes it, with a
+// single value standing for the cells
+func gridRecord(start, end string, value float64) testRecord {
+	return testRecord{
+		path:       "/SHG/SARA/PRECIPITATION/" + start + "/" + end + "/IMPORT/",
+		recordType: 420,
+		status:     statusPrimary,
+		head:       []int32{1, 1},
+		values:     floatInts(value),
+		numberData: 1,
+	}
+}
+
+func TestCombinePrecedence(t *testing.T) {
+	pass1 := writeTestDSS(t, append([]testRecord{
+		gridRecord("01JAN2024:0000", "01JAN2024:0100", 1),
+		gridRecord("01JAN2024:0100", "01JAN2024:0200", 2),
+		gridRecord("01JAN2024:0200", "01JAN2024:0300", 3),
+	}, testRecords()[0]), 4, 64)
+	pass2 := writeTestDSS(t, []testRecord{
+		gridRecord("01JAN2024:0100", "01JAN2024:0200", 20),
+	}, 4, 64)
+	hrrr := writeTestDSS(t, []testRecord{
+		gridRecord("01JAN2024:0200", "01JAN2024:0300", 30),
+		gridRecord("01JAN2024:0300", "01JAN2024:0400", 40),
+	}, 4, 64)
+
+	// The observed file as HEC-DSS writes it from the Pass 1/Pass 2 plan
+	observedPlan, err := PlanCombine("observed.dss",
+		CombineSource{Name: "pass2", Path: pass2},
+		CombineSource{Name: "pass1", Path: pass1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	grid := observedPlan.Series[1].Records
+	if len(grid) != 3 || grid[1].Source != "pass2" || grid[0].Source != "pass1" || grid[2].Source != "pass1" {
+		t.Fatalf("pass 2 should replace pass 1 in its hour only: %+v", grid)
+	}
+	observed := writeTestDSS(t, append([]testRecord{
+		gridRecord("01JAN2024:0000", "01JAN2024:0100", 1),
+		gridRecord("01JAN2024:0100", "01JAN2024:0200", 20),
+		gridRecord("01JAN2024:0200", "01JAN2024:0300", 3),
+	}, testRecords()[0]), 4, 64)
+
+	report, err := PlanCombine("combined.dss",
+		CombineSource{Name: "observed", Path: observed},
+		CombineSource{Name: "hrrr", Path: hrrr, AfterOthers: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if report.Output != "combined.dss" || report.Files["hrrr"] != hrrr {
+		t.Errorf("output %s, files %v", report.Output, report.Files)
+	}
+
+	if report.Records != 5 || report.Sources["observed"] != 4 || report.Sources["hrrr"] != 1 {
+		t.Errorf("report %d records from %v", report.Records, report.Sources)
+	}
+	if len(report.Series) != 2 || report.Series[1].Pathname != "/SHG/SARA/PRECIPITATION///IMPORT/" {
+		t.Fatalf("series %+v", report.Series)
+	}
+	grid = report.Series[1].Records
+	wantSources := []string{"observed", "observed", "observed", "hrrr"}
+	if len(grid) != len(wantSources) {
+		t.Fatalf("%d grid records, want %d", len(grid), len(wantSources))
+	}
+	// The forecast only follows the last observed hour
+	for i, r := range grid {
+		if r.Source != wantSources[i] {
+			t.Errorf("hour %d from %s, want %s", i, r.Source, wantSources[i])
+		}
+		if want := time.Date(2024, 1, 1, i, 0, 0, 0, time.UTC); r.Start == nil || !r.Start.Equal(want) {
+			t.Errorf("hour %d starts %v, want %v", i, r.Start, want)
+		}
+	}
+	if p := grid[3].Pathname; p != "/SHG/SARA/PRECIPITATION/01JAN2024:0300/01JAN2024:0400/IMPORT/" {
+		t.Errorf("forecast record %s", p)
+	}
+}
+
+func TestParseRecordTime(t *testing.T) {
+	cases := map[string]time.Time{
+		"01JAN2024:0130": time.Date(2024, 1, 1, 1, 30, 0, 0, time.UTC),
+		"01JAN2024:2400": time.Date(2024, 1, 2, 0, 0, 0, 0, time.UTC),
+		"15Mar2025":      time.Date(2025, 3, 15, 0, 0, 0, 0, time.UTC),
+	}
+	for part, want := range cases {
+		if got, ok := ParseRecordTime(part); !ok || !got.Equal(want) {
+			t.Errorf("ParseRecordTime(%q) = %v, %v; want %v", part, got, ok, want)
+		}
+	}
+	for _, part := range []string{"1HOUR", "01JAN2024:2460", "", "IMPORT"} {
+		if _, ok := ParseRecordTime(part); ok {
+			t.Errorf("ParseRecordTime(%q) succeeded", part)
+		}
+	}
+}
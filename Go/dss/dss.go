@@ -0,0 +1,223 @@
+// Package dss reads HEC-DSS version 7 files, the format HEC-HMS writes its
+// results and reads its gridded and time series inputs from.
+//
+// A DSS7 file is an array of little-endian 64-bit words; addresses are word
+// offsets. The file header points at blocks of pathname bins, each bin entry
+// points at a record's info block, and the info block at the record's
+// internal header and values. Only the catalog and regular-interval time
+// series (float and double) are read. Grids, paired data, text and irregular
+// series are listed in the catalog but reported as unsupported when read.
+package dss
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+)
+
+// ErrNotDSS7 is returned when a file does not start with a DSS7 header.
+var ErrNotDSS7 = errors.New("dss: not a HEC-DSS version 7 file")
+
+// ErrNotFound is returned when no record matches a pathname.
+var ErrNotFound = errors.New("dss: record not found")
+
+// wordSize is the size in bytes of a DSS7 word
+const wordSize = 8
+
+// File header words used by the reader
+const (
+	keyDSS          = 0  // "ZDSS"
+	keyHeaderSize   = 1  // words in the file header
+	keyVersion      = 2  // "7-xx"
+	keyNumRecords   = 3  // records in the file, aliases excluded
+	keyNumAliases   = 4  // aliases in the file
+	keyFileSize     = 5  // words in the file
+	keyDead         = 6  // words no longer used
+	keyHashStart    = 7  // address of the pathname hash table
+	keyHashesUsed   = 8  // hash table entries in use
+	keyMaxHash      = 9  // hash table size
+	keyBinsPerBlock = 10 // pathname bins per bin block
+	keyBinSize      = 11 // words per pathname bin
+	keyFirstBin     = 12 // address of the first bin block
+	keyNextEmptyBin = 13 // address of the next unused bin
+	fileHeaderSize  = 16
+)
+
+// Pathname bin entry words. An entry with a zero hash ends a bin; the last
+// word of a bin holds the address of the bin it overflowed to, and the word
+// after the last bin of a block the address of the next block.
+const (
+	binHash        = 0 // pathname hash, zero when the rest of the bin is empty
+	binStatus      = 1 // record status
+	binPathLength  = 2 // pathname length in characters
+	binInfoAddress = 3 // address of the record's info block
+	binTypeAndSort = 4 // record type and catalog sort order (two int32)
+	binLastWrite   = 5 // last write, milliseconds since 1970
+	binDates       = 6 // first and last julian date of the data (two int32)
+	binPathname    = 7 // pathname, 8 characters per word
+)
+
+// Record info block words
+const (
+	infoFlag             = 0 // always infoFlagValue
+	infoStatus           = 1
+	infoPathLength       = 2
+	infoHash             = 3
+	infoTypeVersion      = 4 // record type and version (two int32)
+	infoExpansion        = 5
+	infoLastWrite        = 6 // milliseconds since 1970
+	infoProgram          = 7 // 16 characters
+	infoFirstDate        = 9
+	infoLastDate         = 10
+	infoCreated          = 11
+	infoReserved         = 12
+	infoInternalHeadAddr = 13
+	infoInternalHeadLen  = 14 // int32 values
+	infoHeader2Addr      = 15
+	infoHeader2Len       = 16
+	infoUserHeadAddr     = 17
+	infoUserHeadLen      = 18
+	infoValues1Addr      = 19
+	infoValues1Len       = 20 // int32 values
+	infoValues2Addr      = 21
+	infoValues2Len       = 22
+	infoValues3Addr      = 23
+	infoValues3Len       = 24
+	infoAllocated        = 25
+	infoNumberData       = 26
+	infoLogicalNumber    = 27
+	infoAliasBin         = 28
+	infoReserved1        = 29
+	infoPathname         = 30
+)
+
+// infoFlagValue marks the start of every info block
+const infoFlagValue = -97534
+
+// Record status of live records; deleted and renamed records keep their
+// bin entries with other statuses.
+const statusPrimary = 11
+
+// File is an open DSS7 file.
+type File struct {
+	r       io.ReaderAt
+	closer  io.Closer
+	Version string // e.g. "7-IU"
+
+	header   []int64
+	pathInfo map[string]int64 // pathname (upper case) -> info block address
+	catalog  []CatalogEntry
+}
+
+// Open opens the DSS7 file at path.
+func Open(path string) (*File, error) {
+	fh, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	f, err := NewReader(fh)
+	if err != nil {
+		fh.Close()
+		return nil, err
+	}
+	f.closer = fh
+	return f, nil
+}
+
+// NewReader reads the header of a DSS7 file from r.
+func NewReader(r io.ReaderAt) (*File, error) {
+	f := &File{r: r}
+	header, err := f.words(0, fileHeaderSize)
+	if err != nil {
+		if errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) {
+			return nil, ErrNotDSS7
+		}
+		return nil, err
+	}
+	if wordString(header[keyDSS:keyDSS+1], 4) != "ZDSS" {
+		return nil, ErrNotDSS7
+	}
+	f.Version = wordString(header[keyVersion:keyVersion+1], 4)
+	if !strings.HasPrefix(f.Version, "7") {
+		return nil, fmt.Errorf("%w: version %q", ErrNotDSS7, f.Version)
+	}
+	if header[keyBinSize] <= binPathname || header[keyBinsPerBlock] <= 0 {
+		return nil, fmt.Errorf("dss: invalid bin layout (%d bins of %d words)", header[keyBinsPerBlock], header[keyBinSize])
+	}
+	f.header = header
+	return f, nil
+}
+
+// Close closes a file opened with Open.
+func (f *File) Close() error {
+	if f.closer == nil {
+		return nil
+	}
+	return f.closer.Close()
+}
+
+// NumRecords returns the number of records the header reports.
+func (f *File) NumRecords() int {
+	return int(f.header[keyNumRecords])
+}
+
+// words reads n words at address addr
+func (f *File) words(addr, n int64) ([]int64, error) {
+	if addr < 0 || n < 0 {
+		return nil, fmt.Errorf("dss: invalid address %d", addr)
+	}
+	buf := make([]byte, n*wordSize)
+	if _, err := f.r.ReadAt(buf, addr*wordSize); err != nil {
+		return nil, fmt.Errorf("dss: reading %d words at %d: %w", n, addr, err)
+	}
+	out := make([]int64, n)
+	for i := range out {
+		out[i] = int64(binary.LittleEndian.Uint64(buf[i*wordSize:]))
+	}
+	return out, nil
+}
+
+// ints reads n int32 values packed two per word at address addr
+func (f *File) ints(addr, n int64) ([]int32, error) {
+	w, err := f.words(addr, (n+1)/2)
+	if err != nil {
+		return nil, err
+	}
+	out := make([]int32, n)
+	for i := range out {
+		out[i] = wordInt(w[i/2], i%2)
+	}
+	return out, nil
+}
+
+// wordInt returns the first (half 0) or second int32 packed in a word
+func wordInt(w int64, half int) int32 {
+	if half == 0 {
+		return int32(uint32(w))
+	}
+	return int32(uint32(uint64(w) >> 32))
+}
+
+// wordString returns the first n characters stored in words, without
+// trailing NULs and blanks
+func wordString(words []int64, n int) string {
+	b := make([]byte, len(words)*wordSize)
+	for i, w := range words {
+		binary.LittleEndian.PutUint64(b[i*wordSize:], uint64(w))
+	}
+	if n < len(b) {
+		b = b[:n]
+	}
+	if i := strings.IndexByte(string(b), 0); i >= 0 {
+		b = b[:i]
+	}
+	return strings.TrimRight(string(b), " ")
+}
+
+// numWords returns the words holding n characters
+func numWords(n int) int {
+	return (n + wordSize - 1) / wordSize
+}
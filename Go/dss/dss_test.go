@@ -0,0 +1,384 @@
+package dss
+
+import (
+	"encoding/binary"
+	"errors"
+	"math"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+type testRecord struct {
+	path       string
+	recordType int
+	status     int64
+	head       []int32
+	values     []int32
+	numberData int64
+}
+
+// stringWords packs s into words, NUL padded
+func stringWords(s string, n int) []int64 {
+	b := make([]byte, n*wordSize)
+	copy(b, s)
+	out := make([]int64, n)
+	for i := range out {
+		out[i] = int64(binary.LittleEndian.Uint64(b[i*wordSize:]))
+	}
+	return out
+}
+
+// intWords packs int32 values two per word
+func intWords(ints []int32) []int64 {
+	out := make([]int64, (len(ints)+1)/2)
+	for i, v := range ints {
+		out[i/2] |= int64(uint64(uint32(v)) << (32 * uint(i%2)))
+	}
+	return out
+}
+
+// tsHeader builds the internal header of a regular time series
+func tsHeader(blockStart, blockEnd, valueSize, granularity, offset int32, units, dataType string) []int32 {
+	head := make([]int32, tsUnits)
+	head[tsGranularity] = granularity
+	head[tsTimeOffset] = offset
+	head[tsBlockStart] = blockStart
+	head[tsBlockEnd] = blockEnd
+	head[tsValueSize] = valueSize
+	head[tsValueElemSize] = valueSize
+	s := []byte(units + "\x00" + dataType + "\x00")
+	for len(s)%4 != 0 {
+		s = append(s, 0)
+	}
+	for i := 0; i < len(s); i += 4 {
+		head = append(head, int32(binary.LittleEndian.Uint32(s[i:])))
+	}
+	return head
+}
+
+func floatInts(values ...float64) []int32 {
+	out := make([]int32, len(values))
+	for i, v := range values {
+		out[i] = int32(math.Float32bits(float32(v)))
+	}
+	return out
+}
+
+func doubleInts(values ...float64) []int32 {
+	out := make([]int32, 0, 2*len(values))
+	for _, v := range values {
+		bits := math.Float64bits(v)
+		out = append(out, int32(uint32(bits)), int32(uint32(bits>>32)))
+	}
+	return out
+}
+
+// writeTestDSS lays out records the way DSS7 does: file header, then each
+// record's info block, internal header and values, then the pathname bin
+// blocks chained through their last word.
+func writeTestDSS(t *testing.T, records []testRecord, binsPerBlock, binSize int) string {
+	t.Helper()
+	words := make([]int64, fileHeaderSize)
+	copy(words[keyDSS:], stringWords("ZDSS", 1))
+	copy(words[keyVersion:], stringWords("7-IU", 1))
+	words[keyHeaderSize] = fileHeaderSize
+	words[keyBinsPerBlock] = int64(binsPerBlock)
+	words[keyBinSize] = int64(binSize)
+
+	infoAddr := make([]int64, len(records))
+	live := 0
+	for i, r := range records {
+		if r.status == statusPrimary {
+			live++
+		}
+		pathWords := numWords(len(r.path))
+		info := make([]int64, infoPathname+pathWords)
+		info[infoFlag] = infoFlagValue
+		info[infoStatus] = r.status
+		info[infoPathLength] = int64(len(r.path))
+		info[infoHash] = int64(i + 1)
+		info[infoTypeVersion] = intWords([]int32{int32(r.recordType), 1})[0]
+		info[infoNumberData] = r.numberData
+		copy(info[infoPathname:], stringWords(r.path, pathWords))
+
+		infoAddr[i] = int64(len(words))
+		headAddr := infoAddr[i] + int64(len(info))
+		info[infoInternalHeadAddr] = headAddr
+		info[infoInternalHeadLen] = int64(len(r.head))
+		head := intWords(r.head)
+		info[infoValues1Addr] = headAddr + int64(len(head))
+		info[infoValues1Len] = int64(len(r.values))
+		words = append(words, info...)
+		words = append(words, head...)
+		words = append(words, intWords(r.values)...)
+	}
+	words[keyNumRecords] = int64(live)
+
+	// Fill the bins in order, starting a new block when one is full
+	var block []int64
+	bin, used := 0, 0
+	newBlock := func() {
+		addr := int64(len(words))
+		if block == nil {
+			words[keyFirstBin] = addr
+		} else {
+			words[len(words)-1] = addr
+		}
+		block = make([]int64, binsPerBlock*binSize+1)
+		words = append(words, block...)
+		block = words[addr:]
+		bin, used = 0, 0
+	}
+	newBlock()
+	for i, r := range records {
+		pathWords := numWords(len(r.path))
+		size := binPathname + pathWords
+		if size > binSize-1 {
+			t.Fatalf("pathname %s does not fit a bin of %d words", r.path, binSize)
+		}
+		if used+size > binSize-1 {
+			bin, used = bin+1, 0
+			if bin == binsPerBlock {
+				newBlock()
+			}
+		}
+		entry := block[bin*binSize+used:]
+		entry[binHash] = int64(i + 1)
+		entry[binStatus] = r.status
+		entry[binPathLength] = int64(len(r.path))
+		entry[binInfoAddress] = infoAddr[i]
+		entry[binTypeAndSort] = intWords([]int32{int32(r.recordType), 0})[0]
+		entry[binLastWrite] = time.Date(2025, 5, 27, 12, 0, 0, 0, time.UTC).UnixMilli()
+		copy(entry[binPathname:], stringWords(r.path, pathWords))
+		used += size
+	}
+	words[keyFileSize] = int64(len(words))
+
+	b := make([]byte, len(words)*wordSize)
+	for i, w := range words {
+		binary.LittleEndian.PutUint64(b[i*wordSize:], uint64(w))
+	}
+	path := filepath.Join(t.TempDir(), "test.dss")
+	if err := os.WriteFile(path, b, 0644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+// testRecords holds two hourly blocks of a junction's flow, a daily double
+// series, a deleted record and a paired data record
+func testRecords() []testRecord {
+	return []testRecord{
+		{
+			path:       "//CUL-048/FLOW/02JAN2024/1HOUR/RUN:REALTIME/",
+			recordType: TypeRegularFloat,
+			status:     statusPrimary,
+			head:       tsHeader(0, 2, 1, 1, 0, "CFS", "INST-VAL"),
+			values:     floatInts(40, 45, 50),
+			numberData: 3,
+		},
+		{
+			path:       "//CUL-048/FLOW/01JAN2024/1HOUR/RUN:REALTIME/",
+			recordType: TypeRegularFloat,
+			status:     statusPrimary,
+			head:       tsHeader(21, 23, 1, 1, 0, "CFS", "INST-VAL"),
+			values:     floatInts(10, -3.402823466e38, 30),
+			numberData: 3,
+		},
+		{
+			path:       "//CUL-048/FLOW/03JAN2024/1HOUR/RUN:OLD/",
+			recordType: TypeRegularFloat,
+			status:     12,
+			head:       tsHeader(0, 0, 1, 1, 0, "CFS", "INST-VAL"),
+			values:     floatInts(1),
+			numberData: 1,
+		},
+		{
+			path:       "/LEON/GAGE/PRECIP-INC/01JAN2024/1DAY/OBS/",
+			recordType: TypeRegularDouble,
+			status:     statusPrimary,
+			head:       tsHeader(0, 1, 2, 60, 480, "IN", "PER-CUM"),
+			values:     doubleInts(0.25, 1.5),
+			numberData: 2,
+		},
+		{
+			path:       "//CUL-048/FLOW-STAGE///RATING/",
+			recordType: TypePairedFloat,
+			status:     statusPrimary,
+			head:       []int32{0},
+			values:     floatInts(0, 1),
+			numberData: 1,
+		},
+	}
+}
+
+func TestCatalog(t *testing.T) {
+	// Small bins so the records span several bins and blocks
+	f, err := Open(writeTestDSS(t, testRecords(), 2, 14))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	if f.Version != "7-IU" || f.NumRecords() != 4 {
+		t.Errorf("version %q, %d records", f.Version, f.NumRecords())
+	}
+	catalog, err := f.Catalog()
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{
+		"//CUL-048/FLOW-STAGE///RATING/",
+		"//CUL-048/FLOW/01JAN2024/1HOUR/RUN:REALTIME/",
+		"//CUL-048/FLOW/02JAN2024/1HOUR/RUN:REALTIME/",
+		"/LEON/GAGE/PRECIP-INC/01JAN2024/1DAY/OBS/",
+	}
+	if len(catalog) != len(want) {
+		t.Fatalf("catalog has %d records, want %d: %+v", len(catalog), len(want), catalog)
+	}
+	for i, e := range catalog {
+		if e.Pathname != want[i] {
+			t.Errorf("catalog[%d] = %s, want %s", i, e.Pathname, want[i])
+		}
+	}
+	if TypeName(catalog[0].Type) != "PD" || TypeName(catalog[3].Type) != "RTD" {
+		t.Errorf("types %d and %d", catalog[0].Type, catalog[3].Type)
+	}
+	if !catalog[1].LastWrite.Equal(time.Date(2025, 5, 27, 12, 0, 0, 0, time.UTC)) {
+		t.Errorf("last write %v", catalog[1].LastWrite)
+	}
+}
+
+func TestReadTimeSeriesJoinsBlocks(t *testing.T) {
+	f, err := Open(writeTestDSS(t, testRecords(), 4, 64))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	ts, err := f.ReadTimeSeries("//cul-048/flow//1Hour/run:realtime/")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ts.Pathname != "//cul-048/flow/01JAN2024 - 02JAN2024/1Hour/run:realtime/" {
+		t.Errorf("pathname %s", ts.Pathname)
+	}
+	if ts.Units != "CFS" || ts.Type != "INST-VAL" || ts.Interval != time.Hour {
+		t.Errorf("units %q, type %q, interval %v", ts.Units, ts.Type, ts.Interval)
+	}
+	wantTimes := []time.Time{
+		time.Date(2024, 1, 1, 22, 0, 0, 0, time.UTC),
+		time.Date(2024, 1, 1, 23, 0, 0, 0, time.UTC),
+		time.Date(2024, 1, 2, 0, 0, 0, 0, time.UTC),
+		time.Date(2024, 1, 2, 1, 0, 0, 0, time.UTC),
+		time.Date(2024, 1, 2, 2, 0, 0, 0, time.UTC),
+		time.Date(2024, 1, 2, 3, 0, 0, 0, time.UTC),
+	}
+	wantValues := []float64{10, math.NaN(), 30, 40, 45, 50}
+	if len(ts.Times) != len(wantTimes) {
+		t.Fatalf("%d values, want %d", len(ts.Times), len(wantTimes))
+	}
+	for i := range wantTimes {
+		if !ts.Times[i].Equal(wantTimes[i]) {
+			t.Errorf("time %d = %v, want %v", i, ts.Times[i], wantTimes[i])
+		}
+		if math.IsNaN(wantValues[i]) != math.IsNaN(ts.Values[i]) || (!math.IsNaN(wantValues[i]) && ts.Values[i] != wantValues[i]) {
+			t.Errorf("value %d = %v, want %v", i, ts.Values[i], wantValues[i])
+		}
+	}
+
+	one, err := f.ReadTimeSeries("//CUL-048/FLOW/02JAN2024/1HOUR/RUN:REALTIME/")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(one.Values) != 3 || one.Values[0] != 40 {
+		t.Errorf("single block values %v", one.Values)
+	}
+}
+
+func TestReadTimeSeriesDoubleWithOffset(t *testing.T) {
+	f, err := Open(writeTestDSS(t, testRecords(), 4, 64))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	ts, err := f.ReadTimeSeries("/LEON/GAGE/PRECIP-INC//1DAY/OBS/")
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Daily values observed at 08:00
+	if len(ts.Values) != 2 || ts.Values[0] != 0.25 || ts.Values[1] != 1.5 {
+		t.Errorf("values %v", ts.Values)
+	}
+	if !ts.Times[0].Equal(time.Date(2024, 1, 1, 8, 0, 0, 0, time.UTC)) || !ts.Times[1].Equal(time.Date(2024, 1, 2, 8, 0, 0, 0, time.UTC)) {
+		t.Errorf("times %v", ts.Times)
+	}
+	if ts.Units != "IN" || ts.Type != "PER-CUM" {
+		t.Errorf("units %q, type %q", ts.Units, ts.Type)
+	}
+}
+
+func TestReadErrors(t *testing.T) {
+	f, err := Open(writeTestDSS(t, testRecords(), 4, 64))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	if _, err := f.ReadTimeSeries("//CUL-048/FLOW//1HOUR/RUN:MISSING/"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("missing series: %v", err)
+	}
+	if _, err := f.ReadTimeSeries("//CUL-048/FLOW/03JAN2024/1HOUR/RUN:OLD/"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("deleted record: %v", err)
+	}
+	if _, err := f.ReadTimeSeries("//CUL-048/FLOW/01JAN2024/IR-MONTH/RUN:REALTIME/"); !errors.Is(err, ErrUnsupported) {
+		t.Errorf("irregular interval: %v", err)
+	}
+	if _, err := f.ReadTimeSeries("/CUL-048/FLOW/"); err == nil || !strings.Contains(err.Error(), "invalid pathname") {
+		t.Errorf("bad pathname: %v", err)
+	}
+
+	path := filepath.Join(t.TempDir(), "not.dss")
+	if err := os.WriteFile(path, []byte("HEC-DSS version 6 file"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := Open(path); !errors.Is(err, ErrNotDSS7) {
+		t.Errorf("non DSS7 file: %v", err)
+	}
+}
+
+func TestParseInterval(t *testing.T) {
+	cases := map[string]time.Duration{
+		"15MINUTE": 15 * time.Minute,
+		"1Hour":    time.Hour,
+		"6HOUR":    6 * time.Hour,
+		"1DAY":     24 * time.Hour,
+		"1Week":    7 * 24 * time.Hour,
+	}
+	for e, want := range cases {
+		got, err := ParseInterval(e)
+		if err != nil || got != want {
+			t.Errorf("ParseInterval(%q) = %v, %v; want %v", e, got, err, want)
+		}
+	}
+	for _, e := range []string{"1MONTH", "IR-DAY", "HOUR", ""} {
+		if _, err := ParseInterval(e); !errors.Is(err, ErrUnsupported) {
+			t.Errorf("ParseInterval(%q) = %v, want ErrUnsupported", e, err)
+		}
+	}
+}
+
+func TestHeaderStringsTimeZone(t *testing.T) {
+	head := tsHeader(0, 0, 1, 60, 0, "CFS", "INST-VAL\x00UTC")
+	units, dataType, tz := headerStrings(head[tsUnits:])
+	if units != "CFS" || dataType != "INST-VAL" || tz != "UTC" {
+		t.Errorf("got %q, %q, %q", units, dataType, tz)
+	}
+	if _, _, tz := headerStrings(tsHeader(0, 0, 1, 60, 0, "CFS", "INST-VAL")[tsUnits:]); tz != "" {
+		t.Errorf("time zone %q without one stored", tz)
+	}
+}
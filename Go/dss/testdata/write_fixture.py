@@ -0,0 +1,71 @@
+# -*- coding: utf-8 -*-
+# write_fixture.py  - run with HEC-DSSVue's jython
+# Usage:
+#   jython write_fixture.py <dir>
+#
+# Writes hec_sample.dss (DSS version 7, by HEC's own library) and
+# hec_sample.json with the values read back from it through HecDss. The Go
+# tests in the dss package read every testdata/*.dss that has such a .json
+# and compare the two, so they check this package against real HEC files.
+
+import sys, os, json, datetime
+from hec.heclib.dss import HecDss
+from hec.heclib.util import HecTime
+from hec.io import TimeSeriesContainer
+from hec.script import Constants
+
+out_dir = sys.argv[1] if len(sys.argv) > 1 else os.path.dirname(os.path.abspath(__file__))
+dss_path = os.path.join(out_dir, "hec_sample.dss")
+json_path = os.path.join(out_dir, "hec_sample.json")
+if os.path.exists(dss_path):
+    os.remove(dss_path)
+
+# HecTime values count minutes from 31Dec1899 00:00
+EPOCH = datetime.datetime(1899, 12, 31)
+
+def iso(minutes):
+    return (EPOCH + datetime.timedelta(minutes=minutes)).strftime("%Y-%m-%dT%H:%M:%SZ")
+
+def make_series(pathname, start, interval, values, units, data_type):
+    tsc = TimeSeriesContainer()
+    tsc.fullName = pathname
+    t0 = HecTime(start[0], start[1]).value()
+    tsc.interval = interval
+    tsc.times = [t0 + i * interval for i in range(len(values))]
+    tsc.values = values
+    tsc.numberValues = len(values)
+    tsc.units = units
+    tsc.type = data_type
+    return tsc
+
+M = Constants.UNDEFINED
+series = [
+    # Hourly flow across a block boundary (1HOUR blocks hold one month)
+    make_series("//CHI-001/FLOW//1HOUR/RUN:FIXTURE/", ("31Dec2023", "2100"), 60,
+                [10.0, 20.5, M, 40.0, 55.25, 30.0], "CFS", "INST-VAL"),
+    # Daily incremental precipitation
+    make_series("/LEON/GAGE/PRECIP-INC//1DAY/OBS/", ("01Mar2024", "2400"), 1440,
+                [0.0, 0.25, 1.5], "IN", "PER-CUM"),
+]
+
+dss = HecDss.open(dss_path)
+try:
+    for tsc in series:
+        dss.put(tsc)
+
+    expected = {"catalog": sorted(dss.getCatalogedPathnames()), "series": []}
+    for tsc in series:
+        back = dss.get(tsc.fullName, True)
+        expected["series"].append({
+            "pathname": tsc.fullName,
+            "units": back.units,
+            "type": back.type,
+            "times": [iso(t) for t in back.times],
+            "values": [None if v == M else v for v in back.values],
+        })
+finally:
+    dss.close()
+
+with open(json_path, "w") as fp:
+    json.dump(expected, fp, indent=2)
+print("Wrote", dss_path, "and", json_path)
@@ -0,0 +1,264 @@
+package dss
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ErrUnsupported is returned when reading a record this package cannot decode.
+var ErrUnsupported = errors.New("dss: unsupported record")
+
+// Internal header of a regular-interval time series record (int32 values)
+const (
+	tsGranularity   = 0 // seconds per time unit of the offset
+	tsPrecision     = 1
+	tsTimeOffset    = 2 // offset of the values into their interval, 0 for end of interval
+	tsProfileDepths = 3
+	tsBlockStart    = 4 // position in the block of the first stored value
+	tsBlockEnd      = 5 // position in the block of the last stored value
+	tsValueSize     = 6 // int32 words per value: 1 float, 2 double
+	tsValueElemSize = 7
+	tsCompression   = 8
+	tsQualitySize   = 9
+	tsQualityComp   = 10
+	tsInotesSize    = 11
+	tsInotesComp    = 12
+	tsCnotesLength  = 13
+	tsUnits         = 14 // units and type, NUL terminated, from here on
+)
+
+// TimeSeries is a regular-interval time series read from one or more blocks.
+type TimeSeries struct {
+	Pathname string // D part is the first block, or first and last blocks joined by " - "
+	Interval time.Duration
+	Units    string      // e.g. CFS
+	Type     string      // e.g. INST-VAL, PER-AVER, PER-CUM
+	TimeZone string      // time zone name when the writer stored one, e.g. UTC
+	Times    []time.Time // UTC; DSS times carry no time zone
+	Values   []float64   // NaN where missing
+}
+
+// IsMissing reports whether v is one of the DSS missing value markers.
+func IsMissing(v float64) bool {
+	return math.IsNaN(v) || v <= -3.4e38 || v == -901 || v == -902
+}
+
+// ParseInterval returns the duration of a regular E part such as 15MINUTE,
+// 1Hour or 1DAY. Monthly, yearly and irregular intervals are unsupported.
+func ParseInterval(e string) (time.Duration, error) {
+	s := strings.ToUpper(strings.TrimSpace(e))
+	i := 0
+	for i < len(s) && s[i] >= '0' && s[i] <= '9' {
+		i++
+	}
+	n, err := strconv.Atoi(s[:i])
+	if err != nil || n <= 0 {
+		return 0, fmt.Errorf("%w: %q is not a regular interval", ErrUnsupported, e)
+	}
+	var unit time.Duration
+	switch s[i:] {
+	case "MIN", "MINUTE", "MINUTES":
+		unit = time.Minute
+	case "HOUR", "HOURS":
+		unit = time.Hour
+	case "DAY", "DAYS":
+		unit = 24 * time.Hour
+	case "WEEK", "WEEKS":
+		unit = 7 * 24 * time.Hour
+	default:
+		return 0, fmt.Errorf("%w: %q is not a regular interval", ErrUnsupported, e)
+	}
+	return time.Duration(n) * unit, nil
+}
+
+// parseBlockDate parses the D part of a time series block, e.g. 01JAN2024
+func parseBlockDate(d string) (time.Time, error) {
+	t, err := time.Parse("02Jan2006", strings.TrimSpace(d))
+	if err != nil {
+		return t, fmt.Errorf("dss: invalid block date %q", d)
+	}
+	return t, nil
+}
+
+// ReadTimeSeries reads a regular-interval time series. With an empty D part
+// every block of the series is read and joined in time order; otherwise only
+// the named block is read. Pathnames match case insensitively.
+func (f *File) ReadTimeSeries(pathname string) (*TimeSeries, error) {
+	p, err := ParsePathname(pathname)
+	if err != nil {
+		return nil, err
+	}
+	if p.D != "" {
+		return f.readBlock(pathname)
+	}
+
+	catalog, err := f.Catalog()
+	if err != nil {
+		return nil, err
+	}
+	type block struct {
+		path  string
+		d     string
+		start time.Time
+	}
+	var blocks []block
+	for _, entry := range catalog {
+		q, err := ParsePathname(entry.Pathname)
+		if err != nil || !p.sameSeries(q) {
+			continue
+		}
+		start, err := parseBlockDate(q.D)
+		if err != nil {
+			return nil, err
+		}
+		blocks = append(blocks, block{entry.Pathname, q.D, start})
+	}
+	if len(blocks) == 0 {
+		return nil, fmt.Errorf("%w: %s", ErrNotFound, pathname)
+	}
+	sort.Slice(blocks, func(i, j int) bool { return blocks[i].start.Before(blocks[j].start) })
+
+	var ts *TimeSeries
+	for _, b := range blocks {
+		part, err := f.readBlock(b.path)
+		if err != nil {
+			return nil, err
+		}
+		if ts == nil {
+			ts = part
+			continue
+		}
+		for i, t := range part.Times {
+			if n := len(ts.Times); n > 0 && !t.After(ts.Times[n-1]) {
+				continue // overlapping blocks keep the earlier block's values
+			}
+			ts.Times = append(ts.Times, t)
+			ts.Values = append(ts.Values, part.Values[i])
+		}
+	}
+	if len(blocks) > 1 {
+		p.D = blocks[0].d + " - " + blocks[len(blocks)-1].d
+	} else {
+		p.D = blocks[0].d
+	}
+	ts.Pathname = p.String()
+	return ts, nil
+}
+
+// readBlock reads the record of one time series block
+func (f *File) readBlock(pathname string) (*TimeSeries, error) {
+	p, err := ParsePathname(pathname)
+	if err != nil {
+		return nil, err
+	}
+	interval, err := ParseInterval(p.E)
+	if err != nil {
+		return nil, err
+	}
+	blockStart, err := parseBlockDate(p.D)
+	if err != nil {
+		return nil, err
+	}
+	addr, err := f.infoAddress(pathname)
+	if err != nil {
+		return nil, err
+	}
+	info, err := f.words(addr, infoPathname)
+	if err != nil {
+		return nil, err
+	}
+	if info[infoFlag] != infoFlagValue {
+		return nil, fmt.Errorf("dss: corrupt info block of %s", pathname)
+	}
+	recordType := int(wordInt(info[infoTypeVersion], 0))
+	if recordType != TypeRegularFloat && recordType != TypeRegularDouble {
+		return nil, fmt.Errorf("%w: %s is %s, not a regular time series", ErrUnsupported, pathname, TypeName(recordType))
+	}
+
+	head, err := f.ints(info[infoInternalHeadAddr], info[infoInternalHeadLen])
+	if err != nil {
+		return nil, err
+	}
+	if len(head) < tsUnits {
+		return nil, fmt.Errorf("dss: short time series header of %s", pathname)
+	}
+	valueSize := int64(head[tsValueSize])
+	if valueSize != 1 && valueSize != 2 {
+		valueSize = 1
+		if recordType == TypeRegularDouble {
+			valueSize = 2
+		}
+	}
+	if head[tsCompression] != 0 {
+		return nil, fmt.Errorf("%w: %s has compressed values", ErrUnsupported, pathname)
+	}
+
+	ts := &TimeSeries{Pathname: p.String(), Interval: interval}
+	ts.Units, ts.Type, ts.TimeZone = headerStrings(head[tsUnits:])
+
+	n := info[infoNumberData]
+	if n <= 0 {
+		n = int64(head[tsBlockEnd]-head[tsBlockStart]) + 1
+	}
+	if n <= 0 || n*valueSize > info[infoValues1Len] {
+		return nil, fmt.Errorf("dss: %s holds %d values in %d words", pathname, n, info[infoValues1Len])
+	}
+	raw, err := f.ints(info[infoValues1Addr], n*valueSize)
+	if err != nil {
+		return nil, err
+	}
+
+	granularity := time.Duration(head[tsGranularity]) * time.Second
+	if granularity == 0 {
+		granularity = time.Second
+	}
+	offset := time.Duration(head[tsTimeOffset]) * granularity
+
+	ts.Times = make([]time.Time, n)
+	ts.Values = make([]float64, n)
+	for i := int64(0); i < n; i++ {
+		pos := time.Duration(int64(head[tsBlockStart]) + i)
+		if offset == 0 {
+			ts.Times[i] = blockStart.Add((pos + 1) * interval)
+		} else {
+			ts.Times[i] = blockStart.Add(pos*interval + offset)
+		}
+
+		var v float64
+		if valueSize == 2 {
+			bits := uint64(uint32(raw[2*i])) | uint64(uint32(raw[2*i+1]))<<32
+			v = math.Float64frombits(bits)
+		} else {
+			v = float64(math.Float32frombits(uint32(raw[i])))
+		}
+		if IsMissing(v) {
+			v = math.NaN()
+		}
+		ts.Values[i] = v
+	}
+	return ts, nil
+}
+
+// headerStrings splits the NUL terminated units, type and time zone name
+// packed in int32 header values; the time zone is empty when not stored
+func headerStrings(ints []int32) (units, dataType, timeZone string) {
+	b := make([]byte, 0, len(ints)*4)
+	for _, v := range ints {
+		u := uint32(v)
+		b = append(b, byte(u), byte(u>>8), byte(u>>16), byte(u>>24))
+	}
+	parts := strings.SplitN(string(b), "\x00", 4)
+	units = strings.TrimSpace(parts[0])
+	if len(parts) > 1 {
+		dataType = strings.TrimSpace(parts[1])
+	}
+	if len(parts) > 2 {
+		timeZone = strings.TrimSpace(parts[2])
+	}
+	return units, dataType, timeZone
+}
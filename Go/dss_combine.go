@@ -0,0 +1,65 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"strings"
+
+	"HMSBackend/dss"
+)
+
+// combinePass1Pass2 combines the MRMS Pass 1 and Pass 2 rainfall into dst,
+// Pass 2 replacing Pass 1 in the hours it covers
+func combinePass1Pass2(ctx context.Context, pass1, pass2, dst string) error {
+	return combineRainfallDSS(ctx, "CombineTwoDssFilesPass1Pass2Batch.bat", pass1, pass2, dst,
+		dss.CombineSource{Name: "pass2", Path: pass2},
+		dss.CombineSource{Name: "pass1", Path: pass1})
+}
+
+// combineObservedAndForecast combines the observed rainfall with the HRRR
+// forecast into dst, the forecast only after the last observed hour
+func combineObservedAndForecast(ctx context.Context, observed, forecast, dst string) error {
+	return combineRainfallDSS(ctx, "CombineTwoDssFilesRealTimeAndHRRBatch.bat", observed, forecast, dst,
+		dss.CombineSource{Name: "observed", Path: observed},
+		dss.CombineSource{Name: "hrrr", Path: forecast, AfterOthers: true})
+}
+
+// combineRainfallDSS combines two rainfall DSS files with the Jython batch
+// file. The records to take from each file are chosen in Go by the source
+// precedence and written to a <dst>_combine.json report, which the script
+// follows. When they cannot be chosen nothing is combined and the error is
+// returned, so the pipeline stops rather than running on a blind merge.
+func combineRainfallDSS(ctx context.Context, batch, first, second, dst string, sources ...dss.CombineSource) error {
+	reportPath, err := writeCombineReport(dst, sources)
+	if err != nil {
+		return fmt.Errorf("failed to plan the combine into %s: %w", dst, err)
+	}
+	return executeBatchFile(ctx, GetJythonBatchScriptPath(batch), first, second, dst, reportPath)
+}
+
+// writeCombineReport plans the combine of sources into dst and writes the
+// report next to dst
+func writeCombineReport(dst string, sources []dss.CombineSource) (string, error) {
+	report, err := dss.PlanCombine(dst, sources...)
+	if err != nil {
+		return "", err
+	}
+	counts := make([]string, len(sources))
+	for i, src := range sources {
+		counts[i] = fmt.Sprintf("%d from %s", report.Sources[src.Name], src.Name)
+	}
+	log.Printf("INFO: Combining %d records into %s (%s)", report.Records, dst, strings.Join(counts, ", "))
+
+	body, err := json.MarshalIndent(report, "", "  ")
+	if err != nil {
+		return "", err
+	}
+	reportPath := strings.TrimSuffix(dst, ".dss") + "_combine.json"
+	if err := os.WriteFile(reportPath, body, 0644); err != nil {
+		return "", fmt.Errorf("failed to write combine report %s: %w", reportPath, err)
+	}
+	return reportPath, nil
+}
@@ -0,0 +1,32 @@
+package main
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"HMSBackend/dss"
+)
+
+func TestCombineRainfallDSSStopsWhenPlanningFails(t *testing.T) {
+	dir := t.TempDir()
+	pass1, pass2 := filepath.Join(dir, "RainfallRealTime.dss"), filepath.Join(dir, "RainfallRealTimePass2.dss")
+	if err := os.WriteFile(pass1, []byte("not a DSS file"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	dst := filepath.Join(dir, "RainfallRealTimePass1And2.dss")
+
+	err := combineRainfallDSS(context.Background(), "CombineTwoDssFilesPass1Pass2Batch.bat", pass1, pass2, dst,
+		dss.CombineSource{Name: "pass2", Path: pass2},
+		dss.CombineSource{Name: "pass1", Path: pass1})
+	if err == nil || !strings.Contains(err.Error(), "failed to plan the combine") {
+		t.Fatalf("error %v, want the planning error", err)
+	}
+	for _, path := range []string{dst, strings.TrimSuffix(dst, ".dss") + "_combine.json"} {
+		if _, err := os.Stat(path); !os.IsNotExist(err) {
+			t.Errorf("%s was written after the plan failed", filepath.Base(path))
+		}
+	}
+}
@@ -0,0 +1,114 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"math"
+	"sort"
+	"strings"
+
+	"HMSBackend/dss"
+)
+
+// dssMissingBelow is the value at or below which HEC-DSS treats data as
+// missing. HEC-DSSVue returns -3.4028E38 (UNDEFINED), which
+// extract_all_dss_data.py writes to the flows JSON as is.
+const dssMissingBelow = -3e38
+
+// defaultFlowUnit is used for records that do not store their units
+const defaultFlowUnit = "cfs"
+
+// readDSSJunctionFlows reads the hourly FLOW series of every junction of a
+// run from an HMS results DSS file, as extract_all_dss_data.py does: one
+// series per B part whose F part contains runToken (e.g. RUN:REALTIME),
+// skipping series without values. Missing values are left out and units
+// come from the record.
+func readDSSJunctionFlows(path, runToken string) (*JunctionFlowsFile, error) {
+	f, err := dss.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	catalog, err := f.Catalog()
+	if err != nil {
+		return nil, err
+	}
+	series := map[string]dss.Pathname{}
+	for _, entry := range catalog {
+		p, err := dss.ParsePathname(entry.Pathname)
+		if err != nil || !strings.EqualFold(p.C, "FLOW") || !strings.EqualFold(p.E, "1HOUR") ||
+			!strings.Contains(strings.ToUpper(p.F), strings.ToUpper(runToken)) {
+			continue
+		}
+		if _, ok := series[p.B]; !ok {
+			p.D = ""
+			series[p.B] = p
+		}
+	}
+	if len(series) == 0 {
+		return nil, fmt.Errorf("no FLOW series of %s in %s", runToken, path)
+	}
+
+	names := make([]string, 0, len(series))
+	for name := range series {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	out := &JunctionFlowsFile{}
+	for _, name := range names {
+		ts, err := f.ReadTimeSeries(series[name].String())
+		if err != nil {
+			return nil, fmt.Errorf("failed to read %s: %w", series[name], err)
+		}
+		if len(ts.Values) == 0 {
+			log.Printf("Warning: No data for %s", series[name])
+			continue
+		}
+		s := JunctionFlowSeries{Name: name, Timezone: ts.TimeZone, Unit: strings.ToLower(ts.Units), Data: []JunctionFlowPoint{}}
+		if s.Timezone == "" {
+			s.Timezone = "UTC" // DSS times are read as UTC
+		}
+		if s.Unit == "" {
+			s.Unit = defaultFlowUnit
+		}
+		for i, v := range ts.Values {
+			if missingFlow(v) {
+				continue
+			}
+			s.Data = append(s.Data, JunctionFlowPoint{Time: ts.Times[i].Format(junctionTimeLayout), Value: v})
+			if s.Max == nil || v > *s.Max {
+				peak := v
+				s.Max = &peak
+			}
+		}
+		out.Series = append(out.Series, s)
+	}
+	return out, nil
+}
+
+// missingFlow reports whether v is a missing or non-finite flow
+func missingFlow(v float64) bool {
+	return math.IsNaN(v) || math.IsInf(v, 0) || v <= dssMissingBelow
+}
+
+// dropMissingFlows removes the missing values the Jython extraction writes
+// and recomputes each series' maximum
+func dropMissingFlows(flows *JunctionFlowsFile) {
+	for i, s := range flows.Series {
+		data := s.Data[:0]
+		var peak *float64
+		for _, p := range s.Data {
+			if missingFlow(p.Value) {
+				continue
+			}
+			data = append(data, p)
+			if peak == nil || p.Value > *peak {
+				v := p.Value
+				peak = &v
+			}
+		}
+		flows.Series[i].Data, flows.Series[i].Max = data, peak
+	}
+}
@@ -0,0 +1,42 @@
+package main
+
+import (
+	"math"
+	"testing"
+)
+
+func TestMissingFlow(t *testing.T) {
+	for _, v := range []float64{math.NaN(), math.Inf(1), math.Inf(-1), -math.MaxFloat32, -3.402823466e38} {
+		if !missingFlow(v) {
+			t.Errorf("missingFlow(%g) = false", v)
+		}
+	}
+	for _, v := range []float64{0, -1, 12.5, -1e6} {
+		if missingFlow(v) {
+			t.Errorf("missingFlow(%g) = true", v)
+		}
+	}
+}
+
+func TestDropMissingFlows(t *testing.T) {
+	flows := &JunctionFlowsFile{Series: []JunctionFlowSeries{
+		{Name: "CHI-001", Data: []JunctionFlowPoint{
+			{Time: "01 May 2024T00:00", Value: 10},
+			{Time: "01 May 2024T01:00", Value: -3.402823466e38}, // as HEC-DSSVue writes it
+			{Time: "01 May 2024T02:00", Value: 25},
+		}},
+		{Name: "OUTLET", Data: []JunctionFlowPoint{{Time: "01 May 2024T00:00", Value: -3.402823466e38}}},
+	}}
+	dropMissingFlows(flows)
+
+	s := flows.Series[0]
+	if len(s.Data) != 2 || s.Data[0].Value != 10 || s.Data[1].Value != 25 {
+		t.Errorf("data = %+v, want 10 and 25", s.Data)
+	}
+	if s.Max == nil || *s.Max != 25 {
+		t.Errorf("max %v, want 25", s.Max)
+	}
+	if s := flows.Series[1]; len(s.Data) != 0 || s.Max != nil {
+		t.Errorf("all-missing series = %+v, want no data and no max", s)
+	}
+}
@@ -0,0 +1,415 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"log"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+
+	"HMSBackend/grib2"
+	"HMSBackend/sqlcdb"
+
+	"github.com/labstack/echo/v4"
+)
+
+const (
+	ensembleMemberSucceeded = "succeeded"
+	ensembleMemberFailed    = "failed"
+)
+
+// ensembleMembers lists the members of an ensemble run: every HRRR cycle
+// from latest back `cycles` hours, each at every scale
+func ensembleMembers(latest time.Time, cycles int, scales []float64) []EnsembleMember {
+	var members []EnsembleMember
+	for i := 0; i < cycles; i++ {
+		cycle := latest.Add(-time.Duration(i) * time.Hour)
+		for _, scale := range scales {
+			members = append(members, EnsembleMember{
+				ID:    fmt.Sprintf("%s_x%03.0f", cycle.Format("20060102t15z"), scale*100),
+				Cycle: cycle,
+				Scale: scale,
+			})
+		}
+	}
+	return members
+}
+
+// isAPCP reports whether m is an HRRR total precipitation accumulation
+func isAPCP(m *grib2.Message) bool {
+	p := m.Product
+	return m.Discipline == 0 && p.Category == 1 && p.Number == 8 && p.IsAccumulation()
+}
+
+// identity reports whether a leaves precipitation unchanged
+func (a PrecipAdjustment) identity() bool {
+	return a.Multiplier == 1 && a.Offset == 0
+}
+
+// apply adjusts a depth in mm accumulated over hours. Negative values are
+// missing-data flags and are returned unchanged.
+func (a PrecipAdjustment) apply(mm, hours float64) float64 {
+	if mm < 0 {
+		return mm
+	}
+	return max(mm*a.Multiplier+a.Offset*25.4*hours, 0)
+}
+
+// writeHRRRForecast writes the precipitation fields of one HRRR cycle,
+// adjusted by adj, to dstDir under the original file names so the HRRR
+// merge script picks them up. It returns the number of files written.
+func writeHRRRForecast(cycle time.Time, adj PrecipAdjustment, dstDir string) (int, error) {
+	if err := os.MkdirAll(dstDir, 0755); err != nil {
+		return 0, err
+	}
+	srcDir := GetGribDownloadPath(cycle.Format("20060102"))
+	count := 0
+	for fh := 2; fh <= 12; fh++ {
+		name := fmt.Sprintf("hrrr.t%sz.wrfsfcf%02d.grib2", cycle.Format("15"), fh)
+		var msgs []*grib2.Message
+		err := grib2.ScanFile(filepath.Join(srcDir, name), func(m *grib2.Message) bool {
+			if isAPCP(m) {
+				msgs = append(msgs, m)
+			}
+			return true
+		})
+		if errors.Is(err, fs.ErrNotExist) {
+			continue
+		}
+		if err != nil {
+			return count, fmt.Errorf("failed to read %s: %w", name, err)
+		}
+
+		if len(msgs) == 0 {
+			continue
+		}
+		for i, m := range msgs {
+			if adj.identity() {
+				break // written as downloaded
+			}
+			values, err := m.Values()
+			if err != nil {
+				return count, fmt.Errorf("failed to decode %s: %w", name, err)
+			}
+			hours := m.Product.IntervalDuration().Hours()
+			for k, v := range values {
+				values[k] = float32(adj.apply(float64(v), hours))
+			}
+			if msgs[i], err = m.Repack(values, 2); err != nil {
+				return count, fmt.Errorf("failed to repack %s: %w", name, err)
+			}
+		}
+		if err := grib2.WriteFile(filepath.Join(dstDir, name), msgs...); err != nil {
+			return count, err
+		}
+		count++
+	}
+	return count, nil
+}
+
+// runEnsembleMember computes one member in its own workspace under jobDir:
+// the shared observed rainfall is combined with the member's forecast, HMS
+// runs over window and the junction flows are extracted from the results.
+func runEnsembleMember(ctx context.Context, model ModelConfig, jobDir string, member EnsembleMember, observedDSS string, start, end time.Time) (*JunctionFlowsFile, error) {
+	ws, err := newHMSWorkspaceIn(filepath.Join(jobDir, member.ID), model, pipelineRunRealtime)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create workspace: %w", err)
+	}
+	m := ws.Model
+
+	forecastDir := filepath.Join(ws.Dir, "hrrr")
+	n, err := writeHRRRForecast(member.Cycle, PrecipAdjustment{Multiplier: member.Scale}, forecastDir)
+	if err != nil {
+		return nil, err
+	}
+	if n == 0 {
+		return nil, fmt.Errorf("no HRRR forecast files for cycle %s", member.Cycle.Format("2006-01-02 15Z"))
+	}
+
+	if err := copyFile(observedDSS, m.RainfallPath("RainfallRealTimePass1And2.dss")); err != nil {
+		return nil, fmt.Errorf("failed to copy observed rainfall: %w", err)
+	}
+	if err := executeBatchFile(ctx, GetJythonBatchScriptPath("MergeGRIBFilesRealTimeHRRBatch.bat"),
+		forecastDir, m.ClipShapefile, m.RainfallPath("HRR.dss")); err != nil {
+		return nil, fmt.Errorf("failed to merge forecast: %w", err)
+	}
+	if err := combineObservedAndForecast(ctx,
+		m.RainfallPath("RainfallRealTimePass1And2.dss"),
+		m.RainfallPath("HRR.dss"),
+		m.RainfallPath("RainfallRealTimeAndForcast.dss")); err != nil {
+		return nil, fmt.Errorf("failed to combine rainfall: %w", err)
+	}
+
+	if err := setControlWindow(m.ControlFile(pipelineRunRealtime), start, end); err != nil {
+		return nil, err
+	}
+	if err := ws.compute(ctx, nil); err != nil {
+		return nil, err
+	}
+	return ws.extractFlows(ctx)
+}
+
+// ensembleBand returns the min, median and max of values
+func ensembleBand(values []float64) EnsembleBand {
+	sorted := append([]float64(nil), values...)
+	sort.Float64s(sorted)
+	n := len(sorted)
+	median := sorted[n/2]
+	if n%2 == 0 {
+		median = (sorted[n/2-1] + sorted[n/2]) / 2
+	}
+	return EnsembleBand{
+		Min:    roundTo(sorted[0], 100),
+		Median: roundTo(median, 100),
+		Max:    roundTo(sorted[n-1], 100),
+	}
+}
+
+// ensembleBands combines the members' junction flows into per-junction
+// bands, leaving out missing values. Times are kept in the order the first
+// member reports them.
+func ensembleBands(members []*JunctionFlowsFile) []EnsembleFlowSeries {
+	type junction struct {
+		series EnsembleFlowSeries
+		times  []string
+		values map[string][]float64
+		peaks  []float64
+	}
+	byName := map[string]*junction{}
+	var names []string
+	for _, flows := range members {
+		for _, s := range flows.Series {
+			j, ok := byName[s.Name]
+			if !ok {
+				j = &junction{
+					series: EnsembleFlowSeries{Name: s.Name, Timezone: s.Timezone, Unit: s.Unit},
+					values: map[string][]float64{},
+				}
+				byName[s.Name] = j
+				names = append(names, s.Name)
+			}
+			var peak *float64
+			for _, p := range s.Data {
+				if missingFlow(p.Value) {
+					continue
+				}
+				if _, seen := j.values[p.Time]; !seen {
+					j.times = append(j.times, p.Time)
+				}
+				j.values[p.Time] = append(j.values[p.Time], p.Value)
+				if peak == nil || p.Value > *peak {
+					v := p.Value
+					peak = &v
+				}
+			}
+			if peak == nil {
+				continue
+			}
+			j.series.Members++
+			j.peaks = append(j.peaks, *peak)
+		}
+	}
+
+	sort.Strings(names)
+	series := make([]EnsembleFlowSeries, 0, len(names))
+	for _, name := range names {
+		j := byName[name]
+		if len(j.peaks) == 0 {
+			continue
+		}
+		j.series.Peak = ensembleBand(j.peaks)
+		j.series.Data = make([]EnsembleFlowPoint, 0, len(j.times))
+		for _, t := range j.times {
+			j.series.Data = append(j.series.Data, EnsembleFlowPoint{Time: t, EnsembleBand: ensembleBand(j.values[t])})
+		}
+		series = append(series, j.series)
+	}
+	return series
+}
+
+// RunEnsemblePipeline runs a model for every member of a time-lagged HRRR
+// ensemble at several precipitation scales and publishes the per-junction
+// flow bands. The observed rainfall is merged once and shared by the members.
+func RunEnsemblePipeline(ctx context.Context, queries *sqlcdb.Queries, req EnsembleRequest) (err error) {
+	model, err := GetModel(req.Model)
+	if err != nil {
+		return err
+	}
+
+	dateToUse := req.Date
+	if dateToUse == "" {
+		dateToUse = time.Now().Format("20060102")
+	}
+	runHourToUse := req.RunHour
+	if runHourToUse == "" {
+		runHourToUse = time.Now().UTC().Add(-1 * time.Hour).Format("15")
+	}
+	latest, err := time.Parse("2006010215", dateToUse+runHourToUse)
+	if err != nil {
+		return fmt.Errorf("invalid date or run hour: %w", err)
+	}
+	cycles := req.Cycles
+	if cycles == 0 {
+		cycles = AppConfig.Ensemble.Cycles
+	}
+	scales := req.Scales
+	if len(scales) == 0 {
+		scales = AppConfig.Ensemble.Scales
+	}
+	members := ensembleMembers(latest, cycles, scales)
+	log.Printf("INFO: Running the ensemble pipeline for model %s: %d cycles x %d scales", model.ID, cycles, len(scales))
+
+	runID := startPipelineRun(ctx, queries, pipelineRunEnsemble, map[string]interface{}{
+		"model":    model.ID,
+		"date":     dateToUse,
+		"run_hour": runHourToUse,
+		"cycles":   cycles,
+		"scales":   scales,
+	})
+	defer func() { finishPipelineRun(queries, runID, err) }()
+
+	jobDir, err := filepath.Abs(hmsJobDir(model, pipelineRunEnsemble, runID))
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(jobDir, 0755); err != nil {
+		return fmt.Errorf("failed to create job directory: %w", err)
+	}
+	defer func() { releaseJobDir(jobDir, err != nil, req.KeepWorkspace) }()
+
+	// Step 1: Observed rainfall shared by every member
+	log.Printf("STEP 1: Merging observed MRMS rainfall...")
+	if err = downloadGRIBFiles(dateToUse, true); err != nil {
+		return fmt.Errorf("failed at step 1 (Get GRIB2 Files RealTime): %w", err)
+	}
+	biasMethod, applyBias := resolveBiasOptions("", nil)
+	mrmsDir := GetGribDownloadPath(dateToUse)
+	windowStart, windowEnd := realtimeRunWindow(mrmsDir)
+	mrmsDir = runBiasCorrection(ctx, queries, runID, mrmsDir, filepath.Join(jobDir, biasCorrectedDir), windowStart, windowEnd, biasMethod, applyBias)
+	storePrecipRunSummary(ctx, queries, runID, pipelineRunEnsemble, []string{mrmsDir}, windowStart, windowEnd)
+
+	observedDSS := filepath.Join(jobDir, "RainfallRealTimePass1And2.dss")
+	steps := []struct {
+		name  string
+		batch string
+		args  []string
+	}{
+		{"Merge GRIB Files RealTime", "MergeGRIBFilesRealTimeBatch.bat",
+			[]string{mrmsDir, model.ClipShapefile, filepath.Join(jobDir, "RainfallRealTime.dss")}},
+		{"Merge GRIB Files RealTime Pass 2", "MergeGRIBFilesRealTimePass2Batch.bat",
+			[]string{mrmsDir, model.ClipShapefile, filepath.Join(jobDir, "RainfallRealTimePass2.dss")}},
+	}
+	for _, step := range steps {
+		if err = executeBatchFile(ctx, GetJythonBatchScriptPath(step.batch), step.args...); err != nil {
+			return fmt.Errorf("failed at step 1 (%s): %w", step.name, err)
+		}
+	}
+	if err = combinePass1Pass2(ctx, filepath.Join(jobDir, "RainfallRealTime.dss"), filepath.Join(jobDir, "RainfallRealTimePass2.dss"), observedDSS); err != nil {
+		return fmt.Errorf("failed at step 1 (Combine DSS Records Pass1 Pass2): %w", err)
+	}
+	log.Printf("STEP 1 COMPLETE: Observed rainfall written to %s", observedDSS)
+
+	// Step 2: HRRR cycles of the time-lagged ensemble
+	log.Printf("STEP 2: Downloading %d HRRR cycles...", cycles)
+	for i := 0; i < cycles; i++ {
+		cycle := latest.Add(-time.Duration(i) * time.Hour)
+		if dlErr := downloadHRRRForecastGRIB(cycle.Format("20060102"), cycle.Format("15")); dlErr != nil {
+			log.Printf("Warning: Failed to download HRRR cycle %s: %v", cycle.Format("2006-01-02 15Z"), dlErr)
+		}
+	}
+
+	// Step 3: Compute every member over the same window
+	start, end := realtimeControlWindow(time.Now())
+	var results []*JunctionFlowsFile
+	for i := range members {
+		member := &members[i]
+		log.Printf("STEP 3: Computing ensemble member %s (%d of %d)...", member.ID, i+1, len(members))
+		flows, memberErr := runEnsembleMember(ctx, model, jobDir, *member, observedDSS, start, end)
+		if memberErr != nil {
+			log.Printf("Warning: Ensemble member %s failed: %v", member.ID, memberErr)
+			member.Status, member.Error = ensembleMemberFailed, memberErr.Error()
+			continue
+		}
+		member.Status = ensembleMemberSucceeded
+		results = append(results, flows)
+	}
+	if len(results) == 0 {
+		return fmt.Errorf("failed at step 3: all %d ensemble members failed", len(members))
+	}
+
+	// Step 4: Percentile bands
+	out := EnsembleFlowsFile{
+		Model:     model.ID,
+		RunID:     runID,
+		Generated: time.Now().UTC(),
+		Members:   members,
+		Series:    ensembleBands(results),
+	}
+	data, err := json.MarshalIndent(out, "", "    ")
+	if err != nil {
+		return err
+	}
+	outputPath := GetJSONOutputPath(model.EnsembleJSONOutput)
+	if err = os.WriteFile(outputPath, data, 0644); err != nil {
+		return fmt.Errorf("failed at step 4 (write ensemble flows): %w", err)
+	}
+	log.Printf("INFO: Ensemble pipeline completed: %d of %d members, bands written to %s", len(results), len(members), outputPath)
+	return nil
+}
+
+// handleRunHMSPipelineEnsemble starts an ensemble run in the background
+func handleRunHMSPipelineEnsemble(queries *sqlcdb.Queries) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		var req EnsembleRequest
+		if err := c.Bind(&req); err != nil {
+			log.Printf("Error parsing ensemble request body: %v", err)
+			return respondWithError(c, http.StatusBadRequest, "Invalid request format")
+		}
+		if _, err := GetModel(req.Model); err != nil {
+			return respondWithError(c, http.StatusBadRequest, err.Error())
+		}
+		if req.Cycles < 0 {
+			return respondWithError(c, http.StatusBadRequest, "cycles must be positive")
+		}
+		for _, s := range req.Scales {
+			if s <= 0 {
+				return respondWithError(c, http.StatusBadRequest, "scales must be positive")
+			}
+		}
+		cycles, scales := req.Cycles, len(req.Scales)
+		if cycles == 0 {
+			cycles = AppConfig.Ensemble.Cycles
+		}
+		if scales == 0 {
+			scales = len(AppConfig.Ensemble.Scales)
+		}
+		if limit := AppConfig.Ensemble.MaxMembers; limit > 0 && cycles*scales > limit {
+			return respondWithError(c, http.StatusBadRequest, fmt.Sprintf("%d members requested, at most %d allowed", cycles*scales, limit))
+		}
+
+		log.Printf("Received ensemble pipeline request: model=%s, date=%s, run_hour=%s, cycles=%d, scales=%v",
+			req.Model, req.Date, req.RunHour, req.Cycles, req.Scales)
+
+		go func() {
+			// Members compute one after another, so allow far more than a single run
+			ctx, cancel := context.WithTimeout(context.Background(), 6*time.Hour)
+			defer cancel()
+
+			if err := RunEnsemblePipeline(ctx, queries, req); err != nil {
+				log.Printf("Ensemble pipeline failed: %v", err)
+			}
+		}()
+
+		return respondWithJSON(c, http.StatusAccepted, map[string]interface{}{
+			"message": "HMS ensemble pipeline started",
+			"status":  "accepted",
+			"members": cycles * scales,
+		})
+	}
+}
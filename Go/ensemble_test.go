@@ -0,0 +1,37 @@
+package main
+
+import "testing"
+
+func TestEnsembleBandsSkipsMissing(t *testing.T) {
+	times := []string{"01 May 2024T00:00", "01 May 2024T01:00"}
+	member := func(values ...float64) *JunctionFlowsFile {
+		s := JunctionFlowSeries{Name: "CHI-001", Unit: "cfs"}
+		for i, v := range values {
+			s.Data = append(s.Data, JunctionFlowPoint{Time: times[i], Value: v})
+		}
+		return &JunctionFlowsFile{Series: []JunctionFlowSeries{s}}
+	}
+	missing := -3.402823466e38
+	series := ensembleBands([]*JunctionFlowsFile{
+		member(10, 30),
+		member(missing, 20),
+		member(14, 40),
+		member(missing, missing), // no values: not a member of the band
+	})
+	if len(series) != 1 {
+		t.Fatalf("got %d series, want 1", len(series))
+	}
+	s := series[0]
+	if s.Members != 3 {
+		t.Errorf("members %d, want 3", s.Members)
+	}
+	if first := s.Data[0].EnsembleBand; first.Min != 10 || first.Median != 12 || first.Max != 14 {
+		t.Errorf("first hour band %+v, want 10/12/14", first)
+	}
+	if second := s.Data[1].EnsembleBand; second.Min != 20 || second.Median != 30 || second.Max != 40 {
+		t.Errorf("second hour band %+v, want 20/30/40", second)
+	}
+	if s.Peak.Min != 20 || s.Peak.Median != 30 || s.Peak.Max != 40 {
+		t.Errorf("peak band %+v, want 20/30/40", s.Peak)
+	}
+}
@@ -0,0 +1,384 @@
+package main
+
+import (
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"math"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"HMSBackend/sqlcdb"
+
+	"github.com/labstack/echo/v4"
+)
+
+const maxGaugeUploadBytes = 20 << 20
+
+// GaugeSource provides rain gauge readings for a time window. New feeds
+// (e.g. a county ALERT system) are added by implementing it and registering
+// the name in newGaugeSource.
+type GaugeSource interface {
+	Name() string
+	Fetch(ctx context.Context, start, end time.Time) ([]GaugeReading, error)
+}
+
+// newGaugeSource returns the source configured in gauges.source, or nil when
+// readings only come from CSV uploads.
+func newGaugeSource() (GaugeSource, error) {
+	cfg := AppConfig.Gauges
+	switch cfg.Source {
+	case "":
+		return nil, nil
+	case "local":
+		return localGaugeSource{dir: cfg.LocalDir}, nil
+	case "http":
+		if cfg.HTTPURL == "" {
+			return nil, errors.New("gauges.http_url is required for the http gauge source")
+		}
+		return httpGaugeSource{url: cfg.HTTPURL, client: &http.Client{Timeout: 60 * time.Second}}, nil
+	}
+	return nil, fmt.Errorf("unknown gauge source %q", cfg.Source)
+}
+
+// localGaugeSource reads the CSV files dropped in a directory. It stands in
+// for a real gauge feed during development and for manually collected data.
+type localGaugeSource struct {
+	dir string
+}
+
+func (s localGaugeSource) Name() string { return "local" }
+
+func (s localGaugeSource) Fetch(ctx context.Context, start, end time.Time) ([]GaugeReading, error) {
+	paths, err := filepath.Glob(filepath.Join(s.dir, "*.csv"))
+	if err != nil {
+		return nil, err
+	}
+	var readings []GaugeReading
+	for _, path := range paths {
+		f, err := os.Open(path)
+		if err != nil {
+			return nil, err
+		}
+		rows, err := parseGaugeCSV(f)
+		f.Close()
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", filepath.Base(path), err)
+		}
+		readings = append(readings, filterGaugeReadings(rows, start, end)...)
+	}
+	return readings, nil
+}
+
+// httpGaugeSource queries <url>?start=&end= and accepts CSV (same columns as
+// uploads) or a JSON array of GaugeReading.
+type httpGaugeSource struct {
+	url    string
+	client *http.Client
+}
+
+func (s httpGaugeSource) Name() string { return "http" }
+
+func (s httpGaugeSource) Fetch(ctx context.Context, start, end time.Time) ([]GaugeReading, error) {
+	u, err := url.Parse(s.url)
+	if err != nil {
+		return nil, fmt.Errorf("invalid gauge URL: %w", err)
+	}
+	q := u.Query()
+	q.Set("start", start.UTC().Format(time.RFC3339))
+	q.Set("end", end.UTC().Format(time.RFC3339))
+	u.RawQuery = q.Encode()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u.String(), nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch gauge readings: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("gauge source returned status %d", resp.StatusCode)
+	}
+
+	var readings []GaugeReading
+	if strings.Contains(resp.Header.Get("Content-Type"), "json") {
+		if err := json.NewDecoder(resp.Body).Decode(&readings); err != nil {
+			return nil, fmt.Errorf("failed to decode gauge readings: %w", err)
+		}
+		for i := range readings {
+			readings[i].Time = readings[i].Time.UTC()
+		}
+	} else if readings, err = parseGaugeCSV(resp.Body); err != nil {
+		return nil, err
+	}
+	return filterGaugeReadings(readings, start, end), nil
+}
+
+func filterGaugeReadings(readings []GaugeReading, start, end time.Time) []GaugeReading {
+	out := readings[:0]
+	for _, r := range readings {
+		if r.Time.After(start) && !r.Time.After(end) {
+			out = append(out, r)
+		}
+	}
+	return out
+}
+
+// parseGaugeCSV reads readings from a CSV with a header row. Required columns
+// are station_id, time and precip_in (or precip_mm); name, lat and lon are
+// optional but a station's location must be known before its readings are
+// stored. Times without an offset are UTC.
+func parseGaugeCSV(r io.Reader) ([]GaugeReading, error) {
+	cr := csv.NewReader(r)
+	cr.TrimLeadingSpace = true
+	cr.FieldsPerRecord = -1
+	header, err := cr.Read()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read CSV header: %w", err)
+	}
+	col := map[string]int{}
+	for i, h := range header {
+		col[strings.ToLower(strings.TrimSpace(strings.TrimPrefix(h, "\ufeff")))] = i
+	}
+	find := func(names ...string) int {
+		for _, n := range names {
+			if i, ok := col[n]; ok {
+				return i
+			}
+		}
+		return -1
+	}
+	station := find("station_id", "station", "gauge_id")
+	timeCol := find("time", "valid_time", "datetime", "timestamp")
+	precipIn, precipMM := find("precip_in", "precip", "rain_in"), find("precip_mm", "rain_mm")
+	name, lat, lon := find("name", "station_name"), find("lat", "latitude"), find("lon", "longitude")
+	if station < 0 || timeCol < 0 || (precipIn < 0 && precipMM < 0) {
+		return nil, errors.New("CSV needs station_id, time and precip_in (or precip_mm) columns")
+	}
+
+	field := func(rec []string, i int) string {
+		if i < 0 || i >= len(rec) {
+			return ""
+		}
+		return strings.TrimSpace(rec[i])
+	}
+	var readings []GaugeReading
+	for line := 2; ; line++ {
+		rec, err := cr.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", line, err)
+		}
+		reading := GaugeReading{StationID: field(rec, station), Name: field(rec, name)}
+		if reading.StationID == "" {
+			return nil, fmt.Errorf("line %d: missing station_id", line)
+		}
+		if reading.Time, err = parseQueryTime(field(rec, timeCol)); err != nil {
+			return nil, fmt.Errorf("line %d: invalid time %q", line, field(rec, timeCol))
+		}
+		reading.Time = reading.Time.UTC()
+		if precipIn >= 0 {
+			reading.PrecipIn, err = strconv.ParseFloat(field(rec, precipIn), 64)
+		} else {
+			reading.PrecipIn, err = strconv.ParseFloat(field(rec, precipMM), 64)
+			reading.PrecipIn /= mmPerInch
+		}
+		if err != nil || reading.PrecipIn < 0 {
+			return nil, fmt.Errorf("line %d: invalid precipitation", line)
+		}
+		if latV, err := strconv.ParseFloat(field(rec, lat), 64); err == nil {
+			if lonV, err := strconv.ParseFloat(field(rec, lon), 64); err == nil {
+				reading.Lat, reading.Lon = &latV, &lonV
+			}
+		}
+		readings = append(readings, reading)
+	}
+	return readings, nil
+}
+
+// storeGaugeReadings saves readings, registering stations that come with a
+// location. Readings of unknown stations without one are skipped and their
+// ids returned.
+func storeGaugeReadings(ctx context.Context, queries *sqlcdb.Queries, readings []GaugeReading, source string) (int, []string, error) {
+	gauges, err := queries.ListRainGauges(ctx)
+	if err != nil {
+		return 0, nil, fmt.Errorf("failed to list rain gauges: %w", err)
+	}
+	known := map[string]bool{}
+	for _, g := range gauges {
+		known[g.StationID] = true
+	}
+
+	registered := map[string]bool{}
+	for _, r := range readings {
+		if r.Lat == nil || r.Lon == nil || registered[r.StationID] {
+			continue
+		}
+		err := queries.UpsertRainGauge(ctx, sqlcdb.UpsertRainGaugeParams{
+			StationID: r.StationID,
+			Name:      r.Name,
+			Lat:       *r.Lat,
+			Lon:       *r.Lon,
+			Source:    source,
+		})
+		if err != nil {
+			return 0, nil, fmt.Errorf("failed to store gauge %s: %w", r.StationID, err)
+		}
+		registered[r.StationID], known[r.StationID] = true, true
+	}
+
+	stored := 0
+	unknown := map[string]bool{}
+	for _, r := range readings {
+		if !known[r.StationID] {
+			unknown[r.StationID] = true
+			continue
+		}
+		err := queries.UpsertGaugeObservation(ctx, sqlcdb.UpsertGaugeObservationParams{
+			StationID: r.StationID,
+			ValidTime: r.Time.UTC(),
+			PrecipIn:  r.PrecipIn,
+			Source:    source,
+		})
+		if err != nil {
+			return stored, nil, fmt.Errorf("failed to store reading of %s: %w", r.StationID, err)
+		}
+		stored++
+	}
+	var skipped []string
+	for id := range unknown {
+		skipped = append(skipped, id)
+	}
+	return stored, skipped, nil
+}
+
+// ingestGaugeSource pulls the configured source's readings for a window into
+// the database. Without a configured source it does nothing.
+func ingestGaugeSource(ctx context.Context, queries *sqlcdb.Queries, start, end time.Time) error {
+	source, err := newGaugeSource()
+	if err != nil || source == nil {
+		return err
+	}
+	readings, err := source.Fetch(ctx, start, end)
+	if err != nil {
+		return fmt.Errorf("%s gauge source: %w", source.Name(), err)
+	}
+	stored, skipped, err := storeGaugeReadings(ctx, queries, readings, source.Name())
+	if err != nil {
+		return err
+	}
+	if len(skipped) > 0 {
+		log.Printf("Warning: Skipped readings of gauges without a location: %s", strings.Join(skipped, ", "))
+	}
+	log.Printf("INFO: Stored %d gauge readings from the %s source", stored, source.Name())
+	return nil
+}
+
+// handleUploadGaugeCSV handles POST /api/gauges/upload (admins) with a CSV
+// either as the multipart field "file" or as the raw request body. The
+// readings feed the operational bias factors.
+func handleUploadGaugeCSV(queries *sqlcdb.Queries) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		if ok, err := requireAdmin(c, queries); !ok {
+			return err
+		}
+		var body io.Reader
+		if fh, err := c.FormFile("file"); err == nil {
+			f, err := fh.Open()
+			if err != nil {
+				return respondWithError(c, http.StatusBadRequest, "Failed to open uploaded file")
+			}
+			defer f.Close()
+			body = f
+		} else {
+			body = c.Request().Body
+		}
+
+		readings, err := parseGaugeCSV(io.LimitReader(body, maxGaugeUploadBytes))
+		if err != nil {
+			return respondWithError(c, http.StatusBadRequest, err.Error())
+		}
+		stored, skipped, err := storeGaugeReadings(c.Request().Context(), queries, readings, "upload")
+		if err != nil {
+			log.Printf("Error storing gauge upload: %v", err)
+			return respondWithError(c, http.StatusInternalServerError, "Failed to store gauge readings")
+		}
+		return respondWithJSON(c, http.StatusOK, echo.Map{
+			"readings":         len(readings),
+			"stored":           stored,
+			"skipped_stations": skipped,
+		})
+	}
+}
+
+// handleListGauges serves GET /api/gauges
+func handleListGauges(queries *sqlcdb.Queries) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		gauges, err := queries.ListRainGauges(c.Request().Context())
+		if err != nil {
+			log.Printf("Error listing rain gauges: %v", err)
+			return respondWithError(c, http.StatusInternalServerError, "Failed to list rain gauges")
+		}
+		if gauges == nil {
+			gauges = []sqlcdb.RainGauge{}
+		}
+		return respondWithJSON(c, http.StatusOK, gauges)
+	}
+}
+
+// handleGetGaugeObservations serves GET /api/gauges/:station/observations?from=&to=
+// (default the last 48 hours)
+func handleGetGaugeObservations(queries *sqlcdb.Queries) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		to := time.Now().UTC()
+		from := to.Add(-48 * time.Hour)
+		var err error
+		if v := c.QueryParam("to"); v != "" {
+			if to, err = parseQueryTime(v); err != nil {
+				return respondWithError(c, http.StatusBadRequest, "Invalid to time")
+			}
+		}
+		if v := c.QueryParam("from"); v != "" {
+			if from, err = parseQueryTime(v); err != nil {
+				return respondWithError(c, http.StatusBadRequest, "Invalid from time")
+			}
+		}
+
+		obs, err := queries.ListGaugeObservations(c.Request().Context(), sqlcdb.ListGaugeObservationsParams{
+			StationID: c.Param("station"),
+			FromTime:  from.UTC(),
+			ToTime:    to.UTC(),
+		})
+		if err != nil {
+			log.Printf("Error listing gauge observations: %v", err)
+			return respondWithError(c, http.StatusInternalServerError, "Failed to list gauge observations")
+		}
+		total := 0.0
+		for _, o := range obs {
+			total += o.PrecipIn
+		}
+		if obs == nil {
+			obs = []sqlcdb.GaugeObservation{}
+		}
+		return respondWithJSON(c, http.StatusOK, echo.Map{
+			"station_id":   c.Param("station"),
+			"from":         from.UTC(),
+			"to":           to.UTC(),
+			"total":        math.Round(total*1000) / 1000,
+			"observations": obs,
+		})
+	}
+}
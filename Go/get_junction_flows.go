@@ -1,44 +1,41 @@
 package main
 
 import (
-	"context"
 	"log"
 	"net/http"
 	"os"
-	"time"
+
+	"HMSBackend/sqlcdb"
 
 	"github.com/labstack/echo/v4"
 )
 
-// ProcessAllJunctionFlows executes the Jython script to generate all junction flow data
-func ProcessAllJunctionFlows() error {
-	// Execute the Jython script to generate all junction flows
-	scriptPath := GetPythonScriptPath("Jython_Scripts/extract_all_dss_data.py")
-	log.Printf("Executing Jython script: %s", scriptPath)
-
-	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Minute) // Increased timeout for processing all junctions
-	defer cancel()
-
-	err := executeJythonScript(ctx, scriptPath)
-	if err != nil {
-		log.Printf("Error executing Jython script for all junction flow data: %v", err)
-		return err
-	}
-
-	log.Printf("Successfully executed Jython script for all junction flows")
-	return nil
-}
-
-// handleGetAllJunctionFlows serves the output.json file
-func handleGetAllJunctionFlows(c echo.Context) error {
-	// Read the JSON file
-	jsonPath := GetJSONOutputPath("output.json")
-	jsonData, err := os.ReadFile(jsonPath)
-	if err != nil {
-		log.Printf("Error reading JSON file: %v", err)
-		return respondWithError(c, http.StatusInternalServerError, "Failed to read junction flow data")
+// handleGetAllJunctionFlows serves the junction flows JSON of a model (?model=, default model without it),
+// with the flood status of the junctions that have thresholds.
+// With ?ensemble=true it serves the flow bands of the model's latest ensemble run instead.
+func handleGetAllJunctionFlows(queries *sqlcdb.Queries) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		model, err := GetModel(c.QueryParam("model"))
+		if err != nil {
+			return respondWithError(c, http.StatusBadRequest, err.Error())
+		}
+
+		if c.QueryParam("ensemble") == "true" {
+			jsonData, err := os.ReadFile(GetJSONOutputPath(model.EnsembleJSONOutput))
+			if err != nil {
+				log.Printf("Error reading JSON file: %v", err)
+				return respondWithError(c, http.StatusInternalServerError, "Failed to read junction flow data")
+			}
+			// Return the JSON data directly
+			return c.JSONBlob(http.StatusOK, jsonData)
+		}
+
+		flows, err := loadJunctionFlows(GetJSONOutputPath(model.JSONOutput))
+		if err != nil {
+			log.Printf("Error reading JSON file: %v", err)
+			return respondWithError(c, http.StatusInternalServerError, "Failed to read junction flow data")
+		}
+		annotateJunctionFlows(c.Request().Context(), queries, model.ID, flows)
+		return respondWithJSON(c, http.StatusOK, flows)
 	}
-
-	// Return the JSON data directly
-	return c.JSONBlob(http.StatusOK, jsonData)
 }
@@ -1,21 +1,24 @@
 package main
 
 import (
-	"bytes"
 	"compress/gzip"
 	"context"
-	"encoding/json"
 	"fmt"
 	"io"
 	"log"
+	"math"
 	"net/http"
 	"net/url"
 	"os"
-	"os/exec"
 	"path/filepath"
 	"regexp"
+	"strconv"
 	"time"
 
+	"HMSBackend/cog"
+	"HMSBackend/grib2"
+	"HMSBackend/sqlcdb"
+
 	"github.com/labstack/echo/v4"
 )
 
@@ -33,10 +36,16 @@ func FetchLatestQPE(ctx context.Context) (string, error) {
 		return "", fmt.Errorf("mrmsDataSourceURL is not configured")
 	}
 
-	log.Printf("Fetching GRIB index from: %s", mrmsDataSourceURL)
+	return fetchLatestMRMSGrib(ctx, mrmsDataSourceURL, filepath.Join(AppConfig.Paths.GribFilesDir, "latest_qpe.grib2"))
+}
+
+// fetchLatestMRMSGrib downloads the newest *.grib2.gz listed in an MRMS
+// directory index, decompressing it to outputFilePath.
+func fetchLatestMRMSGrib(ctx context.Context, indexURL string, outputFilePath string) (string, error) {
+	log.Printf("Fetching GRIB index from: %s", indexURL)
 
 	// 1. Fetch the HTML index
-	req, err := http.NewRequestWithContext(ctx, "GET", mrmsDataSourceURL, nil)
+	req, err := http.NewRequestWithContext(ctx, "GET", indexURL, nil)
 	if err != nil {
 		return "", fmt.Errorf("failed to create request for MRMS index: %w", err)
 	}
@@ -44,7 +53,7 @@ func FetchLatestQPE(ctx context.Context) (string, error) {
 	client := &http.Client{Timeout: 30 * time.Second}
 	resp, err := client.Do(req)
 	if err != nil {
-		return "", fmt.Errorf("failed to fetch MRMS index from %s: %w", mrmsDataSourceURL, err)
+		return "", fmt.Errorf("failed to fetch MRMS index from %s: %w", indexURL, err)
 	}
 	defer resp.Body.Close()
 
@@ -64,7 +73,7 @@ func FetchLatestQPE(ctx context.Context) (string, error) {
 	matches := re.FindAllStringSubmatch(bodyString, -1)
 
 	if len(matches) == 0 {
-		return "", fmt.Errorf("no .grib2.gz files found in MRMS index at %s. HTML content might have changed or list is empty", mrmsDataSourceURL)
+		return "", fmt.Errorf("no .grib2.gz files found in MRMS index at %s. HTML content might have changed or list is empty", indexURL)
 	}
 
 	// The last match is considered the newest
@@ -72,12 +81,12 @@ func FetchLatestQPE(ctx context.Context) (string, error) {
 	log.Printf("Latest GRIB file found in index: %s", latestFileRelativePath)
 
 	// Construct full download URL
-	baseParsedURL, err := url.Parse(mrmsDataSourceURL)
+	baseParsedURL, err := url.Parse(indexURL)
 	if err != nil {
-		return "", fmt.Errorf("failed to parse base MRMS URL %s: %w", mrmsDataSourceURL, err)
+		return "", fmt.Errorf("failed to parse base MRMS URL %s: %w", indexURL, err)
 	}
 	// ResolveReference correctly handles joining, whether latestFileRelativePath is absolute or relative,
-	// and whether mrmsDataSourceURL ends with a slash.
+	// and whether indexURL ends with a slash.
 	fileDownloadURL := baseParsedURL.ResolveReference(&url.URL{Path: latestFileRelativePath})
 	log.Printf("Constructed full download URL: %s", fileDownloadURL.String())
 
@@ -107,13 +116,12 @@ func FetchLatestQPE(ctx context.Context) (string, error) {
 	}
 	defer gzReader.Close()
 
-	// Ensure gribFiles directory exists
-	gribFilesDir := AppConfig.Paths.GribFilesDir
+	// Ensure the output directory exists
+	gribFilesDir := filepath.Dir(outputFilePath)
 	if err := os.MkdirAll(gribFilesDir, 0755); err != nil {
 		return "", fmt.Errorf("failed to create directory %s: %w", gribFilesDir, err)
 	}
 
-	outputFilePath := filepath.Join(gribFilesDir, "latest_qpe.grib2")
 	outFile, err := os.Create(outputFilePath)
 	if err != nil {
 		return "", fmt.Errorf("failed to create output file %s: %w", outputFilePath, err)
@@ -131,43 +139,57 @@ func FetchLatestQPE(ctx context.Context) (string, error) {
 }
 
 // ---- Echo handler -------------------------------------------------
-func handelGetLatestPrecip(c echo.Context) error {
-	ctx, cancel := context.WithTimeout(c.Request().Context(), 2*time.Minute)
-	defer cancel()
-
-	meta, err := runGRIBtoCOG(ctx) // This already returns the PrecipMeta with CogPath
-	if err != nil {
-		// Use your existing respondWithError function
-		// Ensure the error message from runGRIBtoCOG is passed
-		return respondWithError(c, http.StatusInternalServerError, err.Error())
-	}
-
-	// Construct the URL for the COG file
-	// meta.CogPath from Python is something like "data/cogs_output\\20250519_21Z.tif"
-	// We only need the filename part for the URL if /cogs is mapped to data/cogs_output
-	fileName := filepath.Base(meta.COGPath) // Extracts "20250519_21Z.tif"
-
-	// This is the URL prefix you configured with e.Static() in main.go
-	cogURLPrefix := "/cogs/"
-	cogAccessURL := cogURLPrefix + fileName
-
-	// Log the constructed URL for debugging
-	log.Printf("Constructed COG access URL for frontend: %s", cogAccessURL)
-
-	// Use your existing respondWithJSON function
-	// Send a response that includes this new cogAccessURL
-	return respondWithJSON(c, http.StatusOK, echo.Map{
-		"timestamp": meta.Timestamp,
-		// "tileURL": "https://cdn.example.com/precip/" + meta.Timestamp + "/{z}/{x}/{y}.png", // Old placeholder
-		"cog_url": cogAccessURL, // This is the URL the frontend will use
-		"bounds":  meta.Bounds,
-		"width":   meta.Width,  // Include width from meta
-		"height":  meta.Height, // Include height from meta
-	})
+func handelGetLatestPrecip(queries *sqlcdb.Queries) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		ctx, cancel := context.WithTimeout(c.Request().Context(), 2*time.Minute)
+		defer cancel()
+
+		acc, err := runGRIBtoCOG(ctx) // Returns the PrecipMeta (with CogPath) plus catalog fields
+		if err != nil {
+			// Use your existing respondWithError function
+			// Ensure the error message from runGRIBtoCOG is passed
+			return respondWithError(c, http.StatusInternalServerError, err.Error())
+		}
+		meta := acc.Meta
+
+		// Index the COG so it shows up in /api/precip/catalog
+		if err := recordPrecipCog(ctx, queries, acc); err != nil {
+			log.Printf("Warning: %v", err)
+		}
+
+		// Construct the URL for the COG file
+		// meta.COGPath is something like "data/cogs_output/20250519_21Z.tif"
+		// We only need the filename part for the URL if /cogs is mapped to data/cogs_output
+		fileName := filepath.Base(meta.COGPath) // Extracts "20250519_21Z.tif"
+
+		// This is the URL prefix you configured with e.Static() in main.go
+		cogURLPrefix := "/cogs/"
+		cogAccessURL := cogURLPrefix + fileName
+
+		// Log the constructed URL for debugging
+		log.Printf("Constructed COG access URL for frontend: %s", cogAccessURL)
+
+		// Use your existing respondWithJSON function
+		// Send a response that includes this new cogAccessURL
+		return respondWithJSON(c, http.StatusOK, echo.Map{
+			"timestamp": meta.Timestamp,
+			// "tileURL": "https://cdn.example.com/precip/" + meta.Timestamp + "/{z}/{x}/{y}.png", // Old placeholder
+			"cog_url": cogAccessURL, // This is the URL the frontend will use
+			"bounds":  meta.Bounds,
+			"width":   meta.Width,  // Include width from meta
+			"height":  meta.Height, // Include height from meta
+		})
+	}
 }
 
-// ---- Spawns Python ------------------------------------------------
-func runGRIBtoCOG(ctx context.Context) (*PrecipMeta, error) {
+// latestPrecipProduct is the catalog product for /api/precip/latest COGs
+const latestPrecipProduct = "qpe_latest"
+
+// mrmsDurationPattern extracts the accumulation hours from an MRMS product URL
+var mrmsDurationPattern = regexp.MustCompile(`_(\d+)H/?$`)
+
+// ---- GRIB to COG --------------------------------------------------
+func runGRIBtoCOG(ctx context.Context) (*PrecipAccumulation, error) {
 	// --- Path Configuration ---
 	// inFile is now determined by FetchLatestQPE
 	latestGribFilePath, err := FetchLatestQPE(ctx)
@@ -177,59 +199,84 @@ func runGRIBtoCOG(ctx context.Context) (*PrecipMeta, error) {
 	}
 	log.Printf("Using GRIB file for COG conversion: %s", latestGribFilePath)
 
-	// outDir: Use configured path
-	outDir := AppConfig.Paths.StaticCogDir
+	msg, err := firstGribMessage(latestGribFilePath)
+	if err != nil {
+		return nil, err
+	}
+	end := msg.ValidTime()
+	hours := 0
+	if m := mrmsDurationPattern.FindStringSubmatch(mrmsDataSourceURL); m != nil {
+		hours, _ = strconv.Atoi(m[1])
+	}
 
-	// Python script path: Use configured path
-	scriptRelativePath := GetPythonScriptPath(filepath.Join("get_rainfall_accumulation", "grib_to_cog.py"))
+	tag := end.Format("20060102_15Z")
+	acc, err := publishAccumulation(latestGribFilePath, tag, PrecipAccumulation{
+		Product:       latestPrecipProduct,
+		Kind:          precipKindObserved,
+		DurationHours: hours,
+		Start:         end.Add(-time.Duration(hours) * time.Hour),
+		End:           end,
+	})
+	if err != nil {
+		return nil, err
+	}
+	// latest_qpe.grib2 is overwritten by the next fetch, so it is not tracked
+	acc.SourceGrib = ""
+	return acc, nil
+}
 
-	// --- Ensure output directory exists ---
+// cogNoData marks cells without data (MRMS no-coverage flags, bitmap gaps)
+const cogNoData = -9999
+
+// convertGRIBToCOG writes the first field of a lat/lon GRIB2 file as
+// StaticCogDir/<tag>.tif, converting millimetres to inches. Negative values
+// (MRMS "no coverage") become nodata.
+func convertGRIBToCOG(gribPath string, tag string) (*PrecipMeta, error) {
+	outDir := AppConfig.Paths.StaticCogDir
 	if err := os.MkdirAll(outDir, 0755); err != nil {
 		log.Printf("Error creating output directory '%s': %v", outDir, err)
 		return nil, fmt.Errorf("failed to create output directory %s: %w", outDir, err)
 	}
 
-	tag := time.Now().UTC().Format("20060102_15Z")
-	py := GetPythonPath("grib2cog") // Python interpreter
-
-	// Log the command and arguments for easier debugging
-	// Use latestGribFilePath as the inFile argument for the Python script
-	log.Printf("Executing Python script for COG conversion: %s %s %s %s %s", py, scriptRelativePath, latestGribFilePath, tag, outDir)
-
-	cmd := exec.CommandContext(ctx, py, scriptRelativePath, latestGribFilePath, tag, outDir)
-
-	// Capture stdout and stderr in separate buffers
-	var stdoutBuf, stderrBuf bytes.Buffer
-	cmd.Stdout = &stdoutBuf
-	cmd.Stderr = &stderrBuf
-
-	// Run the command
-	err = cmd.Run() // Use Run() when you have separate buffers for stdout/stderr
-
-	// --- Log Python's output ---
-	// Log stderr (Python's error messages, tracebacks, or prints to sys.stderr)
-	if stderrBuf.Len() > 0 {
-		log.Printf("Python STDERR:\n%s", stderrBuf.String())
+	msg, err := firstGribMessage(gribPath)
+	if err != nil {
+		return nil, err
 	}
-	// Log stdout (Python's print() statements)
-	// This will show you everything Python printed, including debug prints.
-	if stdoutBuf.Len() > 0 {
-		log.Printf("Python STDOUT:\n%s", stdoutBuf.String())
+	grid, ok := msg.Grid.(*grib2.LatLonGrid)
+	if !ok {
+		return nil, fmt.Errorf("%s is not on a lat/lon grid; regrid it before conversion", gribPath)
 	}
-
-	// --- Handle command execution error ---
+	values, err := msg.Values()
 	if err != nil {
-		// The actual Python traceback should be in the stderr log above.
-		return nil, fmt.Errorf("python script execution failed: %w. See STDERR log above for Python errors", err)
+		return nil, fmt.Errorf("failed to decode %s: %w", gribPath, err)
 	}
 
-	// --- Process Python's standard output (expected to be JSON) ---
-	var meta PrecipMeta
-	// json.Unmarshal expects stdoutBuf.Bytes() to be *only* the JSON.
-	if err := json.Unmarshal(stdoutBuf.Bytes(), &meta); err != nil {
-		log.Printf("Failed to unmarshal JSON from Python script. Raw STDOUT that caused error was:\n%s", stdoutBuf.String())
-		return nil, fmt.Errorf("failed to unmarshal JSON from python script: %w", err)
+	values = grid.NorthUp(values)
+	nan := float32(math.NaN())
+	for i, v := range values {
+		if v < 0 {
+			values[i] = nan
+		} else {
+			values[i] = v / mmPerInch
+		}
+	}
+
+	cogPath := filepath.Join(outDir, tag+".tif")
+	bounds := grid.Bounds()
+	for i := range bounds {
+		bounds[i] = math.Round(bounds[i]*1e6) / 1e6 // GRIB stores micro-degrees
+	}
+	log.Printf("Writing COG %s (%dx%d) from %s", cogPath, grid.Nx, grid.Ny, gribPath)
+	if err := cog.WriteFile(cogPath, values, grid.Nx, grid.Ny, bounds, cog.Options{NoData: cogNoData, Units: "inches"}); err != nil {
+		return nil, fmt.Errorf("failed to write COG %s: %w", cogPath, err)
 	}
 
-	return &meta, nil
+	return &PrecipMeta{
+		Timestamp: tag,
+		COGPath:   cogPath,
+		Bounds:    bounds,
+		Width:     grid.Nx,
+		Height:    grid.Ny,
+		Units:     "inches",
+	}, nil
 }
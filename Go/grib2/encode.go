@@ -0,0 +1,378 @@
+package grib2
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"image"
+	"image/png"
+	"io"
+	"math"
+	"os"
+	"time"
+)
+
+// NewMessage builds a message for a regular lat/lon grid with identification
+// and product sections filled from the arguments. Call Pack to attach data.
+func NewMessage(discipline uint8, centre uint16, ref time.Time, grid *LatLonGrid, p Product) *Message {
+	m := &Message{
+		Discipline:    discipline,
+		Centre:        centre,
+		ReferenceTime: ref.UTC(),
+		Grid:          grid,
+		Product:       p,
+		numPoints:     grid.Nx * grid.Ny,
+	}
+	m.sec1 = encodeIdentification(centre, m.ReferenceTime)
+	m.sec3 = encodeLatLonGrid(grid)
+	m.sec4 = encodeProduct(p)
+	return m
+}
+
+// Repack returns a copy of m whose data is replaced by values, keeping the
+// identification, grid and product sections byte-for-byte. This lets callers
+// adjust a field (e.g. apply a bias factor) without changing how downstream
+// tools such as HEC-Vortex name the variable. PNG packed messages (as MRMS
+// publishes) stay PNG packed; everything else is written with simple packing.
+func (m *Message) Repack(values []float32, decimalScale int) (*Message, error) {
+	out := *m
+	pack := out.Pack
+	if d, err := m.parseDRS(); err == nil && d.template == 41 {
+		pack = out.PackPNG
+	}
+	if err := pack(values, decimalScale); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// packing holds the scaling chosen for a set of values: packed integers are
+// round((v*10^D - ref) / 2^binaryScale) stored in bits bits.
+type packing struct {
+	ref          float32
+	binaryScale  int
+	decimalScale int
+	bits         int
+}
+
+// choosePacking picks the reference and binary scale so the values fit in
+// maxBits bits at the given decimal scale.
+func choosePacking(present []float64, decimalScale, maxBits int) packing {
+	dscale := math.Pow(10, float64(decimalScale))
+	minV, maxV := math.Inf(1), math.Inf(-1)
+	for _, v := range present {
+		minV = math.Min(minV, v*dscale)
+		maxV = math.Max(maxV, v*dscale)
+	}
+	if len(present) == 0 {
+		minV, maxV = 0, 0
+	}
+	ref := float32(minV)
+	if float64(ref) > minV {
+		ref = math.Nextafter32(ref, float32(math.Inf(-1)))
+	}
+	binaryScale := 0
+	limit := math.Pow(2, float64(maxBits)) - 1
+	rangeV := math.Round(maxV - float64(ref))
+	for rangeV > limit {
+		binaryScale++
+		rangeV = math.Round((maxV - float64(ref)) / math.Pow(2, float64(binaryScale)))
+	}
+	bits := 0
+	if rangeV > 0 {
+		bits = int(math.Ceil(math.Log2(rangeV + 1)))
+	}
+	return packing{ref: ref, binaryScale: binaryScale, decimalScale: decimalScale, bits: bits}
+}
+
+// integer returns the packed integer for v
+func (p packing) integer(v float64) uint64 {
+	x := math.Round((v*math.Pow(10, float64(p.decimalScale)) - float64(p.ref)) / math.Pow(2, float64(p.binaryScale)))
+	if x < 0 {
+		x = 0
+	}
+	return uint64(x)
+}
+
+// section5 encodes the data representation section for template 5.0 or 5.41.
+func (p packing) section5(template uint16, numValues int) []byte {
+	sec5 := make([]byte, 21)
+	binary.BigEndian.PutUint32(sec5[0:], 21)
+	sec5[4] = 5
+	binary.BigEndian.PutUint32(sec5[5:], uint32(numValues))
+	binary.BigEndian.PutUint16(sec5[9:], template)
+	binary.BigEndian.PutUint32(sec5[11:], math.Float32bits(p.ref))
+	putSignedInt(sec5[15:17], int64(p.binaryScale))
+	putSignedInt(sec5[17:19], int64(p.decimalScale))
+	sec5[19] = byte(p.bits)
+	sec5[20] = 0
+	return sec5
+}
+
+// presentValues splits values into the non-missing values and the bitmap
+// section (section 6) describing them.
+func presentValues(values []float32) ([]float64, []byte) {
+	present := make([]float64, 0, len(values))
+	hasMissing := false
+	for _, v := range values {
+		if math.IsNaN(float64(v)) {
+			hasMissing = true
+			continue
+		}
+		present = append(present, float64(v))
+	}
+	if !hasMissing {
+		return present, []byte{0, 0, 0, 6, 6, 255}
+	}
+	bitmap := make([]byte, 6+(len(values)+7)/8)
+	binary.BigEndian.PutUint32(bitmap, uint32(len(bitmap)))
+	bitmap[4] = 6
+	bitmap[5] = 0
+	for k, v := range values {
+		if !math.IsNaN(float64(v)) {
+			bitmap[6+(k>>3)] |= 0x80 >> uint(k&7)
+		}
+	}
+	return present, bitmap
+}
+
+// Pack encodes values (in grid storage order, NaN for missing) with simple
+// packing (template 5.0) at the given decimal scale.
+func (m *Message) Pack(values []float32, decimalScale int) error {
+	if len(values) != m.numPoints {
+		return errors.New("grib2: value count does not match grid")
+	}
+	present, bitmap := presentValues(values)
+	p := choosePacking(present, decimalScale, 32)
+
+	packedLen := (len(present)*p.bits + 7) / 8
+	sec7 := make([]byte, 5+packedLen)
+	binary.BigEndian.PutUint32(sec7, uint32(len(sec7)))
+	sec7[4] = 7
+	if p.bits > 0 {
+		w := bitWriter{buf: sec7[5:]}
+		for _, v := range present {
+			w.write(p.integer(v), p.bits)
+		}
+	}
+	m.sec5 = p.section5(0, len(present))
+	m.sec6 = bitmap
+	m.sec7 = sec7
+	return nil
+}
+
+// PackPNG encodes values with PNG packing (template 5.41) using 8 or 16 bit
+// greyscale, which keeps large mostly-constant grids such as MRMS small.
+func (m *Message) PackPNG(values []float32, decimalScale int) error {
+	if len(values) != m.numPoints {
+		return errors.New("grib2: value count does not match grid")
+	}
+	present, bitmap := presentValues(values)
+	p := choosePacking(present, decimalScale, 16)
+	switch {
+	case p.bits > 8:
+		p.bits = 16
+	case p.bits > 0:
+		p.bits = 8
+	}
+
+	var data []byte
+	if p.bits > 0 {
+		// Without a bitmap the image has the grid's shape, otherwise one row
+		w, h := len(present), 1
+		if len(present) == len(values) && m.Grid != nil {
+			if nx, ny := m.Grid.Size(); nx*ny == len(values) {
+				w, h = nx, ny
+			}
+		}
+		var img image.Image
+		if p.bits == 8 {
+			g := image.NewGray(image.Rect(0, 0, w, h))
+			for i, v := range present {
+				g.Pix[(i/w)*g.Stride+i%w] = uint8(p.integer(v))
+			}
+			img = g
+		} else {
+			g := image.NewGray16(image.Rect(0, 0, w, h))
+			for i, v := range present {
+				x := p.integer(v)
+				o := (i/w)*g.Stride + (i%w)*2
+				g.Pix[o], g.Pix[o+1] = uint8(x>>8), uint8(x)
+			}
+			img = g
+		}
+		var buf bytes.Buffer
+		if err := png.Encode(&buf, img); err != nil {
+			return fmt.Errorf("grib2: encoding PNG data: %w", err)
+		}
+		data = buf.Bytes()
+	}
+
+	sec7 := make([]byte, 5+len(data))
+	binary.BigEndian.PutUint32(sec7, uint32(len(sec7)))
+	sec7[4] = 7
+	copy(sec7[5:], data)
+	m.sec5 = p.section5(41, len(present))
+	m.sec6 = bitmap
+	m.sec7 = sec7
+	return nil
+}
+
+// bitWriter packs big-endian bit fields into a pre-sized buffer.
+type bitWriter struct {
+	buf []byte
+	pos int
+}
+
+func (w *bitWriter) write(v uint64, n int) {
+	for i := n - 1; i >= 0; i-- {
+		if v&(1<<uint(i)) != 0 {
+			w.buf[w.pos>>3] |= 0x80 >> uint(w.pos&7)
+		}
+		w.pos++
+	}
+}
+
+// Write serialises messages to w.
+func Write(w io.Writer, msgs ...*Message) error {
+	for _, m := range msgs {
+		if m.sec5 == nil || m.sec7 == nil {
+			return errors.New("grib2: message has no packed data")
+		}
+		sections := [][]byte{m.sec1, m.sec3, m.sec4, m.sec5, m.sec6, m.sec7}
+		total := 16 + 4
+		for _, s := range sections {
+			total += len(s)
+		}
+		sec0 := make([]byte, 16)
+		copy(sec0, "GRIB")
+		sec0[6] = m.Discipline
+		sec0[7] = 2
+		binary.BigEndian.PutUint64(sec0[8:], uint64(total))
+		if _, err := w.Write(sec0); err != nil {
+			return err
+		}
+		for _, s := range sections {
+			if s == nil {
+				continue
+			}
+			if _, err := w.Write(s); err != nil {
+				return err
+			}
+		}
+		if _, err := io.WriteString(w, "7777"); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// WriteFile writes messages to a new file at path.
+func WriteFile(path string, msgs ...*Message) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	if err := Write(f, msgs...); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// Bytes returns the complete encoded message, including sections 0 and 8.
+func (m *Message) Bytes() ([]byte, error) {
+	var buf sliceWriter
+	if err := Write(&buf, m); err != nil {
+		return nil, err
+	}
+	return buf, nil
+}
+
+type sliceWriter []byte
+
+func (s *sliceWriter) Write(p []byte) (int, error) {
+	*s = append(*s, p...)
+	return len(p), nil
+}
+
+func encodeIdentification(centre uint16, ref time.Time) []byte {
+	s := make([]byte, 21)
+	binary.BigEndian.PutUint32(s, 21)
+	s[4] = 1
+	binary.BigEndian.PutUint16(s[5:], centre)
+	s[9] = 2  // master tables version
+	s[10] = 1 // local tables version
+	s[11] = 1 // reference time is start of forecast
+	binary.BigEndian.PutUint16(s[12:], uint16(ref.Year()))
+	s[14] = byte(ref.Month())
+	s[15] = byte(ref.Day())
+	s[16] = byte(ref.Hour())
+	s[17] = byte(ref.Minute())
+	s[18] = byte(ref.Second())
+	s[19] = 0 // operational products
+	s[20] = 1 // forecast products
+	return s
+}
+
+func encodeLatLonGrid(g *LatLonGrid) []byte {
+	s := make([]byte, 72)
+	binary.BigEndian.PutUint32(s, 72)
+	s[4] = 3
+	binary.BigEndian.PutUint32(s[6:], uint32(g.Nx*g.Ny))
+	binary.BigEndian.PutUint16(s[12:], 0)
+	s[14] = 6 // spherical earth, radius 6371229 m
+	binary.BigEndian.PutUint32(s[30:], uint32(g.Nx))
+	binary.BigEndian.PutUint32(s[34:], uint32(g.Ny))
+	binary.BigEndian.PutUint32(s[42:], math.MaxUint32) // 1e-6 degree units
+	putSignedInt(s[46:50], int64(math.Round(g.Lat1*1e6)))
+	putSignedInt(s[50:54], int64(math.Round(normaliseLon360(g.Lon1)*1e6)))
+	s[54] = 0x30
+	putSignedInt(s[55:59], int64(math.Round(g.Lat2*1e6)))
+	putSignedInt(s[59:63], int64(math.Round(normaliseLon360(g.Lon2)*1e6)))
+	binary.BigEndian.PutUint32(s[63:], uint32(math.Round(g.Dx*1e6)))
+	binary.BigEndian.PutUint32(s[67:], uint32(math.Round(g.Dy*1e6)))
+	s[71] = g.ScanMode
+	return s
+}
+
+func encodeProduct(p Product) []byte {
+	size := 34
+	if p.Template == 8 {
+		size = 58
+	}
+	s := make([]byte, size)
+	binary.BigEndian.PutUint32(s, uint32(size))
+	s[4] = 4
+	binary.BigEndian.PutUint16(s[7:], p.Template)
+	s[9] = p.Category
+	s[10] = p.Number
+	s[11] = 2 // forecast
+	s[12] = 0 // background process
+	s[13] = 0 // analysis or forecast process
+	s[17] = p.TimeUnit
+	binary.BigEndian.PutUint32(s[18:], uint32(p.ForecastTime))
+	s[22] = p.SurfaceType
+	s[23] = 0
+	binary.BigEndian.PutUint32(s[24:], uint32(p.SurfaceValue))
+	s[28] = 255
+	s[29] = 255
+	binary.BigEndian.PutUint32(s[30:], math.MaxUint32)
+	if p.Template == 8 {
+		end := p.IntervalEnd.UTC()
+		binary.BigEndian.PutUint16(s[34:], uint16(end.Year()))
+		s[36] = byte(end.Month())
+		s[37] = byte(end.Day())
+		s[38] = byte(end.Hour())
+		s[39] = byte(end.Minute())
+		s[40] = byte(end.Second())
+		s[41] = 1 // one time range specification
+		s[46] = p.StatisticalProcess
+		s[47] = 2 // successive times, same reference
+		s[48] = p.IntervalUnit
+		binary.BigEndian.PutUint32(s[49:], uint32(p.IntervalLength))
+		s[53] = 255
+	}
+	return s
+}
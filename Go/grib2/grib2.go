@@ -0,0 +1,361 @@
+// Package grib2 reads and writes the subset of WMO GRIB edition 2 used by the
+// MRMS and HRRR precipitation products consumed by the HMS backend.
+//
+// Supported grid templates: 3.0 (regular lat/lon) and 3.30 (Lambert conformal).
+// Supported product templates: 4.0 (analysis/forecast) and 4.8 (statistically
+// processed, e.g. accumulations). Supported data representations: 5.0 (simple
+// packing), 5.2/5.3 (complex packing, with or without spatial differencing) and
+// 5.41 (PNG). Anything else is reported as an error when the values are read.
+package grib2
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"time"
+)
+
+// ErrNotGRIB2 is returned when the stream does not contain a GRIB2 message.
+var ErrNotGRIB2 = errors.New("grib2: not a GRIB edition 2 message")
+
+// Message is a single decoded GRIB2 message. Only one field per message is
+// supported, which is how NCEP distributes MRMS and HRRR files.
+type Message struct {
+	Discipline    uint8
+	Centre        uint16
+	ReferenceTime time.Time
+	Grid          Grid
+	Product       Product
+
+	// Raw sections kept so a message can be repacked with new values while
+	// preserving its identification, grid and product definitions.
+	sec1, sec3, sec4 []byte
+	sec5, sec6, sec7 []byte
+	numPoints        int
+}
+
+// Product describes the parameter and time range of a message (section 4).
+type Product struct {
+	Template     uint16
+	Category     uint8
+	Number       uint8
+	TimeUnit     uint8
+	ForecastTime int
+	SurfaceType  uint8
+	SurfaceValue float64
+
+	// Statistical processing (template 4.8). IntervalEnd is zero otherwise.
+	StatisticalProcess uint8
+	IntervalUnit       uint8
+	IntervalLength     int
+	IntervalEnd        time.Time
+}
+
+// IsAccumulation reports whether the product is an accumulation over a time
+// interval (statistical process 1).
+func (p Product) IsAccumulation() bool {
+	return p.Template == 8 && p.StatisticalProcess == 1
+}
+
+// ForecastOffset returns the forecast time as a duration.
+func (p Product) ForecastOffset() time.Duration {
+	return unitDuration(p.TimeUnit, p.ForecastTime)
+}
+
+// IntervalDuration returns the length of the statistical processing interval.
+func (p Product) IntervalDuration() time.Duration {
+	return unitDuration(p.IntervalUnit, p.IntervalLength)
+}
+
+// unitDuration converts a GRIB2 (code table 4.4) time value to a duration.
+func unitDuration(unit uint8, value int) time.Duration {
+	switch unit {
+	case 0:
+		return time.Duration(value) * time.Minute
+	case 1:
+		return time.Duration(value) * time.Hour
+	case 2:
+		return time.Duration(value) * 24 * time.Hour
+	case 10:
+		return time.Duration(value) * 3 * time.Hour
+	case 11:
+		return time.Duration(value) * 6 * time.Hour
+	case 12:
+		return time.Duration(value) * 12 * time.Hour
+	case 13:
+		return time.Duration(value) * time.Second
+	default:
+		return time.Duration(value) * time.Hour
+	}
+}
+
+// ValidTime returns the time the message values are valid for: the end of the
+// statistical interval for template 4.8, otherwise reference plus forecast time.
+func (m *Message) ValidTime() time.Time {
+	if !m.Product.IntervalEnd.IsZero() {
+		return m.Product.IntervalEnd
+	}
+	return m.ReferenceTime.Add(m.Product.ForecastOffset())
+}
+
+// NumPoints returns the number of grid points defined in section 3.
+func (m *Message) NumPoints() int {
+	return m.numPoints
+}
+
+// ReadFile reads every message in a GRIB2 file.
+func ReadFile(path string) ([]*Message, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return ReadAll(f)
+}
+
+// ReadAll reads every message from r.
+func ReadAll(r io.Reader) ([]*Message, error) {
+	var msgs []*Message
+	err := Scan(r, func(m *Message) bool {
+		msgs = append(msgs, m)
+		return true
+	})
+	return msgs, err
+}
+
+// ScanFile calls fn for each message in the file until fn returns false.
+func ScanFile(path string, fn func(*Message) bool) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	return Scan(f, fn)
+}
+
+// Scan calls fn for each message read from r until fn returns false or the
+// stream ends. Bytes between messages (padding, other formats) are skipped.
+// Section data is retained but not unpacked; call Values to decode.
+func Scan(r io.Reader, fn func(*Message) bool) error {
+	br := bufio.NewReaderSize(r, 1<<16)
+	found := false
+	for {
+		if err := seekIndicator(br); err != nil {
+			if errors.Is(err, io.EOF) {
+				if !found {
+					return ErrNotGRIB2
+				}
+				return nil
+			}
+			return err
+		}
+		// Indicator section: "GRIB" already consumed, 12 octets remain.
+		var sec0 [12]byte
+		if _, err := io.ReadFull(br, sec0[:]); err != nil {
+			return fmt.Errorf("grib2: reading indicator section: %w", err)
+		}
+		if sec0[3] != 2 {
+			return fmt.Errorf("%w (edition %d)", ErrNotGRIB2, sec0[3])
+		}
+		total := binary.BigEndian.Uint64(sec0[4:12])
+		if total < 16+4 {
+			return fmt.Errorf("grib2: invalid message length %d", total)
+		}
+		body := make([]byte, total-16)
+		if _, err := io.ReadFull(br, body); err != nil {
+			return fmt.Errorf("grib2: reading message body: %w", err)
+		}
+		msg, err := parseMessage(sec0[2], body)
+		if err != nil {
+			return err
+		}
+		found = true
+		if !fn(msg) {
+			return nil
+		}
+	}
+}
+
+// seekIndicator advances br past the next "GRIB" marker.
+func seekIndicator(br *bufio.Reader) error {
+	const marker = "GRIB"
+	matched := 0
+	for {
+		b, err := br.ReadByte()
+		if err != nil {
+			return err
+		}
+		if b == marker[matched] {
+			matched++
+			if matched == len(marker) {
+				return nil
+			}
+			continue
+		}
+		matched = 0
+		if b == marker[0] {
+			matched = 1
+		}
+	}
+}
+
+// parseMessage splits a message body (everything after section 0) into sections.
+func parseMessage(discipline uint8, body []byte) (*Message, error) {
+	m := &Message{Discipline: discipline}
+	pos := 0
+	for pos < len(body) {
+		if len(body)-pos >= 4 && string(body[pos:pos+4]) == "7777" {
+			break
+		}
+		if len(body)-pos < 5 {
+			return nil, fmt.Errorf("grib2: truncated section at offset %d", pos)
+		}
+		length := int(binary.BigEndian.Uint32(body[pos:]))
+		num := body[pos+4]
+		if length < 5 || pos+length > len(body) {
+			return nil, fmt.Errorf("grib2: invalid length %d for section %d", length, num)
+		}
+		sec := body[pos : pos+length]
+		switch num {
+		case 1:
+			m.sec1 = sec
+		case 2:
+			// Local use section, ignored.
+		case 3:
+			m.sec3 = sec
+		case 4:
+			m.sec4 = sec
+		case 5:
+			m.sec5 = sec
+		case 6:
+			m.sec6 = sec
+		case 7:
+			m.sec7 = sec
+			if m.sec3 != nil && m.sec4 != nil && m.sec5 != nil {
+				// NCEP files carry one field per message; anything after the
+				// first data section would repeat sections 3-7.
+				pos = len(body)
+				continue
+			}
+		default:
+			return nil, fmt.Errorf("grib2: unexpected section number %d", num)
+		}
+		pos += length
+	}
+	if m.sec1 == nil || m.sec3 == nil || m.sec4 == nil || m.sec5 == nil || m.sec7 == nil {
+		return nil, errors.New("grib2: message is missing a required section")
+	}
+	if err := m.parseIdentification(); err != nil {
+		return nil, err
+	}
+	if err := m.parseGrid(); err != nil {
+		return nil, err
+	}
+	if err := m.parseProduct(); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+func (m *Message) parseIdentification() error {
+	s := m.sec1
+	if len(s) < 21 {
+		return errors.New("grib2: identification section too short")
+	}
+	m.Centre = binary.BigEndian.Uint16(s[5:7])
+	year := int(binary.BigEndian.Uint16(s[12:14]))
+	m.ReferenceTime = time.Date(year, time.Month(s[14]), int(s[15]), int(s[16]), int(s[17]), int(s[18]), 0, time.UTC)
+	return nil
+}
+
+func (m *Message) parseGrid() error {
+	s := m.sec3
+	if len(s) < 14 {
+		return errors.New("grib2: grid definition section too short")
+	}
+	m.numPoints = int(binary.BigEndian.Uint32(s[6:10]))
+	tmpl := binary.BigEndian.Uint16(s[12:14])
+	var err error
+	switch tmpl {
+	case 0:
+		m.Grid, err = parseLatLonGrid(s)
+	case 30:
+		m.Grid, err = parseLambertGrid(s)
+	default:
+		err = fmt.Errorf("grib2: unsupported grid template 3.%d", tmpl)
+	}
+	return err
+}
+
+func (m *Message) parseProduct() error {
+	s := m.sec4
+	if len(s) < 34 {
+		return errors.New("grib2: product definition section too short")
+	}
+	p := Product{Template: binary.BigEndian.Uint16(s[7:9])}
+	switch p.Template {
+	case 0, 8:
+		p.Category = s[9]
+		p.Number = s[10]
+		p.TimeUnit = s[17]
+		p.ForecastTime = int(int32(binary.BigEndian.Uint32(s[18:22])))
+		p.SurfaceType = s[22]
+		p.SurfaceValue = scaledValue(s[23], binary.BigEndian.Uint32(s[24:28]))
+	default:
+		m.Product = p
+		return nil
+	}
+	if p.Template == 8 {
+		if len(s) < 58 {
+			return errors.New("grib2: product template 4.8 too short")
+		}
+		year := int(binary.BigEndian.Uint16(s[34:36]))
+		p.IntervalEnd = time.Date(year, time.Month(s[36]), int(s[37]), int(s[38]), int(s[39]), int(s[40]), 0, time.UTC)
+		p.StatisticalProcess = s[46]
+		p.IntervalUnit = s[48]
+		p.IntervalLength = int(binary.BigEndian.Uint32(s[49:53]))
+	}
+	m.Product = p
+	return nil
+}
+
+// scaledValue applies a GRIB2 scale factor/scaled value pair.
+func scaledValue(factor uint8, value uint32) float64 {
+	if value == math.MaxUint32 {
+		return 0
+	}
+	return float64(value) / math.Pow(10, float64(int8(factor)))
+}
+
+// signedInt decodes a GRIB2 sign-magnitude integer of n octets.
+func signedInt(b []byte) int64 {
+	var v uint64
+	for _, c := range b {
+		v = v<<8 | uint64(c)
+	}
+	sign := uint64(1) << (8*uint(len(b)) - 1)
+	if v&sign != 0 {
+		return -int64(v &^ sign)
+	}
+	return int64(v)
+}
+
+// putSignedInt encodes v as a GRIB2 sign-magnitude integer into b.
+func putSignedInt(b []byte, v int64) {
+	neg := v < 0
+	if neg {
+		v = -v
+	}
+	u := uint64(v)
+	for i := len(b) - 1; i >= 0; i-- {
+		b[i] = byte(u)
+		u >>= 8
+	}
+	if neg {
+		b[0] |= 0x80
+	}
+}
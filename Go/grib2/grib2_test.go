@@ -0,0 +1,223 @@
+package grib2
+
+import (
+	"bytes"
+	"encoding/binary"
+	"math"
+	"os"
+	"testing"
+	"time"
+)
+
+func TestPackRoundTrip(t *testing.T) {
+	grid := NewLatLonGrid([4]float64{-99, 29, -98, 30}, 0.25)
+	ref := time.Date(2025, 5, 19, 18, 0, 0, 0, time.UTC)
+	msg := NewMessage(0, 7, ref, grid, Product{
+		Template:           8,
+		Category:           1,
+		Number:             8,
+		TimeUnit:           1,
+		SurfaceType:        1,
+		StatisticalProcess: 1,
+		IntervalUnit:       1,
+		IntervalLength:     6,
+		IntervalEnd:        ref.Add(6 * time.Hour),
+	})
+	values := make([]float32, grid.Nx*grid.Ny)
+	for i := range values {
+		values[i] = float32(i) * 1.25
+	}
+	values[3] = float32(math.NaN())
+	if err := msg.Pack(values, 2); err != nil {
+		t.Fatalf("Pack: %v", err)
+	}
+
+	var buf bytes.Buffer
+	if err := Write(&buf, msg); err != nil {
+		t.Fatalf("Write: %v", err)
+	}
+	msgs, err := ReadAll(&buf)
+	if err != nil {
+		t.Fatalf("ReadAll: %v", err)
+	}
+	if len(msgs) != 1 {
+		t.Fatalf("got %d messages, want 1", len(msgs))
+	}
+	got := msgs[0]
+	if !got.Product.IsAccumulation() || got.Product.IntervalDuration() != 6*time.Hour {
+		t.Errorf("product = %+v, want 6 hour accumulation", got.Product)
+	}
+	if !got.ValidTime().Equal(ref.Add(6 * time.Hour)) {
+		t.Errorf("valid time = %v", got.ValidTime())
+	}
+	decoded, err := got.Values()
+	if err != nil {
+		t.Fatalf("Values: %v", err)
+	}
+	for i, v := range values {
+		if math.IsNaN(float64(v)) {
+			if !math.IsNaN(float64(decoded[i])) {
+				t.Errorf("value %d = %v, want NaN", i, decoded[i])
+			}
+			continue
+		}
+		if math.Abs(float64(decoded[i]-v)) > 0.006 {
+			t.Errorf("value %d = %v, want %v", i, decoded[i], v)
+		}
+	}
+	if b := got.Grid.(*LatLonGrid).Bounds(); math.Abs(b[0]+99) > 1e-6 || math.Abs(b[3]-30) > 1e-6 {
+		t.Errorf("bounds = %v", b)
+	}
+}
+
+func TestLatLonNearest(t *testing.T) {
+	// MRMS CONUS layout: north-west origin, 0.01 degree cells.
+	g := &LatLonGrid{Nx: 7000, Ny: 3500, Lat1: 54.995, Lon1: 230.005, Lat2: 20.005, Lon2: 299.995, Dx: 0.01, Dy: 0.01}
+	k, ok := g.Nearest(29.42, -98.49)
+	if !ok {
+		t.Fatal("point outside grid")
+	}
+	lat, lon := g.LatLon(k)
+	if math.Abs(lat-29.42) > 0.0051 || math.Abs(lon+98.49) > 0.0051 {
+		t.Errorf("nearest point = %v,%v", lat, lon)
+	}
+	if _, ok := g.Nearest(10, -98); ok {
+		t.Error("expected point south of grid to be outside")
+	}
+}
+
+func TestLambertRoundTrip(t *testing.T) {
+	// HRRR CONUS grid definition.
+	g := &LambertGrid{Nx: 1799, Ny: 1059, Lat1: 21.138123, Lon1: 237.280472, LoV: 262.5,
+		Latin1: 38.5, Latin2: 38.5, Dx: 3000, Dy: 3000, ScanMode: 0x40, Radius: 6371229}
+	g.init()
+	lat, lon := g.LatLon(0)
+	if math.Abs(lat-21.138123) > 1e-4 || math.Abs(lon-NormaliseLon(237.280472)) > 1e-4 {
+		t.Errorf("first point = %v,%v", lat, lon)
+	}
+	k, ok := g.Nearest(29.42, -98.49)
+	if !ok {
+		t.Fatal("San Antonio outside HRRR grid")
+	}
+	lat, lon = g.LatLon(k)
+	if math.Abs(lat-29.42) > 0.03 || math.Abs(lon+98.49) > 0.03 {
+		t.Errorf("nearest point = %v,%v", lat, lon)
+	}
+}
+
+// packComplex builds a minimal template 5.3 section pair holding values with
+// second order spatial differencing, one group per value.
+func packComplex(values []int64) (sec5, data []byte) {
+	d := make([]int64, len(values))
+	for i := 2; i < len(values); i++ {
+		d[i] = values[i] - 2*values[i-1] + values[i-2]
+	}
+	minsd := int64(math.MaxInt64)
+	for _, v := range d[2:] {
+		minsd = min(minsd, v)
+	}
+	sec5 = make([]byte, 49)
+	binary.BigEndian.PutUint32(sec5[5:], uint32(len(values)))
+	binary.BigEndian.PutUint16(sec5[9:], 3)
+	sec5[19] = 8                                               // group reference bits
+	binary.BigEndian.PutUint32(sec5[31:], uint32(len(values))) // groups
+	sec5[36] = 0                                               // width bits (all width 0)
+	binary.BigEndian.PutUint32(sec5[37:], 1)                   // group length reference
+	binary.BigEndian.PutUint32(sec5[42:], 1)                   // last group length
+	sec5[47] = 2
+	sec5[48] = 2
+	data = make([]byte, 6, 6+len(values))
+	putSignedInt(data[0:2], values[0])
+	putSignedInt(data[2:4], values[1])
+	putSignedInt(data[4:6], minsd)
+	for i := range values {
+		if i < 2 {
+			data = append(data, 0)
+			continue
+		}
+		data = append(data, byte(d[i]-minsd))
+	}
+	return sec5, data
+}
+
+func TestUnpackComplexSpatialDifferencing(t *testing.T) {
+	want := []int64{10, 12, 15, 15, 11, 4, 4, 9}
+	sec5, data := packComplex(want)
+	got, err := unpackComplex(drs{template: 3, numValues: len(want)}, sec5, data)
+	if err != nil {
+		t.Fatalf("unpackComplex: %v", err)
+	}
+	for i := range want {
+		if got[i] != float32(want[i]) {
+			t.Errorf("value %d = %v, want %d", i, got[i], want[i])
+		}
+	}
+}
+
+func TestReadMRMSSample(t *testing.T) {
+	const path = "../../gribFiles/test.grib2"
+	if _, err := os.Stat(path); err != nil {
+		t.Skip("sample MRMS file not available")
+	}
+	msgs, err := ReadFile(path)
+	if err != nil {
+		t.Fatalf("ReadFile: %v", err)
+	}
+	g, ok := msgs[0].Grid.(*LatLonGrid)
+	if !ok || g.Nx != 7000 || g.Ny != 3500 {
+		t.Fatalf("unexpected grid %+v", msgs[0].Grid)
+	}
+	values, err := msgs[0].Values()
+	if err != nil {
+		t.Fatalf("Values: %v", err)
+	}
+	if len(values) != g.Nx*g.Ny {
+		t.Fatalf("got %d values", len(values))
+	}
+}
+
+func TestRepackKeepsPNGPacking(t *testing.T) {
+	grid := NewLatLonGrid([4]float64{-99, 29, -98, 30}, 0.1)
+	msg := NewMessage(209, 161, time.Date(2025, 5, 19, 18, 0, 0, 0, time.UTC), grid, Product{Category: 6, Number: 37})
+	values := make([]float32, grid.Nx*grid.Ny)
+	for i := range values {
+		values[i] = float32(i%37) * 0.3
+	}
+	values[0] = -3 // MRMS no-coverage flag
+	if err := msg.PackPNG(values, 1); err != nil {
+		t.Fatalf("PackPNG: %v", err)
+	}
+
+	scaled := make([]float32, len(values))
+	for i, v := range values {
+		scaled[i] = v
+		if v > 0 {
+			scaled[i] = v * 1.5
+		}
+	}
+	repacked, err := msg.Repack(scaled, 1)
+	if err != nil {
+		t.Fatalf("Repack: %v", err)
+	}
+	if d, _ := repacked.parseDRS(); d.template != 41 {
+		t.Fatalf("repacked template = 5.%d, want 5.41", d.template)
+	}
+
+	var buf bytes.Buffer
+	if err := Write(&buf, repacked); err != nil {
+		t.Fatalf("Write: %v", err)
+	}
+	msgs, err := ReadAll(&buf)
+	if err != nil {
+		t.Fatalf("ReadAll: %v", err)
+	}
+	decoded, err := msgs[0].Values()
+	if err != nil {
+		t.Fatalf("Values: %v", err)
+	}
+	for i, v := range scaled {
+		if math.Abs(float64(decoded[i]-v)) > 0.051 {
+			t.Fatalf("value %d = %v, want %v", i, decoded[i], v)
+		}
+	}
+}
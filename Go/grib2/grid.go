@@ -0,0 +1,357 @@
+package grib2
+
+import (
+	"encoding/binary"
+	"errors"
+	"math"
+)
+
+// Grid maps between storage order and geographic coordinates.
+type Grid interface {
+	// Size returns the number of points along the x and y axes.
+	Size() (nx, ny int)
+	// LatLon returns the coordinates of the point stored at index k.
+	LatLon(k int) (lat, lon float64)
+	// Nearest returns the storage index of the grid point nearest to lat/lon.
+	// ok is false when the location lies outside the grid.
+	Nearest(lat, lon float64) (k int, ok bool)
+}
+
+// Scanning mode flags (code table 3.4).
+const (
+	scanNegativeI  = 0x80
+	scanPositiveJ  = 0x40
+	scanConsecJ    = 0x20
+	defaultRadiusM = 6371229.0
+)
+
+// LatLonGrid is a regular latitude/longitude grid (template 3.0). Longitudes
+// are kept in the range GRIB uses (0..360) and normalised on output.
+type LatLonGrid struct {
+	Nx, Ny     int
+	Lat1, Lon1 float64
+	Lat2, Lon2 float64
+	Dx, Dy     float64
+	ScanMode   uint8
+}
+
+func parseLatLonGrid(s []byte) (*LatLonGrid, error) {
+	if len(s) < 72 {
+		return nil, errors.New("grib2: grid template 3.0 too short")
+	}
+	unit := angleUnit(binary.BigEndian.Uint32(s[38:42]), binary.BigEndian.Uint32(s[42:46]))
+	g := &LatLonGrid{
+		Nx:       int(binary.BigEndian.Uint32(s[30:34])),
+		Ny:       int(binary.BigEndian.Uint32(s[34:38])),
+		Lat1:     float64(signedInt(s[46:50])) * unit,
+		Lon1:     float64(signedInt(s[50:54])) * unit,
+		Lat2:     float64(signedInt(s[55:59])) * unit,
+		Lon2:     float64(signedInt(s[59:63])) * unit,
+		Dx:       float64(binary.BigEndian.Uint32(s[63:67])) * unit,
+		Dy:       float64(binary.BigEndian.Uint32(s[67:71])) * unit,
+		ScanMode: s[71],
+	}
+	if g.Nx <= 0 || g.Ny <= 0 {
+		return nil, errors.New("grib2: lat/lon grid has no points")
+	}
+	// Some producers leave the increments missing; derive them from the corners.
+	if g.Dx == 0 || binary.BigEndian.Uint32(s[63:67]) == math.MaxUint32 {
+		g.Dx = math.Abs(normaliseLon360(g.Lon2)-normaliseLon360(g.Lon1)) / float64(max(g.Nx-1, 1))
+	}
+	if g.Dy == 0 || binary.BigEndian.Uint32(s[67:71]) == math.MaxUint32 {
+		g.Dy = math.Abs(g.Lat2-g.Lat1) / float64(max(g.Ny-1, 1))
+	}
+	return g, nil
+}
+
+// angleUnit returns the size in degrees of one unit of a grid angle.
+func angleUnit(basic, subdivisions uint32) float64 {
+	if basic == 0 || basic == math.MaxUint32 || subdivisions == 0 || subdivisions == math.MaxUint32 {
+		return 1e-6
+	}
+	return float64(basic) / float64(subdivisions)
+}
+
+// Size implements Grid.
+func (g *LatLonGrid) Size() (int, int) { return g.Nx, g.Ny }
+
+// column/row of a storage index, in scanning order.
+func (g *LatLonGrid) storageIJ(k int) (i, j int) {
+	if g.ScanMode&scanConsecJ != 0 {
+		return k / g.Ny, k % g.Ny
+	}
+	return k % g.Nx, k / g.Nx
+}
+
+func (g *LatLonGrid) storageIndex(i, j int) int {
+	if g.ScanMode&scanConsecJ != 0 {
+		return i*g.Ny + j
+	}
+	return j*g.Nx + i
+}
+
+func (g *LatLonGrid) lat(j int) float64 {
+	if g.ScanMode&scanPositiveJ != 0 {
+		return g.Lat1 + float64(j)*g.Dy
+	}
+	return g.Lat1 - float64(j)*g.Dy
+}
+
+func (g *LatLonGrid) lon(i int) float64 {
+	if g.ScanMode&scanNegativeI != 0 {
+		return g.Lon1 - float64(i)*g.Dx
+	}
+	return g.Lon1 + float64(i)*g.Dx
+}
+
+// LatLon implements Grid.
+func (g *LatLonGrid) LatLon(k int) (float64, float64) {
+	i, j := g.storageIJ(k)
+	return g.lat(j), NormaliseLon(g.lon(i))
+}
+
+// Nearest implements Grid.
+func (g *LatLonGrid) Nearest(lat, lon float64) (int, bool) {
+	fj := (lat - g.Lat1) / g.Dy
+	if g.ScanMode&scanPositiveJ == 0 {
+		fj = -fj
+	}
+	dlon := normaliseLon360(lon) - normaliseLon360(g.Lon1)
+	if g.ScanMode&scanNegativeI != 0 {
+		dlon = -dlon
+	}
+	if dlon < -g.Dx/2 {
+		dlon += 360
+	}
+	fi := dlon / g.Dx
+	i, j := int(math.Round(fi)), int(math.Round(fj))
+	if i < 0 || i >= g.Nx || j < 0 || j >= g.Ny {
+		return 0, false
+	}
+	return g.storageIndex(i, j), true
+}
+
+// Bounds returns the outer cell edges as west, south, east, north with
+// longitudes in -180..180.
+func (g *LatLonGrid) Bounds() [4]float64 {
+	latA, latB := g.lat(0), g.lat(g.Ny-1)
+	lonA, lonB := NormaliseLon(g.lon(0)), NormaliseLon(g.lon(g.Nx-1))
+	return [4]float64{
+		math.Min(lonA, lonB) - g.Dx/2,
+		math.Min(latA, latB) - g.Dy/2,
+		math.Max(lonA, lonB) + g.Dx/2,
+		math.Max(latA, latB) + g.Dy/2,
+	}
+}
+
+// NorthUp reorders values stored in the grid's scanning order into row-major
+// order starting at the north-west corner, as raster formats expect.
+func (g *LatLonGrid) NorthUp(values []float32) []float32 {
+	if g.ScanMode&(scanNegativeI|scanPositiveJ|scanConsecJ) == 0 {
+		return values
+	}
+	out := make([]float32, g.Nx*g.Ny)
+	for k, v := range values {
+		i, j := g.storageIJ(k)
+		if g.ScanMode&scanNegativeI != 0 {
+			i = g.Nx - 1 - i
+		}
+		if g.ScanMode&scanPositiveJ != 0 {
+			j = g.Ny - 1 - j
+		}
+		out[j*g.Nx+i] = v
+	}
+	return out
+}
+
+// NewLatLonGrid returns a north-up, west-to-east grid covering the given cell
+// edges (west, south, east, north) with square cells of res degrees.
+func NewLatLonGrid(bounds [4]float64, res float64) *LatLonGrid {
+	nx := int(math.Round((bounds[2] - bounds[0]) / res))
+	ny := int(math.Round((bounds[3] - bounds[1]) / res))
+	nx, ny = max(nx, 1), max(ny, 1)
+	return &LatLonGrid{
+		Nx:   nx,
+		Ny:   ny,
+		Lat1: bounds[3] - res/2,
+		Lon1: normaliseLon360(bounds[0] + res/2),
+		Lat2: bounds[3] - res/2 - float64(ny-1)*res,
+		Lon2: normaliseLon360(bounds[0] + res/2 + float64(nx-1)*res),
+		Dx:   res,
+		Dy:   res,
+	}
+}
+
+// LambertGrid is a Lambert conformal conic grid (template 3.30) on a sphere,
+// as used by HRRR.
+type LambertGrid struct {
+	Nx, Ny         int
+	Lat1, Lon1     float64
+	LoV            float64
+	Latin1, Latin2 float64
+	Dx, Dy         float64 // metres
+	ScanMode       uint8
+	Radius         float64
+
+	n, f   float64
+	x0, y0 float64 // projected coordinates of the first grid point
+}
+
+func parseLambertGrid(s []byte) (*LambertGrid, error) {
+	if len(s) < 81 {
+		return nil, errors.New("grib2: grid template 3.30 too short")
+	}
+	g := &LambertGrid{
+		Nx:       int(binary.BigEndian.Uint32(s[30:34])),
+		Ny:       int(binary.BigEndian.Uint32(s[34:38])),
+		Lat1:     float64(signedInt(s[38:42])) * 1e-6,
+		Lon1:     float64(signedInt(s[42:46])) * 1e-6,
+		LoV:      float64(signedInt(s[51:55])) * 1e-6,
+		Dx:       float64(binary.BigEndian.Uint32(s[55:59])) * 1e-3,
+		Dy:       float64(binary.BigEndian.Uint32(s[59:63])) * 1e-3,
+		ScanMode: s[64],
+		Latin1:   float64(signedInt(s[65:69])) * 1e-6,
+		Latin2:   float64(signedInt(s[69:73])) * 1e-6,
+		Radius:   earthRadius(s),
+	}
+	if g.Nx <= 0 || g.Ny <= 0 {
+		return nil, errors.New("grib2: Lambert grid has no points")
+	}
+	g.init()
+	return g, nil
+}
+
+// earthRadius returns the sphere radius described by octets 15-30 of section 3.
+func earthRadius(s []byte) float64 {
+	switch s[14] {
+	case 0:
+		return 6367470.0
+	case 1:
+		if r := scaledValue(s[15], binary.BigEndian.Uint32(s[16:20])); r > 0 {
+			return r
+		}
+	case 6:
+		return 6371229.0
+	case 8:
+		return 6371200.0
+	}
+	return defaultRadiusM
+}
+
+func (g *LambertGrid) init() {
+	phi1 := g.Latin1 * math.Pi / 180
+	phi2 := g.Latin2 * math.Pi / 180
+	if math.Abs(phi1-phi2) < 1e-10 {
+		g.n = math.Sin(phi1)
+	} else {
+		g.n = math.Log(math.Cos(phi1)/math.Cos(phi2)) /
+			math.Log(math.Tan(math.Pi/4+phi2/2)/math.Tan(math.Pi/4+phi1/2))
+	}
+	g.f = math.Cos(phi1) * math.Pow(math.Tan(math.Pi/4+phi1/2), g.n) / g.n
+	g.x0, g.y0 = g.project(g.Lat1, g.Lon1)
+}
+
+// project converts lat/lon in degrees to projected metres (origin at the pole).
+func (g *LambertGrid) project(lat, lon float64) (float64, float64) {
+	phi := lat * math.Pi / 180
+	rho := g.Radius * g.f / math.Pow(math.Tan(math.Pi/4+phi/2), g.n)
+	dl := NormaliseLon(lon-g.LoV) * math.Pi / 180
+	theta := g.n * dl
+	return rho * math.Sin(theta), -rho * math.Cos(theta)
+}
+
+// unproject is the inverse of project.
+func (g *LambertGrid) unproject(x, y float64) (float64, float64) {
+	rho := math.Hypot(x, y)
+	theta := math.Atan2(x, -y)
+	if g.n < 0 {
+		rho = -rho
+		theta = math.Atan2(-x, y)
+	}
+	lon := g.LoV + theta/g.n*180/math.Pi
+	if rho == 0 {
+		return math.Copysign(90, g.n), NormaliseLon(lon)
+	}
+	phi := 2*math.Atan(math.Pow(g.Radius*g.f/rho, 1/g.n)) - math.Pi/2
+	return phi * 180 / math.Pi, NormaliseLon(lon)
+}
+
+// Size implements Grid.
+func (g *LambertGrid) Size() (int, int) { return g.Nx, g.Ny }
+
+func (g *LambertGrid) storageIJ(k int) (int, int) {
+	if g.ScanMode&scanConsecJ != 0 {
+		return k / g.Ny, k % g.Ny
+	}
+	return k % g.Nx, k / g.Nx
+}
+
+// LatLon implements Grid.
+func (g *LambertGrid) LatLon(k int) (float64, float64) {
+	i, j := g.storageIJ(k)
+	x, y := g.x0+g.signI()*float64(i)*g.Dx, g.y0+g.signJ()*float64(j)*g.Dy
+	return g.unproject(x, y)
+}
+
+// Nearest implements Grid.
+func (g *LambertGrid) Nearest(lat, lon float64) (int, bool) {
+	x, y := g.project(lat, lon)
+	i := int(math.Round((x - g.x0) / (g.signI() * g.Dx)))
+	j := int(math.Round((y - g.y0) / (g.signJ() * g.Dy)))
+	if i < 0 || i >= g.Nx || j < 0 || j >= g.Ny {
+		return 0, false
+	}
+	if g.ScanMode&scanConsecJ != 0 {
+		return i*g.Ny + j, true
+	}
+	return j*g.Nx + i, true
+}
+
+func (g *LambertGrid) signI() float64 {
+	if g.ScanMode&scanNegativeI != 0 {
+		return -1
+	}
+	return 1
+}
+
+func (g *LambertGrid) signJ() float64 {
+	if g.ScanMode&scanPositiveJ != 0 {
+		return 1
+	}
+	return -1
+}
+
+// Regrid samples src values onto dst using nearest-neighbour lookup. Points of
+// dst outside src are set to NaN. The result is in dst's storage order.
+func Regrid(src Grid, values []float32, dst Grid) []float32 {
+	nx, ny := dst.Size()
+	out := make([]float32, nx*ny)
+	for k := range out {
+		lat, lon := dst.LatLon(k)
+		if sk, ok := src.Nearest(lat, lon); ok && sk < len(values) {
+			out[k] = values[sk]
+		} else {
+			out[k] = float32(math.NaN())
+		}
+	}
+	return out
+}
+
+// NormaliseLon maps a longitude to the range -180..180.
+func NormaliseLon(lon float64) float64 {
+	lon = math.Mod(lon, 360)
+	if lon > 180 {
+		lon -= 360
+	} else if lon <= -180 {
+		lon += 360
+	}
+	return lon
+}
+
+func normaliseLon360(lon float64) float64 {
+	lon = math.Mod(lon, 360)
+	if lon < 0 {
+		lon += 360
+	}
+	return lon
+}
@@ -0,0 +1,362 @@
+package grib2
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"image"
+	"image/png"
+	"math"
+)
+
+// drs holds the data representation parameters shared by templates 5.0, 5.2,
+// 5.3 and 5.41.
+type drs struct {
+	template      uint16
+	numValues     int
+	ref           float64
+	binaryScale   int
+	decimalScale  int
+	bitsPerValue  int
+	originalFloat bool
+}
+
+func (m *Message) parseDRS() (drs, error) {
+	s := m.sec5
+	if len(s) < 21 {
+		return drs{}, errors.New("grib2: data representation section too short")
+	}
+	d := drs{
+		template:      binary.BigEndian.Uint16(s[9:11]),
+		numValues:     int(binary.BigEndian.Uint32(s[5:9])),
+		ref:           float64(math.Float32frombits(binary.BigEndian.Uint32(s[11:15]))),
+		binaryScale:   int(signedInt(s[15:17])),
+		decimalScale:  int(signedInt(s[17:19])),
+		bitsPerValue:  int(s[19]),
+		originalFloat: s[20] == 0,
+	}
+	return d, nil
+}
+
+// scale converts a packed integer to its physical value: (R + X*2^E) / 10^D.
+func (d drs) scaler() func(x float64) float32 {
+	bscale := math.Pow(2, float64(d.binaryScale))
+	dscale := math.Pow(10, float64(-d.decimalScale))
+	return func(x float64) float32 {
+		return float32((d.ref + x*bscale) * dscale)
+	}
+}
+
+// Values decodes the message data into a slice in grid storage order. Points
+// masked by the bitmap or flagged as missing by complex packing are NaN.
+func (m *Message) Values() ([]float32, error) {
+	d, err := m.parseDRS()
+	if err != nil {
+		return nil, err
+	}
+	var packed []float32
+	switch d.template {
+	case 0:
+		packed, err = unpackSimple(d, m.sec7[5:])
+	case 2, 3:
+		packed, err = unpackComplex(d, m.sec5, m.sec7[5:])
+	case 41:
+		packed, err = unpackPNG(d, m.sec7[5:])
+	default:
+		err = fmt.Errorf("grib2: unsupported data representation template 5.%d", d.template)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return m.applyBitmap(packed)
+}
+
+// applyBitmap expands the packed values onto the full grid.
+func (m *Message) applyBitmap(packed []float32) ([]float32, error) {
+	if m.sec6 == nil || len(m.sec6) < 6 || m.sec6[5] == 255 {
+		if len(packed) != m.numPoints {
+			return nil, fmt.Errorf("grib2: decoded %d values for %d grid points", len(packed), m.numPoints)
+		}
+		return packed, nil
+	}
+	if m.sec6[5] != 0 {
+		return nil, fmt.Errorf("grib2: unsupported bitmap indicator %d", m.sec6[5])
+	}
+	bitmap := m.sec6[6:]
+	if len(bitmap)*8 < m.numPoints {
+		return nil, errors.New("grib2: bitmap shorter than grid")
+	}
+	out := make([]float32, m.numPoints)
+	nan := float32(math.NaN())
+	n := 0
+	for k := range out {
+		if bitmap[k>>3]&(0x80>>uint(k&7)) != 0 {
+			if n >= len(packed) {
+				return nil, errors.New("grib2: bitmap selects more points than were packed")
+			}
+			out[k] = packed[n]
+			n++
+		} else {
+			out[k] = nan
+		}
+	}
+	return out, nil
+}
+
+// bitReader reads big-endian bit fields from a byte slice.
+type bitReader struct {
+	buf []byte
+	pos int // bit position
+}
+
+func (r *bitReader) read(n int) (uint64, error) {
+	if n == 0 {
+		return 0, nil
+	}
+	if r.pos+n > len(r.buf)*8 {
+		return 0, errors.New("grib2: packed data truncated")
+	}
+	var v uint64
+	for n > 0 {
+		byteIdx := r.pos >> 3
+		bitOff := r.pos & 7
+		avail := 8 - bitOff
+		take := avail
+		if take > n {
+			take = n
+		}
+		b := uint64(r.buf[byteIdx]>>(uint(avail-take))) & (1<<uint(take) - 1)
+		v = v<<uint(take) | b
+		n -= take
+		r.pos += take
+	}
+	return v, nil
+}
+
+// align advances to the next octet boundary.
+func (r *bitReader) align() {
+	r.pos = (r.pos + 7) &^ 7
+}
+
+func unpackSimple(d drs, data []byte) ([]float32, error) {
+	out := make([]float32, d.numValues)
+	scale := d.scaler()
+	if d.bitsPerValue == 0 {
+		v := scale(0)
+		for i := range out {
+			out[i] = v
+		}
+		return out, nil
+	}
+	r := bitReader{buf: data}
+	for i := range out {
+		x, err := r.read(d.bitsPerValue)
+		if err != nil {
+			return nil, err
+		}
+		out[i] = scale(float64(x))
+	}
+	return out, nil
+}
+
+func unpackPNG(d drs, data []byte) ([]float32, error) {
+	out := make([]float32, d.numValues)
+	scale := d.scaler()
+	if d.bitsPerValue == 0 || len(data) == 0 {
+		v := scale(0)
+		for i := range out {
+			out[i] = v
+		}
+		return out, nil
+	}
+	img, err := png.Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, fmt.Errorf("grib2: decoding PNG data: %w", err)
+	}
+	b := img.Bounds()
+	if b.Dx()*b.Dy() < d.numValues {
+		return nil, fmt.Errorf("grib2: PNG has %d pixels for %d values", b.Dx()*b.Dy(), d.numValues)
+	}
+	w := b.Dx()
+	switch im := img.(type) {
+	case *image.Gray:
+		for i := range out {
+			out[i] = scale(float64(im.Pix[(i/w)*im.Stride+i%w]))
+		}
+	case *image.Gray16:
+		for i := range out {
+			o := (i/w)*im.Stride + (i%w)*2
+			out[i] = scale(float64(uint16(im.Pix[o])<<8 | uint16(im.Pix[o+1])))
+		}
+	case *image.RGBA:
+		rgbaValues(out, im.Pix, im.Stride, w, d.bitsPerValue, scale)
+	case *image.NRGBA:
+		rgbaValues(out, im.Pix, im.Stride, w, d.bitsPerValue, scale)
+	case *image.Paletted:
+		for i := range out {
+			out[i] = scale(float64(im.Pix[(i/w)*im.Stride+i%w]))
+		}
+	default:
+		return nil, fmt.Errorf("grib2: unsupported PNG colour model %T", img)
+	}
+	return out, nil
+}
+
+// rgbaValues combines 24- or 32-bit PNG pixels into packed integers.
+func rgbaValues(out []float32, pix []byte, stride, w, bits int, scale func(float64) float32) {
+	for i := range out {
+		o := (i/w)*stride + (i%w)*4
+		x := uint32(pix[o])<<16 | uint32(pix[o+1])<<8 | uint32(pix[o+2])
+		if bits == 32 {
+			x = x<<8 | uint32(pix[o+3])
+		}
+		out[i] = scale(float64(x))
+	}
+}
+
+// unpackComplex decodes templates 5.2 (complex packing) and 5.3 (complex
+// packing with spatial differencing).
+func unpackComplex(d drs, sec5, data []byte) ([]float32, error) {
+	if len(sec5) < 47 || (d.template == 3 && len(sec5) < 49) {
+		return nil, errors.New("grib2: complex packing template too short")
+	}
+	refBits := int(sec5[19])
+	missingMgmt := sec5[22]
+	ng := int(binary.BigEndian.Uint32(sec5[31:35]))
+	widthRef := int(sec5[35])
+	widthBits := int(sec5[36])
+	lenRef := int(binary.BigEndian.Uint32(sec5[37:41]))
+	lenInc := int(sec5[41])
+	lastLen := int(binary.BigEndian.Uint32(sec5[42:46]))
+	lenBits := int(sec5[46])
+	order, ndes := 0, 0
+	if d.template == 3 {
+		order, ndes = int(sec5[47]), int(sec5[48])
+	}
+
+	out := make([]float32, d.numValues)
+	nan := float32(math.NaN())
+	if ng == 0 {
+		v := d.scaler()(0)
+		for i := range out {
+			out[i] = v
+		}
+		return out, nil
+	}
+
+	r := bitReader{buf: data}
+	var ival1, ival2, minsd int64
+	if order > 0 {
+		if ndes == 0 || len(data) < (order+1)*ndes {
+			return nil, errors.New("grib2: spatial differencing descriptors missing")
+		}
+		ival1 = signedInt(data[0:ndes])
+		if order == 2 {
+			ival2 = signedInt(data[ndes : 2*ndes])
+		}
+		minsd = signedInt(data[order*ndes : (order+1)*ndes])
+		r.pos = (order + 1) * ndes * 8
+	}
+
+	refs := make([]int64, ng)
+	for g := range refs {
+		v, err := r.read(refBits)
+		if err != nil {
+			return nil, err
+		}
+		refs[g] = int64(v)
+	}
+	r.align()
+	widths := make([]int, ng)
+	for g := range widths {
+		v, err := r.read(widthBits)
+		if err != nil {
+			return nil, err
+		}
+		widths[g] = widthRef + int(v)
+	}
+	r.align()
+	lengths := make([]int, ng)
+	for g := range lengths {
+		v, err := r.read(lenBits)
+		if err != nil {
+			return nil, err
+		}
+		lengths[g] = lenRef + lenInc*int(v)
+	}
+	lengths[ng-1] = lastLen
+	r.align()
+
+	ifld := make([]int64, d.numValues)
+	missing := make([]uint8, d.numValues) // 0 = value, 1 = primary, 2 = secondary
+	n := 0
+	for g := 0; g < ng; g++ {
+		w := widths[g]
+		refAllOnes := refBits > 0 && refs[g] == int64(1)<<uint(refBits)-1
+		for k := 0; k < lengths[g]; k++ {
+			if n >= len(ifld) {
+				return nil, errors.New("grib2: complex packing groups exceed value count")
+			}
+			if w == 0 {
+				ifld[n] = refs[g]
+				if missingMgmt != 0 && refAllOnes {
+					missing[n] = 1
+				}
+			} else {
+				x, err := r.read(w)
+				if err != nil {
+					return nil, err
+				}
+				allOnes := uint64(1)<<uint(w) - 1
+				switch {
+				case missingMgmt == 1 && x == allOnes:
+					missing[n] = 1
+				case missingMgmt == 2 && x == allOnes:
+					missing[n] = 1
+				case missingMgmt == 2 && x == allOnes-1:
+					missing[n] = 2
+				default:
+					ifld[n] = refs[g] + int64(x)
+				}
+			}
+			n++
+		}
+	}
+	if n != len(ifld) {
+		return nil, fmt.Errorf("grib2: complex packing produced %d of %d values", n, len(ifld))
+	}
+
+	if order > 0 {
+		// Undo spatial differencing over the non-missing values only.
+		var prev1, prev2 int64
+		seen := 0
+		for i := range ifld {
+			if missing[i] != 0 {
+				continue
+			}
+			switch {
+			case seen == 0:
+				ifld[i] = ival1
+			case seen == 1 && order == 2:
+				ifld[i] = ival2
+			case order == 1:
+				ifld[i] = ifld[i] + minsd + prev1
+			default:
+				ifld[i] = ifld[i] + minsd + 2*prev1 - prev2
+			}
+			prev2, prev1 = prev1, ifld[i]
+			seen++
+		}
+	}
+
+	scale := d.scaler()
+	for i, x := range ifld {
+		if missing[i] != 0 {
+			out[i] = nan
+			continue
+		}
+		out[i] = scale(float64(x))
+	}
+	return out, nil
+}
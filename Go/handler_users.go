@@ -167,6 +167,53 @@ func handleUserSession(c echo.Context) error {
 	}
 }
 
+// sessionUser returns the database user signed in with the request's
+// ArcGIS token
+func sessionUser(c echo.Context, queries *sqlcdb.Queries) (sqlcdb.GetUserByEmailRow, error) {
+	cookie, err := c.Cookie("arcgis_token")
+	if err != nil {
+		return sqlcdb.GetUserByEmailRow{}, fmt.Errorf("missing token: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(c.Request().Context(), "GET", AppConfig.URLs.ArcGISSelfEndpoint, nil)
+	if err != nil {
+		return sqlcdb.GetUserByEmailRow{}, err
+	}
+	req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", cookie.Value))
+
+	client := &http.Client{Timeout: 30 * time.Second}
+	resp, err := client.Do(req)
+	if err != nil {
+		return sqlcdb.GetUserByEmailRow{}, fmt.Errorf("error fetching user info: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return sqlcdb.GetUserByEmailRow{}, fmt.Errorf("ArcGIS returned status %d", resp.StatusCode)
+	}
+
+	var arcgisUser struct {
+		Email string `json:"email"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&arcgisUser); err != nil {
+		return sqlcdb.GetUserByEmailRow{}, fmt.Errorf("error parsing user info: %w", err)
+	}
+	return queries.GetUserByEmail(c.Request().Context(), arcgisUser.Email)
+}
+
+// requireAdmin checks that the request is signed in as an admin or
+// superUser, responding itself and returning false when it is not
+func requireAdmin(c echo.Context, queries *sqlcdb.Queries) (bool, error) {
+	user, err := sessionUser(c, queries)
+	if err != nil {
+		log.Printf("Admin check failed: %v", err)
+		return false, respondWithError(c, http.StatusUnauthorized, "Authentication required")
+	}
+	if user.Role != "superUser" && user.Role != "admin" {
+		return false, respondWithError(c, http.StatusForbidden, "Admin role required")
+	}
+	return true, nil
+}
+
 func handleGetAllUsers(queries *sqlcdb.Queries) echo.HandlerFunc {
 	return func(c echo.Context) error {
 		cookie, err := c.Cookie("arcgis_token")
@@ -0,0 +1,164 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// historicalChunk is a range of days whose MRMS data is downloaded, bias
+// corrected and merged into the run's rainfall DSS file together
+type historicalChunk struct {
+	Start, End time.Time // first and last day
+}
+
+// dirName names the chunk's download directory
+func (ch historicalChunk) dirName() string {
+	return ch.Start.Format("20060102") + "_" + ch.End.Format("20060102")
+}
+
+// days returns the number of days in the chunk
+func (ch historicalChunk) days() int {
+	return int(ch.End.Sub(ch.Start).Hours()/24) + 1
+}
+
+// historicalChunks splits the days from start to end into chunks of at most
+// days days
+func historicalChunks(start, end time.Time, days int) []historicalChunk {
+	if days < 1 {
+		days = 1
+	}
+	var chunks []historicalChunk
+	for d := start; !d.After(end); d = d.AddDate(0, 0, days) {
+		last := d.AddDate(0, 0, days-1)
+		if last.After(end) {
+			last = end
+		}
+		chunks = append(chunks, historicalChunk{Start: d, End: last})
+	}
+	return chunks
+}
+
+// mergeChunkBias combines the bias factors of a chunked run's chunks into
+// the run's record: gauge and radar totals are summed over the chunks, the
+// factors recomputed from the sums, and the chunks' own factors (the ones
+// applied to their grids) kept in Chunks. It returns nil without results.
+func mergeChunkBias(runID int32, method string, results []*PrecipBiasResult) *PrecipBiasResult {
+	var merged *PrecipBiasResult
+	byStation := map[string]int{}
+	var sumGauge, sumRadar float64
+	applied := 0
+	for _, r := range results {
+		if r == nil {
+			continue
+		}
+		if merged == nil {
+			merged = &PrecipBiasResult{
+				RunID:           runID,
+				Method:          method,
+				WindowStart:     r.WindowStart,
+				WindowEnd:       r.WindowEnd,
+				MeanFieldFactor: 1,
+				Gauges:          []PrecipBiasGauge{},
+			}
+		}
+		if r.WindowStart.Before(merged.WindowStart) {
+			merged.WindowStart = r.WindowStart
+		}
+		if r.WindowEnd.After(merged.WindowEnd) {
+			merged.WindowEnd = r.WindowEnd
+		}
+		if r.Applied {
+			applied++
+		}
+		for _, g := range r.Gauges {
+			i, ok := byStation[g.StationID]
+			if !ok {
+				i = len(merged.Gauges)
+				byStation[g.StationID] = i
+				merged.Gauges = append(merged.Gauges, PrecipBiasGauge{StationID: g.StationID, Name: g.Name, Lat: g.Lat, Lon: g.Lon})
+			}
+			m := &merged.Gauges[i]
+			m.GaugeTotal = roundTo(m.GaugeTotal+g.GaugeTotal, 1000)
+			m.RadarTotal = roundTo(m.RadarTotal+g.RadarTotal, 1000)
+			m.Used = m.Used || g.Used
+			if g.Used {
+				sumGauge += g.GaugeTotal
+				sumRadar += g.RadarTotal
+			}
+		}
+		chunk := *r
+		chunk.RunID, chunk.Chunks = runID, nil
+		merged.Chunks = append(merged.Chunks, chunk)
+	}
+	if merged == nil {
+		return nil
+	}
+
+	for i := range merged.Gauges {
+		g := &merged.Gauges[i]
+		if g.Used {
+			merged.GaugesUsed++
+			if g.RadarTotal > 0 {
+				g.Factor = roundTo(clampFactor(g.GaugeTotal/g.RadarTotal), 1000)
+			}
+		}
+	}
+	if sumRadar > 0 {
+		merged.MeanFieldFactor = roundTo(clampFactor(sumGauge/sumRadar), 1000)
+	}
+	merged.Applied = applied == len(merged.Chunks)
+	switch {
+	case merged.GaugesUsed == 0:
+		merged.Message = "no chunk had enough gauges for a bias factor"
+	case applied > 0 && !merged.Applied:
+		merged.Message = fmt.Sprintf("correction applied to %d of %d chunks", applied, len(merged.Chunks))
+	}
+	return merged
+}
+
+// historicalMaxDays returns the longest range a user of role may request,
+// historical.max_days without a role or a limit for it
+func historicalMaxDays(role string) int {
+	cfg := AppConfig.Historical
+	for r, days := range cfg.MaxDaysByRole {
+		if role != "" && strings.EqualFold(r, role) {
+			return days
+		}
+	}
+	return cfg.MaxDays
+}
+
+// parseHistoricalRange parses the dates of a historical request and checks
+// them against the MRMS archive. A positive maxDays limits the range length.
+func parseHistoricalRange(req HistoricalDownloadRequest, maxDays int) (startDate, endDate time.Time, err error) {
+	startDate, err = time.Parse("20060102", req.StartDate)
+	if err != nil {
+		return startDate, endDate, fmt.Errorf("invalid start date format: %w", err)
+	}
+	endDate, err = time.Parse("20060102", req.EndDate)
+	if err != nil {
+		return startDate, endDate, fmt.Errorf("invalid end date format: %w", err)
+	}
+
+	minDate, err := time.Parse("20060102", AppConfig.Historical.EarliestDate)
+	if err != nil {
+		return startDate, endDate, fmt.Errorf("invalid historical.earliest_date: %w", err)
+	}
+	if startDate.Before(minDate) || endDate.Before(minDate) {
+		return startDate, endDate, fmt.Errorf("dates must be from %s to current date", minDate.Format("2006-01-02"))
+	}
+	maxDate := time.Now()
+	if startDate.After(maxDate) || endDate.After(maxDate) {
+		return startDate, endDate, fmt.Errorf("dates cannot be in the future")
+	}
+	if startDate.After(endDate) {
+		return startDate, endDate, fmt.Errorf("start date must be before or equal to end date")
+	}
+
+	days := historicalChunk{Start: startDate, End: endDate}.days()
+	if maxDays > 0 && days > maxDays {
+		return startDate, endDate, fmt.Errorf("date range cannot exceed %d days", maxDays)
+	}
+	return startDate, endDate, nil
+}
@@ -0,0 +1,88 @@
+package main
+
+import (
+	"testing"
+	"time"
+)
+
+func mustDay(s string) time.Time {
+	t, err := time.Parse("20060102", s)
+	if err != nil {
+		panic(err)
+	}
+	return t
+}
+
+func TestHistoricalChunks(t *testing.T) {
+	tests := []struct {
+		name       string
+		start, end string
+		days       int
+		want       []string // dirName of each chunk
+	}{
+		{"single day", "20240101", "20240101", 7, []string{"20240101_20240101"}},
+		{"fits one chunk", "20240101", "20240107", 7, []string{"20240101_20240107"}},
+		{"last chunk shorter", "20240101", "20240110", 7, []string{"20240101_20240107", "20240108_20240110"}},
+		{"month boundary", "20240129", "20240203", 3, []string{"20240129_20240131", "20240201_20240203"}},
+		{"zero days means one", "20240101", "20240102", 0, []string{"20240101_20240101", "20240102_20240102"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			chunks := historicalChunks(mustDay(tt.start), mustDay(tt.end), tt.days)
+			if len(chunks) != len(tt.want) {
+				t.Fatalf("got %d chunks, want %d", len(chunks), len(tt.want))
+			}
+			total := 0
+			for i, ch := range chunks {
+				if ch.dirName() != tt.want[i] {
+					t.Errorf("chunk %d is %s, want %s", i, ch.dirName(), tt.want[i])
+				}
+				total += ch.days()
+			}
+			if want := (historicalChunk{Start: mustDay(tt.start), End: mustDay(tt.end)}).days(); total != want {
+				t.Errorf("chunks cover %d days, want %d", total, want)
+			}
+		})
+	}
+}
+
+func TestMergeChunkBias(t *testing.T) {
+	if mergeChunkBias(1, biasMethodMeanField, []*PrecipBiasResult{nil}) != nil {
+		t.Error("expected nil without results")
+	}
+
+	first := &PrecipBiasResult{
+		WindowStart: mustDay("20240101").Add(-time.Hour), WindowEnd: mustDay("20240107").Add(23 * time.Hour),
+		MeanFieldFactor: 2, GaugesUsed: 1, Applied: true,
+		Gauges: []PrecipBiasGauge{
+			{StationID: "A", GaugeTotal: 2, RadarTotal: 1, Factor: 2, Used: true},
+			{StationID: "B", GaugeTotal: 0.01, RadarTotal: 0.02},
+		},
+	}
+	second := &PrecipBiasResult{
+		WindowStart: mustDay("20240108").Add(-time.Hour), WindowEnd: mustDay("20240110").Add(23 * time.Hour),
+		MeanFieldFactor: 0.5, GaugesUsed: 2,
+		Gauges: []PrecipBiasGauge{
+			{StationID: "A", GaugeTotal: 1, RadarTotal: 2, Factor: 0.5, Used: true},
+			{StationID: "B", GaugeTotal: 1, RadarTotal: 1, Factor: 1, Used: true},
+		},
+	}
+	got := mergeChunkBias(7, biasMethodMeanField, []*PrecipBiasResult{first, nil, second})
+
+	if !got.WindowStart.Equal(first.WindowStart) || !got.WindowEnd.Equal(second.WindowEnd) {
+		t.Errorf("window %s – %s, want %s – %s", got.WindowStart, got.WindowEnd, first.WindowStart, second.WindowEnd)
+	}
+	// Used pairs: A 2/1, A 1/2, B 1/1 → 4/4
+	if got.MeanFieldFactor != 1 || got.GaugesUsed != 2 {
+		t.Errorf("mean-field %g from %d gauges, want 1 from 2", got.MeanFieldFactor, got.GaugesUsed)
+	}
+	if a := got.Gauges[0]; a.GaugeTotal != 3 || a.RadarTotal != 3 || a.Factor != 1 {
+		t.Errorf("gauge A = %+v, want totals 3/3 and factor 1", a)
+	}
+	if got.Applied || got.Message != "correction applied to 1 of 2 chunks" {
+		t.Errorf("applied %v, message %q", got.Applied, got.Message)
+	}
+	if len(got.Chunks) != 2 || got.Chunks[1].RunID != 7 || got.Chunks[1].MeanFieldFactor != 0.5 {
+		t.Errorf("chunks = %+v", got.Chunks)
+	}
+}
@@ -0,0 +1,278 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"HMSBackend/hms"
+	"HMSBackend/sqlcdb"
+
+	"github.com/labstack/echo/v4"
+)
+
+// Files saved with every historical event
+const (
+	eventRainfallFile = "rainfall.dss"
+	eventResultsFile  = "results.dss"
+	eventFlowsFile    = "flows.json"
+)
+
+var eventFiles = []string{eventRainfallFile, eventResultsFile, eventFlowsFile}
+
+// historicalEventName returns the library name of a historical request
+func historicalEventName(req HistoricalDownloadRequest) string {
+	if req.Event != "" {
+		return req.Event
+	}
+	return req.StartDate + "_" + req.EndDate
+}
+
+// saveHistoricalEvent stores a completed historical run in the event
+// library: its rainfall and results DSS files and junction flows are copied
+// to <events_dir>/<model>/<event> and the event is recorded with its inputs.
+func saveHistoricalEvent(ctx context.Context, queries *sqlcdb.Queries, ws *hmsWorkspace, runID int32, req HistoricalDownloadRequest, inputs interface{}, flows *JunctionFlowsFile) error {
+	name := historicalEventName(req)
+	dir := filepath.Join(AppConfig.HMS.EventsDir, ws.source.ID, name)
+	if err := os.RemoveAll(dir); err != nil {
+		return fmt.Errorf("failed to clear %s: %w", dir, err)
+	}
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return fmt.Errorf("failed to create %s: %w", dir, err)
+	}
+
+	m := ws.Model
+	if err := copyFile(m.HistoricalRainfallPath("RainfallHistorical.dss"), filepath.Join(dir, eventRainfallFile)); err != nil {
+		return fmt.Errorf("failed to save rainfall: %w", err)
+	}
+	if err := copyFile(ws.resultsDSS(), filepath.Join(dir, eventResultsFile)); err != nil {
+		return fmt.Errorf("failed to save results: %w", err)
+	}
+	flowsJSON, err := json.Marshal(flows)
+	if err != nil {
+		return err
+	}
+	if err := os.WriteFile(filepath.Join(dir, eventFlowsFile), flowsJSON, 0644); err != nil {
+		return fmt.Errorf("failed to save flows: %w", err)
+	}
+
+	control, err := hms.ReadControlFile(m.ControlFile(pipelineRunHistorical))
+	if err != nil {
+		return fmt.Errorf("failed to read control file: %w", err)
+	}
+	inputsJSON, err := json.Marshal(inputs)
+	if err != nil {
+		return err
+	}
+	_, err = queries.UpsertHistoricalEvent(ctx, sqlcdb.UpsertHistoricalEventParams{
+		Name:         name,
+		Model:        ws.source.ID,
+		Description:  req.Description,
+		StartTime:    control.Start.UTC(),
+		EndTime:      control.End.UTC(),
+		RunID:        sql.NullInt32{Int32: runID, Valid: runID != 0},
+		Inputs:       inputsJSON,
+		Flows:        flowsJSON,
+		ArtifactsDir: dir,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to record event %s: %w", name, err)
+	}
+	log.Printf("INFO: Saved historical event %s of model %s to %s", name, ws.source.ID, dir)
+	return nil
+}
+
+func historicalEventSummary(e sqlcdb.HistoricalEvent) HistoricalEventSummary {
+	s := HistoricalEventSummary{
+		Name:        e.Name,
+		Model:       e.Model,
+		Description: e.Description,
+		Start:       e.StartTime,
+		End:         e.EndTime,
+		CreatedAt:   e.CreatedAt,
+	}
+	if e.RunID.Valid {
+		s.RunID = e.RunID.Int32
+	}
+	return s
+}
+
+// handleListHistoricalEvents lists the saved events, latest first,
+// optionally of one model (?model=)
+func handleListHistoricalEvents(queries *sqlcdb.Queries) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		rows, err := queries.ListHistoricalEvents(c.Request().Context(), c.QueryParam("model"))
+		if err != nil {
+			log.Printf("Error listing historical events: %v", err)
+			return respondWithError(c, http.StatusInternalServerError, "Failed to list historical events")
+		}
+		out := make([]HistoricalEventSummary, len(rows))
+		for i, r := range rows {
+			out[i] = historicalEventSummary(sqlcdb.HistoricalEvent{
+				Name:        r.Name,
+				Model:       r.Model,
+				Description: r.Description,
+				StartTime:   r.StartTime,
+				EndTime:     r.EndTime,
+				RunID:       r.RunID,
+				CreatedAt:   r.CreatedAt,
+			})
+		}
+		return respondWithJSON(c, http.StatusOK, out)
+	}
+}
+
+// getHistoricalEvent loads the event named by the :model and :name path
+// parameters, responding itself when that fails
+func getHistoricalEvent(c echo.Context, queries *sqlcdb.Queries) (sqlcdb.HistoricalEvent, bool, error) {
+	e, err := queries.GetHistoricalEvent(c.Request().Context(), sqlcdb.GetHistoricalEventParams{
+		Model: c.Param("model"),
+		Name:  c.Param("name"),
+	})
+	if errors.Is(err, sql.ErrNoRows) {
+		return e, false, respondWithError(c, http.StatusNotFound, "Historical event not found")
+	}
+	if err != nil {
+		log.Printf("Error getting historical event %s/%s: %v", c.Param("model"), c.Param("name"), err)
+		return e, false, respondWithError(c, http.StatusInternalServerError, "Failed to get historical event")
+	}
+	return e, true, nil
+}
+
+// handleGetHistoricalEvent returns a saved event with its inputs and flows
+func handleGetHistoricalEvent(queries *sqlcdb.Queries) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		e, ok, err := getHistoricalEvent(c, queries)
+		if !ok {
+			return err
+		}
+		out := HistoricalEventResponse{
+			HistoricalEventSummary: historicalEventSummary(e),
+			Inputs:                 e.Inputs,
+			Flows:                  decodeScenarioFlows(e.Flows, e.Model, e.Name),
+			Artifacts:              []string{},
+		}
+		for _, f := range eventFiles {
+			if _, err := os.Stat(filepath.Join(e.ArtifactsDir, f)); err == nil {
+				out.Artifacts = append(out.Artifacts, f)
+			}
+		}
+		return respondWithJSON(c, http.StatusOK, out)
+	}
+}
+
+// handleGetHistoricalEventFile downloads one of an event's saved files
+func handleGetHistoricalEventFile(queries *sqlcdb.Queries) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		file := c.Param("file")
+		known := false
+		for _, f := range eventFiles {
+			known = known || f == file
+		}
+		if !known {
+			return respondWithError(c, http.StatusNotFound, fmt.Sprintf("file must be one of %s", strings.Join(eventFiles, ", ")))
+		}
+		e, ok, err := getHistoricalEvent(c, queries)
+		if !ok {
+			return err
+		}
+		path := filepath.Join(e.ArtifactsDir, file)
+		if _, err := os.Stat(path); err != nil {
+			return respondWithError(c, http.StatusNotFound, "File not found")
+		}
+		return c.Attachment(path, e.Name+"_"+file)
+	}
+}
+
+// handleDeleteHistoricalEvent deletes an event and its saved files (admins)
+func handleDeleteHistoricalEvent(queries *sqlcdb.Queries) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		if ok, err := requireAdmin(c, queries); !ok {
+			return err
+		}
+		dir, err := queries.DeleteHistoricalEvent(c.Request().Context(), sqlcdb.DeleteHistoricalEventParams{
+			Model: c.Param("model"),
+			Name:  c.Param("name"),
+		})
+		if errors.Is(err, sql.ErrNoRows) {
+			return respondWithError(c, http.StatusNotFound, "Historical event not found")
+		}
+		if err != nil {
+			log.Printf("Error deleting historical event %s/%s: %v", c.Param("model"), c.Param("name"), err)
+			return respondWithError(c, http.StatusInternalServerError, "Failed to delete historical event")
+		}
+
+		// Only remove directories of the library itself
+		if rel, relErr := filepath.Rel(AppConfig.HMS.EventsDir, dir); relErr == nil && rel != "." && !strings.HasPrefix(rel, "..") {
+			if err := os.RemoveAll(dir); err != nil {
+				log.Printf("Warning: Failed to remove %s: %v", dir, err)
+			}
+		} else {
+			log.Printf("Warning: Left %s of deleted event %s in place: outside %s", dir, c.Param("name"), AppConfig.HMS.EventsDir)
+		}
+		return respondWithJSON(c, http.StatusOK, map[string]string{"message": "Historical event deleted successfully"})
+	}
+}
+
+// handleCompareHistoricalEvents compares the junction peak flows of saved
+// events of a model (?model=&names=may2025,oct2013)
+func handleCompareHistoricalEvents(queries *sqlcdb.Queries) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		model, err := GetModel(c.QueryParam("model"))
+		if err != nil {
+			return respondWithError(c, http.StatusBadRequest, err.Error())
+		}
+		var names []string
+		for _, n := range strings.Split(c.QueryParam("names"), ",") {
+			if n = strings.TrimSpace(n); n != "" {
+				names = append(names, n)
+			}
+		}
+		if len(names) < 2 {
+			return respondWithError(c, http.StatusBadRequest, "names must list at least two events")
+		}
+
+		out := HistoricalEventComparison{Model: model.ID}
+		index := map[string]int{}
+		for _, name := range names {
+			e, err := queries.GetHistoricalEvent(c.Request().Context(), sqlcdb.GetHistoricalEventParams{Model: model.ID, Name: name})
+			if errors.Is(err, sql.ErrNoRows) {
+				return respondWithError(c, http.StatusNotFound, fmt.Sprintf("Historical event %s not found", name))
+			}
+			if err != nil {
+				log.Printf("Error getting historical event %s/%s: %v", model.ID, name, err)
+				return respondWithError(c, http.StatusInternalServerError, "Failed to get historical events")
+			}
+			out.Events = append(out.Events, historicalEventSummary(e))
+
+			flows := decodeScenarioFlows(e.Flows, e.Model, e.Name)
+			if flows == nil {
+				continue
+			}
+			for _, s := range flows.Series {
+				i, ok := index[s.Name]
+				if !ok {
+					i = len(out.Junctions)
+					index[s.Name] = i
+					out.Junctions = append(out.Junctions, HistoricalEventPeaks{Name: s.Name, Peaks: map[string]*float64{}})
+				}
+				out.Junctions[i].Peaks[name] = seriesPeak(s)
+			}
+		}
+		for i := range out.Junctions {
+			for _, name := range names {
+				if _, ok := out.Junctions[i].Peaks[name]; !ok {
+					out.Junctions[i].Peaks[name] = nil
+				}
+			}
+		}
+		return respondWithJSON(c, http.StatusOK, out)
+	}
+}
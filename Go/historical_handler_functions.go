@@ -3,18 +3,18 @@ package main
 import (
 	"compress/gzip"
 	"context"
-	"encoding/json"
 	"fmt"
 	"io"
 	"log"
 	"net/http"
 	"os"
-	"os/exec"
 	"path/filepath"
 	"strconv"
 	"strings"
 	"time"
 
+	"HMSBackend/sqlcdb"
+
 	"github.com/labstack/echo/v4"
 )
 
@@ -150,183 +150,185 @@ func roundTimeUp(timeStr string) string {
 	return fmt.Sprintf("%02d:00", hour)
 }
 
-// updateHistoricalControlFile updates the control file with the specified dates and times
-func updateHistoricalControlFile(startDate, endDate time.Time, startTime, endTime string) error {
+// updateHistoricalControlFile updates a model's historical control file with the specified dates and times
+func updateHistoricalControlFile(model ModelConfig, startDate, endDate time.Time, startTime, endTime string) error {
 	// Path to the control file
-	controlFilePath := GetHMSControlFile("historical")
-
-	// Format dates for the control file (e.g., "9 May 2025")
-	startDateStr := startDate.Format("2 January 2006")
-	endDateStr := endDate.Format("2 January 2006")
+	controlFilePath := model.ControlFile(pipelineRunHistorical)
 
 	// Round times appropriately
 	startTimeRounded := roundTimeDown(startTime)
 	endTimeRounded := roundTimeUp(endTime)
 
-	log.Printf("Updating control file with: Start: %s %s, End: %s %s",
-		startDateStr, startTimeRounded, endDateStr, endTimeRounded)
-
-	// Read the control file
-	content, err := os.ReadFile(controlFilePath)
+	start, err := time.Parse("20060102 15:04", startDate.Format("20060102")+" "+startTimeRounded)
 	if err != nil {
-		return fmt.Errorf("failed to read control file: %w", err)
-	}
-
-	lines := strings.Split(string(content), "\n")
-	updatedLines := make([]string, 0, len(lines))
-
-	// Update specific lines
-	for _, line := range lines {
-		trimmedLine := strings.TrimSpace(line)
-
-		switch {
-		case strings.HasPrefix(trimmedLine, "Start Date:"):
-			updatedLines = append(updatedLines, fmt.Sprintf("     Start Date: %s", startDateStr))
-		case strings.HasPrefix(trimmedLine, "Start Time:"):
-			updatedLines = append(updatedLines, fmt.Sprintf("     Start Time: %s", startTimeRounded))
-		case strings.HasPrefix(trimmedLine, "End Date:"):
-			updatedLines = append(updatedLines, fmt.Sprintf("     End Date: %s", endDateStr))
-		case strings.HasPrefix(trimmedLine, "End Time:"):
-			updatedLines = append(updatedLines, fmt.Sprintf("     End Time: %s", endTimeRounded))
-		default:
-			updatedLines = append(updatedLines, line)
-		}
+		return fmt.Errorf("invalid start time %q: %w", startTimeRounded, err)
 	}
-
-	// Write back to file
-	updatedContent := strings.Join(updatedLines, "\n")
-	err = os.WriteFile(controlFilePath, []byte(updatedContent), 0644)
+	end, err := time.Parse("20060102 15:04", endDate.Format("20060102")+" "+endTimeRounded)
 	if err != nil {
-		return fmt.Errorf("failed to write control file: %w", err)
+		return fmt.Errorf("invalid end time %q: %w", endTimeRounded, err)
+	}
+
+	log.Printf("Updating control file with: Start: %s, End: %s",
+		start.Format("2 January 2006 15:04"), end.Format("2 January 2006 15:04"))
+
+	if err := setControlWindow(controlFilePath, start, end); err != nil {
+		return err
 	}
 
 	log.Printf("Successfully updated control file: %s", controlFilePath)
 	return nil
 }
 
-// runHMSPipelineHistorical orchestrates the complete historical HMS processing pipeline
-func runHMSPipelineHistorical(ctx context.Context, req HistoricalDownloadRequest) error {
-	log.Printf("INFO: Starting historical HMS pipeline from %s to %s", req.StartDate, req.EndDate)
-
-	// Step 0: Delete existing DSS files if they exist
-	// Delete RainHistorical.dss
-	existingDSSPath1 := filepath.Join(AppConfig.Paths.HMSHistoricalModelsDir, "LeonCreek", "RainHistorical.dss")
-	if _, err := os.Stat(existingDSSPath1); err == nil {
-		log.Printf("Deleting existing RainHistorical.dss file...")
-		if err := os.Remove(existingDSSPath1); err != nil {
-			log.Printf("Warning: Failed to delete existing DSS file: %v", err)
-			// Continue anyway as it might get overwritten
-		} else {
-			log.Printf("Successfully deleted existing RainHistorical.dss file")
-		}
-	}
-
-	// Delete RainfallHistorical.dss
-	existingDSSPath2 := GetHistoricalDSSPath("RainfallHistorical.dss")
-	if _, err := os.Stat(existingDSSPath2); err == nil {
-		log.Printf("Deleting existing RainfallHistorical.dss file...")
-		if err := os.Remove(existingDSSPath2); err != nil {
-			log.Printf("Warning: Failed to delete existing Rainfall DSS file: %v", err)
-			// Continue anyway as it might get overwritten
-		} else {
-			log.Printf("Successfully deleted existing RainfallHistorical.dss file")
-		}
-	}
+// historicalComputeNote states, on the responses and progress of chunked
+// runs, what chunking does not do
+const historicalComputeNote = "Chunks split the rainfall download, bias correction and merge only: HMS computes the whole range in one run, without warm-started segments, and progress is reported per chunk until the compute starts"
 
-	// Step 1: Download historical MRMS data
-	log.Printf("STEP 1: Downloading historical MRMS data...")
+// runHMSPipelineHistorical orchestrates the complete historical HMS processing
+// pipeline of the recorded run runID. Long ranges are downloaded, bias
+// corrected and merged into the rainfall DSS file one chunk of
+// historical.chunk_days days at a time and computed as a single HMS run; the
+// run's progress is stored for GET /api/pipeline-runs/:id.
+func runHMSPipelineHistorical(ctx context.Context, queries *sqlcdb.Queries, runID int32, req HistoricalDownloadRequest) (err error) {
+	defer func() { finishPipelineRun(queries, runID, err) }()
 
-	// Validate dates
-	startDate, err := time.Parse("20060102", req.StartDate)
+	model, err := GetModel(req.Model)
 	if err != nil {
-		return fmt.Errorf("invalid start date format: %w", err)
+		return err
 	}
+	req.Model = model.ID
+	log.Printf("INFO: Starting historical HMS pipeline for model %s from %s to %s", model.ID, req.StartDate, req.EndDate)
 
-	endDate, err := time.Parse("20060102", req.EndDate)
+	// The handler checked the range against the user's limit
+	startDate, endDate, err := parseHistoricalRange(req, 0)
 	if err != nil {
-		return fmt.Errorf("invalid end date format: %w", err)
+		return err
 	}
-
-	// Check if dates are in valid range (2021 to current)
-	minDate := time.Date(2021, 1, 1, 0, 0, 0, 0, time.UTC)
-	maxDate := time.Now()
-
-	if startDate.Before(minDate) || endDate.Before(minDate) {
-		return fmt.Errorf("dates must be from 2021 to current date")
+	chunks := historicalChunks(startDate, endDate, AppConfig.Historical.ChunkDays)
+	progress := HistoricalProgress{Chunks: len(chunks), Days: historicalChunk{Start: startDate, End: endDate}.days()}
+	if len(chunks) > 1 {
+		progress.Note = historicalComputeNote
 	}
 
-	if startDate.After(maxDate) || endDate.After(maxDate) {
-		return fmt.Errorf("dates cannot be in the future")
-	}
+	// Every file the run writes goes to a private copy of the historical project
+	ws, err := newHMSWorkspace(model, pipelineRunHistorical, runID, model.HistoricalRainfallPath("RainfallHistorical.dss"))
+	if err != nil {
+		return fmt.Errorf("failed to create HMS workspace: %w", err)
+	}
+	defer func() { ws.close(err != nil, req.KeepWorkspace) }()
+	model = ws.Model
+
+	// Steps 1 and 2 run per chunk: the merge appends each chunk's grids to
+	// the rainfall DSS file. Chunks are downloaded into the workspace and,
+	// once merged and summarised, deleted so long ranges do not fill the disk.
+	outputDSS := model.HistoricalRainfallPath("RainfallHistorical.dss")
+	biasMethod, applyBias := resolveBiasOptions(req.BiasMethod, req.ApplyBias)
+	var biasResults []*PrecipBiasResult
+	var summaryHours summaryHours
+	summaryRegions, summaryErr := loadSummaryRegions()
+
+	for i, chunk := range chunks {
+		progress.Step, progress.Chunk = "download", i+1
+		setPipelineRunProgress(ctx, queries, runID, progress)
+
+		// Step 1: Download historical MRMS data
+		log.Printf("STEP 1: Downloading historical MRMS data for %s to %s (chunk %d of %d)...",
+			chunk.Start.Format("20060102"), chunk.End.Format("20060102"), i+1, len(chunks))
+
+		// The workspace path is absolute, as the batch scripts need
+		absChunkDir := filepath.Join(ws.Dir, "mrms", chunk.dirName())
+		correctedDir := filepath.Join(ws.Dir, biasCorrectedDir, chunk.dirName())
+		if err := os.MkdirAll(absChunkDir, 0755); err != nil {
+			return fmt.Errorf("failed to create output directory: %w", err)
+		}
 
-	if startDate.After(endDate) {
-		return fmt.Errorf("start date must be before or equal to end date")
-	}
+		downloadedCount := 0
+		for currentDate := chunk.Start; !currentDate.After(chunk.End); currentDate = currentDate.AddDate(0, 0, 1) {
+			if err := ctx.Err(); err != nil {
+				return err
+			}
+			err := downloadMRMSForDate(currentDate, absChunkDir)
+			if err != nil {
+				log.Printf("Failed to download data for %s: %v", currentDate.Format("20060102"), err)
+				progress.FailedDates = append(progress.FailedDates, currentDate.Format("20060102"))
+			} else {
+				downloadedCount++
+			}
+		}
+		progress.DaysDownloaded += downloadedCount
 
-	// Check if date range is within 5 days
-	daysDifference := int(endDate.Sub(startDate).Hours() / 24)
-	if daysDifference > 4 {
-		return fmt.Errorf("date range cannot exceed 5 days")
-	}
+		if downloadedCount == 0 {
+			log.Printf("Warning: No MRMS data downloaded for chunk %d of %d", i+1, len(chunks))
+			continue
+		}
 
-	// Create output directory
-	outputDir := filepath.Join(AppConfig.Paths.GribFilesDir, "historical", req.EndDate)
-	if err := os.MkdirAll(outputDir, 0755); err != nil {
-		return fmt.Errorf("failed to create output directory: %w", err)
-	}
+		log.Printf("STEP 1 COMPLETE: Downloaded MRMS data for %d days", downloadedCount)
+
+		// Gauge bias correction of the downloaded hours, those ending 00Z of
+		// the chunk's first day through 23Z of its last. The window excludes
+		// its start, so it opens at 23Z of the day before. Each chunk is
+		// corrected with its own factors; they are recorded on the run below.
+		windowStart, windowEnd := chunk.Start.Add(-time.Hour), chunk.End.Add(23*time.Hour)
+		var bias *PrecipBiasResult
+		mrmsDir, bias := correctBias(ctx, queries, runID, absChunkDir, correctedDir, windowStart, windowEnd, biasMethod, applyBias)
+		biasResults = append(biasResults, bias)
+
+		// Step 2: Merge GRIB files
+		progress.Step = "merge"
+		setPipelineRunProgress(ctx, queries, runID, progress)
+		log.Printf("STEP 2: Merging GRIB files (chunk %d of %d)...", i+1, len(chunks))
+
+		// Execute the merge GRIB files batch script
+		err = executeBatchFile(ctx,
+			GetJythonBatchScriptPath("MergeGRIBFilesRealTimePass2Batch.bat"),
+			mrmsDir,
+			model.ClipShapefile, // empty uses the script's default
+			outputDSS,
+		)
 
-	// Convert to absolute path for batch script execution
-	absOutputDir, err := filepath.Abs(outputDir)
-	if err != nil {
-		return fmt.Errorf("failed to get absolute path for output directory: %w", err)
-	}
+		if err != nil {
+			return fmt.Errorf("failed to merge GRIB files: %w", err)
+		}
 
-	// Download files for each day
-	currentDate := startDate
-	downloadedCount := 0
-	failedDates := []string{}
+		log.Printf("STEP 2 COMPLETE: Successfully merged GRIB files to: %s", outputDSS)
 
-	for !currentDate.After(endDate) {
-		err := downloadMRMSForDate(currentDate, outputDir)
-		if err != nil {
-			log.Printf("Failed to download data for %s: %v", currentDate.Format("20060102"), err)
-			failedDates = append(failedDates, currentDate.Format("20060102"))
-		} else {
-			downloadedCount++
+		if summaryErr == nil {
+			if _, err := summaryHours.add(indexMRMSHourlyFiles(mrmsDir), windowStart, windowEnd, summaryRegions); err != nil {
+				summaryErr = err
+			}
+		}
+		if !req.KeepWorkspace {
+			for _, dir := range []string{absChunkDir, correctedDir} {
+				if err := os.RemoveAll(dir); err != nil {
+					log.Printf("Warning: Failed to remove merged chunk %s: %v", dir, err)
+				}
+			}
 		}
-		currentDate = currentDate.AddDate(0, 0, 1)
 	}
 
-	if downloadedCount == 0 {
+	if progress.DaysDownloaded == 0 {
 		return fmt.Errorf("failed to download any MRMS data")
 	}
 
-	log.Printf("STEP 1 COMPLETE: Downloaded MRMS data for %d days", downloadedCount)
-
-	// Step 2: Merge GRIB files
-	log.Printf("STEP 2: Merging GRIB files...")
-
-	// For now, using a dummy output DSS file path as requested
-	outputDSS := GetHistoricalDSSPath("RainfallHistorical.dss")
-
-	// Execute the merge GRIB files batch script
-	err = executeBatchFile(ctx,
-		GetJythonBatchScriptPath("MergeGRIBFilesRealTimePass2Batch.bat"),
-		absOutputDir,
-		"", // Empty string for shapefile_path to use default
-		outputDSS,
-	)
-
-	if err != nil {
-		return fmt.Errorf("failed to merge GRIB files: %w", err)
+	// A single chunk records its factors as they are; a chunked run records
+	// the chunks' factors combined
+	if len(chunks) == 1 {
+		storeBiasFactors(ctx, queries, biasResults[0])
+	} else {
+		storeBiasFactors(ctx, queries, mergeChunkBias(runID, biasMethod, biasResults))
 	}
 
-	log.Printf("STEP 2 COMPLETE: Successfully merged GRIB files to: %s", outputDSS)
+	// Summary of all downloaded hours, read chunk by chunk above
+	if summaryErr != nil {
+		log.Printf("Warning: Precipitation summary for run %d skipped: %v", runID, summaryErr)
+	} else {
+		summary, err := summaryHours.summary(startDate.Add(-time.Hour), endDate.Add(23*time.Hour), summaryRegions)
+		storePrecipSummary(ctx, queries, runID, pipelineRunHistorical, summary, err)
+	}
 
 	// Step 3: Update the control file
 	log.Printf("STEP 3: Updating control file with dates and times...")
 
-	err = updateHistoricalControlFile(startDate, endDate, req.StartTime, req.EndTime)
+	err = updateHistoricalControlFile(model, startDate, endDate, req.StartTime, req.EndTime)
 	if err != nil {
 		return fmt.Errorf("failed to update control file: %w", err)
 	}
@@ -335,121 +337,176 @@ func runHMSPipelineHistorical(ctx context.Context, req HistoricalDownloadRequest
 
 	// Step 4: Run HMS historical computation
 	log.Printf("STEP 4: Running HMS historical computation...")
+	progress.Step = "compute"
+	setPipelineRunProgress(ctx, queries, runID, progress)
 
-	// Use batch script for HMS execution
+	// Use batch script for HMS execution with a compute script generated for this run
 	batchPath := GetHMSBatchScriptPath("HMSHistoricalBatch.bat")
-	scriptPath := GetHMSScript("historical")
-	hmsModelsDir := AppConfig.Paths.HMSHistoricalModelsDir
-
-	err = executeBatchFile(ctx, batchPath, scriptPath, hmsModelsDir)
+	scriptPath, err := writeHMSComputeScript(ws, req.Overrides)
 	if err != nil {
 		return fmt.Errorf("failed at step 4 (HMS Historical Computation): %w", err)
 	}
 
-	log.Printf("STEP 4 COMPLETE: HMS historical computation completed successfully")
-
-	log.Printf("INFO: Historical HMS pipeline completed successfully")
-	return nil
-}
+	hmsModelsDir := filepath.Dir(model.HistoricalDir)
 
-// handleRunHMSPipelineHistorical handles the request to run the historical HMS processing pipeline
-func handleRunHMSPipelineHistorical(c echo.Context) error {
-	// Parse request body - using the existing HistoricalDownloadRequest structure
-	var req HistoricalDownloadRequest
-	if err := c.Bind(&req); err != nil {
-		log.Printf("Error parsing historical pipeline request: %v", err)
-		return respondWithError(c, http.StatusBadRequest, "Invalid request format")
+	err = executeBatchFile(ctx, batchPath, scriptPath, hmsModelsDir)
+	if err != nil {
+		return fmt.Errorf("failed at step 4 (HMS Historical Computation): %w", err)
 	}
 
-	// Basic validation
-	if req.StartDate == "" || req.EndDate == "" {
-		return respondWithError(c, http.StatusBadRequest, "start_date and end_date are required")
+	if err = ws.publishResults(); err != nil {
+		return fmt.Errorf("failed at step 4 (HMS Historical Computation): %w", err)
 	}
 
-	log.Printf("Received historical HMS pipeline request: start=%s, end=%s, start_time=%s, end_time=%s",
-		req.StartDate, req.EndDate, req.StartTime, req.EndTime)
-
-	// Create a new context with a timeout
-	ctx, cancel := context.WithTimeout(context.Background(), 60*time.Minute)
-	defer cancel()
+	log.Printf("STEP 4 COMPLETE: HMS historical computation completed successfully")
 
-	// Run the complete historical pipeline synchronously
-	err := runHMSPipelineHistorical(ctx, req)
+	// Step 5: Save the run in the event library
+	log.Printf("STEP 5: Saving historical event %s...", historicalEventName(req))
+	progress.Step = "save"
+	setPipelineRunProgress(ctx, queries, runID, progress)
+	flows, err := ws.extractFlows(ctx)
 	if err != nil {
-		log.Printf("Historical HMS pipeline failed: %v", err)
-		return respondWithError(c, http.StatusInternalServerError, fmt.Sprintf("Pipeline failed: %v", err))
+		return fmt.Errorf("failed at step 5 (Save Historical Event): %w", err)
 	}
+	inputs := map[string]interface{}{
+		"request":     req,
+		"bias_method": biasMethod,
+		"apply_bias":  applyBias,
+	}
+	if err = saveHistoricalEvent(ctx, queries, ws, runID, req, inputs, flows); err != nil {
+		return fmt.Errorf("failed at step 5 (Save Historical Event): %w", err)
+	}
+	log.Printf("STEP 5 COMPLETE: Historical event saved")
+	progress.Step = "done"
+	setPipelineRunProgress(ctx, queries, runID, progress)
 
-	log.Printf("Historical HMS pipeline completed successfully")
-
-	// Return a success response after completion
-	return respondWithJSON(c, http.StatusOK, map[string]string{
-		"message":    "Historical HMS processing pipeline completed successfully",
-		"status":     "completed",
-		"start_date": req.StartDate,
-		"end_date":   req.EndDate,
-	})
+	log.Printf("INFO: Historical HMS pipeline completed successfully")
+	return nil
 }
 
-// runExtractDSSDataJython runs the Jython script to extract DSS data for all junctions
-func runExtractDSSDataJython(ctx context.Context) error {
-	log.Printf("INFO: Extracting DSS data for all junctions")
+// handleRunHMSPipelineHistorical handles the request to run the historical HMS processing pipeline.
+// Chunking only splits the MRMS download, bias correction and merge: HMS
+// still computes the whole range as one run, with no per-chunk runs or warm
+// starts, so the whole range must fit in a single HMS compute.
+func handleRunHMSPipelineHistorical(queries *sqlcdb.Queries) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		// Parse request body - using the existing HistoricalDownloadRequest structure
+		var req HistoricalDownloadRequest
+		if err := c.Bind(&req); err != nil {
+			log.Printf("Error parsing historical pipeline request: %v", err)
+			return respondWithError(c, http.StatusBadRequest, "Invalid request format")
+		}
 
-	// Paths
-	jythonPath := GetJythonPath()
-	scriptPath := GetPythonScriptPath("Jython_Scripts/extract_dss_data_historical.py")
+		// Basic validation
+		if req.StartDate == "" || req.EndDate == "" {
+			return respondWithError(c, http.StatusBadRequest, "start_date and end_date are required")
+		}
+		if !validBiasMethod(req.BiasMethod) {
+			return respondWithError(c, http.StatusBadRequest, "bias_method must be none, mean_field or local")
+		}
+		model, err := GetModel(req.Model)
+		if err != nil {
+			return respondWithError(c, http.StatusBadRequest, err.Error())
+		}
+		if err := validateParameterOverrides(model, req.Overrides); err != nil {
+			return respondWithError(c, http.StatusBadRequest, err.Error())
+		}
+		if err := validateScenarioName(historicalEventName(req)); err != nil {
+			return respondWithError(c, http.StatusBadRequest, "event: "+err.Error())
+		}
 
-	// Build command with no arguments (script extracts all junctions)
-	cmd := exec.CommandContext(ctx, jythonPath, scriptPath)
+		// The longest range depends on the signed-in user's role
+		role := ""
+		if user, err := sessionUser(c, queries); err == nil {
+			role = user.Role
+		}
+		maxDays := historicalMaxDays(role)
+		startDate, endDate, err := parseHistoricalRange(req, maxDays)
+		if err != nil {
+			return respondWithError(c, http.StatusBadRequest, err.Error())
+		}
+		chunks := historicalChunks(startDate, endDate, AppConfig.Historical.ChunkDays)
+
+		log.Printf("Received historical HMS pipeline request: start=%s, end=%s, start_time=%s, end_time=%s, role=%q (max %d days), %d chunks",
+			req.StartDate, req.EndDate, req.StartTime, req.EndTime, role, maxDays, len(chunks))
+
+		runID := startPipelineRun(c.Request().Context(), queries, pipelineRunHistorical, req)
+
+		// Ranges longer than one chunk run in the background, reporting
+		// their progress on the pipeline run
+		if len(chunks) > 1 {
+			go func() {
+				ctx, cancel := context.WithTimeout(context.Background(), time.Duration(len(chunks))*time.Hour)
+				defer cancel()
+				if err := runHMSPipelineHistorical(ctx, queries, runID, req); err != nil {
+					log.Printf("Historical HMS pipeline run %d failed: %v", runID, err)
+					return
+				}
+				log.Printf("Historical HMS pipeline run %d completed successfully", runID)
+			}()
+
+			return respondWithJSON(c, http.StatusAccepted, map[string]interface{}{
+				"message":    "Historical HMS processing pipeline started",
+				"status":     "accepted",
+				"start_date": req.StartDate,
+				"end_date":   req.EndDate,
+				"event":      historicalEventName(req),
+				"run_id":     runID,
+				"chunks":     len(chunks),
+				"note":       historicalComputeNote,
+			})
+		}
 
-	// Run the command and capture output
-	output, err := cmd.CombinedOutput()
+		// Create a new context with a timeout
+		ctx, cancel := context.WithTimeout(context.Background(), 60*time.Minute)
+		defer cancel()
 
-	if err != nil {
-		// Check if it's an exit error to get the exit code
-		if exitErr, ok := err.(*exec.ExitError); ok {
-			exitCode := exitErr.ExitCode()
-			log.Printf("Jython script failed with exit code %d. Output: %s", exitCode, string(output))
-			return fmt.Errorf("jython script failed with exit code %d", exitCode)
+		// Run the complete historical pipeline synchronously
+		err = runHMSPipelineHistorical(ctx, queries, runID, req)
+		if err != nil {
+			log.Printf("Historical HMS pipeline failed: %v", err)
+			return respondWithError(c, http.StatusInternalServerError, fmt.Sprintf("Pipeline failed: %v", err))
 		}
-		log.Printf("Jython script failed: %v. Output: %s", err, string(output))
-		return fmt.Errorf("failed to run Jython script: %w", err)
-	}
 
-	log.Printf("Successfully extracted DSS data. Output:\n%s", indentOutput(string(output)))
-	return nil
+		log.Printf("Historical HMS pipeline completed successfully")
+
+		// Return a success response after completion
+		return respondWithJSON(c, http.StatusOK, map[string]string{
+			"message":    "Historical HMS processing pipeline completed successfully",
+			"status":     "completed",
+			"start_date": req.StartDate,
+			"end_date":   req.EndDate,
+			"event":      historicalEventName(req),
+		})
+	}
 }
 
 // handleExtractHistoricalDSSData handles the request to extract historical DSS data for all junctions
 func handleExtractHistoricalDSSData(c echo.Context) error {
 	log.Printf("Received request to extract DSS data for all junctions")
 
+	model, err := GetModel(c.QueryParam("model"))
+	if err != nil {
+		return respondWithError(c, http.StatusBadRequest, err.Error())
+	}
+
 	// Create a context with timeout
 	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Minute)
 	defer cancel()
 
-	// Run the Jython script (no parameters needed)
-	err := runExtractDSSDataJython(ctx)
+	// Read the published historical results in-process
+	jsonFilePath := GetJSONOutputPath(model.HistoricalJSONOutput)
+	flows, err := extractJunctionFlows(ctx, model.HistoricalDSS, "RUN:"+strings.ToUpper(model.HistoricalRun), jsonFilePath)
 	if err != nil {
 		log.Printf("Failed to extract DSS data: %v", err)
 		return respondWithError(c, http.StatusInternalServerError, "Failed to extract DSS data")
 	}
 
-	// Read the generated JSON file
-	jsonFilePath := GetJSONOutputPath("outputHistorical.json")
-	jsonData, err := os.ReadFile(jsonFilePath)
-	if err != nil {
-		log.Printf("Failed to read output JSON file: %v", err)
-		return respondWithError(c, http.StatusInternalServerError, "Failed to read extracted data")
-	}
-
-	// Parse the JSON to verify it's valid
-	var result map[string]interface{}
-	if err := json.Unmarshal(jsonData, &result); err != nil {
-		log.Printf("Failed to parse output JSON: %v", err)
-		return respondWithError(c, http.StatusInternalServerError, "Invalid JSON output")
+	// Keep the historical flows JSON up to date for /api/junctions?run=historical
+	if err := writeJunctionFlows(jsonFilePath, flows); err != nil {
+		log.Printf("Failed to write output JSON file: %v", err)
+		return respondWithError(c, http.StatusInternalServerError, "Failed to write extracted data")
 	}
 
-	// Return the JSON data
-	return c.JSON(http.StatusOK, result)
+	return c.JSON(http.StatusOK, flows)
 }
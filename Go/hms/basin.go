@@ -0,0 +1,242 @@
+package hms
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Element kinds of a basin model
+const (
+	Subbasin  = "Subbasin"
+	Reach     = "Reach"
+	Junction  = "Junction"
+	Reservoir = "Reservoir"
+	Source    = "Source"
+	Sink      = "Sink"
+	Diversion = "Diversion"
+)
+
+var elementKinds = map[string]bool{
+	Subbasin: true, Reach: true, Junction: true, Reservoir: true,
+	Source: true, Sink: true, Diversion: true,
+}
+
+// layoutKeys are drawing and bookkeeping fields left out of Parameters
+var layoutKeys = map[string]bool{
+	"Canvas X": true, "Canvas Y": true, "From Canvas X": true, "From Canvas Y": true,
+	"Label X": true, "Label Y": true, "Last Modified Date": true, "Last Modified Time": true,
+	"Downstream": true, "Divert To": true, "Description": true, "Area": true,
+}
+
+// Element is a hydrologic element of a basin model.
+type Element struct {
+	Name        string
+	Kind        string
+	Description string
+	Downstream  string  // empty for outlets
+	DivertTo    string  // diversions only
+	Area        float64 // subbasins only, square miles (English) or km² (Metric)
+
+	// Parameters holds the element's method settings ("Loss", "Transform",
+	// "Route", ...) in file order of first appearance. Nested sections
+	// (indented further) are not included.
+	Parameters map[string]string
+}
+
+// Basin is a parsed basin model (.basin file).
+type Basin struct {
+	Name       string
+	UnitSystem string
+	Elements   []*Element
+
+	byName   map[string]*Element
+	upstream map[string][]string // direct upstream elements, sorted
+}
+
+// ParseBasin reads a .basin file. Every Downstream and Divert To must name
+// an element of the model and the connections must not form a cycle.
+func ParseBasin(r io.Reader) (*Basin, error) {
+	f, err := Parse(r)
+	if err != nil {
+		return nil, err
+	}
+	b := &Basin{byName: map[string]*Element{}, upstream: map[string][]string{}}
+	if block := f.Block("Basin"); block != nil {
+		b.Name = block.Name
+		b.UnitSystem, _ = block.Get("Unit System")
+	}
+
+	for _, block := range f.Blocks() {
+		if !elementKinds[block.Type] {
+			continue
+		}
+		if _, dup := b.byName[block.Name]; dup {
+			return nil, fmt.Errorf("hms: basin %s: duplicate element %q", b.Name, block.Name)
+		}
+		e := &Element{Name: block.Name, Kind: block.Type, Parameters: map[string]string{}}
+		e.Description, _ = block.Get("Description")
+		e.Downstream, _ = block.Get("Downstream")
+		e.DivertTo, _ = block.Get("Divert To")
+		if v, ok := block.Get("Area"); ok && block.Type == Subbasin {
+			if e.Area, err = strconv.ParseFloat(v, 64); err != nil {
+				return nil, fmt.Errorf("hms: subbasin %s: invalid area %q", e.Name, v)
+			}
+		}
+		for _, fl := range block.Fields {
+			if fl.Key == "" || layoutKeys[fl.Key] || !isTopLevel(fl) {
+				continue
+			}
+			if _, seen := e.Parameters[fl.Key]; !seen {
+				e.Parameters[fl.Key] = fl.Value
+			}
+		}
+		b.Elements = append(b.Elements, e)
+		b.byName[e.Name] = e
+	}
+
+	for _, e := range b.Elements {
+		for _, to := range []string{e.Downstream, e.DivertTo} {
+			if to == "" {
+				continue
+			}
+			if _, ok := b.byName[to]; !ok {
+				return nil, fmt.Errorf("hms: %s %s drains to unknown element %q", e.Kind, e.Name, to)
+			}
+			b.upstream[to] = append(b.upstream[to], e.Name)
+		}
+	}
+	for name := range b.upstream {
+		sort.Strings(b.upstream[name])
+	}
+	if err := b.checkAcyclic(); err != nil {
+		return nil, err
+	}
+	return b, nil
+}
+
+// isTopLevel reports whether a field is indented one level into its block.
+// Parsed fields keep their original text; added ones are always top level.
+func isTopLevel(f Field) bool {
+	if f.raw == "" {
+		return true
+	}
+	indent := len(f.raw) - len(strings.TrimLeft(f.raw, " \t"))
+	return indent <= len(fieldIndent)
+}
+
+// ReadBasinFile parses the .basin file at path.
+func ReadBasinFile(path string) (*Basin, error) {
+	fh, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer fh.Close()
+	b, err := ParseBasin(fh)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return b, nil
+}
+
+func (b *Basin) checkAcyclic() error {
+	const (
+		visiting = 1
+		done     = 2
+	)
+	state := map[string]int{}
+	var visit func(name string) error
+	visit = func(name string) error {
+		switch state[name] {
+		case visiting:
+			return fmt.Errorf("hms: basin %s: connections form a cycle through %s", b.Name, name)
+		case done:
+			return nil
+		}
+		state[name] = visiting
+		e := b.byName[name]
+		for _, to := range []string{e.Downstream, e.DivertTo} {
+			if to != "" {
+				if err := visit(to); err != nil {
+					return err
+				}
+			}
+		}
+		state[name] = done
+		return nil
+	}
+	for _, e := range b.Elements {
+		if err := visit(e.Name); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ErrNoElement is returned for element names that are not in the model.
+var ErrNoElement = errors.New("hms: no such element")
+
+// Element returns the named element.
+func (b *Basin) Element(name string) (*Element, bool) {
+	e, ok := b.byName[name]
+	return e, ok
+}
+
+// DirectUpstream returns the elements that drain (or divert) straight into name.
+func (b *Basin) DirectUpstream(name string) []string {
+	return b.upstream[name]
+}
+
+// Upstream returns every element whose flow reaches name, nearest first.
+func (b *Basin) Upstream(name string) ([]string, error) {
+	if _, ok := b.byName[name]; !ok {
+		return nil, fmt.Errorf("%w: %q", ErrNoElement, name)
+	}
+	var out []string
+	seen := map[string]bool{name: true}
+	queue := []string{name}
+	for len(queue) > 0 {
+		next := queue[0]
+		queue = queue[1:]
+		for _, up := range b.upstream[next] {
+			if !seen[up] {
+				seen[up] = true
+				out = append(out, up)
+				queue = append(queue, up)
+			}
+		}
+	}
+	return out, nil
+}
+
+// Downstream returns the elements flow from name passes through to the
+// outlet, in order. Diversions are not followed.
+func (b *Basin) Downstream(name string) ([]string, error) {
+	e, ok := b.byName[name]
+	if !ok {
+		return nil, fmt.Errorf("%w: %q", ErrNoElement, name)
+	}
+	var out []string
+	for e.Downstream != "" {
+		out = append(out, e.Downstream)
+		e = b.byName[e.Downstream]
+	}
+	return out, nil
+}
+
+// DrainageArea sums the area of name and of the subbasins upstream of it.
+func (b *Basin) DrainageArea(name string) (float64, error) {
+	up, err := b.Upstream(name)
+	if err != nil {
+		return 0, err
+	}
+	area := b.byName[name].Area
+	for _, n := range up {
+		area += b.byName[n].Area
+	}
+	return area, nil
+}
@@ -0,0 +1,82 @@
+package hms
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParseBasin(t *testing.T) {
+	b, err := ReadBasinFile("testdata/leon.basin")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if b.Name != "Leon_Watershed" || b.UnitSystem != "English" {
+		t.Errorf("basin = %q (%s)", b.Name, b.UnitSystem)
+	}
+	if len(b.Elements) != 8 {
+		t.Fatalf("got %d elements, want 8", len(b.Elements))
+	}
+
+	sub, ok := b.Element("LC-010")
+	if !ok {
+		t.Fatal("LC-010 missing")
+	}
+	if sub.Kind != Subbasin || sub.Area != 2.5 || sub.Downstream != "CUL-041" || sub.Description != "Upper Leon Creek" {
+		t.Errorf("LC-010 = %+v", sub)
+	}
+	if sub.Parameters["Transform"] != "Clark" || sub.Parameters["Initial Loss"] != "0.6" {
+		t.Errorf("LC-010 parameters = %v", sub.Parameters)
+	}
+	if _, ok := sub.Parameters["Canvas X"]; ok {
+		t.Error("layout fields should not be parameters")
+	}
+
+	// The nested spillway section does not overwrite the reservoir's own fields
+	res, _ := b.Element("Res-1")
+	if res.Parameters["Route"] != "Controlled Outflow" || res.Parameters["Spillway"] != "Spillway-1" {
+		t.Errorf("Res-1 parameters = %v", res.Parameters)
+	}
+	if _, ok := res.Parameters["Length"]; ok {
+		t.Error("nested spillway fields should be skipped")
+	}
+}
+
+func TestBasinGraph(t *testing.T) {
+	b, err := ReadBasinFile("testdata/leon.basin")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := b.DirectUpstream("CUL-041"), []string{"LC-010", "LC-020"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("direct upstream = %v, want %v", got, want)
+	}
+	up, _ := b.Upstream("CUL-048")
+	if want := []string{"R-041", "Res-1", "CUL-041", "LC-030", "LC-010", "LC-020"}; !reflect.DeepEqual(up, want) {
+		t.Errorf("upstream = %v, want %v", up, want)
+	}
+	down, _ := b.Downstream("LC-020")
+	if want := []string{"CUL-041", "R-041", "CUL-048", "Outlet"}; !reflect.DeepEqual(down, want) {
+		t.Errorf("downstream = %v, want %v", down, want)
+	}
+	if area, _ := b.DrainageArea("CUL-048"); area != 7 {
+		t.Errorf("drainage area = %v, want 7", area)
+	}
+	if _, err := b.Upstream("CUL-999"); !errors.Is(err, ErrNoElement) {
+		t.Errorf("unknown element error = %v", err)
+	}
+}
+
+func TestParseBasinErrors(t *testing.T) {
+	for _, c := range []struct{ name, text, want string }{
+		{"unknown downstream", "Junction: A\n     Downstream: B\nEnd:\n", "unknown element"},
+		{"cycle", "Junction: A\n     Downstream: B\nEnd:\nJunction: B\n     Downstream: A\nEnd:\n", "cycle"},
+		{"duplicate", "Sink: A\nEnd:\nSink: A\nEnd:\n", "duplicate"},
+		{"area", "Subbasin: A\n     Area: lots\nEnd:\n", "invalid area"},
+	} {
+		_, err := ParseBasin(strings.NewReader(c.text))
+		if err == nil || !strings.Contains(err.Error(), c.want) {
+			t.Errorf("%s: error = %v, want %q", c.name, err, c.want)
+		}
+	}
+}
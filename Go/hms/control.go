@@ -0,0 +1,192 @@
+package hms
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	controlDateLayout = "2 January 2006"
+	controlTimeLayout = "15:04"
+)
+
+// ControlIntervals are the time intervals HEC-HMS accepts for a control
+// specification.
+var ControlIntervals = []time.Duration{
+	1 * time.Minute, 2 * time.Minute, 3 * time.Minute, 4 * time.Minute, 5 * time.Minute,
+	6 * time.Minute, 10 * time.Minute, 12 * time.Minute, 15 * time.Minute, 20 * time.Minute,
+	30 * time.Minute, 1 * time.Hour, 2 * time.Hour, 3 * time.Hour, 4 * time.Hour,
+	6 * time.Hour, 8 * time.Hour, 12 * time.Hour, 24 * time.Hour,
+}
+
+// Control is a HEC-HMS control specification (.control file). Start and End
+// are wall-clock times in the file's time zone and are returned as UTC.
+// Fields without a typed counterpart are kept as they are.
+type Control struct {
+	Name         string
+	Description  string
+	Start        time.Time
+	End          time.Time
+	TimeInterval time.Duration
+
+	file   *File
+	block  *Block
+	parsed controlValues // values as read, to leave unchanged fields untouched
+}
+
+type controlValues struct {
+	name, description string
+	start, end        time.Time
+	interval          time.Duration
+}
+
+func (c *Control) values() controlValues {
+	return controlValues{c.Name, c.Description, c.Start, c.End, c.TimeInterval}
+}
+
+// parseControlDateTime parses a "Start Date"/"Start Time" pair. HEC-HMS
+// writes midnight at the end of a day as 24:00.
+func parseControlDateTime(date, clock string) (time.Time, error) {
+	d, err := time.Parse(controlDateLayout, strings.TrimSpace(date))
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid date %q", date)
+	}
+	clock = strings.TrimSpace(clock)
+	if clock == "24:00" {
+		return d.AddDate(0, 0, 1), nil
+	}
+	t, err := time.Parse(controlTimeLayout, clock)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid time %q", clock)
+	}
+	return d.Add(time.Duration(t.Hour())*time.Hour + time.Duration(t.Minute())*time.Minute), nil
+}
+
+// ParseControl reads a .control file. It fails on a missing Control block or
+// on dates, times and intervals HEC-HMS would not accept.
+func ParseControl(r io.Reader) (*Control, error) {
+	f, err := Parse(r)
+	if err != nil {
+		return nil, err
+	}
+	block := f.Block("Control")
+	if block == nil {
+		return nil, errors.New("hms: no Control block")
+	}
+
+	c := &Control{Name: block.Name, file: f, block: block}
+	c.Description, _ = block.Get("Description")
+
+	startDate, _ := block.Get("Start Date")
+	startTime, _ := block.Get("Start Time")
+	if c.Start, err = parseControlDateTime(startDate, startTime); err != nil {
+		return nil, fmt.Errorf("hms: control %s: start: %w", c.Name, err)
+	}
+	endDate, _ := block.Get("End Date")
+	endTime, _ := block.Get("End Time")
+	if c.End, err = parseControlDateTime(endDate, endTime); err != nil {
+		return nil, fmt.Errorf("hms: control %s: end: %w", c.Name, err)
+	}
+	if v, ok := block.Get("Time Interval"); ok {
+		minutes, err := strconv.Atoi(v)
+		if err != nil {
+			return nil, fmt.Errorf("hms: control %s: invalid time interval %q", c.Name, v)
+		}
+		c.TimeInterval = time.Duration(minutes) * time.Minute
+	}
+
+	c.parsed = c.values()
+	return c, nil
+}
+
+// ReadControlFile parses the .control file at path.
+func ReadControlFile(path string) (*Control, error) {
+	fh, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer fh.Close()
+	c, err := ParseControl(fh)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return c, nil
+}
+
+// Validate reports every problem that would make HEC-HMS reject the
+// specification.
+func (c *Control) Validate() error {
+	var errs []error
+	if strings.TrimSpace(c.Name) == "" {
+		errs = append(errs, errors.New("name is required"))
+	}
+	if c.Start.IsZero() || c.End.IsZero() {
+		errs = append(errs, errors.New("start and end are required"))
+	} else if !c.End.After(c.Start) {
+		errs = append(errs, fmt.Errorf("end %s is not after start %s",
+			c.End.Format(time.RFC3339), c.Start.Format(time.RFC3339)))
+	}
+	if c.Start.Second() != 0 || c.End.Second() != 0 || c.Start.Nanosecond() != 0 || c.End.Nanosecond() != 0 {
+		errs = append(errs, errors.New("start and end must be whole minutes"))
+	}
+	valid := false
+	for _, d := range ControlIntervals {
+		valid = valid || d == c.TimeInterval
+	}
+	if !valid {
+		errs = append(errs, fmt.Errorf("time interval %s is not supported by HEC-HMS", c.TimeInterval))
+	} else if !c.End.IsZero() && c.End.Sub(c.Start) < c.TimeInterval {
+		errs = append(errs, fmt.Errorf("time window is shorter than the %s interval", c.TimeInterval))
+	}
+	if len(errs) > 0 {
+		return fmt.Errorf("hms: invalid control %q: %w", c.Name, errors.Join(errs...))
+	}
+	return nil
+}
+
+// Bytes validates the specification and serialises it. Only the fields that
+// changed since parsing are rewritten.
+func (c *Control) Bytes() ([]byte, error) {
+	if err := c.Validate(); err != nil {
+		return nil, err
+	}
+	if c.file == nil {
+		c.block = &Block{Type: "Control"}
+		c.file = &File{items: []interface{}{c.block, ""}, newline: "\n", final: false}
+	}
+	b := c.block
+	if c.Name != c.parsed.name {
+		b.Name, b.header = c.Name, ""
+	}
+	if c.Description != c.parsed.description {
+		b.Set("Description", c.Description, "")
+	}
+	if !c.Start.Equal(c.parsed.start) {
+		b.Set("Start Date", c.Start.Format(controlDateLayout), "Time Zone Region")
+		b.Set("Start Time", c.Start.Format(controlTimeLayout), "Start Date")
+	}
+	if !c.End.Equal(c.parsed.end) {
+		b.Set("End Date", c.End.Format(controlDateLayout), "Start Time")
+		b.Set("End Time", c.End.Format(controlTimeLayout), "End Date")
+	}
+	if c.TimeInterval != c.parsed.interval {
+		b.Set("Time Interval", strconv.Itoa(int(c.TimeInterval/time.Minute)), "End Time")
+	}
+	c.parsed = c.values()
+	return c.file.Bytes(), nil
+}
+
+// WriteFile validates the specification and writes it to path. Nothing is
+// written when it is invalid.
+func (c *Control) WriteFile(path string) error {
+	data, err := c.Bytes()
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, data, 0644)
+}
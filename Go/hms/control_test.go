@@ -0,0 +1,135 @@
+package hms
+
+import (
+	"bytes"
+	"flag"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+var update = flag.Bool("update", false, "rewrite the golden files in testdata")
+
+func readControl(t *testing.T, name string) (*Control, []byte) {
+	t.Helper()
+	data, err := os.ReadFile(filepath.Join("testdata", name))
+	if err != nil {
+		t.Fatal(err)
+	}
+	c, err := ParseControl(bytes.NewReader(data))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return c, data
+}
+
+func checkGolden(t *testing.T, name string, got []byte) {
+	t.Helper()
+	path := filepath.Join("testdata", name)
+	if *update {
+		if err := os.WriteFile(path, got, 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	want, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(got, want) {
+		t.Errorf("%s differs:\n--- got ---\n%s\n--- want ---\n%s", name, got, want)
+	}
+}
+
+func TestParseControl(t *testing.T) {
+	c, _ := readControl(t, "historical.control")
+	if c.Name != "Historical" || c.Description != "May 2025 event" {
+		t.Errorf("name/description = %q/%q", c.Name, c.Description)
+	}
+	if want := time.Date(2025, 5, 9, 0, 0, 0, 0, time.UTC); !c.Start.Equal(want) {
+		t.Errorf("start = %v, want %v", c.Start, want)
+	}
+	// 24:00 is midnight at the end of the day
+	if want := time.Date(2025, 5, 14, 0, 0, 0, 0, time.UTC); !c.End.Equal(want) {
+		t.Errorf("end = %v, want %v", c.End, want)
+	}
+	if c.TimeInterval != time.Hour {
+		t.Errorf("interval = %v, want 1h", c.TimeInterval)
+	}
+}
+
+func TestControlRoundTrip(t *testing.T) {
+	for _, name := range []string{"realtime.control", "historical.control"} {
+		c, data := readControl(t, name)
+		got, err := c.Bytes()
+		if err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+		if !bytes.Equal(got, data) {
+			t.Errorf("%s did not round trip:\n%q\n%q", name, got, data)
+		}
+	}
+}
+
+func TestControlModify(t *testing.T) {
+	c, _ := readControl(t, "realtime.control")
+	c.Start = time.Date(2025, 7, 3, 1, 0, 0, 0, time.UTC)
+	c.End = time.Date(2025, 7, 5, 12, 0, 0, 0, time.UTC)
+	c.TimeInterval = 5 * time.Minute
+	c.Description = "Hill Country flood"
+	got, err := c.Bytes()
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkGolden(t, "realtime_modified.control.golden", got)
+
+	// Only the end moves; the 24:00 end of the original is rewritten, the
+	// untouched start keeps its text
+	h, _ := readControl(t, "historical.control")
+	h.End = time.Date(2025, 5, 12, 6, 0, 0, 0, time.UTC)
+	got, err = h.Bytes()
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkGolden(t, "historical_modified.control.golden", got)
+}
+
+func TestControlValidate(t *testing.T) {
+	base, _ := readControl(t, "realtime.control")
+	for _, c := range []struct {
+		name   string
+		modify func(*Control)
+		want   string
+	}{
+		{"end before start", func(c *Control) { c.End = c.Start.Add(-time.Hour) }, "is not after start"},
+		{"interval", func(c *Control) { c.TimeInterval = 7 * time.Minute }, "not supported"},
+		{"window", func(c *Control) { c.End = c.Start.Add(30 * time.Minute); c.TimeInterval = time.Hour }, "shorter than"},
+		{"name", func(c *Control) { c.Name = " " }, "name is required"},
+		{"seconds", func(c *Control) { c.Start = c.Start.Add(time.Second) }, "whole minutes"},
+	} {
+		cc := *base
+		c.modify(&cc)
+		err := cc.Validate()
+		if err == nil || !strings.Contains(err.Error(), c.want) {
+			t.Errorf("%s: error = %v, want %q", c.name, err, c.want)
+		}
+		if _, err := cc.Bytes(); err == nil {
+			t.Errorf("%s: Bytes accepted an invalid control", c.name)
+		}
+	}
+}
+
+func TestParseControlErrors(t *testing.T) {
+	for _, c := range []struct{ name, text, want string }{
+		{"no block", "Basin: Leon\nEnd:\n", "no Control block"},
+		{"unterminated", "Control: RT\n     Start Date: 1 May 2025\n", "missing End:"},
+		{"bad date", "Control: RT\n     Start Date: 31 Febuary 2025\n     Start Time: 00:00\n     End Date: 1 March 2025\n     End Time: 00:00\nEnd:\n", "invalid date"},
+		{"bad time", "Control: RT\n     Start Date: 1 May 2025\n     Start Time: 25:00\n     End Date: 2 May 2025\n     End Time: 00:00\nEnd:\n", "invalid time"},
+	} {
+		_, err := ParseControl(strings.NewReader(c.text))
+		if err == nil || !strings.Contains(err.Error(), c.want) {
+			t.Errorf("%s: error = %v, want %q", c.name, err, c.want)
+		}
+	}
+}
@@ -0,0 +1,209 @@
+// Package hms reads and writes HEC-HMS project files (.control, .basin, ...).
+//
+// These files are sequences of blocks such as
+//
+//	Control: RealTime
+//	     Start Date: 9 May 2025
+//	     Start Time: 00:00
+//	End:
+//
+// with indented "Key: Value" lines. The generic File keeps every line it does
+// not understand or that is not modified, so writing a parsed file back
+// reproduces it byte for byte.
+package hms
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// fieldIndent is the indentation HEC-HMS uses for the lines inside a block
+const fieldIndent = "     "
+
+// Field is one line inside a block. Lines that are not "Key: Value" pairs
+// (blank lines, comments) have an empty Key.
+type Field struct {
+	Key   string
+	Value string
+	raw   string // original text, cleared when the field is modified
+}
+
+// Block is a "Type: Name" ... "End:" section of a project file.
+type Block struct {
+	Type   string
+	Name   string
+	Fields []Field
+	header string
+	end    string
+}
+
+// Get returns the value of the first field with key.
+func (b *Block) Get(key string) (string, bool) {
+	for _, f := range b.Fields {
+		if f.Key == key {
+			return f.Value, true
+		}
+	}
+	return "", false
+}
+
+// Set changes the first field with key, or adds it after the field named
+// after: first in the block when after is empty, last when there is no such
+// field.
+func (b *Block) Set(key, value, after string) {
+	for i := range b.Fields {
+		if b.Fields[i].Key == key {
+			if b.Fields[i].Value != value {
+				b.Fields[i] = Field{Key: key, Value: value}
+			}
+			return
+		}
+	}
+	pos := -1
+	if after == "" {
+		pos = 0
+	}
+	for i := range b.Fields {
+		if after != "" && b.Fields[i].Key == after {
+			pos = i + 1
+			break
+		}
+	}
+	if pos < 0 {
+		// Keep new fields ahead of trailing blank lines
+		pos = len(b.Fields)
+		for pos > 0 && b.Fields[pos-1].Key == "" && strings.TrimSpace(b.Fields[pos-1].raw) == "" {
+			pos--
+		}
+	}
+	b.Fields = append(b.Fields, Field{})
+	copy(b.Fields[pos+1:], b.Fields[pos:])
+	b.Fields[pos] = Field{Key: key, Value: value}
+}
+
+// File is a parsed project file: blocks interleaved with the lines between them.
+type File struct {
+	items   []interface{} // string (a line outside blocks) or *Block
+	newline string
+	final   bool // whether the last line ends with a newline
+}
+
+// Blocks returns the blocks in file order.
+func (f *File) Blocks() []*Block {
+	var blocks []*Block
+	for _, it := range f.items {
+		if b, ok := it.(*Block); ok {
+			blocks = append(blocks, b)
+		}
+	}
+	return blocks
+}
+
+// Block returns the first block of the given type, or nil.
+func (f *File) Block(typ string) *Block {
+	for _, b := range f.Blocks() {
+		if b.Type == typ {
+			return b
+		}
+	}
+	return nil
+}
+
+// splitKey splits "Key: Value" at the first colon.
+func splitKey(line string) (string, string, bool) {
+	key, value, ok := strings.Cut(strings.TrimSpace(line), ":")
+	if !ok || key == "" {
+		return "", "", false
+	}
+	return strings.TrimSpace(key), strings.TrimSpace(value), true
+}
+
+// Parse reads a project file. Blocks must be closed with "End:".
+func Parse(r io.Reader) (*File, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	f := &File{newline: "\n", final: len(data) == 0 || data[len(data)-1] == '\n'}
+	if bytes.Contains(data, []byte("\r\n")) {
+		f.newline = "\r\n"
+	}
+
+	var block *Block
+	lineNo := 0
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	scanner.Buffer(make([]byte, 64*1024), 16*1024*1024)
+	for scanner.Scan() {
+		lineNo++
+		line := strings.TrimSuffix(scanner.Text(), "\r")
+		if block == nil {
+			key, value, ok := splitKey(line)
+			if !ok || line != strings.TrimLeft(line, " \t") {
+				f.items = append(f.items, line)
+				continue
+			}
+			if key == "End" {
+				return nil, fmt.Errorf("hms: line %d: End: outside a block", lineNo)
+			}
+			block = &Block{Type: key, Name: value, header: line}
+			continue
+		}
+		if strings.TrimSpace(line) == "End:" && line == strings.TrimLeft(line, " \t") {
+			block.end = line
+			f.items = append(f.items, block)
+			block = nil
+			continue
+		}
+		key, value, ok := splitKey(line)
+		if !ok {
+			block.Fields = append(block.Fields, Field{raw: line})
+			continue
+		}
+		block.Fields = append(block.Fields, Field{Key: key, Value: value, raw: line})
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	if block != nil {
+		return nil, fmt.Errorf("hms: %s block %q is missing End:", block.Type, block.Name)
+	}
+	return f, nil
+}
+
+// Bytes serialises the file, keeping unmodified lines and the original newlines.
+func (f *File) Bytes() []byte {
+	var lines []string
+	for _, it := range f.items {
+		switch v := it.(type) {
+		case string:
+			lines = append(lines, v)
+		case *Block:
+			header := v.header
+			if header == "" {
+				header = v.Type + ": " + v.Name
+			}
+			lines = append(lines, header)
+			for _, fl := range v.Fields {
+				switch {
+				case fl.raw != "" || fl.Key == "":
+					lines = append(lines, fl.raw)
+				default:
+					lines = append(lines, fieldIndent+fl.Key+": "+fl.Value)
+				}
+			}
+			end := v.end
+			if end == "" {
+				end = "End:"
+			}
+			lines = append(lines, end)
+		}
+	}
+	out := strings.Join(lines, f.newline)
+	if f.final && len(lines) > 0 {
+		out += f.newline
+	}
+	return []byte(out)
+}
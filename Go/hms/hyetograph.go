@@ -0,0 +1,196 @@
+package hms
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+	"time"
+)
+
+// Hyetograph distributions
+const (
+	SCSTypeII        = "scs_type2"
+	SCSTypeIII       = "scs_type3"
+	Uniform          = "uniform"
+	Custom           = "custom"
+	AlternatingBlock = "atlas14" // nested storm built from a depth-duration table
+)
+
+// curvePoint is one point of a cumulative rainfall distribution: the
+// fraction of the storm's time elapsed and of its depth fallen by then.
+type curvePoint struct {
+	time, depth float64
+}
+
+// scsCurve tabulates a 24-hour NRCS distribution (TR-55) by hour, with the
+// half-hour points around the peak.
+func scsCurve(hours, fractions []float64) []curvePoint {
+	curve := make([]curvePoint, len(hours))
+	for i := range hours {
+		curve[i] = curvePoint{hours[i] / 24, fractions[i]}
+	}
+	return curve
+}
+
+var scsCurves = map[string][]curvePoint{
+	SCSTypeII: scsCurve(
+		[]float64{0, 2, 4, 6, 7, 8, 8.5, 9, 9.5, 10, 10.5, 11, 11.5, 11.75, 12, 12.5, 13, 13.5, 14, 14.5, 15, 16, 17, 18, 19, 20, 21, 22, 23, 24},
+		[]float64{0, .022, .048, .080, .098, .120, .133, .147, .163, .181, .204, .235, .283, .357, .663, .735, .772, .799, .820, .835, .850, .880, .902, .921, .937, .952, .965, .977, .989, 1},
+	),
+	SCSTypeIII: scsCurve(
+		[]float64{0, 2, 4, 6, 7, 8, 8.5, 9, 9.5, 10, 10.5, 11, 11.5, 11.75, 12, 12.5, 13, 13.5, 14, 14.5, 15, 16, 17, 18, 19, 20, 21, 22, 23, 24},
+		[]float64{0, .020, .043, .072, .089, .115, .130, .148, .167, .189, .216, .250, .298, .339, .500, .702, .751, .785, .811, .834, .854, .886, .910, .928, .943, .957, .969, .981, .991, 1},
+	),
+}
+
+// DepthDuration is a precipitation frequency (e.g. NOAA Atlas 14) table of
+// one return period: depths by duration in hours.
+type DepthDuration map[float64]float64
+
+// Depth returns the depth of a storm lasting hours, interpolated log-log
+// between the tabulated durations. Durations outside the table are an error.
+func (d DepthDuration) Depth(hours float64) (float64, error) {
+	durations := make([]float64, 0, len(d))
+	for h, v := range d {
+		if h <= 0 || v <= 0 {
+			return 0, fmt.Errorf("hms: depth-duration table has a non-positive entry (%g h: %g)", h, v)
+		}
+		durations = append(durations, h)
+	}
+	if len(durations) == 0 {
+		return 0, errors.New("hms: depth-duration table is empty")
+	}
+	sort.Float64s(durations)
+	if v, ok := d[hours]; ok {
+		return v, nil
+	}
+	if hours < durations[0] || hours > durations[len(durations)-1] {
+		return 0, fmt.Errorf("hms: %g hours is outside the depth-duration table (%g to %g hours)",
+			hours, durations[0], durations[len(durations)-1])
+	}
+	i := sort.SearchFloat64s(durations, hours)
+	h0, h1 := durations[i-1], durations[i]
+	f := math.Log(hours/h0) / math.Log(h1/h0)
+	return math.Exp(math.Log(d[h0]) + f*math.Log(d[h1]/d[h0])), nil
+}
+
+// Storm describes a design storm: its total depth, duration and how the
+// depth is distributed in time.
+type Storm struct {
+	Distribution string
+	Depth        float64 // total depth; with AlternatingBlock it rescales the table's depth when set
+	Duration     time.Duration
+	Interval     time.Duration
+	Weights      []float64     // Custom: relative depths of equal steps spanning the duration
+	Table        DepthDuration // AlternatingBlock
+}
+
+// Hyetograph returns the incremental depth of every interval of the storm.
+// The SCS distributions are stretched or compressed to the storm duration.
+func (s Storm) Hyetograph() ([]float64, error) {
+	if s.Interval <= 0 || s.Duration <= 0 {
+		return nil, errors.New("hms: storm duration and interval must be positive")
+	}
+	if s.Duration%s.Interval != 0 {
+		return nil, fmt.Errorf("hms: storm duration %s is not a multiple of the interval %s", s.Duration, s.Interval)
+	}
+	if s.Depth < 0 {
+		return nil, errors.New("hms: storm depth cannot be negative")
+	}
+	n := int(s.Duration / s.Interval)
+
+	switch s.Distribution {
+	case SCSTypeII, SCSTypeIII:
+		return sampleCurve(scsCurves[s.Distribution], n, s.Depth), nil
+	case Uniform:
+		return sampleCurve([]curvePoint{{0, 0}, {1, 1}}, n, s.Depth), nil
+	case Custom:
+		curve, err := weightsCurve(s.Weights)
+		if err != nil {
+			return nil, err
+		}
+		return sampleCurve(curve, n, s.Depth), nil
+	case AlternatingBlock:
+		return s.alternatingBlock(n)
+	}
+	return nil, fmt.Errorf("hms: unknown storm distribution %q", s.Distribution)
+}
+
+// weightsCurve turns the relative depths of equal steps into a cumulative curve
+func weightsCurve(weights []float64) ([]curvePoint, error) {
+	var total float64
+	for _, w := range weights {
+		if w < 0 {
+			return nil, errors.New("hms: custom weights cannot be negative")
+		}
+		total += w
+	}
+	if total == 0 {
+		return nil, errors.New("hms: custom distribution needs at least one positive weight")
+	}
+	curve := []curvePoint{{0, 0}}
+	var sum float64
+	for i, w := range weights {
+		sum += w
+		curve = append(curve, curvePoint{float64(i+1) / float64(len(weights)), sum / total})
+	}
+	return curve, nil
+}
+
+// sampleCurve splits depth into n equal steps of a cumulative curve,
+// interpolating linearly between its points
+func sampleCurve(curve []curvePoint, n int, depth float64) []float64 {
+	at := func(t float64) float64 {
+		i := sort.Search(len(curve), func(i int) bool { return curve[i].time >= t })
+		if i == 0 {
+			return curve[0].depth
+		}
+		if i == len(curve) {
+			return curve[len(curve)-1].depth
+		}
+		a, b := curve[i-1], curve[i]
+		return a.depth + (b.depth-a.depth)*(t-a.time)/(b.time-a.time)
+	}
+	out := make([]float64, n)
+	prev := 0.0
+	for k := range out {
+		cum := at(float64(k+1) / float64(n))
+		out[k] = (cum - prev) * depth
+		prev = cum
+	}
+	return out
+}
+
+// alternatingBlock nests the depth-duration table: the largest increment is
+// placed at the middle of the storm and the next ones alternate right and
+// left of it, so every duration centred on the peak has its table depth.
+func (s Storm) alternatingBlock(n int) ([]float64, error) {
+	step := s.Interval.Hours()
+	increments := make([]float64, n)
+	prev := 0.0
+	for k := range increments {
+		d, err := s.Table.Depth(float64(k+1) * step)
+		if err != nil {
+			return nil, err
+		}
+		increments[k] = max(d-prev, 0)
+		prev = max(prev, d)
+	}
+	scale := 1.0
+	if s.Depth > 0 && prev > 0 {
+		scale = s.Depth / prev
+	}
+	sort.Sort(sort.Reverse(sort.Float64Slice(increments)))
+
+	out := make([]float64, n)
+	centre := (n - 1) / 2
+	for k, v := range increments {
+		offset := (k + 1) / 2 // 0, 1, 1, 2, 2, ... to the right, then left
+		if k%2 == 1 {
+			offset = -offset
+		}
+		out[centre-offset] = v * scale
+	}
+	return out, nil
+}
@@ -0,0 +1,130 @@
+package hms
+
+import (
+	"math"
+	"testing"
+	"time"
+)
+
+func sum(values []float64) float64 {
+	var total float64
+	for _, v := range values {
+		total += v
+	}
+	return total
+}
+
+func argmax(values []float64) int {
+	best := 0
+	for i, v := range values {
+		if v > values[best] {
+			best = i
+		}
+	}
+	return best
+}
+
+func TestSCSHyetograph(t *testing.T) {
+	for _, dist := range []string{SCSTypeII, SCSTypeIII} {
+		s := Storm{Distribution: dist, Depth: 10, Duration: 24 * time.Hour, Interval: time.Hour}
+		got, err := s.Hyetograph()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(got) != 24 {
+			t.Fatalf("%s: got %d steps, want 24", dist, len(got))
+		}
+		if math.Abs(sum(got)-10) > 1e-9 {
+			t.Errorf("%s: total = %g, want 10", dist, sum(got))
+		}
+		// Both peak in the hours either side of noon
+		if p := argmax(got); p != 11 && p != 12 {
+			t.Errorf("%s: peak at step %d, want 11 or 12", dist, p)
+		}
+	}
+
+	// Hour 11-12 of a Type II storm holds 66.3% - 23.5% of the depth
+	s := Storm{Distribution: SCSTypeII, Depth: 1, Duration: 24 * time.Hour, Interval: time.Hour}
+	got, _ := s.Hyetograph()
+	if math.Abs(got[11]-0.428) > 1e-9 {
+		t.Errorf("type II peak hour = %g, want 0.428", got[11])
+	}
+
+	// A 6-hour storm compresses the same curve
+	s = Storm{Distribution: SCSTypeII, Depth: 4, Duration: 6 * time.Hour, Interval: 15 * time.Minute}
+	got, _ = s.Hyetograph()
+	if len(got) != 24 || math.Abs(sum(got)-4) > 1e-9 || argmax(got) != 11 {
+		t.Errorf("6 hour type II = %v", got)
+	}
+}
+
+func TestUniformAndCustomHyetograph(t *testing.T) {
+	got, err := Storm{Distribution: Uniform, Depth: 3, Duration: 3 * time.Hour, Interval: 30 * time.Minute}.Hyetograph()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, v := range got {
+		if math.Abs(v-0.5) > 1e-9 {
+			t.Fatalf("uniform = %v", got)
+		}
+	}
+
+	// Two equal halves of 1 and 3 parts, sampled every half hour
+	got, err = Storm{Distribution: Custom, Depth: 4, Duration: 2 * time.Hour, Interval: 30 * time.Minute, Weights: []float64{1, 3}}.Hyetograph()
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []float64{0.5, 0.5, 1.5, 1.5}
+	for i := range want {
+		if math.Abs(got[i]-want[i]) > 1e-9 {
+			t.Fatalf("custom = %v, want %v", got, want)
+		}
+	}
+
+	if _, err := (Storm{Distribution: Custom, Depth: 1, Duration: time.Hour, Interval: time.Hour}).Hyetograph(); err == nil {
+		t.Error("custom without weights should fail")
+	}
+	if _, err := (Storm{Distribution: Uniform, Depth: 1, Duration: time.Hour, Interval: 25 * time.Minute}).Hyetograph(); err == nil {
+		t.Error("interval that does not divide the duration should fail")
+	}
+	if _, err := (Storm{Distribution: "scs_type1a", Depth: 1, Duration: time.Hour, Interval: time.Hour}).Hyetograph(); err == nil {
+		t.Error("unknown distribution should fail")
+	}
+}
+
+func TestDepthDuration(t *testing.T) {
+	table := DepthDuration{1: 2, 4: 8, 24: 12}
+	if d, _ := table.Depth(4); d != 8 {
+		t.Errorf("tabulated depth = %g", d)
+	}
+	// Log-log halfway between 1 and 4 hours
+	if d, _ := table.Depth(2); math.Abs(d-4) > 1e-9 {
+		t.Errorf("2 hour depth = %g, want 4", d)
+	}
+	if _, err := table.Depth(0.5); err == nil {
+		t.Error("duration below the table should fail")
+	}
+	if _, err := (DepthDuration{}).Depth(1); err == nil {
+		t.Error("empty table should fail")
+	}
+}
+
+func TestAlternatingBlockHyetograph(t *testing.T) {
+	table := DepthDuration{1: 2, 2: 3, 3: 3.5, 4: 3.8, 5: 4}
+	got, err := Storm{Distribution: AlternatingBlock, Duration: 5 * time.Hour, Interval: time.Hour, Table: table}.Hyetograph()
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []float64{0.2, 0.5, 2, 1, 0.3}
+	for i := range want {
+		if math.Abs(got[i]-want[i]) > 1e-9 {
+			t.Fatalf("alternating block = %v, want %v", got, want)
+		}
+	}
+
+	// A depth rescales the table
+	got, _ = Storm{Distribution: AlternatingBlock, Depth: 8, Duration: 5 * time.Hour, Interval: time.Hour, Table: table}.Hyetograph()
+	if math.Abs(sum(got)-8) > 1e-9 || math.Abs(got[2]-4) > 1e-9 {
+		t.Errorf("rescaled = %v", got)
+	}
+}
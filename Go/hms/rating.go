@@ -0,0 +1,92 @@
+package hms
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+)
+
+// Rating curve kinds
+const (
+	RatingTable    = "table"
+	RatingPowerLaw = "power"
+)
+
+// RatingPoint is one flow–stage pair of a rating table.
+type RatingPoint struct {
+	Flow  float64 `json:"flow"`
+	Stage float64 `json:"stage"`
+}
+
+// RatingCurve converts flow to stage, either by interpolating a table or
+// with the power law Q = Coefficient·(h − Offset)^Exponent.
+type RatingCurve struct {
+	Kind        string        `json:"kind"`
+	Points      []RatingPoint `json:"points,omitempty"`
+	Coefficient float64       `json:"coefficient,omitempty"`
+	Exponent    float64       `json:"exponent,omitempty"`
+	Offset      float64       `json:"offset,omitempty"` // stage of zero flow
+}
+
+// Validate checks the curve and sorts a table by flow.
+func (r *RatingCurve) Validate() error {
+	switch r.Kind {
+	case RatingTable:
+		if len(r.Points) < 2 {
+			return errors.New("hms: a rating table needs at least two points")
+		}
+		sort.Slice(r.Points, func(i, j int) bool { return r.Points[i].Flow < r.Points[j].Flow })
+		for i, p := range r.Points {
+			if !finite(p.Flow) || !finite(p.Stage) || p.Flow < 0 {
+				return fmt.Errorf("hms: invalid rating point %g, %g", p.Flow, p.Stage)
+			}
+			if i > 0 && p.Flow == r.Points[i-1].Flow {
+				return fmt.Errorf("hms: flow %g repeats in the rating table", p.Flow)
+			}
+			if i > 0 && p.Stage < r.Points[i-1].Stage {
+				return fmt.Errorf("hms: stage falls from %g to %g as flow rises", r.Points[i-1].Stage, p.Stage)
+			}
+		}
+	case RatingPowerLaw:
+		if !finite(r.Coefficient) || !finite(r.Exponent) || !finite(r.Offset) {
+			return errors.New("hms: power law coefficient, exponent and offset must be finite")
+		}
+		if r.Coefficient <= 0 || r.Exponent <= 0 {
+			return errors.New("hms: power law coefficient and exponent must be positive")
+		}
+	default:
+		return fmt.Errorf("hms: rating curve kind must be %s or %s", RatingTable, RatingPowerLaw)
+	}
+	return nil
+}
+
+func finite(v float64) bool {
+	return !math.IsNaN(v) && !math.IsInf(v, 0)
+}
+
+// Stage returns the stage of flow. Tables interpolate linearly, hold the
+// lowest stage below the lowest flow and extend their last segment above
+// the highest.
+func (r RatingCurve) Stage(flow float64) float64 {
+	if r.Kind == RatingPowerLaw {
+		if flow <= 0 {
+			return r.Offset
+		}
+		return r.Offset + math.Pow(flow/r.Coefficient, 1/r.Exponent)
+	}
+
+	pts := r.Points
+	if len(pts) == 0 {
+		return math.NaN()
+	}
+	if flow <= pts[0].Flow {
+		return pts[0].Stage
+	}
+	i := sort.Search(len(pts), func(i int) bool { return pts[i].Flow >= flow })
+	if i == len(pts) {
+		i = len(pts) - 1
+	}
+	a, b := pts[i-1], pts[i]
+	return a.Stage + (b.Stage-a.Stage)*(flow-a.Flow)/(b.Flow-a.Flow)
+}
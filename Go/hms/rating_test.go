@@ -0,0 +1,65 @@
+package hms
+
+import (
+	"math"
+	"testing"
+)
+
+func TestRatingTable(t *testing.T) {
+	r := RatingCurve{Kind: RatingTable, Points: []RatingPoint{
+		{Flow: 1000, Stage: 6},
+		{Flow: 0, Stage: 1},
+		{Flow: 200, Stage: 3},
+	}}
+	if err := r.Validate(); err != nil {
+		t.Fatal(err)
+	}
+	cases := map[float64]float64{
+		-5:   1, // held at the lowest stage
+		0:    1,
+		100:  2,
+		200:  3,
+		600:  4.5,
+		1000: 6,
+		1400: 7.5, // last segment extended
+	}
+	for flow, want := range cases {
+		if got := r.Stage(flow); math.Abs(got-want) > 1e-9 {
+			t.Errorf("Stage(%g) = %g, want %g", flow, got, want)
+		}
+	}
+}
+
+func TestRatingPowerLaw(t *testing.T) {
+	// Q = 50·(h − 2)^2
+	r := RatingCurve{Kind: RatingPowerLaw, Coefficient: 50, Exponent: 2, Offset: 2}
+	if err := r.Validate(); err != nil {
+		t.Fatal(err)
+	}
+	for flow, want := range map[float64]float64{0: 2, 50: 3, 800: 6} {
+		if got := r.Stage(flow); math.Abs(got-want) > 1e-9 {
+			t.Errorf("Stage(%g) = %g, want %g", flow, got, want)
+		}
+	}
+}
+
+func TestRatingValidate(t *testing.T) {
+	bad := map[string]RatingCurve{
+		"kind":        {Kind: "linear"},
+		"one point":   {Kind: RatingTable, Points: []RatingPoint{{Flow: 1, Stage: 1}}},
+		"repeated":    {Kind: RatingTable, Points: []RatingPoint{{Flow: 1, Stage: 1}, {Flow: 1, Stage: 2}}},
+		"falling":     {Kind: RatingTable, Points: []RatingPoint{{Flow: 1, Stage: 2}, {Flow: 5, Stage: 1}}},
+		"negative":    {Kind: RatingTable, Points: []RatingPoint{{Flow: -1, Stage: 0}, {Flow: 5, Stage: 1}}},
+		"power zero":  {Kind: RatingPowerLaw, Coefficient: 0, Exponent: 2},
+		"power NaN":   {Kind: RatingPowerLaw, Coefficient: math.NaN(), Exponent: 2},
+		"power +Inf":  {Kind: RatingPowerLaw, Coefficient: 50, Exponent: math.Inf(1)},
+		"offset -Inf": {Kind: RatingPowerLaw, Coefficient: 50, Exponent: 2, Offset: math.Inf(-1)},
+		"NaN stage":   {Kind: RatingTable, Points: []RatingPoint{{Flow: 0, Stage: math.NaN()}, {Flow: 5, Stage: 1}}},
+		"Inf flow":    {Kind: RatingTable, Points: []RatingPoint{{Flow: 0, Stage: 0}, {Flow: math.Inf(1), Stage: 1}}},
+	}
+	for name, r := range bad {
+		if err := r.Validate(); err == nil {
+			t.Errorf("%s: expected an error", name)
+		}
+	}
+}
@@ -0,0 +1,161 @@
+package hms
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"strings"
+	"text/template"
+)
+
+// ParameterOverride sets one field of a basin model element for a compute,
+// e.g. {"LC-010", "Initial Loss", "0.8"}. The field must already exist on the
+// element.
+type ParameterOverride struct {
+	Element   string `json:"element"`
+	Parameter string `json:"parameter"`
+	Value     string `json:"value"`
+}
+
+// Export copies a file once the runs have computed.
+type Export struct {
+	Source      string
+	Destination string
+}
+
+// ComputeScript is a HEC-HMS Jython script that opens a project, computes
+// one or more simulation runs and exports their results. Parameter overrides
+// are written to the basin file for the compute and undone afterwards.
+type ComputeScript struct {
+	Title       string // written as a comment, e.g. the job it was made for
+	ProjectName string
+	ProjectDir  string
+	Runs        []string
+	BasinFile   string // required with Overrides
+	Overrides   []ParameterOverride
+	Exports     []Export
+}
+
+// Validate checks the fields the script needs.
+func (s ComputeScript) Validate() error {
+	var errs []error
+	if s.ProjectName == "" || s.ProjectDir == "" {
+		errs = append(errs, errors.New("project name and directory are required"))
+	}
+	if len(s.Runs) == 0 {
+		errs = append(errs, errors.New("at least one run is required"))
+	}
+	for _, r := range s.Runs {
+		if strings.TrimSpace(r) == "" {
+			errs = append(errs, errors.New("run names cannot be empty"))
+		}
+	}
+	if len(s.Overrides) > 0 && s.BasinFile == "" {
+		errs = append(errs, errors.New("parameter overrides need the basin file"))
+	}
+	for _, o := range s.Overrides {
+		if o.Element == "" || o.Parameter == "" {
+			errs = append(errs, fmt.Errorf("override %+v needs an element and a parameter", o))
+		}
+	}
+	for _, e := range s.Exports {
+		if e.Source == "" || e.Destination == "" {
+			errs = append(errs, fmt.Errorf("export %+v needs a source and a destination", e))
+		}
+	}
+	if len(errs) > 0 {
+		return fmt.Errorf("hms: invalid compute script: %w", errors.Join(errs...))
+	}
+	return nil
+}
+
+// pyString quotes s as a Python string literal.
+func pyString(s string) string {
+	r := strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`, "\r", `\r`, "\t", `\t`)
+	return `"` + r.Replace(s) + `"`
+}
+
+var computeScriptTemplate = template.Must(template.New("compute").Funcs(template.FuncMap{"py": pyString}).Parse(
+	`# -*- coding: utf-8 -*-
+# Generated HEC-HMS compute script{{if .Title}}: {{.Title}}{{end}}
+import os, shutil, sys
+from hms.model.JythonHms import *
+
+PROJECT_NAME = {{py .ProjectName}}
+PROJECT_DIR = {{py .ProjectDir}}
+RUNS = [{{range $i, $r := .Runs}}{{if $i}}, {{end}}{{py $r}}{{end}}]
+BASIN_FILE = {{py .BasinFile}}
+OVERRIDES = [{{range .Overrides}}
+    ({{py .Element}}, {{py .Parameter}}, {{py .Value}}),{{end}}
+]
+EXPORTS = [{{range .Exports}}
+    ({{py .Source}}, {{py .Destination}}),{{end}}
+]
+
+
+def apply_overrides(path, overrides):
+    """Rewrites "Key: value" lines of the named elements; fails on any that is missing."""
+    f = open(path, "r")
+    lines = f.readlines()
+    f.close()
+    pending = dict(((e, k), v) for e, k, v in overrides)
+    element = None
+    for i, line in enumerate(lines):
+        stripped = line.strip()
+        if not line[:1].isspace() and ":" in stripped:
+            element = None if stripped == "End:" else stripped.split(":", 1)[1].strip()
+            continue
+        if element is None or ":" not in stripped:
+            continue
+        key = stripped.split(":", 1)[0].strip()
+        if (element, key) in pending:
+            ending = line[len(line.rstrip("\r\n")):]
+            indent = line[:len(line) - len(line.lstrip())]
+            lines[i] = "%s%s: %s%s" % (indent, key, pending.pop((element, key)), ending)
+    if pending:
+        raise ValueError("parameters not found in %s: %s" % (path, sorted(pending.keys())))
+    f = open(path, "w")
+    f.writelines(lines)
+    f.close()
+
+
+backup = None
+if OVERRIDES:
+    backup = BASIN_FILE + ".backup"
+    shutil.copyfile(BASIN_FILE, backup)
+try:
+    if OVERRIDES:
+        apply_overrides(BASIN_FILE, OVERRIDES)
+        print("Applied %d parameter override(s) to %s" % (len(OVERRIDES), BASIN_FILE))
+    OpenProject(PROJECT_NAME, PROJECT_DIR)
+    for run in RUNS:
+        print("Computing run %s" % run)
+        ComputeRun(run)
+finally:
+    if backup:
+        shutil.copyfile(backup, BASIN_FILE)
+        os.remove(backup)
+
+for source, destination in EXPORTS:
+    if not os.path.exists(source):
+        print("ERROR: export source not found: %s" % source)
+        sys.exit(1)
+    if not os.path.isdir(os.path.dirname(destination)):
+        os.makedirs(os.path.dirname(destination))
+    shutil.copyfile(source, destination)
+    print("Exported %s to %s" % (source, destination))
+
+Exit(0)
+`))
+
+// Bytes validates the script and renders it.
+func (s ComputeScript) Bytes() ([]byte, error) {
+	if err := s.Validate(); err != nil {
+		return nil, err
+	}
+	var buf bytes.Buffer
+	if err := computeScriptTemplate.Execute(&buf, s); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
@@ -0,0 +1,47 @@
+package hms
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestComputeScript(t *testing.T) {
+	s := ComputeScript{
+		Title:       "realtime run 42 (leon_creek)",
+		ProjectName: "Leon_46",
+		ProjectDir:  `D:\hms_models\LeonCreek`,
+		Runs:        []string{"RainrealTime"},
+		BasinFile:   `D:\hms_models\LeonCreek\Leon_Watershed.basin`,
+		Overrides:   []ParameterOverride{{"LC-010", "Initial Loss", "0.8"}, {"LC-020", "Percent Impervious Area", "30"}},
+		Exports:     []Export{{`D:\hms_models\LeonCreek\RainrealTime.dss`, `D:\jobs\realtime_42\results.dss`}},
+	}
+	got, err := s.Bytes()
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkGolden(t, "compute.script.golden", got)
+}
+
+func TestComputeScriptValidate(t *testing.T) {
+	for _, c := range []struct {
+		name   string
+		script ComputeScript
+		want   string
+	}{
+		{"no runs", ComputeScript{ProjectName: "P", ProjectDir: "d"}, "at least one run"},
+		{"no project", ComputeScript{Runs: []string{"R"}}, "project name"},
+		{"overrides", ComputeScript{ProjectName: "P", ProjectDir: "d", Runs: []string{"R"},
+			Overrides: []ParameterOverride{{"A", "Area", "1"}}}, "basin file"},
+	} {
+		_, err := c.script.Bytes()
+		if err == nil || !strings.Contains(err.Error(), c.want) {
+			t.Errorf("%s: error = %v, want %q", c.name, err, c.want)
+		}
+	}
+}
+
+func TestPyString(t *testing.T) {
+	if got, want := pyString(`C:\a "b"`), `"C:\\a \"b\""`; got != want {
+		t.Errorf("pyString = %s, want %s", got, want)
+	}
+}
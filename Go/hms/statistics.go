@@ -0,0 +1,68 @@
+package hms
+
+import (
+	"errors"
+	"math"
+)
+
+// Fit holds the goodness-of-fit statistics of a simulated flow series
+// against the observed one, as reported by HEC-HMS optimization trials.
+type Fit struct {
+	N           int     `json:"n"`            // paired values
+	NSE         float64 `json:"nse"`          // Nash-Sutcliffe efficiency, 1 is perfect
+	KGE         float64 `json:"kge"`          // Kling-Gupta efficiency, 1 is perfect
+	PercentBias float64 `json:"percent_bias"` // 100·Σ(sim−obs)/Σobs, positive when over-simulated
+	PeakError   float64 `json:"peak_error"`   // 100·(peak sim − peak obs)/peak obs
+}
+
+// GoodnessOfFit compares simulated with observed values paired by index.
+// The observed values must vary and sum and peak above zero.
+func GoodnessOfFit(observed, simulated []float64) (Fit, error) {
+	if len(observed) != len(simulated) {
+		return Fit{}, errors.New("hms: observed and simulated series differ in length")
+	}
+	n := len(observed)
+	if n < 2 {
+		return Fit{}, errors.New("hms: at least two paired values are needed")
+	}
+
+	var sumObs, sumSim float64
+	peakObs, peakSim := math.Inf(-1), math.Inf(-1)
+	for i := range observed {
+		sumObs += observed[i]
+		sumSim += simulated[i]
+		peakObs = max(peakObs, observed[i])
+		peakSim = max(peakSim, simulated[i])
+	}
+	if sumObs <= 0 || peakObs <= 0 {
+		return Fit{}, errors.New("hms: observed flows must be positive")
+	}
+	meanObs, meanSim := sumObs/float64(n), sumSim/float64(n)
+
+	var sse, varObs, varSim, cov float64
+	for i := range observed {
+		do, ds := observed[i]-meanObs, simulated[i]-meanSim
+		sse += (simulated[i] - observed[i]) * (simulated[i] - observed[i])
+		varObs += do * do
+		varSim += ds * ds
+		cov += do * ds
+	}
+	if varObs == 0 {
+		return Fit{}, errors.New("hms: observed flows are constant")
+	}
+
+	// Correlation is undefined for a constant simulation; treat it as none
+	r := 0.0
+	if varSim > 0 {
+		r = cov / math.Sqrt(varObs*varSim)
+	}
+	alpha := math.Sqrt(varSim / varObs)
+	beta := meanSim / meanObs
+	return Fit{
+		N:           n,
+		NSE:         1 - sse/varObs,
+		KGE:         1 - math.Sqrt((r-1)*(r-1)+(alpha-1)*(alpha-1)+(beta-1)*(beta-1)),
+		PercentBias: 100 * (sumSim - sumObs) / sumObs,
+		PeakError:   100 * (peakSim - peakObs) / peakObs,
+	}, nil
+}
@@ -0,0 +1,57 @@
+package hms
+
+import (
+	"math"
+	"testing"
+)
+
+func TestGoodnessOfFit(t *testing.T) {
+	obs := []float64{10, 40, 120, 80, 30, 15}
+
+	fit, err := GoodnessOfFit(obs, obs)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if fit.N != 6 || fit.NSE != 1 || fit.KGE != 1 || fit.PercentBias != 0 || fit.PeakError != 0 {
+		t.Errorf("perfect fit = %+v", fit)
+	}
+
+	// 10% high everywhere: r = 1, alpha = beta = 1.1
+	sim := make([]float64, len(obs))
+	for i, v := range obs {
+		sim[i] = v * 1.1
+	}
+	fit, _ = GoodnessOfFit(obs, sim)
+	if math.Abs(fit.PercentBias-10) > 1e-9 || math.Abs(fit.PeakError-10) > 1e-9 {
+		t.Errorf("bias = %g, peak error = %g, want 10 and 10", fit.PercentBias, fit.PeakError)
+	}
+	if want := 1 - math.Sqrt(0.02); math.Abs(fit.KGE-want) > 1e-9 {
+		t.Errorf("KGE = %g, want %g", fit.KGE, want)
+	}
+
+	// Σ(s-o)² = 2 against Σ(o-ō)² = 5
+	fit, _ = GoodnessOfFit([]float64{1, 2, 3, 4}, []float64{2, 2, 3, 3})
+	if math.Abs(fit.NSE-0.6) > 1e-9 {
+		t.Errorf("NSE = %g, want 0.6", fit.NSE)
+	}
+
+	// Simulating the observed mean scores zero
+	fit, _ = GoodnessOfFit([]float64{1, 2, 3, 4}, []float64{2.5, 2.5, 2.5, 2.5})
+	if math.Abs(fit.NSE) > 1e-9 {
+		t.Errorf("mean NSE = %g, want 0", fit.NSE)
+	}
+}
+
+func TestGoodnessOfFitErrors(t *testing.T) {
+	cases := map[string][2][]float64{
+		"length":   {{1, 2, 3}, {1, 2}},
+		"short":    {{1}, {1}},
+		"constant": {{5, 5, 5}, {4, 5, 6}},
+		"zero":     {{0, 0, 0}, {1, 2, 3}},
+	}
+	for name, c := range cases {
+		if _, err := GoodnessOfFit(c[0], c[1]); err == nil {
+			t.Errorf("%s: expected an error", name)
+		}
+	}
+}
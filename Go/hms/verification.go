@@ -0,0 +1,155 @@
+package hms
+
+import (
+	"math"
+	"sort"
+	"time"
+)
+
+// ForecastPair is a forecast flow and the flow observed (or simulated from
+// observed precipitation) at the same time.
+type ForecastPair struct {
+	Issue    time.Time // when the forecast was issued
+	Time     time.Time
+	Forecast float64
+	Observed float64
+}
+
+// LeadHours returns how far ahead of its issue time the value was forecast.
+func (p ForecastPair) LeadHours() float64 {
+	return p.Time.Sub(p.Issue).Hours()
+}
+
+// LeadTimeMetrics verifies the forecast values with lead times in
+// (FromHours, ToHours]. Peak statistics compare each forecast's peak with
+// the observed peak over the same hours, binned by the observed peak's lead
+// time. The contingency counts are left zero without a threshold.
+type LeadTimeMetrics struct {
+	FromHours int      `json:"from_hours"`
+	ToHours   int      `json:"to_hours"`
+	Pairs     int      `json:"pairs"`
+	MAE       *float64 `json:"mae"`
+	NSE       *float64 `json:"nse"` // null when the observed flows are constant
+
+	Peaks                int      `json:"peaks"`
+	PeakTimingErrorHours *float64 `json:"peak_timing_error_hours"` // mean forecast − observed peak time, positive when late
+	PeakMagnitudeError   *float64 `json:"peak_magnitude_error"`    // mean 100·(forecast − observed peak)/observed peak
+
+	Hits             int      `json:"hits"`
+	Misses           int      `json:"misses"`
+	FalseAlarms      int      `json:"false_alarms"`
+	CorrectNegatives int      `json:"correct_negatives"`
+	POD              *float64 `json:"pod"` // probability of detection
+	FAR              *float64 `json:"far"` // false alarm ratio
+	CSI              *float64 `json:"csi"` // critical success index
+}
+
+func ratio(num, den float64) *float64 {
+	if den == 0 {
+		return nil
+	}
+	r := num / den
+	return &r
+}
+
+// VerifyForecasts computes the metrics of pairs in lead time bins of
+// binHours, from the first bin through the last holding a pair. Exceedance
+// of threshold is counted when it is above zero.
+func VerifyForecasts(pairs []ForecastPair, binHours int, threshold float64) []LeadTimeMetrics {
+	if binHours < 1 {
+		binHours = 1
+	}
+	bin := func(lead float64) int { return int(math.Ceil(lead/float64(binHours))) - 1 }
+
+	var bins [][]ForecastPair
+	byIssue := map[time.Time][]ForecastPair{}
+	for _, p := range pairs {
+		lead := p.LeadHours()
+		if lead <= 0 {
+			continue
+		}
+		for b := bin(lead); len(bins) <= b; {
+			bins = append(bins, nil)
+		}
+		bins[bin(lead)] = append(bins[bin(lead)], p)
+		byIssue[p.Issue] = append(byIssue[p.Issue], p)
+	}
+
+	out := make([]LeadTimeMetrics, len(bins))
+	for b, binPairs := range bins {
+		m := &out[b]
+		m.FromHours, m.ToHours = b*binHours, (b+1)*binHours
+		m.Pairs = len(binPairs)
+		if m.Pairs == 0 {
+			continue
+		}
+
+		var sumAbs, sumSq, sumObs float64
+		for _, p := range binPairs {
+			d := p.Forecast - p.Observed
+			sumAbs += math.Abs(d)
+			sumSq += d * d
+			sumObs += p.Observed
+			if threshold > 0 {
+				switch f, o := p.Forecast >= threshold, p.Observed >= threshold; {
+				case f && o:
+					m.Hits++
+				case o:
+					m.Misses++
+				case f:
+					m.FalseAlarms++
+				default:
+					m.CorrectNegatives++
+				}
+			}
+		}
+		m.MAE = ratio(sumAbs, float64(m.Pairs))
+		mean := sumObs / float64(m.Pairs)
+		var varObs float64
+		for _, p := range binPairs {
+			varObs += (p.Observed - mean) * (p.Observed - mean)
+		}
+		if varObs > 0 {
+			nse := 1 - sumSq/varObs
+			m.NSE = &nse
+		}
+		if threshold > 0 {
+			m.POD = ratio(float64(m.Hits), float64(m.Hits+m.Misses))
+			m.FAR = ratio(float64(m.FalseAlarms), float64(m.Hits+m.FalseAlarms))
+			m.CSI = ratio(float64(m.Hits), float64(m.Hits+m.Misses+m.FalseAlarms))
+		}
+	}
+
+	// Peaks of each forecast, in issue order so the sums are reproducible
+	issues := make([]time.Time, 0, len(byIssue))
+	for t := range byIssue {
+		issues = append(issues, t)
+	}
+	sort.Slice(issues, func(i, j int) bool { return issues[i].Before(issues[j]) })
+	timing := make([]float64, len(bins))
+	magnitude := make([]float64, len(bins))
+	for _, issue := range issues {
+		ps := byIssue[issue]
+		obsPeak, fcstPeak := ps[0], ps[0]
+		for _, p := range ps[1:] {
+			if p.Observed > obsPeak.Observed {
+				obsPeak = p
+			}
+			if p.Forecast > fcstPeak.Forecast {
+				fcstPeak = p
+			}
+		}
+		if obsPeak.Observed <= 0 {
+			continue
+		}
+		b := bin(obsPeak.LeadHours())
+		out[b].Peaks++
+		timing[b] += fcstPeak.Time.Sub(obsPeak.Time).Hours()
+		magnitude[b] += 100 * (fcstPeak.Forecast - obsPeak.Observed) / obsPeak.Observed
+	}
+	for b := range out {
+		out[b].PeakTimingErrorHours = ratio(timing[b], float64(out[b].Peaks))
+		out[b].PeakMagnitudeError = ratio(magnitude[b], float64(out[b].Peaks))
+	}
+	return out
+}
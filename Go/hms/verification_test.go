@@ -0,0 +1,72 @@
+package hms
+
+import (
+	"math"
+	"testing"
+	"time"
+)
+
+func TestVerifyForecasts(t *testing.T) {
+	at := func(h int) time.Time { return time.Date(2024, 5, 1, h, 0, 0, 0, time.UTC) }
+	pairs := []ForecastPair{
+		{Issue: at(0), Time: at(0), Forecast: 99, Observed: 1}, // not a forecast
+		{Issue: at(0), Time: at(2), Forecast: 10, Observed: 10},
+		{Issue: at(0), Time: at(5), Forecast: 30, Observed: 20},
+		{Issue: at(0), Time: at(8), Forecast: 50, Observed: 60},
+		{Issue: at(0), Time: at(11), Forecast: 40, Observed: 30},
+		{Issue: at(6), Time: at(8), Forecast: 55, Observed: 60},
+		{Issue: at(6), Time: at(11), Forecast: 65, Observed: 30},
+	}
+	bins := VerifyForecasts(pairs, 6, 25)
+	if len(bins) != 2 {
+		t.Fatalf("%d bins, want 2", len(bins))
+	}
+	near := func(name string, got *float64, want float64) {
+		t.Helper()
+		if got == nil || math.Abs(*got-want) > 1e-9 {
+			t.Errorf("%s = %v, want %g", name, got, want)
+		}
+	}
+
+	b := bins[0]
+	if b.FromHours != 0 || b.ToHours != 6 || b.Pairs != 4 {
+		t.Errorf("bin 0 covers (%d, %d] with %d pairs", b.FromHours, b.ToHours, b.Pairs)
+	}
+	near("bin 0 MAE", b.MAE, 12.5)
+	near("bin 0 NSE", b.NSE, 1-1350.0/1400)
+	if b.Hits != 2 || b.Misses != 0 || b.FalseAlarms != 1 || b.CorrectNegatives != 1 {
+		t.Errorf("bin 0 contingency %d/%d/%d/%d", b.Hits, b.Misses, b.FalseAlarms, b.CorrectNegatives)
+	}
+	near("POD", b.POD, 1)
+	near("FAR", b.FAR, 1.0/3)
+	near("CSI", b.CSI, 2.0/3)
+	// The 06Z forecast peaked 3 hours late and 5 cfs high
+	if b.Peaks != 1 {
+		t.Errorf("bin 0 has %d peaks, want 1", b.Peaks)
+	}
+	near("bin 0 peak timing", b.PeakTimingErrorHours, 3)
+	near("bin 0 peak magnitude", b.PeakMagnitudeError, 100*5.0/60)
+
+	b = bins[1]
+	near("bin 1 MAE", b.MAE, 10)
+	near("bin 1 NSE", b.NSE, 1-200.0/450)
+	if b.Hits != 2 || b.Peaks != 1 {
+		t.Errorf("bin 1 has %d hits and %d peaks", b.Hits, b.Peaks)
+	}
+	near("bin 1 peak timing", b.PeakTimingErrorHours, 0)
+	near("bin 1 peak magnitude", b.PeakMagnitudeError, -100*10.0/60)
+}
+
+func TestVerifyForecastsWithoutThreshold(t *testing.T) {
+	issue := time.Date(2024, 5, 1, 0, 0, 0, 0, time.UTC)
+	bins := VerifyForecasts([]ForecastPair{
+		{Issue: issue, Time: issue.Add(7 * time.Hour), Forecast: 12, Observed: 10},
+		{Issue: issue, Time: issue.Add(8 * time.Hour), Forecast: 12, Observed: 10},
+	}, 3, 0)
+	if len(bins) != 3 || bins[0].Pairs != 0 || bins[0].MAE != nil || bins[2].Pairs != 2 {
+		t.Fatalf("bins %+v", bins)
+	}
+	if bins[2].NSE != nil || bins[2].POD != nil || bins[2].Hits+bins[2].CorrectNegatives != 0 {
+		t.Errorf("constant observed flows without a threshold: %+v", bins[2])
+	}
+}
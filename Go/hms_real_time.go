@@ -15,6 +15,9 @@ import (
 	"strings"
 	"time"
 
+	"HMSBackend/hms"
+	"HMSBackend/sqlcdb"
+
 	"github.com/labstack/echo/v4"
 )
 
@@ -49,16 +52,17 @@ func executePythonScript(ctx context.Context, scriptPath string, scriptArgs ...s
 	return nil
 }
 
-// executePythonScript is a helper function to execute a Python script
-func executeJythonScript(ctx context.Context, scriptPath string) error {
+// executeJythonScript is a helper function to execute a Jython script
+func executeJythonScript(ctx context.Context, scriptPath string, scriptArgs ...string) error {
 	absScriptPath, err := filepath.Abs(scriptPath)
 	if err != nil {
 		return fmt.Errorf("failed to get absolute path for script %s: %w", scriptPath, err)
 	}
 
-	cmd := exec.CommandContext(ctx, GetJythonPath(), absScriptPath)
+	cmdArgs := append([]string{absScriptPath}, scriptArgs...)
+	cmd := exec.CommandContext(ctx, GetJythonPath(), cmdArgs...)
 
-	log.Printf("INFO: Executing command: %s %s", GetJythonPath(), absScriptPath)
+	log.Printf("INFO: Executing command: %s %s", GetJythonPath(), strings.Join(cmdArgs, " "))
 
 	output, err := cmd.CombinedOutput() // Captures both stdout and stderr
 
@@ -513,60 +517,41 @@ func downloadHRRRForecastGRIB(dateStr string, runHour string) error {
 	return nil
 }
 
-// updateControlFile updates the HMS control file with current date and time settings
-func updateControlFile() error {
-	controlFilePath := GetHMSControlFile("realtime")
-
-	log.Printf("setControlFile: Updating control file at: %s", controlFilePath)
+// setControlWindow sets the simulation window of an HMS control file. The
+// file is parsed and validated first so a bad window never reaches HMS.
+func setControlWindow(controlFilePath string, start, end time.Time) error {
+	control, err := hms.ReadControlFile(controlFilePath)
+	if err != nil {
+		return fmt.Errorf("failed to read control file: %w", err)
+	}
+	control.Start, control.End = start.UTC(), end.UTC()
+	if err := control.WriteFile(controlFilePath); err != nil {
+		return fmt.Errorf("failed to write control file: %w", err)
+	}
+	return nil
+}
 
-	// Get the current time in UTC and round down to the hour
-	nowUTC := time.Now().UTC().Truncate(time.Hour)
+// realtimeControlWindow returns the realtime simulation window: 47 hours
+// before through 12 hours after the UTC hour of now
+func realtimeControlWindow(now time.Time) (time.Time, time.Time) {
+	nowUTC := now.UTC().Truncate(time.Hour)
 	log.Printf("setControlFile: Current UTC time (rounded down): %s", nowUTC.Format("2006-01-02 15:04:05"))
+	return nowUTC.Add(-47 * time.Hour), nowUTC.Add(12 * time.Hour)
+}
 
-	// Calculate start datetime (47 hours before current UTC time)
-	startDateTime := nowUTC.Add(-47 * time.Hour)
-	startTimeStr := startDateTime.Format("15:04")
-	startDateStr := startDateTime.Format("2 January 2006") // Day without leading zero
+// updateControlFile updates a model's realtime control file with current date and time settings
+func updateControlFile(model ModelConfig) error {
+	controlFilePath := model.ControlFile(pipelineRunRealtime)
 
-	// Calculate end datetime (12 hours after current UTC time)
-	endDateTime := nowUTC.Add(12 * time.Hour)
-	endTimeStr := endDateTime.Format("15:04")
-	endDateStr := endDateTime.Format("2 January 2006") // Day without leading zero
+	log.Printf("setControlFile: Updating control file at: %s", controlFilePath)
 
-	log.Printf("setControlFile: Calculated Start: %s %s (UTC-47h)", startDateStr, startTimeStr)
-	log.Printf("setControlFile: Calculated End:   %s %s (UTC+12h)", endDateStr, endTimeStr)
+	startDateTime, endDateTime := realtimeControlWindow(time.Now())
 
-	// Read the control file
-	content, err := os.ReadFile(controlFilePath)
-	if err != nil {
-		return fmt.Errorf("failed to read control file: %w", err)
-	}
+	log.Printf("setControlFile: Calculated Start: %s (UTC-47h)", startDateTime.Format("2 January 2006 15:04"))
+	log.Printf("setControlFile: Calculated End:   %s (UTC+12h)", endDateTime.Format("2 January 2006 15:04"))
 
-	// Process the file line by line
-	lines := strings.Split(string(content), "\n")
-	var updatedLines []string
-
-	for _, line := range lines {
-		trimmedLine := strings.TrimSpace(line)
-		switch {
-		case strings.HasPrefix(trimmedLine, "Start Date:"):
-			updatedLines = append(updatedLines, fmt.Sprintf("     Start Date: %s", startDateStr))
-		case strings.HasPrefix(trimmedLine, "End Date:"):
-			updatedLines = append(updatedLines, fmt.Sprintf("     End Date: %s", endDateStr))
-		case strings.HasPrefix(trimmedLine, "Start Time:"):
-			updatedLines = append(updatedLines, fmt.Sprintf("     Start Time: %s", startTimeStr))
-		case strings.HasPrefix(trimmedLine, "End Time:"):
-			updatedLines = append(updatedLines, fmt.Sprintf("     End Time: %s", endTimeStr))
-		default:
-			updatedLines = append(updatedLines, line)
-		}
-	}
-
-	// Write the updated content back to the file
-	updatedContent := strings.Join(updatedLines, "\n")
-	err = os.WriteFile(controlFilePath, []byte(updatedContent), 0644)
-	if err != nil {
-		return fmt.Errorf("failed to write control file: %w", err)
+	if err := setControlWindow(controlFilePath, startDateTime, endDateTime); err != nil {
+		return err
 	}
 
 	log.Printf("setControlFile: Successfully updated control file")
@@ -574,10 +559,17 @@ func updateControlFile() error {
 }
 
 // RunProcessingPipeline orchestrates a sequence of Python script executions.
-// It accepts an optional date in YYYYMMDD format and an optional run hour in HH format.
-func RunProcessingPipeline(ctx context.Context, optionalDateYYYYMMDD string, optionalRunHourHH string) error {
+// The request's model (default model when empty), date (YYYYMMDD) and run
+// hour (HH) are optional, as are the gauge bias overrides.
+func RunProcessingPipeline(ctx context.Context, queries *sqlcdb.Queries, req PipelineRequest) (err error) {
+	model, err := GetModel(req.Model)
+	if err != nil {
+		return err
+	}
+	log.Printf("INFO: Running the realtime pipeline for model %s", model.ID)
+
 	// --- Date Calculation (used for download steps if not provided) ---
-	dateToUse := optionalDateYYYYMMDD
+	dateToUse := req.Date
 	if dateToUse == "" {
 		// Default to current local date
 		dateToUse = time.Now().Format("20060102") // YYYYMMDD format
@@ -587,7 +579,7 @@ func RunProcessingPipeline(ctx context.Context, optionalDateYYYYMMDD string, opt
 	}
 
 	// --- Run Hour Calculation (for HRRR download if not provided) ---
-	runHourToUse := optionalRunHourHH
+	runHourToUse := req.RunHour
 	if runHourToUse == "" {
 		// Default to current UTC hour minus 1
 		utcTimeMinusOneHour := time.Now().UTC().Add(-1 * time.Hour)
@@ -597,7 +589,24 @@ func RunProcessingPipeline(ctx context.Context, optionalDateYYYYMMDD string, opt
 		log.Printf("INFO: Using provided run hour for HRRR download: %sZ", runHourToUse)
 	}
 
-	var err error
+	biasMethod, applyBias := resolveBiasOptions(req.BiasMethod, req.ApplyBias)
+	runID := startPipelineRun(ctx, queries, pipelineRunRealtime, map[string]interface{}{
+		"model":       model.ID,
+		"date":        dateToUse,
+		"run_hour":    runHourToUse,
+		"bias_method": biasMethod,
+		"apply_bias":  applyBias,
+		"overrides":   req.Overrides,
+	})
+	defer func() { finishPipelineRun(queries, runID, err) }()
+
+	// Every file the run writes goes to a private copy of the project
+	ws, err := newHMSWorkspace(model, pipelineRunRealtime, runID)
+	if err != nil {
+		return fmt.Errorf("failed to create HMS workspace: %w", err)
+	}
+	defer func() { ws.close(err != nil, req.KeepWorkspace) }()
+	model = ws.Model
 
 	// Step 1: Download GRIB files using Go function
 	log.Printf("STEP 1: Running 'Get GRIB2 Files RealTime'...")
@@ -606,6 +615,13 @@ func RunProcessingPipeline(ctx context.Context, optionalDateYYYYMMDD string, opt
 		return fmt.Errorf("failed at step 1 (Get GRIB2 Files RealTime): %w", err)
 	}
 	log.Printf("STEP 1: 'Get GRIB2 Files RealTime' completed successfully.")
+
+	// Gauge bias correction and the precipitation summary; failures are only
+	// logged. mrmsDir is where the MRMS merge steps read the grids from.
+	mrmsDir := GetGribDownloadPath(dateToUse)
+	windowStart, windowEnd := realtimeRunWindow(mrmsDir)
+	mrmsDir = runBiasCorrection(ctx, queries, runID, mrmsDir, filepath.Join(ws.Dir, biasCorrectedDir), windowStart, windowEnd, biasMethod, applyBias)
+	storePrecipRunSummary(ctx, queries, runID, pipelineRunRealtime, []string{mrmsDir}, windowStart, windowEnd)
 	log.Printf("INFO: Waiting 300ms before next task...")
 	time.Sleep(1000 * time.Millisecond)
 
@@ -625,14 +641,14 @@ func RunProcessingPipeline(ctx context.Context, optionalDateYYYYMMDD string, opt
 		path     string
 		isBatch  bool
 		argsFunc func() []string // Function to generate args, allows use of dateToUse/runHourToUse
+		run      func() error    // Go implementation of the step, used instead of path when set
 	}{
 		{
 			name:    "Merge GRIB Files RealTime",
 			path:    GetJythonBatchScriptPath("MergeGRIBFilesRealTimeBatch.bat"),
 			isBatch: true,
 			argsFunc: func() []string {
-				// Pass the full folder path to the batch file
-				return []string{GetGribDownloadPath(dateToUse)}
+				return []string{mrmsDir, model.ClipShapefile, model.RainfallPath("RainfallRealTime.dss")}
 			},
 		},
 		{
@@ -641,11 +657,7 @@ func RunProcessingPipeline(ctx context.Context, optionalDateYYYYMMDD string, opt
 			isBatch: true,
 			argsFunc: func() []string {
 				// Pass the arguments as separate elements
-				return []string{
-					GetGribDownloadPath(dateToUse),
-					"", // Empty string for shapefile_path to use default
-					GetDSSPath("RainfallRealTimePass2.dss"),
-				}
+				return []string{mrmsDir, model.ClipShapefile, model.RainfallPath("RainfallRealTimePass2.dss")}
 			},
 		},
 		{
@@ -653,32 +665,25 @@ func RunProcessingPipeline(ctx context.Context, optionalDateYYYYMMDD string, opt
 			path:    GetJythonBatchScriptPath("MergeGRIBFilesRealTimeHRRBatch.bat"),
 			isBatch: true,
 			argsFunc: func() []string {
-				// Pass the full folder path to the batch file
-				return []string{GetGribDownloadPath(dateToUse)}
+				return []string{GetGribDownloadPath(dateToUse), model.ClipShapefile, model.RainfallPath("HRR.dss")}
 			},
 		},
 		{
-			name:    "Combine DSS Records Pass1 Pass2",
-			path:    GetJythonBatchScriptPath("CombineTwoDssFilesPass1Pass2Batch.bat"),
-			isBatch: true,
-			argsFunc: func() []string {
-				return []string{
-					GetDSSPath("RainfallRealTime.dss"),
-					GetDSSPath("RainfallRealTimePass2.dss"),
-					GetDSSPath("RainfallRealTimePass1And2.dss"),
-				}
+			name: "Combine DSS Records Pass1 Pass2",
+			run: func() error {
+				return combinePass1Pass2(ctx,
+					model.RainfallPath("RainfallRealTime.dss"),
+					model.RainfallPath("RainfallRealTimePass2.dss"),
+					model.RainfallPath("RainfallRealTimePass1And2.dss"))
 			},
 		},
 		{
-			name:    "Combine DSS Records Realtime Pass1 Pass2 and HRR",
-			path:    GetJythonBatchScriptPath("CombineTwoDssFilesRealTimeAndHRRBatch.bat"),
-			isBatch: true,
-			argsFunc: func() []string {
-				return []string{
-					GetDSSPath("RainfallRealTimePass1And2.dss"),
-					GetDSSPath("HRR.dss"),
-					GetDSSPath("RainfallRealTimeAndForcast.dss"),
-				}
+			name: "Combine DSS Records Realtime Pass1 Pass2 and HRR",
+			run: func() error {
+				return combineObservedAndForecast(ctx,
+					model.RainfallPath("RainfallRealTimePass1And2.dss"),
+					model.RainfallPath("HRR.dss"),
+					model.RainfallPath("RainfallRealTimeAndForcast.dss"))
 			},
 		},
 		// Step removed - HMS execution will be done separately after the loop
@@ -689,7 +694,9 @@ func RunProcessingPipeline(ctx context.Context, optionalDateYYYYMMDD string, opt
 		log.Printf("STEP %d: Running script '%s'...", stepNum, script.name)
 
 		// Execute either batch file or Python script based on the isBatch flag
-		if script.isBatch {
+		if script.run != nil {
+			err = script.run()
+		} else if script.isBatch {
 			err = executeBatchFile(ctx, script.path, script.argsFunc()...)
 		} else {
 			err = executePythonScript(ctx, script.path, script.argsFunc()...)
@@ -716,7 +723,7 @@ func RunProcessingPipeline(ctx context.Context, optionalDateYYYYMMDD string, opt
 	// Step: Update Control File using Go function
 	controlFileStepNum := len(scriptsToRun) + 3
 	log.Printf("STEP %d: Running 'Set Control File'...", controlFileStepNum)
-	err = updateControlFile()
+	err = updateControlFile(model)
 	if err != nil {
 		return fmt.Errorf("failed at step %d (Set Control File): %w", controlFileStepNum, err)
 	}
@@ -728,10 +735,14 @@ func RunProcessingPipeline(ctx context.Context, optionalDateYYYYMMDD string, opt
 	finalStepNum := controlFileStepNum + 1
 	log.Printf("STEP %d: Running 'HMS RealTime Computation'...", finalStepNum)
 
-	// Use batch script for HMS execution
+	// Use batch script for HMS execution with a compute script generated for this run
 	batchPath := GetHMSBatchScriptPath("HMSRealTimeBatch.bat")
-	scriptPath := GetHMSScript("realtime")
-	hmsModelsDir := AppConfig.Paths.HMSModelsDir
+	scriptPath, err := writeHMSComputeScript(ws, req.Overrides)
+	if err != nil {
+		return fmt.Errorf("failed at step %d (HMS RealTime Computation): %w", finalStepNum, err)
+	}
+
+	hmsModelsDir := filepath.Dir(model.Dir)
 
 	err = executeBatchFile(ctx, batchPath, scriptPath, hmsModelsDir)
 	if err != nil {
@@ -740,50 +751,70 @@ func RunProcessingPipeline(ctx context.Context, optionalDateYYYYMMDD string, opt
 
 	log.Printf("STEP %d: 'HMS RealTime Computation' completed successfully.", finalStepNum)
 
-	err = ProcessAllJunctionFlows()
+	if err = ws.publishResults(); err != nil {
+		return fmt.Errorf("failed at step %d (HMS RealTime Computation): %w", finalStepNum, err)
+	}
 
+	flows, err := ws.extractFlows(ctx)
+	if err == nil {
+		err = writeJunctionFlows(GetJSONOutputPath(model.JSONOutput), flows)
+	}
 	if err != nil {
 		return fmt.Errorf("failed at step %d (Json File Update All Junction FLows): %w", finalStepNum+1, err)
 	}
 
+	// Keep this run's flows next to the earlier runs'; the forecast starts
+	// after the last MRMS hour
+	issueTime := windowEnd
+	if issueTime.IsZero() {
+		issueTime = time.Now().UTC().Truncate(time.Hour)
+	}
+	storeJunctionFlowRun(ctx, queries, runID, model, issueTime, flows)
+
 	log.Println("INFO: All processing steps triggered successfully!")
 	return nil
 }
 
 // handleRunHMSPipeline handles the request to run the HMS processing pipeline
-func handleRunHMSPipeline(c echo.Context) error {
-	// Define a struct for the request body
-	type PipelineRequest struct {
-		Date    string `json:"date"`     // Optional date in YYYYMMDD format
-		RunHour string `json:"run_hour"` // Optional run hour in HH format
-	}
-
-	// Parse request body
-	var req PipelineRequest
-	if err := c.Bind(&req); err != nil {
-		log.Printf("Error parsing request body: %v", err)
-		return respondWithError(c, http.StatusBadRequest, "Invalid request format")
-	}
+func handleRunHMSPipeline(queries *sqlcdb.Queries) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		// Parse request body
+		var req PipelineRequest
+		if err := c.Bind(&req); err != nil {
+			log.Printf("Error parsing request body: %v", err)
+			return respondWithError(c, http.StatusBadRequest, "Invalid request format")
+		}
+		if !validBiasMethod(req.BiasMethod) {
+			return respondWithError(c, http.StatusBadRequest, "bias_method must be none, mean_field or local")
+		}
+		model, err := GetModel(req.Model)
+		if err != nil {
+			return respondWithError(c, http.StatusBadRequest, err.Error())
+		}
+		if err := validateParameterOverrides(model, req.Overrides); err != nil {
+			return respondWithError(c, http.StatusBadRequest, err.Error())
+		}
 
-	// Log the received parameters
-	log.Printf("Received HMS pipeline request: date=%s, run_hour=%s", req.Date, req.RunHour)
+		// Log the received parameters
+		log.Printf("Received HMS pipeline request: model=%s, date=%s, run_hour=%s", req.Model, req.Date, req.RunHour)
 
-	// Run the pipeline in a goroutine to avoid blocking the HTTP response
-	go func() {
-		// Create a new context with a timeout
-		ctx, cancel := context.WithTimeout(context.Background(), 60*time.Minute)
-		defer cancel()
+		// Run the pipeline in a goroutine to avoid blocking the HTTP response
+		go func() {
+			// Create a new context with a timeout
+			ctx, cancel := context.WithTimeout(context.Background(), 60*time.Minute)
+			defer cancel()
 
-		// Run the pipeline
-		err := RunProcessingPipeline(ctx, req.Date, req.RunHour)
-		if err != nil {
-			log.Printf("HMS pipeline failed: %v", err)
-		}
-	}()
+			// Run the pipeline
+			err := RunProcessingPipeline(ctx, queries, req)
+			if err != nil {
+				log.Printf("HMS pipeline failed: %v", err)
+			}
+		}()
 
-	// Return a success response immediately
-	return respondWithJSON(c, http.StatusAccepted, map[string]string{
-		"message": "HMS processing pipeline started",
-		"status":  "accepted",
-	})
+		// Return a success response immediately
+		return respondWithJSON(c, http.StatusAccepted, map[string]string{
+			"message": "HMS processing pipeline started",
+			"status":  "accepted",
+		})
+	}
 }
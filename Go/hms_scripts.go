@@ -0,0 +1,86 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"time"
+
+	"HMSBackend/hms"
+)
+
+// computeScriptName is the generated script inside a job's working directory
+const computeScriptName = "compute.script"
+
+// hmsJobDir returns the working directory of a pipeline run. Runs that could
+// not be recorded (runID 0) are named by their start time instead.
+func hmsJobDir(model ModelConfig, runType string, runID int32) string {
+	name := fmt.Sprintf("%s_%d", runType, runID)
+	if runID == 0 {
+		name = fmt.Sprintf("%s_%s", runType, time.Now().UTC().Format("20060102T150405"))
+	}
+	return filepath.Join(AppConfig.HMS.JobsDir, model.ID, name)
+}
+
+// validateParameterOverrides checks that every override names an element of
+// the model's basin and a field it already has
+func validateParameterOverrides(model ModelConfig, overrides []hms.ParameterOverride) error {
+	if len(overrides) == 0 {
+		return nil
+	}
+	basin, err := loadBasinModel(model.ID)
+	if err != nil {
+		return fmt.Errorf("failed to load basin model: %w", err)
+	}
+	for _, o := range overrides {
+		e, ok := basin.Element(o.Element)
+		if !ok {
+			return fmt.Errorf("override: element %q is not in model %s", o.Element, model.ID)
+		}
+		if _, ok := e.Parameters[o.Parameter]; !ok && !(o.Parameter == "Area" && e.Kind == hms.Subbasin) {
+			return fmt.Errorf("override: %s %s has no parameter %q", e.Kind, e.Name, o.Parameter)
+		}
+	}
+	return nil
+}
+
+// writeHMSComputeScript generates the compute script of a workspace's run
+// into its job directory and returns the script's path. The run's results
+// DSS file is exported to the job directory after the compute.
+func writeHMSComputeScript(ws *hmsWorkspace, overrides []hms.ParameterOverride) (string, error) {
+	model := ws.Model
+	if err := validateParameterOverrides(model, overrides); err != nil {
+		return "", err
+	}
+
+	resultsDSS := model.RealTimeDSS
+	if ws.runType == pipelineRunHistorical {
+		resultsDSS = model.HistoricalDSS
+	}
+	script := hms.ComputeScript{
+		Title:       fmt.Sprintf("%s %s (%s)", model.ID, ws.runType, filepath.Base(ws.Dir)),
+		ProjectName: model.ProjectName,
+		ProjectDir:  filepath.FromSlash(model.ProjectDir(ws.runType)),
+		Runs:        []string{model.RunName(ws.runType)},
+		Overrides:   overrides,
+		Exports: []hms.Export{{
+			Source:      filepath.FromSlash(resultsDSS),
+			Destination: ws.resultsDSS(),
+		}},
+	}
+	if len(overrides) > 0 {
+		script.BasinFile = filepath.FromSlash(model.BasinFile)
+	}
+	data, err := script.Bytes()
+	if err != nil {
+		return "", err
+	}
+
+	scriptPath := filepath.Join(ws.Dir, computeScriptName)
+	if err := os.WriteFile(scriptPath, data, 0644); err != nil {
+		return "", fmt.Errorf("failed to write compute script: %w", err)
+	}
+	log.Printf("INFO: Generated HMS compute script %s", scriptPath)
+	return scriptPath, nil
+}
@@ -0,0 +1,246 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"io/fs"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"HMSBackend/hms"
+)
+
+// Workspace retention (hms.keep_workspaces)
+const (
+	keepWorkspacesNever     = "never"
+	keepWorkspacesOnFailure = "on_failure"
+	keepWorkspacesAlways    = "always"
+)
+
+// hmsWorkspace is a job's private copy of a model project. Pipelines write
+// the rainfall DSS files, the control window and the HMS results into the
+// copy, so runs never touch the configured project and can run side by side.
+type hmsWorkspace struct {
+	Dir     string      // the job directory; the project copy is a subfolder
+	Model   ModelConfig // the model with its project files pointing into the copy
+	source  ModelConfig
+	runType string
+}
+
+// newHMSWorkspace copies the project of a run type into a new job directory.
+// Results of earlier runs, the model's files_to_delete, its archive folder
+// and the exclude paths are left out of the copy.
+func newHMSWorkspace(model ModelConfig, runType string, runID int32, exclude ...string) (*hmsWorkspace, error) {
+	return newHMSWorkspaceIn(hmsJobDir(model, runType, runID), model, runType, exclude...)
+}
+
+// newHMSWorkspaceIn is newHMSWorkspace with an explicit job directory, for
+// jobs that compute several times (e.g. the members of an ensemble run)
+func newHMSWorkspaceIn(jobDir string, model ModelConfig, runType string, exclude ...string) (*hmsWorkspace, error) {
+	jobDir, err := filepath.Abs(jobDir)
+	if err != nil {
+		return nil, err
+	}
+	srcDir := filepath.Clean(filepath.FromSlash(model.ProjectDir(runType)))
+	dstDir := filepath.Join(jobDir, filepath.Base(srcDir))
+
+	skip := map[string]bool{}
+	for _, p := range append([]string{model.RealTimeDSS, model.HistoricalDSS, model.ArchiveDir, AppConfig.HMS.JobsDir}, append(model.FilesToDelete, exclude...)...) {
+		if p != "" {
+			if abs, err := filepath.Abs(filepath.FromSlash(p)); err == nil {
+				skip[abs] = true
+			}
+		}
+	}
+
+	if err := os.RemoveAll(jobDir); err != nil {
+		return nil, fmt.Errorf("failed to clear job directory %s: %w", jobDir, err)
+	}
+	if err := copyTree(srcDir, dstDir, skip); err != nil {
+		return nil, fmt.Errorf("failed to copy project %s: %w", srcDir, err)
+	}
+
+	rebase := func(path string) string {
+		p := filepath.FromSlash(path)
+		if rel, err := filepath.Rel(srcDir, p); err == nil && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+			return filepath.ToSlash(filepath.Join(dstDir, rel))
+		}
+		return path
+	}
+	m := model
+	if runType == pipelineRunHistorical {
+		m.HistoricalDir = rebase(m.HistoricalDir)
+		m.HistoricalControlFile = rebase(m.HistoricalControlFile)
+		m.HistoricalRainfallDir = rebase(m.HistoricalRainfallDir)
+		m.HistoricalDSS = rebase(m.HistoricalDSS)
+		// The historical project keeps its own copy of the basin file
+		m.BasinFile = rebase(filepath.ToSlash(filepath.Join(srcDir, filepath.Base(filepath.FromSlash(m.BasinFile)))))
+	} else {
+		m.Dir = rebase(m.Dir)
+		m.RealTimeControlFile = rebase(m.RealTimeControlFile)
+		m.RainfallDir = rebase(m.RainfallDir)
+		m.RealTimeDSS = rebase(m.RealTimeDSS)
+		m.BasinFile = rebase(m.BasinFile)
+	}
+	m.FilesToDelete = nil
+
+	log.Printf("INFO: Created HMS workspace %s for model %s", dstDir, model.ID)
+	return &hmsWorkspace{Dir: jobDir, Model: m, source: model, runType: runType}, nil
+}
+
+// resultsDSS is the results file exported from the project copy after the compute
+func (w *hmsWorkspace) resultsDSS() string {
+	return filepath.Join(w.Dir, "results.dss")
+}
+
+// compute runs HEC-HMS on the workspace with a generated compute script
+func (w *hmsWorkspace) compute(ctx context.Context, overrides []hms.ParameterOverride) error {
+	scriptPath, err := writeHMSComputeScript(w, overrides)
+	if err != nil {
+		return err
+	}
+	batchPath := GetHMSBatchScriptPath("HMSRealTimeBatch.bat")
+	if w.runType == pipelineRunHistorical {
+		batchPath = GetHMSBatchScriptPath("HMSHistoricalBatch.bat")
+	}
+	if err := executeBatchFile(ctx, batchPath, scriptPath, filepath.Dir(w.Model.ProjectDir(w.runType))); err != nil {
+		return fmt.Errorf("HMS computation failed: %w", err)
+	}
+	return nil
+}
+
+// extractFlows returns the junction flows of the workspace's results. The
+// Jython fallback writes them to flows.json in the job directory.
+func (w *hmsWorkspace) extractFlows(ctx context.Context) (*JunctionFlowsFile, error) {
+	resultsDSS := w.Model.RealTimeDSS
+	if w.runType == pipelineRunHistorical {
+		resultsDSS = w.Model.HistoricalDSS
+	}
+	return extractJunctionFlows(ctx, resultsDSS, "RUN:"+strings.ToUpper(w.Model.RunName(w.runType)), filepath.Join(w.Dir, "flows.json"))
+}
+
+// extractJunctionFlows reads the junction flows of a run from a results DSS
+// file in-process. HEC-DSSVue's Jython remains the fallback for files the Go
+// reader cannot decode, writing its output to fallbackJSON. Missing values
+// are left out either way.
+func extractJunctionFlows(ctx context.Context, resultsDSS, runToken, fallbackJSON string) (*JunctionFlowsFile, error) {
+	flows, err := readDSSJunctionFlows(filepath.FromSlash(resultsDSS), runToken)
+	if err == nil {
+		return flows, nil
+	}
+	log.Printf("Warning: Reading junction flows of %s in Go failed, using Jython: %v", resultsDSS, err)
+
+	if err := executeJythonScript(ctx, GetPythonScriptPath("Jython_Scripts/extract_all_dss_data.py"),
+		filepath.FromSlash(resultsDSS),
+		runToken,
+		fallbackJSON); err != nil {
+		return nil, fmt.Errorf("failed to extract junction flows: %w", err)
+	}
+	flows, err = loadJunctionFlows(fallbackJSON)
+	if err != nil {
+		return nil, err
+	}
+	dropMissingFlows(flows)
+	return flows, nil
+}
+
+// publishResults copies the run's results DSS to the model's configured
+// results file, where the extraction endpoints read it, and for realtime
+// runs also to <archive_dir>/<name>_YYYYMMDD_HH.dss.
+func (w *hmsWorkspace) publishResults() error {
+	target := w.source.RealTimeDSS
+	if w.runType == pipelineRunHistorical {
+		target = w.source.HistoricalDSS
+	}
+	if err := copyFile(w.resultsDSS(), filepath.FromSlash(target)); err != nil {
+		return fmt.Errorf("failed to publish results: %w", err)
+	}
+	log.Printf("INFO: Published HMS results to %s", target)
+
+	if w.runType != pipelineRunRealtime || w.source.ArchiveDir == "" {
+		return nil
+	}
+	base := filepath.Base(filepath.FromSlash(target))
+	ext := filepath.Ext(base)
+	archivePath := filepath.Join(filepath.FromSlash(w.source.ArchiveDir),
+		fmt.Sprintf("%s_%s%s", strings.TrimSuffix(base, ext), time.Now().Format("20060102_15"), ext))
+	if err := copyFile(w.resultsDSS(), archivePath); err != nil {
+		return fmt.Errorf("failed to archive results: %w", err)
+	}
+	log.Printf("INFO: Archived HMS results to %s", archivePath)
+	return nil
+}
+
+// close removes the job directory unless hms.keep_workspaces (or keep, from
+// the request) says to retain it for debugging
+func (w *hmsWorkspace) close(failed, keep bool) {
+	releaseJobDir(w.Dir, failed, keep)
+}
+
+// releaseJobDir applies the hms.keep_workspaces policy to a job directory
+func releaseJobDir(dir string, failed, keep bool) {
+	switch {
+	case keep, AppConfig.HMS.KeepWorkspaces == keepWorkspacesAlways:
+	case failed && AppConfig.HMS.KeepWorkspaces != keepWorkspacesNever:
+	default:
+		if err := os.RemoveAll(dir); err != nil {
+			log.Printf("Warning: Failed to remove HMS workspace %s: %v", dir, err)
+		}
+		return
+	}
+	log.Printf("INFO: Keeping HMS workspace %s", dir)
+}
+
+// copyTree copies the files under src to dst, leaving out the paths in skip
+func copyTree(src, dst string, skip map[string]bool) error {
+	return filepath.WalkDir(src, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if abs, err := filepath.Abs(path); err == nil && skip[abs] {
+			if d.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		rel, err := filepath.Rel(src, path)
+		if err != nil {
+			return err
+		}
+		target := filepath.Join(dst, rel)
+		if d.IsDir() {
+			return os.MkdirAll(target, 0755)
+		}
+		if !d.Type().IsRegular() {
+			return nil
+		}
+		return copyFile(path, target)
+	})
+}
+
+// copyFile copies src to dst, creating dst's directory
+func copyFile(src, dst string) error {
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+
+	if err := os.MkdirAll(filepath.Dir(dst), 0755); err != nil {
+		return err
+	}
+	out, err := os.Create(dst)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(out, in); err != nil {
+		out.Close()
+		os.Remove(dst)
+		return err
+	}
+	return out.Close()
+}
@@ -0,0 +1,307 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log"
+	"math"
+	"net/http"
+	"strconv"
+	"time"
+
+	"HMSBackend/sqlcdb"
+
+	"github.com/labstack/echo/v4"
+)
+
+// storeJunctionFlowRun stores the junction flows of a realtime run, values up
+// to issueTime as observed and later ones as forecast, leaving out missing
+// values. A rerun with the same issue time replaces the stored flows.
+// Failures are only logged.
+func storeJunctionFlowRun(ctx context.Context, queries *sqlcdb.Queries, runID int32, model ModelConfig, issueTime time.Time, flows *JunctionFlowsFile) {
+	run, err := queries.UpsertJunctionFlowRun(ctx, sqlcdb.UpsertJunctionFlowRunParams{
+		Model:     model.ID,
+		RunID:     sql.NullInt32{Int32: runID, Valid: runID != 0},
+		IssueTime: issueTime.UTC(),
+	})
+	if err != nil {
+		log.Printf("Warning: Failed to record the junction flows of run %d: %v", runID, err)
+		return
+	}
+	if err := queries.DeleteJunctionFlows(ctx, run.ID); err != nil {
+		log.Printf("Warning: Failed to replace the junction flows of run %d: %v", runID, err)
+		return
+	}
+
+	var params sqlcdb.InsertJunctionFlowsParams
+	params.FlowRunID = run.ID
+	for _, s := range flows.Series {
+		for _, f := range junctionTimedFlows(s) {
+			params.Junctions = append(params.Junctions, s.Name)
+			params.ValidTimes = append(params.ValidTimes, f.t)
+			params.Flows = append(params.Flows, f.v)
+			params.Observed = append(params.Observed, !f.t.After(issueTime))
+		}
+	}
+	if err := queries.InsertJunctionFlows(ctx, params); err != nil {
+		log.Printf("Warning: Failed to store the junction flows of run %d: %v", runID, err)
+		return
+	}
+	log.Printf("INFO: Stored %d junction flow values of run %d (issued %s)",
+		len(params.Flows), runID, issueTime.UTC().Format(time.RFC3339))
+}
+
+// storedTimedFlows converts stored flow points, in time order, for
+// junctionFlowStats and junctionFloodStatus
+func storedTimedFlows(points []StoredFlowPoint) []timedFlow {
+	out := make([]timedFlow, len(points))
+	for i, p := range points {
+		out[i] = timedFlow{t: p.Time, v: p.Value, stage: p.Stage}
+	}
+	return out
+}
+
+func junctionFlowRunSummary(r sqlcdb.JunctionFlowRun) JunctionFlowRunSummary {
+	s := JunctionFlowRunSummary{ID: r.ID, Model: r.Model, IssueTime: r.IssueTime, CreatedAt: r.CreatedAt}
+	if r.RunID.Valid {
+		s.RunID = r.RunID.Int32
+	}
+	return s
+}
+
+// parseIssueWindow reads the ?from= and ?to= issue times, by default the
+// given hours up to now
+func parseIssueWindow(c echo.Context, hours int) (time.Time, time.Time, error) {
+	to := time.Now().UTC()
+	from := to.Add(-time.Duration(hours) * time.Hour)
+	var err error
+	if v := c.QueryParam("to"); v != "" {
+		if to, err = parseQueryTime(v); err != nil {
+			return from, to, errors.New("Invalid to time")
+		}
+	}
+	if v := c.QueryParam("from"); v != "" {
+		if from, err = parseQueryTime(v); err != nil {
+			return from, to, errors.New("Invalid from time")
+		}
+	}
+	return from.UTC(), to.UTC(), nil
+}
+
+// handleListJunctionFlowRuns serves GET /api/junction-flow-runs?model=&from=&to=&limit=,
+// the stored runs of a model issued in the window (default the last 7 days)
+func handleListJunctionFlowRuns(queries *sqlcdb.Queries) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		model, err := GetModel(c.QueryParam("model"))
+		if err != nil {
+			return respondWithError(c, http.StatusBadRequest, err.Error())
+		}
+		from, to, err := parseIssueWindow(c, 7*24)
+		if err != nil {
+			return respondWithError(c, http.StatusBadRequest, err.Error())
+		}
+		limit := 200
+		if v := c.QueryParam("limit"); v != "" {
+			if limit, err = strconv.Atoi(v); err != nil || limit < 1 {
+				return respondWithError(c, http.StatusBadRequest, "Invalid limit")
+			}
+		}
+
+		runs, err := queries.ListJunctionFlowRuns(c.Request().Context(), sqlcdb.ListJunctionFlowRunsParams{
+			Model:    model.ID,
+			FromTime: from,
+			ToTime:   to,
+			MaxRows:  int32(limit),
+		})
+		if err != nil {
+			log.Printf("Error listing junction flow runs: %v", err)
+			return respondWithError(c, http.StatusInternalServerError, "Failed to list junction flow runs")
+		}
+		out := make([]JunctionFlowRunSummary, len(runs))
+		for i, r := range runs {
+			out[i] = junctionFlowRunSummary(r)
+		}
+		return respondWithJSON(c, http.StatusOK, out)
+	}
+}
+
+// handleGetJunctionForecasts serves GET /api/junctions/:name/forecasts?model=&from=&to=,
+// the junction's series of every run issued in the window (default the last
+// 24 hours) for comparing successive forecasts
+func handleGetJunctionForecasts(queries *sqlcdb.Queries) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		model, err := GetModel(c.QueryParam("model"))
+		if err != nil {
+			return respondWithError(c, http.StatusBadRequest, err.Error())
+		}
+		from, to, err := parseIssueWindow(c, 24)
+		if err != nil {
+			return respondWithError(c, http.StatusBadRequest, err.Error())
+		}
+
+		rows, err := queries.ListJunctionForecasts(c.Request().Context(), sqlcdb.ListJunctionForecastsParams{
+			Model:    model.ID,
+			Junction: c.Param("name"),
+			FromTime: from,
+			ToTime:   to,
+		})
+		if err != nil {
+			log.Printf("Error listing junction forecasts: %v", err)
+			return respondWithError(c, http.StatusInternalServerError, "Failed to list junction forecasts")
+		}
+
+		out := JunctionForecastsResponse{Model: model.ID, Name: c.Param("name"), Forecasts: []JunctionForecast{}}
+		curve := loadJunctionRatingCurve(c.Request().Context(), queries, model.ID, out.Name)
+		if curve != nil {
+			out.StageUnit = curve.StageUnit
+		}
+		for _, r := range rows {
+			n := len(out.Forecasts)
+			if n == 0 || out.Forecasts[n-1].ID != r.FlowRunID {
+				run := junctionFlowRunSummary(sqlcdb.JunctionFlowRun{ID: r.FlowRunID, Model: model.ID, RunID: r.RunID, IssueTime: r.IssueTime})
+				out.Forecasts = append(out.Forecasts, JunctionForecast{JunctionFlowRunSummary: run})
+				n++
+			}
+			f := &out.Forecasts[n-1]
+			point := StoredFlowPoint{Time: r.ValidTime, Value: r.Flow, Observed: r.Observed}
+			if curve != nil {
+				point.Stage = curve.roundedStage(r.Flow)
+			}
+			f.Data = append(f.Data, point)
+			if !r.Observed && (f.Peak == nil || r.Flow > *f.Peak) {
+				peak, at := r.Flow, r.ValidTime
+				f.Peak, f.PeakTime = &peak, &at
+			}
+		}
+		for i, f := range out.Forecasts {
+			out.Forecasts[i].Flood = storedFloodStatus(c.Request().Context(), queries, model.ID, out.Name, storedTimedFlows(f.Data), f.IssueTime)
+		}
+		return respondWithJSON(c, http.StatusOK, out)
+	}
+}
+
+// handleGetForecastVsObserved serves
+// GET /api/junctions/:name/forecast-vs-observed?model=&run=, pairing the
+// forecast of a stored run (the latest without run=) with the simulated
+// observed flows of the runs issued after each forecast hour
+func handleGetForecastVsObserved(queries *sqlcdb.Queries) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		ctx := c.Request().Context()
+		model, err := GetModel(c.QueryParam("model"))
+		if err != nil {
+			return respondWithError(c, http.StatusBadRequest, err.Error())
+		}
+
+		var run sqlcdb.JunctionFlowRun
+		if v := c.QueryParam("run"); v != "" {
+			id, err := strconv.Atoi(v)
+			if err != nil {
+				return respondWithError(c, http.StatusBadRequest, "Invalid run id")
+			}
+			run, err = queries.GetJunctionFlowRun(ctx, int32(id))
+			if err == nil && run.Model != model.ID {
+				err = sql.ErrNoRows
+			}
+		} else {
+			var runs []sqlcdb.JunctionFlowRun
+			runs, err = queries.ListJunctionFlowRuns(ctx, sqlcdb.ListJunctionFlowRunsParams{
+				Model:    model.ID,
+				FromTime: time.Time{},
+				ToTime:   time.Now().UTC(),
+				MaxRows:  1,
+			})
+			if err == nil && len(runs) == 0 {
+				err = sql.ErrNoRows
+			}
+			if err == nil {
+				run = runs[0]
+			}
+		}
+		if errors.Is(err, sql.ErrNoRows) {
+			return respondWithError(c, http.StatusNotFound, fmt.Sprintf("No stored junction flows for model %s", model.ID))
+		}
+		if err != nil {
+			log.Printf("Error getting junction flow run: %v", err)
+			return respondWithError(c, http.StatusInternalServerError, "Failed to get junction flow run")
+		}
+
+		name := c.Param("name")
+		points, err := queries.ListRunJunctionFlows(ctx, sqlcdb.ListRunJunctionFlowsParams{FlowRunID: run.ID, Junction: name})
+		if err != nil {
+			log.Printf("Error listing junction flows of run %d: %v", run.ID, err)
+			return respondWithError(c, http.StatusInternalServerError, "Failed to list junction flows")
+		}
+		if len(points) == 0 {
+			return respondWithError(c, http.StatusNotFound, fmt.Sprintf("Junction %s not found in run %d", name, run.ID))
+		}
+		observed, err := queries.ListSimulatedObservedFlows(ctx, sqlcdb.ListSimulatedObservedFlowsParams{
+			Model:    model.ID,
+			Junction: name,
+			FromTime: run.IssueTime.Add(time.Minute),
+			ToTime:   points[len(points)-1].ValidTime,
+		})
+		if err != nil {
+			log.Printf("Error listing simulated observed flows: %v", err)
+			return respondWithError(c, http.StatusInternalServerError, "Failed to list simulated observed flows")
+		}
+		byTime := make(map[time.Time]sqlcdb.ListSimulatedObservedFlowsRow, len(observed))
+		for _, o := range observed {
+			byTime[o.ValidTime] = o
+		}
+
+		out := ForecastVsObservedResponse{
+			Model:  model.ID,
+			Name:   name,
+			Run:    junctionFlowRunSummary(run),
+			Points: []ForecastObservedPoint{},
+		}
+		curve := loadJunctionRatingCurve(ctx, queries, model.ID, name)
+		if curve != nil {
+			out.StageUnit = curve.StageUnit
+		}
+		sumAbs := 0.0
+		for _, p := range points {
+			if p.Observed {
+				continue
+			}
+			point := ForecastObservedPoint{
+				Time:      p.ValidTime,
+				LeadHours: p.ValidTime.Sub(run.IssueTime).Hours(),
+				Forecast:  p.Flow,
+			}
+			if curve != nil {
+				point.ForecastStage = curve.roundedStage(p.Flow)
+			}
+			if out.PeakForecast == nil || p.Flow > *out.PeakForecast {
+				peak := p.Flow
+				out.PeakForecast = &peak
+			}
+			if o, ok := byTime[p.ValidTime]; ok {
+				value, issue, diff := o.Flow, o.IssueTime, p.Flow-o.Flow
+				point.Observed, point.ObservedIssue, point.Error = &value, &issue, &diff
+				if curve != nil {
+					point.ObservedStage = curve.roundedStage(value)
+				}
+				out.Matched++
+				sumAbs += math.Abs(diff)
+				if out.PeakObserved == nil || value > *out.PeakObserved {
+					out.PeakObserved = &value
+				}
+			}
+			out.Points = append(out.Points, point)
+		}
+		if out.Matched > 0 {
+			mae := sumAbs / float64(out.Matched)
+			out.MeanAbsError = &mae
+		}
+
+		flows := make([]timedFlow, len(points))
+		for i, p := range points {
+			flows[i] = timedFlow{t: p.ValidTime, v: p.Flow}
+		}
+		out.Flood = storedFloodStatus(ctx, queries, model.ID, name, flows, run.IssueTime)
+		return respondWithJSON(c, http.StatusOK, out)
+	}
+}
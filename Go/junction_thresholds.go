@@ -0,0 +1,322 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"HMSBackend/sqlcdb"
+
+	"github.com/labstack/echo/v4"
+)
+
+const floodCategoryNone = "none"
+
+// floodCategories are the flood categories from lowest to highest
+var floodCategories = []string{"action", "minor", "moderate", "major"}
+
+func nullFloat(v *float64) sql.NullFloat64 {
+	if v == nil {
+		return sql.NullFloat64{}
+	}
+	return sql.NullFloat64{Float64: *v, Valid: true}
+}
+
+func floatPtr(v sql.NullFloat64) *float64 {
+	if !v.Valid {
+		return nil
+	}
+	f := v.Float64
+	return &f
+}
+
+// levels returns the thresholds in category order, nil for those not set
+func (t JunctionThresholds) levels() []*float64 {
+	return []*float64{t.Action, t.Minor, t.Moderate, t.Major}
+}
+
+// lowest returns the lowest threshold set, zero for none
+func (t JunctionThresholds) lowest() float64 {
+	for _, v := range t.levels() {
+		if v != nil {
+			return *v
+		}
+	}
+	return 0
+}
+
+// category returns the highest flood category that flow reaches
+func (t JunctionThresholds) category(flow float64) string {
+	category := floodCategoryNone
+	for i, v := range t.levels() {
+		if v != nil && flow >= *v {
+			category = floodCategories[i]
+		}
+	}
+	return category
+}
+
+// validate checks that at least one threshold is set and that they increase
+func (t JunctionThresholds) validate() error {
+	var prev *float64
+	for i, v := range t.levels() {
+		if v == nil {
+			continue
+		}
+		if *v <= 0 {
+			return fmt.Errorf("%s flow must be positive", floodCategories[i])
+		}
+		if prev != nil && *v <= *prev {
+			return fmt.Errorf("%s flow must be above the lower categories", floodCategories[i])
+		}
+		prev = v
+	}
+	if prev == nil && len(t.Crossings) == 0 {
+		return errors.New("at least one threshold or crossing is required")
+	}
+	for _, c := range t.Crossings {
+		if c.Name == "" {
+			return errors.New("every crossing needs a name")
+		}
+	}
+	return nil
+}
+
+func junctionThresholds(row sqlcdb.JunctionThreshold) JunctionThresholds {
+	t := JunctionThresholds{
+		Model:     row.Model,
+		Junction:  row.Junction,
+		Action:    floatPtr(row.ActionFlow),
+		Minor:     floatPtr(row.MinorFlow),
+		Moderate:  floatPtr(row.ModerateFlow),
+		Major:     floatPtr(row.MajorFlow),
+		Crossings: []LowWaterCrossing{},
+		UpdatedAt: &row.UpdatedAt,
+	}
+	if err := json.Unmarshal(row.Crossings, &t.Crossings); err != nil {
+		log.Printf("Warning: Crossings of junction %s are unreadable: %v", row.Junction, err)
+	}
+	return t
+}
+
+// loadJunctionThresholds returns the thresholds of a model's junctions keyed
+// by upper-case junction name
+func loadJunctionThresholds(ctx context.Context, queries *sqlcdb.Queries, modelID string) (map[string]JunctionThresholds, error) {
+	rows, err := queries.ListJunctionThresholds(ctx, modelID)
+	if err != nil {
+		return nil, err
+	}
+	out := make(map[string]JunctionThresholds, len(rows))
+	for _, r := range rows {
+		out[strings.ToUpper(r.Junction)] = junctionThresholds(r)
+	}
+	return out, nil
+}
+
+// junctionFloodStatus classifies flows in time order by t. The current
+// value is the one junctionFlowStats reports; later values are forecast.
+func junctionFloodStatus(t JunctionThresholds, flows []timedFlow, now time.Time) *JunctionFloodStatus {
+	status := &JunctionFloodStatus{
+		CurrentCategory:      floodCategoryNone,
+		ForecastPeakCategory: floodCategoryNone,
+		Exceedances:          []FloodExceedance{},
+	}
+	stats := junctionFlowStats(flows, now)
+	if stats.Current != nil {
+		status.CurrentCategory = t.category(*stats.Current)
+	}
+	for _, f := range flows {
+		if stats.CurrentTime != nil && !f.t.After(*stats.CurrentTime) {
+			continue
+		}
+		if status.ForecastPeak == nil || f.v > *status.ForecastPeak {
+			peak, at := f.v, f.t
+			status.ForecastPeak, status.ForecastPeakTime = &peak, &at
+		}
+	}
+	if status.ForecastPeak != nil {
+		status.ForecastPeakCategory = t.category(*status.ForecastPeak)
+	}
+
+	for i, level := range t.levels() {
+		if level == nil {
+			continue
+		}
+		for _, f := range flows {
+			if f.v >= *level {
+				status.Exceedances = append(status.Exceedances, FloodExceedance{Category: floodCategories[i], Threshold: *level, Time: f.t})
+				break
+			}
+		}
+	}
+	// Thresholds increase, so the lowest is always reached first
+	if len(status.Exceedances) > 0 {
+		first := status.Exceedances[0].Time
+		status.FirstExceedance = &first
+	}
+
+	for _, c := range t.Crossings {
+		cs := CrossingStatus{LowWaterCrossing: c}
+		if c.FloodFlow != nil {
+			cs.FloodedNow = stats.Current != nil && *stats.Current >= *c.FloodFlow
+			cs.FloodedForecast = status.ForecastPeak != nil && *status.ForecastPeak >= *c.FloodFlow
+		}
+		status.Crossings = append(status.Crossings, cs)
+	}
+	return status
+}
+
+// storedFloodStatus classifies a junction's stored flows of a run issued at
+// issueTime, nil when the junction has no thresholds. Failures are only
+// logged.
+func storedFloodStatus(ctx context.Context, queries *sqlcdb.Queries, modelID, junction string, flows []timedFlow, issueTime time.Time) *JunctionFloodStatus {
+	row, err := queries.GetJunctionThreshold(ctx, sqlcdb.GetJunctionThresholdParams{
+		Model:    modelID,
+		Junction: strings.ToUpper(junction),
+	})
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil
+	}
+	if err != nil {
+		log.Printf("Warning: Flood thresholds of junction %s not applied: %v", junction, err)
+		return nil
+	}
+	return junctionFloodStatus(junctionThresholds(row), flows, issueTime)
+}
+
+// annotateJunctionFlows sets the stage of every series with a rating curve
+// and the flood status of every series with thresholds. Failures are only
+// logged so the flows are still served.
+func annotateJunctionFlows(ctx context.Context, queries *sqlcdb.Queries, modelID string, flows *JunctionFlowsFile) {
+	applyRatingCurves(ctx, queries, modelID, flows)
+	thresholds, err := loadJunctionThresholds(ctx, queries, modelID)
+	if err != nil {
+		log.Printf("Warning: Flood thresholds of model %s not applied: %v", modelID, err)
+		return
+	}
+	now := time.Now().UTC()
+	for i, s := range flows.Series {
+		if t, ok := thresholds[strings.ToUpper(s.Name)]; ok {
+			flows.Series[i].Flood = junctionFloodStatus(t, junctionTimedFlows(s), now)
+		}
+	}
+}
+
+// handleListThresholds serves GET /api/thresholds?model=
+func handleListThresholds(queries *sqlcdb.Queries) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		model, err := GetModel(c.QueryParam("model"))
+		if err != nil {
+			return respondWithError(c, http.StatusBadRequest, err.Error())
+		}
+		rows, err := queries.ListJunctionThresholds(c.Request().Context(), model.ID)
+		if err != nil {
+			log.Printf("Error listing junction thresholds: %v", err)
+			return respondWithError(c, http.StatusInternalServerError, "Failed to list junction thresholds")
+		}
+		out := make([]JunctionThresholds, len(rows))
+		for i, r := range rows {
+			out[i] = junctionThresholds(r)
+		}
+		return respondWithJSON(c, http.StatusOK, out)
+	}
+}
+
+// handleGetThreshold serves GET /api/thresholds/:model/:junction
+func handleGetThreshold(queries *sqlcdb.Queries) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		model, err := GetModel(c.Param("model"))
+		if err != nil {
+			return respondWithError(c, http.StatusBadRequest, err.Error())
+		}
+		row, err := queries.GetJunctionThreshold(c.Request().Context(), sqlcdb.GetJunctionThresholdParams{
+			Model:    model.ID,
+			Junction: strings.ToUpper(c.Param("junction")),
+		})
+		if errors.Is(err, sql.ErrNoRows) {
+			return respondWithError(c, http.StatusNotFound, "Junction has no thresholds")
+		}
+		if err != nil {
+			log.Printf("Error getting junction thresholds: %v", err)
+			return respondWithError(c, http.StatusInternalServerError, "Failed to get junction thresholds")
+		}
+		return respondWithJSON(c, http.StatusOK, junctionThresholds(row))
+	}
+}
+
+// handlePutThreshold handles PUT /api/thresholds/:model/:junction (admins),
+// creating or replacing a junction's thresholds. Junction names are stored
+// upper-case, as HMS writes them, and looked up the same way.
+func handlePutThreshold(queries *sqlcdb.Queries) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		if ok, err := requireAdmin(c, queries); !ok {
+			return err
+		}
+		var req JunctionThresholds
+		if err := c.Bind(&req); err != nil {
+			return respondWithError(c, http.StatusBadRequest, "Invalid request format")
+		}
+		model, err := GetModel(c.Param("model"))
+		if err != nil {
+			return respondWithError(c, http.StatusBadRequest, err.Error())
+		}
+		req.Model, req.Junction = model.ID, strings.ToUpper(c.Param("junction"))
+		if err := req.validate(); err != nil {
+			return respondWithError(c, http.StatusBadRequest, err.Error())
+		}
+		if req.Crossings == nil {
+			req.Crossings = []LowWaterCrossing{}
+		}
+		crossings, err := json.Marshal(req.Crossings)
+		if err != nil {
+			return respondWithError(c, http.StatusBadRequest, "Invalid crossings")
+		}
+
+		row, err := queries.UpsertJunctionThreshold(c.Request().Context(), sqlcdb.UpsertJunctionThresholdParams{
+			Model:        req.Model,
+			Junction:     req.Junction,
+			ActionFlow:   nullFloat(req.Action),
+			MinorFlow:    nullFloat(req.Minor),
+			ModerateFlow: nullFloat(req.Moderate),
+			MajorFlow:    nullFloat(req.Major),
+			Crossings:    crossings,
+		})
+		if err != nil {
+			log.Printf("Error storing junction thresholds: %v", err)
+			return respondWithError(c, http.StatusInternalServerError, "Failed to store junction thresholds")
+		}
+		log.Printf("INFO: Stored flood thresholds of junction %s (model %s)", row.Junction, row.Model)
+		return respondWithJSON(c, http.StatusOK, junctionThresholds(row))
+	}
+}
+
+// handleDeleteThreshold handles DELETE /api/thresholds/:model/:junction (admins)
+func handleDeleteThreshold(queries *sqlcdb.Queries) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		if ok, err := requireAdmin(c, queries); !ok {
+			return err
+		}
+		model, err := GetModel(c.Param("model"))
+		if err != nil {
+			return respondWithError(c, http.StatusBadRequest, err.Error())
+		}
+		n, err := queries.DeleteJunctionThreshold(c.Request().Context(), sqlcdb.DeleteJunctionThresholdParams{
+			Model:    model.ID,
+			Junction: strings.ToUpper(c.Param("junction")),
+		})
+		if err != nil {
+			log.Printf("Error deleting junction thresholds: %v", err)
+			return respondWithError(c, http.StatusInternalServerError, "Failed to delete junction thresholds")
+		}
+		if n == 0 {
+			return respondWithError(c, http.StatusNotFound, "Junction has no thresholds")
+		}
+		return respondWithJSON(c, http.StatusOK, map[string]string{"message": "Thresholds deleted successfully"})
+	}
+}
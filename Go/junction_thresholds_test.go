@@ -0,0 +1,106 @@
+package main
+
+import (
+	"testing"
+	"time"
+)
+
+func flowPtr(v float64) *float64 { return &v }
+
+func TestThresholdCategory(t *testing.T) {
+	thresholds := JunctionThresholds{Action: flowPtr(100), Minor: flowPtr(200), Major: flowPtr(500)}
+	tests := []struct {
+		flow float64
+		want string
+	}{
+		{0, floodCategoryNone},
+		{99.9, floodCategoryNone},
+		{100, "action"},
+		{350, "minor"},
+		// No moderate threshold, so minor holds until major
+		{499, "minor"},
+		{500, "major"},
+		{10000, "major"},
+	}
+	for _, tt := range tests {
+		if got := thresholds.category(tt.flow); got != tt.want {
+			t.Errorf("category(%g) = %s, want %s", tt.flow, got, tt.want)
+		}
+	}
+}
+
+func TestThresholdValidate(t *testing.T) {
+	tests := []struct {
+		name       string
+		thresholds JunctionThresholds
+		wantErr    bool
+	}{
+		{"increasing", JunctionThresholds{Action: flowPtr(1), Minor: flowPtr(2), Moderate: flowPtr(3), Major: flowPtr(4)}, false},
+		{"gaps", JunctionThresholds{Minor: flowPtr(2), Major: flowPtr(4)}, false},
+		{"crossings only", JunctionThresholds{Crossings: []LowWaterCrossing{{Name: "CR 12"}}}, false},
+		{"nothing set", JunctionThresholds{}, true},
+		{"zero", JunctionThresholds{Action: flowPtr(0)}, true},
+		{"equal", JunctionThresholds{Minor: flowPtr(2), Moderate: flowPtr(2)}, true},
+		{"decreasing across a gap", JunctionThresholds{Action: flowPtr(5), Major: flowPtr(4)}, true},
+		{"unnamed crossing", JunctionThresholds{Action: flowPtr(1), Crossings: []LowWaterCrossing{{}}}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.thresholds.validate(); (err != nil) != tt.wantErr {
+				t.Errorf("validate() = %v, want error %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestJunctionFloodStatus(t *testing.T) {
+	thresholds := JunctionThresholds{
+		Action: flowPtr(100), Minor: flowPtr(200), Moderate: flowPtr(300),
+		Crossings: []LowWaterCrossing{
+			{Name: "low", FloodFlow: flowPtr(50)},
+			{Name: "high", FloodFlow: flowPtr(250)},
+			{Name: "unrated"},
+		},
+	}
+	flows := hourlyFlows(60, 120, 150, 260, 180)
+	status := junctionFloodStatus(thresholds, flows, flowStart.Add(2*time.Hour))
+
+	if status.CurrentCategory != "action" {
+		t.Errorf("current category %s, want action", status.CurrentCategory)
+	}
+	if status.ForecastPeak == nil || *status.ForecastPeak != 260 || !status.ForecastPeakTime.Equal(flowStart.Add(3*time.Hour)) {
+		t.Errorf("forecast peak %v at %v, want 260 at 03:00", status.ForecastPeak, status.ForecastPeakTime)
+	}
+	if status.ForecastPeakCategory != "minor" {
+		t.Errorf("forecast peak category %s, want minor", status.ForecastPeakCategory)
+	}
+	if len(status.Exceedances) != 2 ||
+		status.Exceedances[0].Category != "action" || !status.Exceedances[0].Time.Equal(flowStart.Add(time.Hour)) ||
+		status.Exceedances[1].Category != "minor" || !status.Exceedances[1].Time.Equal(flowStart.Add(3*time.Hour)) {
+		t.Errorf("exceedances = %+v", status.Exceedances)
+	}
+	if status.FirstExceedance == nil || !status.FirstExceedance.Equal(flowStart.Add(time.Hour)) {
+		t.Errorf("first exceedance %v, want 01:00", status.FirstExceedance)
+	}
+
+	want := []struct{ now, forecast bool }{{true, true}, {false, true}, {false, false}}
+	if len(status.Crossings) != len(want) {
+		t.Fatalf("%d crossings, want %d", len(status.Crossings), len(want))
+	}
+	for i, cs := range status.Crossings {
+		if cs.FloodedNow != want[i].now || cs.FloodedForecast != want[i].forecast {
+			t.Errorf("crossing %s flooded now %v, forecast %v; want %v, %v",
+				cs.Name, cs.FloodedNow, cs.FloodedForecast, want[i].now, want[i].forecast)
+		}
+	}
+}
+
+func TestJunctionFloodStatusBelowThresholds(t *testing.T) {
+	status := junctionFloodStatus(JunctionThresholds{Action: flowPtr(100)}, hourlyFlows(10, 20, 30), flowStart.Add(5*time.Hour))
+	if status.CurrentCategory != floodCategoryNone || status.ForecastPeak != nil || status.ForecastPeakCategory != floodCategoryNone {
+		t.Errorf("status = %+v", status)
+	}
+	if status.FirstExceedance != nil || len(status.Exceedances) != 0 {
+		t.Errorf("exceedances %+v, want none", status.Exceedances)
+	}
+}
@@ -0,0 +1,320 @@
+package main
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"math"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"HMSBackend/sqlcdb"
+
+	"github.com/labstack/echo/v4"
+)
+
+// junctionTimeLayout is the time format of the junction flows JSON (UTC)
+const junctionTimeLayout = "02 Jan 2006T15:04"
+
+const cubicFeetPerAcreFoot = 43560.0
+
+// trendTolerance is the relative change below which a junction is steady
+const trendTolerance = 0.01
+
+// timedFlow is a junction flow value with its parsed time and, for
+// junctions with a rating curve, its stage
+type timedFlow struct {
+	t     time.Time
+	v     float64
+	stage *float64
+}
+
+// junctionFlowsPath returns the flows JSON of a model's realtime or
+// historical run
+func junctionFlowsPath(model ModelConfig, run string) (string, error) {
+	switch run {
+	case "", pipelineRunRealtime:
+		return GetJSONOutputPath(model.JSONOutput), nil
+	case pipelineRunHistorical:
+		return GetJSONOutputPath(model.HistoricalJSONOutput), nil
+	}
+	return "", fmt.Errorf("run must be %s or %s", pipelineRunRealtime, pipelineRunHistorical)
+}
+
+// loadJunctionFlows reads a junction flows JSON file
+func loadJunctionFlows(path string) (*JunctionFlowsFile, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var flows JunctionFlowsFile
+	if err := json.Unmarshal(data, &flows); err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", path, err)
+	}
+	return &flows, nil
+}
+
+// writeJunctionFlows writes a junction flows JSON file, indented like the
+// Jython extraction scripts' output
+func writeJunctionFlows(path string, flows *JunctionFlowsFile) error {
+	data, err := json.MarshalIndent(flows, "", "    ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, data, 0644)
+}
+
+// junctionTimedFlows parses the times of a junction's flows, skipping
+// values with unreadable times and missing values
+func junctionTimedFlows(s JunctionFlowSeries) []timedFlow {
+	out := make([]timedFlow, 0, len(s.Data))
+	for _, p := range s.Data {
+		t, err := time.Parse(junctionTimeLayout, p.Time)
+		if err != nil || missingFlow(p.Value) {
+			continue
+		}
+		out = append(out, timedFlow{t, p.Value, p.Stage})
+	}
+	return out
+}
+
+// junctionFlowStats computes the statistics of flows in time order. The
+// current value is the latest at or before now, or the first when all
+// values are later. Missing values are skipped.
+func junctionFlowStats(flows []timedFlow, now time.Time) JunctionFlowStats {
+	stats := JunctionFlowStats{Trend: "steady"}
+	present := make([]timedFlow, 0, len(flows))
+	for _, f := range flows {
+		if !missingFlow(f.v) {
+			present = append(present, f)
+		}
+	}
+	flows = present
+	if len(flows) == 0 {
+		return stats
+	}
+
+	peak := 0
+	for i := range flows {
+		if flows[i].v > flows[peak].v {
+			peak = i
+		}
+		if i > 0 {
+			seconds := flows[i].t.Sub(flows[i-1].t).Seconds()
+			stats.Volume += (flows[i].v + flows[i-1].v) / 2 * seconds / cubicFeetPerAcreFoot
+		}
+	}
+	stats.Volume = math.Round(stats.Volume*100) / 100
+	stats.Peak, stats.PeakTime, stats.PeakStage = &flows[peak].v, &flows[peak].t, flows[peak].stage
+	toPeak := flows[peak].t.Sub(flows[0].t).Hours()
+	stats.TimeToPeakHours = &toPeak
+
+	current := 0
+	for i := range flows {
+		if flows[i].t.After(now) {
+			break
+		}
+		current = i
+	}
+	stats.Current, stats.CurrentTime, stats.CurrentStage = &flows[current].v, &flows[current].t, flows[current].stage
+	if current > 0 {
+		prev, cur := flows[current-1].v, flows[current].v
+		switch change := cur - prev; {
+		case math.Abs(change) <= trendTolerance*math.Max(math.Abs(prev), 1):
+		case change > 0:
+			stats.Trend = "rising"
+		default:
+			stats.Trend = "falling"
+		}
+	}
+	return stats
+}
+
+// resampleFlows keeps the flows at multiples of interval (UTC)
+func resampleFlows(flows []timedFlow, interval time.Duration) []timedFlow {
+	out := make([]timedFlow, 0, len(flows))
+	for _, f := range flows {
+		if f.t.Truncate(interval).Equal(f.t) {
+			out = append(out, f)
+		}
+	}
+	return out
+}
+
+// loadModelJunctionFlows loads the flows named by the ?model= and ?run=
+// query parameters with the flood status of the junctions that have
+// thresholds, responding itself when that fails
+func loadModelJunctionFlows(c echo.Context, queries *sqlcdb.Queries) (ModelConfig, *JunctionFlowsFile, bool, error) {
+	model, err := GetModel(c.QueryParam("model"))
+	if err != nil {
+		return model, nil, false, respondWithError(c, http.StatusBadRequest, err.Error())
+	}
+	path, err := junctionFlowsPath(model, c.QueryParam("run"))
+	if err != nil {
+		return model, nil, false, respondWithError(c, http.StatusBadRequest, err.Error())
+	}
+	flows, err := loadJunctionFlows(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return model, nil, false, respondWithError(c, http.StatusNotFound, fmt.Sprintf("No junction flows for model %s", model.ID))
+	}
+	if err != nil {
+		log.Printf("Error reading junction flows %s: %v", path, err)
+		return model, nil, false, respondWithError(c, http.StatusInternalServerError, "Failed to read junction flow data")
+	}
+	annotateJunctionFlows(c.Request().Context(), queries, model.ID, flows)
+	return model, flows, true, nil
+}
+
+// formatStage renders an optional stage for CSV, empty when unset
+func formatStage(stage *float64) string {
+	if stage == nil {
+		return ""
+	}
+	return strconv.FormatFloat(*stage, 'f', -1, 64)
+}
+
+// respondWithCSV writes rows as a CSV attachment named filename
+func respondWithCSV(c echo.Context, filename string, rows [][]string) error {
+	res := c.Response()
+	res.Header().Set(echo.HeaderContentType, "text/csv; charset=utf-8")
+	res.Header().Set(echo.HeaderContentDisposition, fmt.Sprintf("attachment; filename=%q", filename))
+	res.WriteHeader(http.StatusOK)
+	w := csv.NewWriter(res)
+	if err := w.WriteAll(rows); err != nil {
+		log.Printf("Error writing CSV %s: %v", filename, err)
+		return err
+	}
+	return nil
+}
+
+// checkJunctionFormat validates ?format=json|csv, responding itself when it
+// is neither
+func checkJunctionFormat(c echo.Context) (string, bool, error) {
+	switch format := c.QueryParam("format"); format {
+	case "", "json":
+		return "json", true, nil
+	case "csv":
+		return format, true, nil
+	}
+	return "", false, respondWithError(c, http.StatusBadRequest, "format must be json or csv")
+}
+
+// handleListJunctions lists the junctions of a model's latest run with
+// their statistics (?model=&run=realtime|historical). With ?format=csv it
+// exports every junction's flows and stages instead, one row per value.
+func handleListJunctions(queries *sqlcdb.Queries) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		format, ok, err := checkJunctionFormat(c)
+		if !ok {
+			return err
+		}
+		model, flows, ok, err := loadModelJunctionFlows(c, queries)
+		if !ok {
+			return err
+		}
+		if format == "csv" {
+			rows := [][]string{{"junction", "time", "flow", "flow_unit", "stage", "stage_unit"}}
+			for _, s := range flows.Series {
+				for _, f := range junctionTimedFlows(s) {
+					rows = append(rows, []string{s.Name, f.t.Format(time.RFC3339), strconv.FormatFloat(f.v, 'f', -1, 64), s.Unit, formatStage(f.stage), s.StageUnit})
+				}
+			}
+			return respondWithCSV(c, fmt.Sprintf("%s_junctions.csv", model.ID), rows)
+		}
+
+		now := time.Now().UTC()
+		out := make([]JunctionSummary, 0, len(flows.Series))
+		for _, s := range flows.Series {
+			timed := junctionTimedFlows(s)
+			j := JunctionSummary{Name: s.Name, Unit: s.Unit, Points: len(timed), Stats: junctionFlowStats(timed, now), Flood: s.Flood, StageUnit: s.StageUnit}
+			if len(timed) > 0 {
+				j.Start, j.End = &timed[0].t, &timed[len(timed)-1].t
+			}
+			out = append(out, j)
+		}
+		return respondWithJSON(c, http.StatusOK, out)
+	}
+}
+
+// handleGetJunctionFlows returns one junction's flows and statistics,
+// optionally limited to ?start= and ?end= and resampled to ?interval=
+// (e.g. 3h). The statistics cover the window at full resolution.
+// ?format=csv exports the values as time,flow,stage rows.
+func handleGetJunctionFlows(queries *sqlcdb.Queries) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		format, ok, err := checkJunctionFormat(c)
+		if !ok {
+			return err
+		}
+		var start, end time.Time
+		if v := c.QueryParam("start"); v != "" {
+			if start, err = parseQueryTime(v); err != nil {
+				return respondWithError(c, http.StatusBadRequest, "Invalid start time")
+			}
+		}
+		if v := c.QueryParam("end"); v != "" {
+			if end, err = parseQueryTime(v); err != nil {
+				return respondWithError(c, http.StatusBadRequest, "Invalid end time")
+			}
+		}
+		var interval time.Duration
+		if v := c.QueryParam("interval"); v != "" {
+			if interval, err = time.ParseDuration(v); err != nil || interval < time.Minute {
+				return respondWithError(c, http.StatusBadRequest, "interval must be a duration of at least 1m, e.g. 3h")
+			}
+		}
+
+		model, flows, ok, err := loadModelJunctionFlows(c, queries)
+		if !ok {
+			return err
+		}
+		name := c.Param("name")
+		for _, s := range flows.Series {
+			if !strings.EqualFold(s.Name, name) {
+				continue
+			}
+
+			var window []timedFlow
+			for _, f := range junctionTimedFlows(s) {
+				if (start.IsZero() || !f.t.Before(start)) && (end.IsZero() || !f.t.After(end)) {
+					window = append(window, f)
+				}
+			}
+			out := JunctionFlowsResponse{
+				Model:     model.ID,
+				Run:       c.QueryParam("run"),
+				Name:      s.Name,
+				Timezone:  s.Timezone,
+				Unit:      s.Unit,
+				StageUnit: s.StageUnit,
+				Stats:     junctionFlowStats(window, time.Now().UTC()),
+				Flood:     s.Flood,
+				Data:      []JunctionFlowPoint{},
+			}
+			if out.Run == "" {
+				out.Run = pipelineRunRealtime
+			}
+			if interval > 0 {
+				out.Interval = interval.String()
+				window = resampleFlows(window, interval)
+			}
+			if format == "csv" {
+				rows := [][]string{{"time", "flow", "stage"}}
+				for _, f := range window {
+					rows = append(rows, []string{f.t.Format(time.RFC3339), strconv.FormatFloat(f.v, 'f', -1, 64), formatStage(f.stage)})
+				}
+				return respondWithCSV(c, fmt.Sprintf("%s_%s_%s.csv", model.ID, s.Name, out.Run), rows)
+			}
+			for _, f := range window {
+				out.Data = append(out.Data, JunctionFlowPoint{Time: f.t.Format(junctionTimeLayout), Value: f.v, Stage: f.stage})
+			}
+			return respondWithJSON(c, http.StatusOK, out)
+		}
+		return respondWithError(c, http.StatusNotFound, fmt.Sprintf("Junction %s not found", name))
+	}
+}
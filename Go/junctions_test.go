@@ -0,0 +1,146 @@
+package main
+
+import (
+	"math"
+	"testing"
+	"time"
+)
+
+var flowStart = time.Date(2024, 5, 1, 0, 0, 0, 0, time.UTC)
+
+// hourlyFlows returns values an hour apart from flowStart
+func hourlyFlows(values ...float64) []timedFlow {
+	out := make([]timedFlow, len(values))
+	for i, v := range values {
+		out[i] = timedFlow{t: flowStart.Add(time.Duration(i) * time.Hour), v: v}
+	}
+	return out
+}
+
+func TestJunctionFlowStats(t *testing.T) {
+	tests := []struct {
+		name        string
+		flows       []timedFlow
+		now         time.Duration // after flowStart
+		peak        float64
+		toPeak      float64
+		volume      float64
+		current     float64
+		currentHour int
+		trend       string
+	}{
+		// 12.1 cfs for an hour is one acre-ft
+		{"rising", hourlyFlows(12.1, 12.1, 24.2), 2 * time.Hour, 24.2, 2, 2.5, 24.2, 2, "rising"},
+		{"falling before now", hourlyFlows(100, 50, 20), 90 * time.Minute, 100, 0, 9.09, 50, 1, "falling"},
+		{"steady within tolerance", hourlyFlows(100, 100.5), 5 * time.Hour, 100.5, 1, 8.29, 100.5, 1, "steady"},
+		{"small flows use an absolute tolerance", hourlyFlows(0.5, 0.505), time.Hour, 0.505, 1, 0.04, 0.505, 1, "steady"},
+		{"all values later than now", hourlyFlows(5, 8, 3), -time.Hour, 8, 1, 0.99, 5, 0, "steady"},
+		{"first peak wins a tie", hourlyFlows(3, 9, 9, 1), 3 * time.Hour, 9, 1, 1.65, 1, 3, "falling"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			stats := junctionFlowStats(tt.flows, flowStart.Add(tt.now))
+			if stats.Peak == nil || *stats.Peak != tt.peak {
+				t.Errorf("peak %v, want %g", stats.Peak, tt.peak)
+			}
+			if stats.TimeToPeakHours == nil || *stats.TimeToPeakHours != tt.toPeak {
+				t.Errorf("time to peak %v, want %g", stats.TimeToPeakHours, tt.toPeak)
+			}
+			if stats.Volume != tt.volume {
+				t.Errorf("volume %g, want %g", stats.Volume, tt.volume)
+			}
+			if stats.Current == nil || *stats.Current != tt.current {
+				t.Errorf("current %v, want %g", stats.Current, tt.current)
+			}
+			if want := flowStart.Add(time.Duration(tt.currentHour) * time.Hour); stats.CurrentTime == nil || !stats.CurrentTime.Equal(want) {
+				t.Errorf("current time %v, want %v", stats.CurrentTime, want)
+			}
+			if stats.Trend != tt.trend {
+				t.Errorf("trend %s, want %s", stats.Trend, tt.trend)
+			}
+		})
+	}
+}
+
+func TestJunctionFlowStatsEmpty(t *testing.T) {
+	stats := junctionFlowStats(nil, flowStart)
+	if stats.Peak != nil || stats.Current != nil || stats.Volume != 0 || stats.Trend != "steady" {
+		t.Errorf("stats of no flows = %+v", stats)
+	}
+}
+
+func TestJunctionFlowStatsSkipsMissing(t *testing.T) {
+	flows := hourlyFlows(12.1, -3.402823466e38, 24.2, math.NaN())
+	stats := junctionFlowStats(flows, flowStart.Add(3*time.Hour))
+	if stats.Peak == nil || *stats.Peak != 24.2 || *stats.TimeToPeakHours != 2 {
+		t.Errorf("peak %v after %v hours, want 24.2 after 2", stats.Peak, stats.TimeToPeakHours)
+	}
+	// One trapezoid from 00:00 to 02:00 across the missing hour
+	if stats.Volume != 3 {
+		t.Errorf("volume %g, want 3", stats.Volume)
+	}
+	if stats.Current == nil || *stats.Current != 24.2 || stats.Trend != "rising" {
+		t.Errorf("current %v trend %s, want 24.2 rising", stats.Current, stats.Trend)
+	}
+}
+
+func TestJunctionTimedFlowsSkipsMissing(t *testing.T) {
+	s := JunctionFlowSeries{Data: []JunctionFlowPoint{
+		{Time: "01 May 2024T00:00", Value: 5},
+		{Time: "01 May 2024T01:00", Value: -3.402823466e38},
+		{Time: "not a time", Value: 7},
+		{Time: "01 May 2024T02:00", Value: 9},
+	}}
+	got := junctionTimedFlows(s)
+	if len(got) != 2 || got[0].v != 5 || got[1].v != 9 || !got[1].t.Equal(flowStart.Add(2*time.Hour)) {
+		t.Errorf("timed flows = %+v, want 5 at 00:00 and 9 at 02:00", got)
+	}
+}
+
+func TestJunctionFlowStatsStages(t *testing.T) {
+	flows := hourlyFlows(10, 40, 20)
+	for i := range flows {
+		stage := flows[i].v / 10
+		flows[i].stage = &stage
+	}
+	stats := junctionFlowStats(flows, flowStart.Add(2*time.Hour))
+	if stats.PeakStage == nil || *stats.PeakStage != 4 {
+		t.Errorf("peak stage %v, want 4", stats.PeakStage)
+	}
+	if stats.CurrentStage == nil || *stats.CurrentStage != 2 {
+		t.Errorf("current stage %v, want 2", stats.CurrentStage)
+	}
+}
+
+func TestResampleFlows(t *testing.T) {
+	quarterHourly := make([]timedFlow, 9) // 00:00 to 02:00
+	for i := range quarterHourly {
+		quarterHourly[i] = timedFlow{t: flowStart.Add(time.Duration(i) * 15 * time.Minute), v: float64(i)}
+	}
+	tests := []struct {
+		name     string
+		flows    []timedFlow
+		interval time.Duration
+		want     []float64
+	}{
+		{"hourly", quarterHourly, time.Hour, []float64{0, 4, 8}},
+		{"half-hourly", quarterHourly, 30 * time.Minute, []float64{0, 2, 4, 6, 8}},
+		{"same interval keeps all", quarterHourly, 15 * time.Minute, []float64{0, 1, 2, 3, 4, 5, 6, 7, 8}},
+		{"off-hour start", quarterHourly[1:], time.Hour, []float64{4, 8}},
+		{"six-hourly aligns to UTC", hourlyFlows(0, 1, 2, 3, 4, 5, 6, 7), 6 * time.Hour, []float64{0, 6}},
+		{"none", nil, time.Hour, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := resampleFlows(tt.flows, tt.interval)
+			if len(got) != len(tt.want) {
+				t.Fatalf("got %d values, want %d", len(got), len(tt.want))
+			}
+			for i, f := range got {
+				if f.v != tt.want[i] {
+					t.Errorf("value %d = %g, want %g", i, f.v, tt.want[i])
+				}
+			}
+		})
+	}
+}
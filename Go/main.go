@@ -243,16 +243,71 @@ func main() {
 	e.POST("/api/modify/user", handleModifyUser(queries))
 
 	// HMS processing pipeline endpoint
-	e.POST("/api/run-hms-pipeline", handleRunHMSPipeline)
-
-	e.GET("/api/get-all-junction-flows", handleGetAllJunctionFlows)
-
-	e.GET("/api/precip/latest", handelGetLatestPrecip)
+	e.POST("/api/run-hms-pipeline", handleRunHMSPipeline(queries))
+	e.POST("/api/run-hms-pipeline-ensemble", handleRunHMSPipelineEnsemble(queries))
+
+	// Design storm, what-if and other scenarios
+	e.POST("/api/scenarios/design-storm", handleRunDesignStorm(queries))
+	e.POST("/api/scenarios/what-if", handleRunWhatIf(queries))
+	e.GET("/api/scenarios", handleListScenarios(queries))
+	e.GET("/api/scenarios/:model/:name", handleGetScenario(queries))
+	e.DELETE("/api/scenarios/:model/:name", handleDeleteScenario(queries))
+
+	// Parameter calibration against observed flows
+	e.POST("/api/calibrations", handleStartCalibration(queries))
+	e.GET("/api/calibrations", handleListCalibrations(queries))
+	e.GET("/api/calibrations/:id", handleGetCalibration(queries))
+
+	// Library of saved historical runs
+	e.GET("/api/historical-events", handleListHistoricalEvents(queries))
+	e.GET("/api/historical-events/compare", handleCompareHistoricalEvents(queries))
+	e.GET("/api/historical-events/:model/:name", handleGetHistoricalEvent(queries))
+	e.GET("/api/historical-events/:model/:name/files/:file", handleGetHistoricalEventFile(queries))
+	e.DELETE("/api/historical-events/:model/:name", handleDeleteHistoricalEvent(queries))
+
+	e.GET("/api/get-all-junction-flows", handleGetAllJunctionFlows(queries))
+	e.GET("/api/junctions", handleListJunctions(queries))
+	e.GET("/api/junctions/:name/flows", handleGetJunctionFlows(queries))
+	e.GET("/api/junctions/:name/forecasts", handleGetJunctionForecasts(queries))
+	e.GET("/api/junctions/:name/forecast-vs-observed", handleGetForecastVsObserved(queries))
+	e.GET("/api/junction-flow-runs", handleListJunctionFlowRuns(queries))
+
+	e.POST("/api/streamflow-observations", handleUploadStreamflow(queries))
+	e.POST("/api/verifications", handleRunVerification(queries))
+	e.GET("/api/verifications", handleListVerifications(queries))
+	e.GET("/api/verifications/:id", handleGetVerification(queries))
+
+	e.GET("/api/thresholds", handleListThresholds(queries))
+	e.GET("/api/thresholds/:model/:junction", handleGetThreshold(queries))
+	e.PUT("/api/thresholds/:model/:junction", handlePutThreshold(queries))
+	e.DELETE("/api/thresholds/:model/:junction", handleDeleteThreshold(queries))
+	e.GET("/api/rating-curves", handleListRatingCurves(queries))
+	e.GET("/api/rating-curves/:model/:junction", handleGetRatingCurve(queries))
+	e.PUT("/api/rating-curves/:model/:junction", handlePutRatingCurve(queries))
+	e.DELETE("/api/rating-curves/:model/:junction", handleDeleteRatingCurve(queries))
+
+	e.GET("/api/precip/latest", handelGetLatestPrecip(queries))
+	e.GET("/api/precip/accumulation", handleGetPrecipAccumulation(queries))
+	e.GET("/api/precip/catalog", handleGetPrecipCatalog(queries))
+	e.GET("/api/precip/point", handleGetPrecipPoint)
+	e.GET("/api/precip/summary", handleGetPrecipSummary(queries))
+	e.GET("/api/tiles/precip/:timestamp/:z/:x/:y", handleGetPrecipTile)
+	e.GET("/api/precip/bias", handleGetPrecipBias(queries))
+
+	// Rain gauge observations
+	e.POST("/api/gauges/upload", handleUploadGaugeCSV(queries))
+	e.GET("/api/gauges", handleListGauges(queries))
+	e.GET("/api/gauges/:station/observations", handleGetGaugeObservations(queries))
+
+	// HMS model registry and topology
+	e.GET("/api/models", handleListModels)
+	e.GET("/api/models/:model/elements", handleGetModelElements)
+	e.GET("/api/models/:model/elements/:element/graph", handleGetElementGraph)
 
 	//Historical API Calls
-	e.POST("/api/run-hms-pipeline-historical", handleRunHMSPipelineHistorical)
+	e.POST("/api/run-hms-pipeline-historical", handleRunHMSPipelineHistorical(queries))
+	e.GET("/api/pipeline-runs/:id", handleGetPipelineRun(queries))
 	e.POST("/api/extract-historical-dss-data", handleExtractHistoricalDSSData)
-	
 	// SMS API endpoint
 	e.POST("/api/send-sms", handleSendSMS)
 
@@ -262,7 +317,7 @@ func main() {
 	)
 
 	// Start the scheduler
-	StartScheduler() // This will run the archive and pipeline trigger task at HH:15
+	StartScheduler(queries) // This will run the archive and pipeline trigger task at HH:15
 
 	// Start server with TLS
 	if err := e.StartTLS(":"+port, AppConfig.Server.TLSCertPath, AppConfig.Server.TLSKeyPath); err != nil {
@@ -304,3 +359,12 @@ func parseTimeString(timeStr string) (time.Time, error) {
 
 	return time.Time{}, fmt.Errorf("no matching time format found for: %s", timeStr)
 }
+
+// parseQueryTime parses a time query parameter, accepting RFC3339
+// (e.g. 2025-05-19T21:00:00Z) as well as the formats of parseTimeString
+func parseQueryTime(value string) (time.Time, error) {
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return t.UTC(), nil
+	}
+	return parseTimeString(value)
+}
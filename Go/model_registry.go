@@ -0,0 +1,164 @@
+package main
+
+import (
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// legacyModelID is the model built from the pre-registry hms settings
+const legacyModelID = "leon_creek"
+
+// legacyLeonCreekModel describes the Leon Creek project the way the backend
+// used it before the models registry, so existing configs keep working
+func legacyLeonCreekModel() ModelConfig {
+	leon := AppConfig.HMS.LeonCreekModel
+	return ModelConfig{
+		Name:                  "Leon Creek",
+		ProjectName:           "Leon_46",
+		Dir:                   filepath.Join(AppConfig.Paths.HMSModelsDir, "LeonCreek"),
+		HistoricalDir:         filepath.Join(AppConfig.Paths.HMSHistoricalModelsDir, "LeonCreek"),
+		BasinFile:             firstNonEmpty(leon.BasinFile, "Leon_Watershed.basin"),
+		RealTimeRun:           "RainrealTime",
+		HistoricalRun:         "RainHistorical",
+		RealTimeControlFile:   firstNonEmpty(AppConfig.HMS.RealTimeControlFile, "RainRealTime.control"),
+		HistoricalControlFile: firstNonEmpty(AppConfig.HMS.HistoricalControlFile, "RainHistorical.control"),
+		RainfallDir:           leon.RainfallDir,
+		RealTimeDSS:           firstNonEmpty(leon.RealTimeDSS, "RainrealTime.dss"),
+		HistoricalDSS:         firstNonEmpty(leon.HistoricalDSS, "RainHistorical.dss"),
+		FilesToDelete:         leon.FilesToDelete,
+		ArchiveDir:            AppConfig.Paths.DSSArchiveDir,
+		JSONOutput:            "output.json",
+		HistoricalJSONOutput:  "outputHistorical.json",
+		EnsembleJSONOutput:    "outputEnsemble.json",
+	}
+}
+
+func firstNonEmpty(values ...string) string {
+	for _, v := range values {
+		if v != "" {
+			return v
+		}
+	}
+	return ""
+}
+
+// resolvePath makes a model file name absolute relative to dir
+func resolvePath(dir, path string) string {
+	windowsAbs := len(path) > 2 && path[1] == ':' && (path[2] == '/' || path[2] == '\\')
+	if path == "" || filepath.IsAbs(path) || strings.HasPrefix(path, "/") || windowsAbs {
+		return filepath.ToSlash(path)
+	}
+	return filepath.ToSlash(filepath.Join(dir, path))
+}
+
+// buildModelRegistry fills in the defaults of every configured model (or
+// the legacy Leon Creek model when none are configured) and checks that the
+// default model exists
+func buildModelRegistry() error {
+	if len(AppConfig.Models) == 0 {
+		AppConfig.Models = map[string]ModelConfig{legacyModelID: legacyLeonCreekModel()}
+	}
+
+	for id, m := range AppConfig.Models {
+		m.ID = id
+		if m.Dir == "" {
+			return fmt.Errorf("model %s: dir is required", id)
+		}
+		if m.ProjectName == "" {
+			return fmt.Errorf("model %s: project_name is required", id)
+		}
+		m.Name = firstNonEmpty(m.Name, id)
+		m.HistoricalDir = firstNonEmpty(m.HistoricalDir, m.Dir)
+		m.RealTimeRun = firstNonEmpty(m.RealTimeRun, "RainRealTime")
+		m.HistoricalRun = firstNonEmpty(m.HistoricalRun, "RainHistorical")
+
+		m.Dir = filepath.ToSlash(m.Dir)
+		m.HistoricalDir = filepath.ToSlash(m.HistoricalDir)
+		m.BasinFile = resolvePath(m.Dir, firstNonEmpty(m.BasinFile, m.ProjectName+".basin"))
+		m.RealTimeControlFile = resolvePath(m.Dir, firstNonEmpty(m.RealTimeControlFile, m.RealTimeRun+".control"))
+		m.HistoricalControlFile = resolvePath(m.HistoricalDir, firstNonEmpty(m.HistoricalControlFile, m.HistoricalRun+".control"))
+		m.ClipShapefile = filepath.ToSlash(m.ClipShapefile)
+		m.RainfallDir = resolvePath(m.Dir, firstNonEmpty(m.RainfallDir, "Rainfall"))
+		m.HistoricalRainfallDir = resolvePath(m.HistoricalDir, firstNonEmpty(m.HistoricalRainfallDir, "Rainfall"))
+		m.RealTimeDSS = resolvePath(m.Dir, firstNonEmpty(m.RealTimeDSS, m.RealTimeRun+".dss"))
+		m.HistoricalDSS = resolvePath(m.HistoricalDir, firstNonEmpty(m.HistoricalDSS, m.HistoricalRun+".dss"))
+		for i, f := range m.FilesToDelete {
+			m.FilesToDelete[i] = resolvePath(m.RainfallDir, f)
+		}
+		m.ArchiveDir = filepath.ToSlash(firstNonEmpty(m.ArchiveDir, filepath.Join(AppConfig.Paths.DSSArchiveDir, id)))
+		m.JSONOutput = firstNonEmpty(m.JSONOutput, "output_"+id+".json")
+		m.HistoricalJSONOutput = firstNonEmpty(m.HistoricalJSONOutput, "outputHistorical_"+id+".json")
+		m.EnsembleJSONOutput = firstNonEmpty(m.EnsembleJSONOutput, "outputEnsemble_"+id+".json")
+		AppConfig.Models[id] = m
+	}
+
+	if AppConfig.DefaultModel == "" {
+		ids := ModelIDs()
+		AppConfig.DefaultModel = ids[0]
+		if _, ok := AppConfig.Models[legacyModelID]; ok {
+			AppConfig.DefaultModel = legacyModelID
+		}
+	}
+	if _, ok := AppConfig.Models[AppConfig.DefaultModel]; !ok {
+		return fmt.Errorf("default_model %q is not in models", AppConfig.DefaultModel)
+	}
+	return nil
+}
+
+// ModelIDs returns the registered model IDs, sorted
+func ModelIDs() []string {
+	ids := make([]string, 0, len(AppConfig.Models))
+	for id := range AppConfig.Models {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	return ids
+}
+
+// GetModel returns a registered model; an empty ID selects the default model
+func GetModel(id string) (ModelConfig, error) {
+	if id == "" {
+		id = AppConfig.DefaultModel
+	}
+	m, ok := AppConfig.Models[strings.ToLower(id)]
+	if !ok {
+		return ModelConfig{}, fmt.Errorf("%w %q", errUnknownModel, id)
+	}
+	return m, nil
+}
+
+// RainfallPath returns a precipitation DSS file of the model's realtime project
+func (m ModelConfig) RainfallPath(filename string) string {
+	return filepath.Join(m.RainfallDir, filename)
+}
+
+// HistoricalRainfallPath returns a precipitation DSS file of the historical project
+func (m ModelConfig) HistoricalRainfallPath(filename string) string {
+	return filepath.Join(m.HistoricalRainfallDir, filename)
+}
+
+// ControlFile returns the control file of a run type ("realtime" or "historical")
+func (m ModelConfig) ControlFile(runType string) string {
+	if runType == pipelineRunHistorical {
+		return m.HistoricalControlFile
+	}
+	return m.RealTimeControlFile
+}
+
+// ProjectDir returns the project directory a run type computes in
+func (m ModelConfig) ProjectDir(runType string) string {
+	if runType == pipelineRunHistorical {
+		return m.HistoricalDir
+	}
+	return m.Dir
+}
+
+// RunName returns the HMS simulation run of a run type
+func (m ModelConfig) RunName(runType string) string {
+	if runType == pipelineRunHistorical {
+		return m.HistoricalRun
+	}
+	return m.RealTimeRun
+}
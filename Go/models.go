@@ -0,0 +1,169 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"HMSBackend/hms"
+
+	"github.com/labstack/echo/v4"
+)
+
+var errUnknownModel = errors.New("unknown model")
+
+// basinCache keeps parsed basin models until their file changes
+var basinCache = struct {
+	sync.Mutex
+	entries map[string]cachedBasin
+}{entries: map[string]cachedBasin{}}
+
+type cachedBasin struct {
+	modTime time.Time
+	basin   *hms.Basin
+}
+
+// loadBasinModel parses a model's .basin file, reusing the last parse while
+// the file is unchanged
+func loadBasinModel(model string) (*hms.Basin, error) {
+	m, err := GetModel(model)
+	if err != nil {
+		return nil, err
+	}
+	path := m.BasinFile
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, err
+	}
+
+	basinCache.Lock()
+	defer basinCache.Unlock()
+	if c, ok := basinCache.entries[path]; ok && c.modTime.Equal(info.ModTime()) {
+		return c.basin, nil
+	}
+	basin, err := hms.ReadBasinFile(path)
+	if err != nil {
+		return nil, err
+	}
+	basinCache.entries[path] = cachedBasin{modTime: info.ModTime(), basin: basin}
+	return basin, nil
+}
+
+// respondWithModelError maps basin loading errors to HTTP responses
+func respondWithModelError(c echo.Context, model string, err error) error {
+	if errors.Is(err, errUnknownModel) {
+		return respondWithError(c, http.StatusNotFound, err.Error())
+	}
+	log.Printf("Error loading basin model %s: %v", model, err)
+	return respondWithError(c, http.StatusInternalServerError, "Failed to load basin model")
+}
+
+// handleListModels serves GET /api/models
+func handleListModels(c echo.Context) error {
+	models := []ModelSummary{}
+	for _, id := range ModelIDs() {
+		m := AppConfig.Models[id]
+		models = append(models, ModelSummary{
+			ID:            m.ID,
+			Name:          m.Name,
+			ProjectName:   m.ProjectName,
+			MetModel:      m.MetModel,
+			RealTimeRun:   m.RealTimeRun,
+			HistoricalRun: m.HistoricalRun,
+			Default:       id == AppConfig.DefaultModel,
+		})
+	}
+	return respondWithJSON(c, http.StatusOK, models)
+}
+
+func modelElement(basin *hms.Basin, e *hms.Element) ModelElement {
+	upstream := basin.DirectUpstream(e.Name)
+	if upstream == nil {
+		upstream = []string{}
+	}
+	return ModelElement{
+		Name:        e.Name,
+		Type:        strings.ToLower(e.Kind),
+		Description: e.Description,
+		Downstream:  e.Downstream,
+		DivertTo:    e.DivertTo,
+		Upstream:    upstream,
+		Area:        e.Area,
+		Parameters:  e.Parameters,
+	}
+}
+
+// handleGetModelElements serves GET /api/models/:model/elements, optionally
+// filtered by ?type=subbasin|reach|junction|reservoir|source|sink|diversion
+func handleGetModelElements(c echo.Context) error {
+	model := c.Param("model")
+	basin, err := loadBasinModel(model)
+	if err != nil {
+		return respondWithModelError(c, model, err)
+	}
+
+	kind := strings.ToLower(c.QueryParam("type"))
+	elements := []ModelElement{}
+	for _, e := range basin.Elements {
+		if kind != "" && strings.ToLower(e.Kind) != kind {
+			continue
+		}
+		elements = append(elements, modelElement(basin, e))
+	}
+
+	return respondWithJSON(c, http.StatusOK, ModelElementsResponse{
+		Model:      model,
+		Basin:      basin.Name,
+		UnitSystem: basin.UnitSystem,
+		Elements:   elements,
+	})
+}
+
+// handleGetElementGraph serves GET /api/models/:model/elements/:element/graph:
+// everything upstream of the element (and the subbasins among it) and the
+// path from the element to the outlet
+func handleGetElementGraph(c echo.Context) error {
+	model := c.Param("model")
+	basin, err := loadBasinModel(model)
+	if err != nil {
+		return respondWithModelError(c, model, err)
+	}
+
+	name := c.Param("element")
+	e, ok := basin.Element(name)
+	if !ok {
+		return respondWithError(c, http.StatusNotFound, fmt.Sprintf("Element %s not found in model %s", name, model))
+	}
+	upstream, _ := basin.Upstream(name)
+	downstream, _ := basin.Downstream(name)
+	area, _ := basin.DrainageArea(name)
+
+	subbasins := []string{}
+	for _, n := range upstream {
+		if up, _ := basin.Element(n); up.Kind == hms.Subbasin {
+			subbasins = append(subbasins, n)
+		}
+	}
+	sort.Strings(subbasins)
+	if upstream == nil {
+		upstream = []string{}
+	}
+	if downstream == nil {
+		downstream = []string{}
+	}
+
+	return respondWithJSON(c, http.StatusOK, ElementGraphResponse{
+		Model:             model,
+		Element:           modelElement(basin, e),
+		Upstream:          upstream,
+		UpstreamSubbasins: subbasins,
+		Downstream:        downstream,
+		DrainageArea:      area,
+	})
+}
@@ -0,0 +1,98 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"HMSBackend/sqlcdb"
+
+	"github.com/labstack/echo/v4"
+)
+
+const (
+	pipelineRunRealtime    = "realtime"
+	pipelineRunHistorical  = "historical"
+	pipelineRunEnsemble    = "ensemble"
+	pipelineRunCalibration = "calibration"
+
+	pipelineStatusSucceeded = "succeeded"
+	pipelineStatusFailed    = "failed"
+)
+
+// startPipelineRun records the start of a pipeline run and returns its id.
+// A run that cannot be recorded returns 0; the pipeline itself still runs.
+func startPipelineRun(ctx context.Context, queries *sqlcdb.Queries, runType string, params interface{}) int32 {
+	body, err := json.Marshal(params)
+	if err != nil {
+		body = []byte("{}")
+	}
+	run, err := queries.CreatePipelineRun(ctx, sqlcdb.CreatePipelineRunParams{
+		RunType:    runType,
+		Parameters: body,
+	})
+	if err != nil {
+		log.Printf("Warning: Failed to record %s pipeline run: %v", runType, err)
+		return 0
+	}
+	log.Printf("INFO: Recorded %s pipeline run %d", runType, run.ID)
+	return run.ID
+}
+
+// finishPipelineRun stores the outcome of a run started with startPipelineRun.
+// It uses its own context since the pipeline's may already be cancelled.
+func finishPipelineRun(queries *sqlcdb.Queries, runID int32, runErr error) {
+	if runID == 0 {
+		return
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	params := sqlcdb.FinishPipelineRunParams{ID: runID, Status: pipelineStatusSucceeded}
+	if runErr != nil {
+		params.Status = pipelineStatusFailed
+		params.ErrorMessage = runErr.Error()
+	}
+	if err := queries.FinishPipelineRun(ctx, params); err != nil {
+		log.Printf("Warning: Failed to update pipeline run %d: %v", runID, err)
+	}
+}
+
+// setPipelineRunProgress stores the progress of a long run for polling
+// clients. Failures are only logged.
+func setPipelineRunProgress(ctx context.Context, queries *sqlcdb.Queries, runID int32, progress interface{}) {
+	if runID == 0 {
+		return
+	}
+	body, err := json.Marshal(progress)
+	if err != nil {
+		return
+	}
+	if err := queries.SetPipelineRunProgress(ctx, sqlcdb.SetPipelineRunProgressParams{ID: runID, Progress: body}); err != nil {
+		log.Printf("Warning: Failed to update progress of pipeline run %d: %v", runID, err)
+	}
+}
+
+// handleGetPipelineRun returns a pipeline run with its status and progress
+func handleGetPipelineRun(queries *sqlcdb.Queries) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		id, err := strconv.Atoi(c.Param("id"))
+		if err != nil {
+			return respondWithError(c, http.StatusBadRequest, "Invalid pipeline run id")
+		}
+		run, err := queries.GetPipelineRun(c.Request().Context(), int32(id))
+		if errors.Is(err, sql.ErrNoRows) {
+			return respondWithError(c, http.StatusNotFound, "Pipeline run not found")
+		}
+		if err != nil {
+			log.Printf("Error getting pipeline run %d: %v", id, err)
+			return respondWithError(c, http.StatusInternalServerError, "Failed to get pipeline run")
+		}
+		return respondWithJSON(c, http.StatusOK, run)
+	}
+}
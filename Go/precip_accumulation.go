@@ -0,0 +1,472 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log"
+	"math"
+	"net/http"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"HMSBackend/grib2"
+	"HMSBackend/sqlcdb"
+
+	"github.com/labstack/echo/v4"
+)
+
+const (
+	precipKindObserved = "observed"
+	precipKindForecast = "forecast"
+
+	// accumulationTagLayout is appended to the product name to form the COG
+	// file name, e.g. qpe_06h_20250519_2134Z.tif
+	accumulationTagLayout = "20060102_1504Z"
+
+	mmPerInch = 25.4
+)
+
+// accumulationProductName returns the product identifier for a kind and duration
+func accumulationProductName(kind string, hours int) string {
+	if kind == precipKindForecast {
+		return fmt.Sprintf("hrrr_%02dh", hours)
+	}
+	return fmt.Sprintf("qpe_%02dh", hours)
+}
+
+// parseAccumulationHours parses durations such as "6h", "06H" or "6"
+func parseAccumulationHours(duration string) (int, error) {
+	d := strings.TrimSuffix(strings.ToLower(strings.TrimSpace(duration)), "h")
+	hours, err := strconv.Atoi(d)
+	if err != nil || hours <= 0 {
+		return 0, fmt.Errorf("invalid duration %q, expected a value like 6h", duration)
+	}
+	return hours, nil
+}
+
+// configuredAccumulationHours returns the durations published for a kind
+func configuredAccumulationHours(kind string) []int {
+	if kind == precipKindForecast {
+		return AppConfig.Precip.ForecastHours
+	}
+	return AppConfig.Precip.ObservedHours
+}
+
+// BuildPrecipAccumulations builds every configured observed and forecast
+// accumulation product. Failures are logged per product so one missing source
+// does not block the others.
+func BuildPrecipAccumulations(ctx context.Context, queries *sqlcdb.Queries) {
+	log.Println("Precip: Building accumulation products...")
+	for _, kind := range []string{precipKindObserved, precipKindForecast} {
+		for _, hours := range configuredAccumulationHours(kind) {
+			acc, err := buildAccumulation(ctx, queries, kind, hours)
+			if err != nil {
+				log.Printf("Precip: Failed to build %s: %v", accumulationProductName(kind, hours), err)
+				continue
+			}
+			log.Printf("Precip: Built %s ending %s (max %.2f in)", acc.Product, acc.End.Format(time.RFC3339), acc.Stats.Max)
+		}
+	}
+	log.Println("Precip: Accumulation products finished.")
+}
+
+// accumulationBuilds serializes the builds of each product and tracks those
+// the API started in the background
+var accumulationBuilds = struct {
+	sync.Mutex
+	locks   map[string]*sync.Mutex
+	pending map[string]bool
+}{locks: map[string]*sync.Mutex{}, pending: map[string]bool{}}
+
+// startAccumulationBuild builds a product in the background unless the API
+// already started a build of it
+func startAccumulationBuild(queries *sqlcdb.Queries, kind string, hours int) {
+	product := accumulationProductName(kind, hours)
+	accumulationBuilds.Lock()
+	defer accumulationBuilds.Unlock()
+	if accumulationBuilds.pending[product] {
+		return
+	}
+	accumulationBuilds.pending[product] = true
+
+	go func() {
+		defer func() {
+			accumulationBuilds.Lock()
+			delete(accumulationBuilds.pending, product)
+			accumulationBuilds.Unlock()
+		}()
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Minute)
+		defer cancel()
+		if _, err := buildAccumulation(ctx, queries, kind, hours); err != nil {
+			log.Printf("Precip: Failed to build %s: %v", product, err)
+		}
+	}()
+}
+
+// buildAccumulation builds a single product and records it in the COG
+// catalog. Builds of the same product run one at a time.
+func buildAccumulation(ctx context.Context, queries *sqlcdb.Queries, kind string, hours int) (*PrecipAccumulation, error) {
+	product := accumulationProductName(kind, hours)
+	accumulationBuilds.Lock()
+	lock, ok := accumulationBuilds.locks[product]
+	if !ok {
+		lock = &sync.Mutex{}
+		accumulationBuilds.locks[product] = lock
+	}
+	accumulationBuilds.Unlock()
+	lock.Lock()
+	defer lock.Unlock()
+
+	var acc *PrecipAccumulation
+	var err error
+	if kind == precipKindForecast {
+		acc, err = buildForecastAccumulation(ctx, hours)
+	} else {
+		acc, err = buildObservedAccumulation(ctx, hours)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := recordPrecipCog(ctx, queries, acc); err != nil {
+		return nil, err
+	}
+	return acc, nil
+}
+
+// buildObservedAccumulation publishes the newest MRMS <product>_<NN>H grid
+func buildObservedAccumulation(ctx context.Context, hours int) (*PrecipAccumulation, error) {
+	product := accumulationProductName(precipKindObserved, hours)
+	indexURL := fmt.Sprintf("%s%s_%02dH/", AppConfig.URLs.MRMSProducts, AppConfig.Precip.ObservedProduct, hours)
+
+	// Download under a name of its own, renamed below once its time is known
+	if err := os.MkdirAll(AppConfig.Precip.AccumulationGribDir, 0755); err != nil {
+		return nil, fmt.Errorf("failed to create directory %s: %w", AppConfig.Precip.AccumulationGribDir, err)
+	}
+	tmp, err := os.CreateTemp(AppConfig.Precip.AccumulationGribDir, product+"_*.grib2.part")
+	if err != nil {
+		return nil, err
+	}
+	tmp.Close()
+	downloadPath := tmp.Name()
+	defer os.Remove(downloadPath) // gone after the rename
+	if _, err := fetchLatestMRMSGrib(ctx, indexURL, downloadPath); err != nil {
+		return nil, fmt.Errorf("failed to fetch %s: %w", indexURL, err)
+	}
+
+	msg, err := firstGribMessage(downloadPath)
+	if err != nil {
+		return nil, err
+	}
+	end := msg.ValidTime()
+	tag := product + "_" + end.Format(accumulationTagLayout)
+
+	// Keep the source grid under its own name so later requests can find it
+	gribPath := filepath.Join(AppConfig.Precip.AccumulationGribDir, tag+".grib2")
+	if err := os.Rename(downloadPath, gribPath); err != nil {
+		return nil, fmt.Errorf("failed to store GRIB file %s: %w", gribPath, err)
+	}
+
+	return publishAccumulation(gribPath, tag, PrecipAccumulation{
+		Product:       product,
+		Kind:          precipKindObserved,
+		DurationHours: hours,
+		Start:         end.Add(-time.Duration(hours) * time.Hour),
+		End:           end,
+	})
+}
+
+// hrrrForecastFilePattern matches files saved by downloadHRRRForecastGRIB
+var hrrrForecastFilePattern = regexp.MustCompile(`^hrrr\.t(\d{2})z\.wrfsfcf(\d{2})\.grib2$`)
+
+// latestHRRRForecastFile finds the newest downloaded HRRR file for the given
+// forecast hour, looking in today's and yesterday's download folders.
+func latestHRRRForecastFile(forecastHour int) (string, error) {
+	var best string
+	var bestCycle time.Time
+	for _, dateStr := range gribSearchDates(1) {
+		entries, err := os.ReadDir(GetGribDownloadPath(dateStr))
+		if err != nil {
+			continue
+		}
+		for _, entry := range entries {
+			m := hrrrForecastFilePattern.FindStringSubmatch(entry.Name())
+			if m == nil {
+				continue
+			}
+			if fh, _ := strconv.Atoi(m[2]); fh != forecastHour {
+				continue
+			}
+			cycle, err := time.Parse("2006010215", dateStr+m[1])
+			if err != nil {
+				continue
+			}
+			if best == "" || cycle.After(bestCycle) {
+				best = filepath.Join(GetGribDownloadPath(dateStr), entry.Name())
+				bestCycle = cycle
+			}
+		}
+	}
+	if best == "" {
+		return "", fmt.Errorf("no HRRR f%02d file found in %s", forecastHour, AppConfig.Paths.GrbDownloadsDir)
+	}
+	return best, nil
+}
+
+// buildForecastAccumulation publishes the HRRR total precipitation accumulated
+// from the cycle start to forecast hour `hours`, regridded to lat/lon.
+func buildForecastAccumulation(ctx context.Context, hours int) (*PrecipAccumulation, error) {
+	product := accumulationProductName(precipKindForecast, hours)
+
+	sourcePath, err := latestHRRRForecastFile(hours)
+	if err != nil {
+		// Nothing downloaded yet (e.g. the pipeline has not run); fetch the
+		// previous hour's cycle the same way the pipeline does
+		runTime := time.Now().UTC().Add(-1 * time.Hour)
+		log.Printf("Precip: %v; downloading HRRR cycle %sZ", err, runTime.Format("15"))
+		if dlErr := downloadHRRRForecastGRIB(runTime.Format("20060102"), runTime.Format("15")); dlErr != nil {
+			return nil, fmt.Errorf("failed to download HRRR forecast: %w", dlErr)
+		}
+		if sourcePath, err = latestHRRRForecastFile(hours); err != nil {
+			return nil, err
+		}
+	}
+
+	var apcp *grib2.Message
+	err = grib2.ScanFile(sourcePath, func(m *grib2.Message) bool {
+		p := m.Product
+		if m.Discipline == 0 && p.Category == 1 && p.Number == 8 && p.IsAccumulation() &&
+			p.ForecastOffset() == 0 && p.IntervalDuration() == time.Duration(hours)*time.Hour {
+			apcp = m
+			return false
+		}
+		return true
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", sourcePath, err)
+	}
+	if apcp == nil {
+		return nil, fmt.Errorf("no 0-%d hour APCP field in %s", hours, sourcePath)
+	}
+	values, err := apcp.Values()
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode APCP from %s: %w", sourcePath, err)
+	}
+
+	bounds := AppConfig.Precip.ForecastBounds
+	if len(bounds) != 4 {
+		return nil, fmt.Errorf("precip.forecast_bounds must have 4 values, got %d", len(bounds))
+	}
+	dst := grib2.NewLatLonGrid([4]float64{bounds[0], bounds[1], bounds[2], bounds[3]}, AppConfig.Precip.ForecastResolution)
+	regridded := grib2.Regrid(apcp.Grid, values, dst)
+
+	cycle := apcp.ReferenceTime
+	end := cycle.Add(time.Duration(hours) * time.Hour)
+	out := grib2.NewMessage(0, apcp.Centre, cycle, dst, grib2.Product{
+		Template:           8,
+		Category:           1,
+		Number:             8,
+		TimeUnit:           1,
+		SurfaceType:        1,
+		StatisticalProcess: 1,
+		IntervalUnit:       1,
+		IntervalLength:     hours,
+		IntervalEnd:        end,
+	})
+	if err := out.Pack(regridded, 2); err != nil {
+		return nil, fmt.Errorf("failed to pack regridded HRRR field: %w", err)
+	}
+
+	tag := product + "_" + end.Format(accumulationTagLayout)
+	if err := os.MkdirAll(AppConfig.Precip.AccumulationGribDir, 0755); err != nil {
+		return nil, fmt.Errorf("failed to create directory %s: %w", AppConfig.Precip.AccumulationGribDir, err)
+	}
+	gribPath := filepath.Join(AppConfig.Precip.AccumulationGribDir, tag+".grib2")
+	if err := grib2.WriteFile(gribPath, out); err != nil {
+		return nil, fmt.Errorf("failed to write %s: %w", gribPath, err)
+	}
+
+	return publishAccumulation(gribPath, tag, PrecipAccumulation{
+		Product:       product,
+		Kind:          precipKindForecast,
+		DurationHours: hours,
+		Start:         cycle,
+		End:           end,
+	})
+}
+
+// publishAccumulation converts gribPath to a COG and fills in metadata and statistics
+func publishAccumulation(gribPath, tag string, acc PrecipAccumulation) (*PrecipAccumulation, error) {
+	meta, err := convertGRIBToCOG(gribPath, tag)
+	if err != nil {
+		return nil, err
+	}
+	// A rebuilt COG invalidates anything rendered from the previous file
+	closeCachedCog(meta.COGPath)
+	if err := os.RemoveAll(precipTileCacheDir(tag)); err != nil {
+		log.Printf("Precip: Failed to clear cached tiles for %s: %v", tag, err)
+	}
+	stats, err := computePrecipStats(gribPath)
+	if err != nil {
+		return nil, err
+	}
+	acc.Meta = *meta
+	acc.Meta.Units = "inches"
+	acc.Stats = stats
+	acc.SourceGrib = gribPath
+	acc.CreatedAt = time.Now().UTC()
+	return &acc, nil
+}
+
+// firstGribMessage returns the first message of a GRIB2 file
+func firstGribMessage(path string) (*grib2.Message, error) {
+	var msg *grib2.Message
+	err := grib2.ScanFile(path, func(m *grib2.Message) bool {
+		msg = m
+		return false
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to read GRIB file %s: %w", path, err)
+	}
+	return msg, nil
+}
+
+// computePrecipStats decodes a precipitation grid in millimetres and summarises
+// it in inches. Negative values (MRMS no-coverage flags) are ignored.
+func computePrecipStats(gribPath string) (PrecipStats, error) {
+	msg, err := firstGribMessage(gribPath)
+	if err != nil {
+		return PrecipStats{}, err
+	}
+	values, err := msg.Values()
+	if err != nil {
+		return PrecipStats{}, fmt.Errorf("failed to decode %s: %w", gribPath, err)
+	}
+
+	stats := PrecipStats{Min: math.Inf(1), Max: math.Inf(-1)}
+	sum := 0.0
+	wet := 0
+	for _, v := range values {
+		if math.IsNaN(float64(v)) || v < 0 {
+			continue
+		}
+		inches := float64(v) / mmPerInch
+		stats.Min = math.Min(stats.Min, inches)
+		stats.Max = math.Max(stats.Max, inches)
+		sum += inches
+		if inches >= 0.01 {
+			wet++
+		}
+		stats.ValidCells++
+	}
+	if stats.ValidCells == 0 {
+		return PrecipStats{}, nil
+	}
+	stats.Mean = sum / float64(stats.ValidCells)
+	stats.WetFraction = float64(wet) / float64(stats.ValidCells)
+	return stats, nil
+}
+
+// findAccumulation returns the newest product ending at or before end and no
+// more than an hour earlier. A zero end selects the newest product.
+func findAccumulation(ctx context.Context, queries *sqlcdb.Queries, product string, end time.Time) (*PrecipAccumulation, error) {
+	var row sqlcdb.PrecipCog
+	var err error
+	if end.IsZero() {
+		row, err = queries.GetLatestPrecipCog(ctx, product)
+	} else {
+		row, err = queries.GetPrecipCogEndingBetween(ctx, sqlcdb.GetPrecipCogEndingBetweenParams{
+			Product:    product,
+			AfterTime:  end.UTC().Add(-time.Hour),
+			BeforeTime: end.UTC(),
+		})
+	}
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return precipAccumulationFromCog(row), nil
+}
+
+// accumulationResponse is the JSON shape returned for a published accumulation
+func accumulationResponse(acc *PrecipAccumulation) echo.Map {
+	return echo.Map{
+		"product":        acc.Product,
+		"kind":           acc.Kind,
+		"duration_hours": acc.DurationHours,
+		"start":          acc.Start,
+		"end":            acc.End,
+		"timestamp":      acc.Meta.Timestamp,
+		"cog_url":        "/cogs/" + filepath.Base(acc.Meta.COGPath),
+		"bounds":         acc.Meta.Bounds,
+		"width":          acc.Meta.Width,
+		"height":         acc.Meta.Height,
+		"units":          acc.Meta.Units,
+		"stats":          acc.Stats,
+	}
+}
+
+// handleGetPrecipAccumulation serves GET /api/precip/accumulation?duration=6h&end=&kind=.
+// A product with nothing published yet is answered 202 while it is built.
+func handleGetPrecipAccumulation(queries *sqlcdb.Queries) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		hours, err := parseAccumulationHours(c.QueryParam("duration"))
+		if err != nil {
+			return respondWithError(c, http.StatusBadRequest, err.Error())
+		}
+
+		kind := c.QueryParam("kind")
+		if kind == "" {
+			kind = precipKindObserved
+		}
+		if kind != precipKindObserved && kind != precipKindForecast {
+			return respondWithError(c, http.StatusBadRequest, "kind must be 'observed' or 'forecast'")
+		}
+
+		supported := false
+		for _, h := range configuredAccumulationHours(kind) {
+			supported = supported || h == hours
+		}
+		if !supported {
+			return respondWithError(c, http.StatusBadRequest, fmt.Sprintf("%dh is not a configured %s duration", hours, kind))
+		}
+
+		var end time.Time
+		if endStr := c.QueryParam("end"); endStr != "" {
+			if end, err = parseQueryTime(endStr); err != nil {
+				return respondWithError(c, http.StatusBadRequest, "Invalid end time")
+			}
+		}
+
+		product := accumulationProductName(kind, hours)
+		acc, err := findAccumulation(c.Request().Context(), queries, product, end)
+		if err != nil {
+			log.Printf("Precip: Catalog lookup for %s failed: %v", product, err)
+			return respondWithError(c, http.StatusInternalServerError, "Failed to read precipitation catalog")
+		}
+
+		// Build the newest product in the background if nothing has been
+		// published yet; the client retries once it is in the catalog
+		if acc == nil && end.IsZero() {
+			startAccumulationBuild(queries, kind, hours)
+			return respondWithJSON(c, http.StatusAccepted, map[string]string{
+				"message": fmt.Sprintf("%s has not been published yet and is being built; retry shortly", product),
+				"status":  "building",
+				"product": product,
+			})
+		}
+		if acc == nil {
+			return respondWithError(c, http.StatusNotFound, fmt.Sprintf("No %s accumulation ending at %s", product, end.Format(time.RFC3339)))
+		}
+
+		return respondWithJSON(c, http.StatusOK, accumulationResponse(acc))
+	}
+}
@@ -0,0 +1,392 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"math"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"time"
+
+	"HMSBackend/grib2"
+	"HMSBackend/sqlcdb"
+
+	"github.com/labstack/echo/v4"
+)
+
+const (
+	biasMethodNone      = "none"
+	biasMethodMeanField = "mean_field"
+	biasMethodLocal     = "local"
+
+	// Corrected grids are written here, inside the run's download folder,
+	// and the MRMS merge steps read from it instead
+	biasCorrectedDir = "bias_corrected"
+)
+
+func validBiasMethod(method string) bool {
+	switch method {
+	case "", biasMethodNone, biasMethodMeanField, biasMethodLocal:
+		return true
+	}
+	return false
+}
+
+// resolveBiasOptions applies a request's optional overrides to the config
+func resolveBiasOptions(method string, apply *bool) (string, bool) {
+	if method == "" {
+		method = AppConfig.Gauges.BiasMethod
+	}
+	if method == "" {
+		method = biasMethodNone
+	}
+	applyBias := AppConfig.Gauges.ApplyBias
+	if apply != nil {
+		applyBias = *apply
+	}
+	return method, applyBias
+}
+
+func clampFactor(f float64) float64 {
+	cfg := AppConfig.Gauges
+	if cfg.MinFactor > 0 && f < cfg.MinFactor {
+		return cfg.MinFactor
+	}
+	if cfg.MaxFactor > 0 && f > cfg.MaxFactor {
+		return cfg.MaxFactor
+	}
+	return f
+}
+
+// distanceKm is the equirectangular distance, accurate enough at gauge spacing
+func distanceKm(lat1, lon1, lat2, lon2 float64) float64 {
+	x := (lon2 - lon1) * math.Cos((lat1+lat2)/2*math.Pi/180)
+	y := lat2 - lat1
+	return math.Hypot(x, y) * kmPerDegree
+}
+
+// computeBiasFactors pairs the gauge totals stored for (start, end] with the
+// MRMS totals at the gauge cells. Pairs where both reach gauges.min_total are
+// used: the mean-field factor is sum(gauge)/sum(radar) and every used gauge
+// gets its own factor for the local method.
+func computeBiasFactors(ctx context.Context, queries *sqlcdb.Queries, files map[time.Time]mrmsHourlyFile, start, end time.Time, method string) (*PrecipBiasResult, grib2.Grid, error) {
+	result := &PrecipBiasResult{
+		Method:          method,
+		WindowStart:     start.UTC(),
+		WindowEnd:       end.UTC(),
+		MeanFieldFactor: 1,
+		Gauges:          []PrecipBiasGauge{},
+	}
+
+	var paths []string
+	var newest time.Time
+	for t, f := range files {
+		if t.After(start) && !t.After(end) {
+			paths = append(paths, f.path)
+			if t.After(newest) {
+				newest = t
+			}
+		}
+	}
+	if len(paths) == 0 {
+		return nil, nil, fmt.Errorf("no MRMS hourly grids between %s and %s", start.Format(time.RFC3339), end.Format(time.RFC3339))
+	}
+	sort.Strings(paths)
+	first, err := firstGribMessage(files[newest].path)
+	if err != nil {
+		return nil, nil, err
+	}
+	if first == nil {
+		return nil, nil, fmt.Errorf("no GRIB messages in %s", files[newest].path)
+	}
+
+	totals, err := queries.ListGaugeTotals(ctx, sqlcdb.ListGaugeTotalsParams{FromTime: start.UTC(), ToTime: end.UTC()})
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to load gauge totals: %w", err)
+	}
+
+	var cells []summaryCell
+	for _, g := range totals {
+		k, ok := first.Grid.Nearest(g.Lat, g.Lon)
+		if !ok {
+			continue
+		}
+		cells = append(cells, summaryCell{index: k, lat: g.Lat, lon: g.Lon})
+		result.Gauges = append(result.Gauges, PrecipBiasGauge{
+			StationID:  g.StationID,
+			Name:       g.Name,
+			Lat:        g.Lat,
+			Lon:        g.Lon,
+			GaugeTotal: roundTo(g.TotalPrecip, 1000),
+		})
+	}
+	nx, ny := first.Grid.Size()
+	hourly := readSummaryHours(paths, nx, ny, cells)
+
+	minTotal := AppConfig.Gauges.MinTotal
+	var sumGauge, sumRadar float64
+	for i := range result.Gauges {
+		g := &result.Gauges[i]
+		var radar float64
+		for h := range hourly {
+			if v := hourly[h][i]; !math.IsNaN(float64(v)) {
+				radar += float64(v)
+			}
+		}
+		g.RadarTotal = roundTo(radar, 1000)
+		if g.GaugeTotal < minTotal || radar < minTotal {
+			continue
+		}
+		g.Used = true
+		g.Factor = roundTo(clampFactor(g.GaugeTotal/radar), 1000)
+		sumGauge += g.GaugeTotal
+		sumRadar += radar
+		result.GaugesUsed++
+	}
+
+	if result.GaugesUsed < max(1, AppConfig.Gauges.MinGauges) {
+		result.Message = fmt.Sprintf("%d of %d gauges reached %.2f in with radar; at least %d are needed",
+			result.GaugesUsed, len(result.Gauges), minTotal, max(1, AppConfig.Gauges.MinGauges))
+		return result, first.Grid, nil
+	}
+	result.MeanFieldFactor = roundTo(clampFactor(sumGauge/sumRadar), 1000)
+	return result, first.Grid, nil
+}
+
+// biasFactorField returns the multiplier for each grid cell. Mean-field uses
+// one factor everywhere; local blends the used gauges' factors by inverse
+// squared distance (in log space) within gauges.local_radius_km, with the
+// mean-field factor weighted as a gauge at the radius so cells far from any
+// gauge fall back to it.
+func biasFactorField(grid grib2.Grid, result *PrecipBiasResult) func(k int) float64 {
+	meanField := result.MeanFieldFactor
+	radius := AppConfig.Gauges.LocalRadiusKm
+	if result.Method != biasMethodLocal || radius <= 0 {
+		return func(int) float64 { return meanField }
+	}
+
+	local := map[int]float64{}
+	for _, g := range result.Gauges {
+		if !g.Used {
+			continue
+		}
+		dLat := radius / kmPerDegree
+		dLon := dLat / math.Cos(g.Lat*math.Pi/180)
+		visitCells(grid, [4]float64{g.Lon - dLon, g.Lat - dLat, g.Lon + dLon, g.Lat + dLat}, func(k int, lat, lon float64) {
+			if _, done := local[k]; done {
+				return
+			}
+			if distanceKm(g.Lat, g.Lon, lat, lon) > radius {
+				return
+			}
+			wSum := 1 / (radius * radius)
+			logSum := wSum * math.Log(meanField)
+			for _, o := range result.Gauges {
+				if !o.Used {
+					continue
+				}
+				d := distanceKm(o.Lat, o.Lon, lat, lon)
+				if d > radius {
+					continue
+				}
+				w := 1 / math.Max(d*d, 1)
+				wSum += w
+				logSum += w * math.Log(o.Factor)
+			}
+			local[k] = math.Exp(logSum / wSum)
+		})
+	}
+	return func(k int) float64 {
+		if f, ok := local[k]; ok {
+			return f
+		}
+		return meanField
+	}
+}
+
+// applyBiasCorrection writes a scaled copy of every MRMS grid in dir to
+// outDir, a folder of the run's own workspace: dir is the download folder
+// every run of the date shares. Values keep their original packing and the
+// MRMS no-coverage flags (negative values) are left untouched.
+func applyBiasCorrection(dir, outDir string, factor func(k int) float64) (string, error) {
+	count, err := rewriteMRMSGrids(dir, outDir, func(k int, v float64) float64 {
+		if v > 0 {
+			return v * factor(k)
+		}
+		return v
+	})
+	if err != nil {
+		return "", err
+	}
+	log.Printf("INFO: Wrote %d bias corrected MRMS grids to %s", count, outDir)
+	return outDir, nil
+}
+
+// rewriteMRMSGrids writes a copy of every MRMS grid in dir to outDir with
+// each value v at index k replaced by adjust(k, v). It returns the number of
+// files written.
+func rewriteMRMSGrids(dir, outDir string, adjust func(k int, v float64) float64) (int, error) {
+	if err := os.RemoveAll(outDir); err != nil {
+		return 0, fmt.Errorf("failed to clear %s: %w", outDir, err)
+	}
+	if err := os.MkdirAll(outDir, 0755); err != nil {
+		return 0, fmt.Errorf("failed to create %s: %w", outDir, err)
+	}
+
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return 0, err
+	}
+	count := 0
+	for _, entry := range entries {
+		if entry.IsDir() || mrmsSourcePattern.FindStringSubmatch(entry.Name()) == nil {
+			continue
+		}
+		msgs, err := grib2.ReadFile(filepath.Join(dir, entry.Name()))
+		if err != nil {
+			return count, err
+		}
+		for i, msg := range msgs {
+			values, err := msg.Values()
+			if err != nil {
+				return count, fmt.Errorf("failed to decode %s: %w", entry.Name(), err)
+			}
+			for k, v := range values {
+				values[k] = float32(adjust(k, float64(v)))
+			}
+			if msgs[i], err = msg.Repack(values, 1); err != nil {
+				return count, fmt.Errorf("failed to repack %s: %w", entry.Name(), err)
+			}
+		}
+		if err := grib2.WriteFile(filepath.Join(outDir, entry.Name()), msgs...); err != nil {
+			return count, err
+		}
+		count++
+	}
+	return count, nil
+}
+
+// runBiasCorrection computes (and when apply is set, applies) the gauge bias
+// of a run's MRMS grids in dir and stores the factors on the run. It returns
+// the folder the MRMS merge steps should read: the corrected copy written to
+// outDir when the correction was applied, dir otherwise. Failures are logged
+// and leave the grids uncorrected.
+func runBiasCorrection(ctx context.Context, queries *sqlcdb.Queries, runID int32, dir, outDir string, start, end time.Time, method string, apply bool) string {
+	mrmsDir, result := correctBias(ctx, queries, runID, dir, outDir, start, end, method, apply)
+	storeBiasFactors(ctx, queries, result)
+	return mrmsDir
+}
+
+// correctBias is runBiasCorrection without storing the factors, which it
+// returns instead (nil when none were computed)
+func correctBias(ctx context.Context, queries *sqlcdb.Queries, runID int32, dir, outDir string, start, end time.Time, method string, apply bool) (string, *PrecipBiasResult) {
+	if method == biasMethodNone {
+		return dir, nil
+	}
+	if err := ingestGaugeSource(ctx, queries, start, end); err != nil {
+		log.Printf("Warning: Gauge ingestion failed, using stored readings only: %v", err)
+	}
+
+	result, grid, err := computeBiasFactors(ctx, queries, indexMRMSHourlyFiles(dir), start, end, method)
+	if err != nil {
+		log.Printf("Warning: Bias factors for run %d failed: %v", runID, err)
+		return dir, nil
+	}
+	result.RunID = runID
+	log.Printf("INFO: Bias (%s) for run %d: mean-field %.3f from %d gauges", method, runID, result.MeanFieldFactor, result.GaugesUsed)
+
+	mrmsDir := dir
+	if apply && result.Message == "" {
+		if corrected, err := applyBiasCorrection(dir, outDir, biasFactorField(grid, result)); err != nil {
+			log.Printf("Warning: Failed to apply bias correction for run %d: %v", runID, err)
+		} else {
+			mrmsDir, result.Applied = corrected, true
+		}
+	}
+	return mrmsDir, result
+}
+
+// reapplyBiasCorrection writes the correction of a stored result to outDir
+// again, for runs that recompute another run's rainfall
+func reapplyBiasCorrection(dir, outDir string, result *PrecipBiasResult) (string, error) {
+	var newest time.Time
+	var path string
+	for t, f := range indexMRMSHourlyFiles(dir) {
+		if path == "" || t.After(newest) {
+			newest, path = t, f.path
+		}
+	}
+	if path == "" {
+		return "", fmt.Errorf("no MRMS hourly grids in %s", dir)
+	}
+	first, err := firstGribMessage(path)
+	if err != nil {
+		return "", err
+	}
+	if first == nil {
+		return "", fmt.Errorf("no GRIB messages in %s", path)
+	}
+	return applyBiasCorrection(dir, outDir, biasFactorField(first.Grid, result))
+}
+
+// storeBiasFactors records result on its run; nil results and results
+// without a run are skipped
+func storeBiasFactors(ctx context.Context, queries *sqlcdb.Queries, result *PrecipBiasResult) {
+	if result == nil || result.RunID == 0 {
+		return
+	}
+	details, _ := json.Marshal(result)
+	_, err := queries.UpsertPrecipBiasFactor(ctx, sqlcdb.UpsertPrecipBiasFactorParams{
+		RunID:           result.RunID,
+		Method:          result.Method,
+		WindowStart:     result.WindowStart,
+		WindowEnd:       result.WindowEnd,
+		MeanFieldFactor: result.MeanFieldFactor,
+		GaugesUsed:      int32(result.GaugesUsed),
+		Applied:         result.Applied,
+		Details:         details,
+	})
+	if err != nil {
+		log.Printf("Warning: Failed to store bias factors for run %d: %v", result.RunID, err)
+	}
+}
+
+// handleGetPrecipBias serves GET /api/precip/bias?run_id= (latest run without it)
+func handleGetPrecipBias(queries *sqlcdb.Queries) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		ctx := c.Request().Context()
+
+		var row sqlcdb.PrecipBiasFactor
+		var err error
+		if idStr := c.QueryParam("run_id"); idStr != "" {
+			id, convErr := strconv.Atoi(idStr)
+			if convErr != nil || id <= 0 {
+				return respondWithError(c, http.StatusBadRequest, "run_id must be a positive integer")
+			}
+			row, err = queries.GetPrecipBiasFactor(ctx, int32(id))
+		} else {
+			row, err = queries.GetLatestPrecipBiasFactor(ctx)
+		}
+		if errors.Is(err, sql.ErrNoRows) {
+			return respondWithError(c, http.StatusNotFound, "No bias factors found")
+		}
+		if err != nil {
+			log.Printf("Error loading bias factors: %v", err)
+			return respondWithError(c, http.StatusInternalServerError, "Failed to load bias factors")
+		}
+
+		var result PrecipBiasResult
+		if err := json.Unmarshal(row.Details, &result); err != nil {
+			log.Printf("Error decoding bias factors %d: %v", row.ID, err)
+			return respondWithError(c, http.StatusInternalServerError, "Failed to decode bias factors")
+		}
+		return respondWithJSON(c, http.StatusOK, result)
+	}
+}
@@ -0,0 +1,229 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"HMSBackend/sqlcdb"
+
+	"github.com/labstack/echo/v4"
+)
+
+// recordPrecipCog adds (or refreshes) a published COG in the precip_cogs catalog
+func recordPrecipCog(ctx context.Context, queries *sqlcdb.Queries, acc *PrecipAccumulation) error {
+	_, err := queries.UpsertPrecipCog(ctx, sqlcdb.UpsertPrecipCogParams{
+		Product:       acc.Product,
+		Kind:          acc.Kind,
+		DurationHours: int32(acc.DurationHours),
+		ValidStart:    acc.Start.UTC(),
+		ValidEnd:      acc.End.UTC(),
+		FileName:      filepath.Base(acc.Meta.COGPath),
+		SourceGrib:    acc.SourceGrib,
+		West:          acc.Meta.Bounds[0],
+		South:         acc.Meta.Bounds[1],
+		East:          acc.Meta.Bounds[2],
+		North:         acc.Meta.Bounds[3],
+		Width:         int32(acc.Meta.Width),
+		Height:        int32(acc.Meta.Height),
+		Units:         acc.Meta.Units,
+		MinValue:      acc.Stats.Min,
+		MaxValue:      acc.Stats.Max,
+		MeanValue:     acc.Stats.Mean,
+		WetFraction:   acc.Stats.WetFraction,
+		ValidCells:    int32(acc.Stats.ValidCells),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to record %s in precipitation catalog: %w", acc.Meta.COGPath, err)
+	}
+	return nil
+}
+
+// ImportPrecipSidecars indexes the accumulation metadata files that earlier
+// versions wrote next to each COG (<tag>.json in StaticCogDir) and removes
+// them once catalogued, so products built before the catalog are kept.
+func ImportPrecipSidecars(ctx context.Context, queries *sqlcdb.Queries) {
+	paths, err := filepath.Glob(filepath.Join(AppConfig.Paths.StaticCogDir, "*.json"))
+	if err != nil {
+		log.Printf("Precip: Failed to list metadata files: %v", err)
+		return
+	}
+	imported := 0
+	for _, path := range paths {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			log.Printf("Precip: Skipping unreadable metadata %s: %v", path, err)
+			continue
+		}
+		var acc PrecipAccumulation
+		if err := json.Unmarshal(data, &acc); err != nil || acc.Product == "" || acc.Meta.COGPath == "" {
+			continue // not an accumulation sidecar
+		}
+		if _, err := os.Stat(filepath.Join(AppConfig.Paths.StaticCogDir, filepath.Base(acc.Meta.COGPath))); err != nil {
+			log.Printf("Precip: Skipping metadata %s, its COG is missing", path)
+			continue
+		}
+		if err := recordPrecipCog(ctx, queries, &acc); err != nil {
+			log.Printf("Precip: %v", err)
+			continue
+		}
+		if err := os.Remove(path); err != nil {
+			log.Printf("Precip: Failed to remove imported metadata %s: %v", path, err)
+		}
+		imported++
+	}
+	if imported > 0 {
+		log.Printf("Precip: Imported %d accumulation metadata files into the catalog", imported)
+	}
+}
+
+// precipAccumulationFromCog converts a catalog row back to a PrecipAccumulation
+func precipAccumulationFromCog(row sqlcdb.PrecipCog) *PrecipAccumulation {
+	return &PrecipAccumulation{
+		Product:       row.Product,
+		Kind:          row.Kind,
+		DurationHours: int(row.DurationHours),
+		Start:         row.ValidStart.UTC(),
+		End:           row.ValidEnd.UTC(),
+		Meta: PrecipMeta{
+			Timestamp: strings.TrimSuffix(row.FileName, filepath.Ext(row.FileName)),
+			COGPath:   filepath.Join(AppConfig.Paths.StaticCogDir, row.FileName),
+			Bounds:    [4]float64{row.West, row.South, row.East, row.North},
+			Width:     int(row.Width),
+			Height:    int(row.Height),
+			Units:     row.Units,
+		},
+		Stats: PrecipStats{
+			Min:         row.MinValue,
+			Max:         row.MaxValue,
+			Mean:        row.MeanValue,
+			WetFraction: row.WetFraction,
+			ValidCells:  int(row.ValidCells),
+		},
+		SourceGrib: row.SourceGrib,
+		CreatedAt:  row.CreatedAt.UTC(),
+	}
+}
+
+// handleGetPrecipCatalog serves GET /api/precip/catalog?from=&to=&product=
+// Entries are ordered by end time so the frontend can drive a time slider.
+// from/to default to the last 24 hours; product is optional.
+func handleGetPrecipCatalog(queries *sqlcdb.Queries) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		to := time.Now().UTC()
+		from := to.Add(-24 * time.Hour)
+		var err error
+
+		if toStr := c.QueryParam("to"); toStr != "" {
+			if to, err = parseQueryTime(toStr); err != nil {
+				return respondWithError(c, http.StatusBadRequest, "Invalid 'to' time")
+			}
+			if c.QueryParam("from") == "" {
+				from = to.Add(-24 * time.Hour)
+			}
+		}
+		if fromStr := c.QueryParam("from"); fromStr != "" {
+			if from, err = parseQueryTime(fromStr); err != nil {
+				return respondWithError(c, http.StatusBadRequest, "Invalid 'from' time")
+			}
+		}
+		if from.After(to) {
+			return respondWithError(c, http.StatusBadRequest, "'from' must be before 'to'")
+		}
+
+		product := c.QueryParam("product")
+		rows, err := queries.ListPrecipCogs(c.Request().Context(), sqlcdb.ListPrecipCogsParams{
+			FromTime: from.UTC(),
+			ToTime:   to.UTC(),
+			Product:  product,
+		})
+		if err != nil {
+			log.Printf("Precip: Failed to list catalog: %v", err)
+			return respondWithError(c, http.StatusInternalServerError, "Failed to read precipitation catalog")
+		}
+
+		items := make([]echo.Map, 0, len(rows))
+		for _, row := range rows {
+			items = append(items, accumulationResponse(precipAccumulationFromCog(row)))
+		}
+
+		return respondWithJSON(c, http.StatusOK, echo.Map{
+			"from":    from.UTC(),
+			"to":      to.UTC(),
+			"product": product,
+			"count":   len(items),
+			"items":   items,
+		})
+	}
+}
+
+// precipRetentionDays returns how long a product is kept; 0 keeps it forever
+func precipRetentionDays(product string) int {
+	if days, ok := AppConfig.Precip.ProductRetentionDays[product]; ok {
+		return days
+	}
+	return AppConfig.Precip.RetentionDays
+}
+
+// ApplyPrecipRetention deletes catalogued COGs older than their product's
+// retention, along with the GRIB file each was built from and its cached tiles.
+func ApplyPrecipRetention(ctx context.Context, queries *sqlcdb.Queries) {
+	products, err := queries.ListPrecipCogProducts(ctx)
+	if err != nil {
+		log.Printf("Precip: Retention skipped, failed to list products: %v", err)
+		return
+	}
+
+	for _, product := range products {
+		days := precipRetentionDays(product)
+		if days <= 0 {
+			continue
+		}
+		cutoff := time.Now().UTC().AddDate(0, 0, -days)
+		rows, err := queries.ListPrecipCogsEndingBefore(ctx, sqlcdb.ListPrecipCogsEndingBeforeParams{
+			Product:  product,
+			ValidEnd: cutoff,
+		})
+		if err != nil {
+			log.Printf("Precip: Retention failed to list %s: %v", product, err)
+			continue
+		}
+
+		removed := 0
+		for _, row := range rows {
+			cogPath := filepath.Join(AppConfig.Paths.StaticCogDir, row.FileName)
+			closeCachedCog(cogPath)
+			files := []string{cogPath}
+			if row.SourceGrib != "" {
+				files = append(files, row.SourceGrib)
+			}
+			failed := false
+			for _, f := range files {
+				if err := os.Remove(f); err != nil && !os.IsNotExist(err) {
+					log.Printf("Precip: Retention failed to delete %s: %v", f, err)
+					failed = true
+				}
+			}
+			if failed {
+				continue
+			}
+			if err := os.RemoveAll(precipTileCacheDir(strings.TrimSuffix(row.FileName, filepath.Ext(row.FileName)))); err != nil {
+				log.Printf("Precip: Retention failed to clear tiles for %s: %v", row.FileName, err)
+			}
+			if err := queries.DeletePrecipCog(ctx, row.ID); err != nil {
+				log.Printf("Precip: Retention failed to delete catalog entry %d: %v", row.ID, err)
+				continue
+			}
+			removed++
+		}
+		if removed > 0 {
+			log.Printf("Precip: Retention removed %d %s COGs older than %d days", removed, product, days)
+		}
+	}
+}
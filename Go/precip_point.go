@@ -0,0 +1,315 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"math"
+	"net/http"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"HMSBackend/grib2"
+
+	"github.com/labstack/echo/v4"
+)
+
+const (
+	defaultPointHours = 24
+	maxPointHours     = 48 // downloadGRIBFiles keeps the last 48 hours
+	pointWorkers      = 4
+)
+
+// gribSearchDates returns the download folder names (YYYYMMDD) that can hold
+// recent files. Folders are named by local date by the pipeline while HRRR
+// cycles and MRMS files are UTC, so both calendars are included.
+func gribSearchDates(daysBack int) []string {
+	now := time.Now()
+	seen := map[string]bool{}
+	var dates []string
+	for d := 0; d <= daysBack; d++ {
+		for _, t := range []time.Time{now.AddDate(0, 0, -d), now.UTC().AddDate(0, 0, -d)} {
+			s := t.Format("20060102")
+			if !seen[s] {
+				seen[s] = true
+				dates = append(dates, s)
+			}
+		}
+	}
+	return dates
+}
+
+// mrmsSourcePattern extracts the product from names like
+// MRMS_MultiSensor_QPE_01H_Pass2_00.00_20250519-210000.grib2 (historical
+// downloads keep the NCEP name without the MRMS_ prefix)
+var mrmsSourcePattern = regexp.MustCompile(`^(?:MRMS_)?(.+?)_\d{2}\.\d{2}_\d{8}-\d{6}\.grib2$`)
+
+// mrmsSourceRank orders the hourly products for the same hour: the gauge
+// corrected Pass 2 over Pass 1, and either over anything else
+func mrmsSourceRank(source string) int {
+	switch {
+	case strings.HasSuffix(source, "_Pass2"):
+		return 2
+	case strings.HasSuffix(source, "_Pass1"):
+		return 1
+	}
+	return 0
+}
+
+type mrmsHourlyFile struct {
+	path   string
+	source string
+}
+
+// mrmsHourlyFiles indexes the downloaded hourly MRMS grids by valid time,
+// preferring Pass 2 over Pass 1 when both exist for an hour.
+func mrmsHourlyFiles(daysBack int) map[time.Time]mrmsHourlyFile {
+	var dirs []string
+	for _, dateStr := range gribSearchDates(daysBack) {
+		dirs = append(dirs, GetGribDownloadPath(dateStr))
+	}
+	return indexMRMSHourlyFiles(dirs...)
+}
+
+// indexMRMSHourlyFiles indexes the hourly MRMS grids found in dirs
+func indexMRMSHourlyFiles(dirs ...string) map[time.Time]mrmsHourlyFile {
+	files := map[time.Time]mrmsHourlyFile{}
+	for _, dir := range dirs {
+		entries, err := os.ReadDir(dir)
+		if err != nil {
+			continue
+		}
+		for _, entry := range entries {
+			m := mrmsSourcePattern.FindStringSubmatch(entry.Name())
+			if m == nil {
+				continue
+			}
+			t, err := parseGRIBFilename(entry.Name())
+			if err != nil {
+				continue
+			}
+			if existing, ok := files[t]; ok && mrmsSourceRank(existing.source) >= mrmsSourceRank(m[1]) {
+				continue
+			}
+			files[t] = mrmsHourlyFile{path: filepath.Join(dir, entry.Name()), source: m[1]}
+		}
+	}
+	return files
+}
+
+// latestHRRRCycleFiles returns the newest downloaded HRRR cycle and its files by forecast hour
+func latestHRRRCycleFiles() (time.Time, map[int]string) {
+	cycles := map[time.Time]map[int]string{}
+	var latest time.Time
+	for _, dateStr := range gribSearchDates(1) {
+		dir := GetGribDownloadPath(dateStr)
+		entries, err := os.ReadDir(dir)
+		if err != nil {
+			continue
+		}
+		for _, entry := range entries {
+			m := hrrrForecastFilePattern.FindStringSubmatch(entry.Name())
+			if m == nil {
+				continue
+			}
+			cycle, err := time.Parse("2006010215", dateStr+m[1])
+			if err != nil {
+				continue
+			}
+			fh, _ := strconv.Atoi(m[2])
+			if cycles[cycle] == nil {
+				cycles[cycle] = map[int]string{}
+			}
+			cycles[cycle][fh] = filepath.Join(dir, entry.Name())
+			if cycle.After(latest) {
+				latest = cycle
+			}
+		}
+	}
+	return latest, cycles[latest]
+}
+
+// samplePrecipGrib returns the value in inches at lat/lon of the first message
+// accepted by match. Missing and negative (no coverage) values report false.
+func samplePrecipGrib(path string, match func(*grib2.Message) bool, lat, lon float64) (float64, bool, error) {
+	var msg *grib2.Message
+	err := grib2.ScanFile(path, func(m *grib2.Message) bool {
+		if match(m) {
+			msg = m
+			return false
+		}
+		return true
+	})
+	if err != nil {
+		return 0, false, err
+	}
+	if msg == nil {
+		return 0, false, fmt.Errorf("no matching field in %s", path)
+	}
+	k, ok := msg.Grid.Nearest(lat, lon)
+	if !ok {
+		return 0, false, nil
+	}
+	values, err := msg.Values()
+	if err != nil {
+		return 0, false, err
+	}
+	v := float64(values[k])
+	if math.IsNaN(v) || v < 0 {
+		return 0, false, nil
+	}
+	return v / mmPerInch, true, nil
+}
+
+// isHourlyAPCP matches the one-hour HRRR accumulation ending at forecast hour fh
+func isHourlyAPCP(fh int) func(*grib2.Message) bool {
+	return func(m *grib2.Message) bool {
+		p := m.Product
+		return m.Discipline == 0 && p.Category == 1 && p.Number == 8 && p.IsAccumulation() &&
+			p.IntervalDuration() == time.Hour && p.ForecastOffset() == time.Duration(fh-1)*time.Hour
+	}
+}
+
+// pointSample is one grid to sample for the series
+type pointSample struct {
+	entry PrecipPointValue
+	path  string
+	match func(*grib2.Message) bool
+}
+
+// samplePoint fills in the values of samples concurrently
+func samplePoint(samples []pointSample, lat, lon float64) {
+	var wg sync.WaitGroup
+	jobs := make(chan int)
+	for w := 0; w < pointWorkers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range jobs {
+				s := &samples[i]
+				v, ok, err := samplePrecipGrib(s.path, s.match, lat, lon)
+				if err != nil {
+					log.Printf("Precip: Point sample of %s failed: %v", s.path, err)
+					continue
+				}
+				if ok {
+					v = math.Round(v*1000) / 1000
+					s.entry.Value = &v
+				}
+			}
+		}()
+	}
+	for i := range samples {
+		jobs <- i
+	}
+	close(jobs)
+	wg.Wait()
+}
+
+// buildPrecipPointSeries samples the last `hours` of MRMS hourly grids and the
+// newest HRRR cycle's hourly forecasts at lat/lon.
+func buildPrecipPointSeries(lat, lon float64, hours int) PrecipPointSeries {
+	result := PrecipPointSeries{Lat: lat, Lon: lon, Units: "inches", Hours: hours}
+	lastHour := time.Now().UTC().Truncate(time.Hour)
+	firstHour := lastHour.Add(-time.Duration(hours-1) * time.Hour)
+
+	var samples []pointSample
+	mrms := mrmsHourlyFiles(hours/24 + 1)
+	for t := firstHour; !t.After(lastHour); t = t.Add(time.Hour) {
+		entry := PrecipPointValue{Start: t.Add(-time.Hour), End: t, Type: precipKindObserved, Source: "missing"}
+		f, ok := mrms[t]
+		if !ok {
+			result.Series = append(result.Series, entry)
+			continue
+		}
+		entry.Source = "MRMS " + f.source
+		samples = append(samples, pointSample{entry: entry, path: f.path, match: func(*grib2.Message) bool { return true }})
+	}
+	observedCount := len(samples)
+
+	// Forecast hours continue after the newest observed hour on disk
+	lastObserved := firstHour.Add(-time.Hour)
+	for t := range mrms {
+		if t.After(lastObserved) && !t.After(lastHour) {
+			lastObserved = t
+		}
+	}
+	cycle, files := latestHRRRCycleFiles()
+	if len(files) > 0 {
+		c := cycle
+		result.HRRRCycle = &c
+		for fh, path := range files {
+			end := cycle.Add(time.Duration(fh) * time.Hour)
+			if !end.After(lastObserved) {
+				continue
+			}
+			samples = append(samples, pointSample{
+				entry: PrecipPointValue{
+					Start:  end.Add(-time.Hour),
+					End:    end,
+					Type:   precipKindForecast,
+					Source: fmt.Sprintf("HRRR t%sz f%02d", cycle.Format("15"), fh),
+				},
+				path:  path,
+				match: isHourlyAPCP(fh),
+			})
+		}
+	}
+
+	samplePoint(samples, lat, lon)
+
+	forecastEnds := map[time.Time]bool{}
+	for i, s := range samples {
+		if i >= observedCount {
+			forecastEnds[s.entry.End] = true
+		}
+	}
+	// Drop unfilled observed hours that a forecast hour covers
+	series := result.Series[:0]
+	for _, e := range result.Series {
+		if !forecastEnds[e.End] {
+			series = append(series, e)
+		}
+	}
+	result.Series = series
+	for _, s := range samples {
+		result.Series = append(result.Series, s.entry)
+		if s.entry.Value == nil {
+			continue
+		}
+		if s.entry.Type == precipKindForecast {
+			result.ForecastTotal += *s.entry.Value
+		} else {
+			result.ObservedTotal += *s.entry.Value
+		}
+	}
+	sort.Slice(result.Series, func(i, j int) bool { return result.Series[i].End.Before(result.Series[j].End) })
+	result.ObservedTotal = math.Round(result.ObservedTotal*1000) / 1000
+	result.ForecastTotal = math.Round(result.ForecastTotal*1000) / 1000
+	return result
+}
+
+// handleGetPrecipPoint serves GET /api/precip/point?lat=&lon=&hours=
+func handleGetPrecipPoint(c echo.Context) error {
+	lat, errLat := strconv.ParseFloat(c.QueryParam("lat"), 64)
+	lon, errLon := strconv.ParseFloat(c.QueryParam("lon"), 64)
+	if errLat != nil || errLon != nil || lat < -90 || lat > 90 || lon < -180 || lon > 360 {
+		return respondWithError(c, http.StatusBadRequest, "lat and lon are required decimal degrees")
+	}
+
+	hours := defaultPointHours
+	if h := c.QueryParam("hours"); h != "" {
+		var err error
+		hours, err = strconv.Atoi(h)
+		if err != nil || hours < 1 || hours > maxPointHours {
+			return respondWithError(c, http.StatusBadRequest, fmt.Sprintf("hours must be between 1 and %d", maxPointHours))
+		}
+	}
+
+	return respondWithJSON(c, http.StatusOK, buildPrecipPointSeries(lat, lon, hours))
+}
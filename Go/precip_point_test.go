@@ -0,0 +1,41 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestIndexMRMSHourlyFilesRanksSources(t *testing.T) {
+	dir := t.TempDir()
+	for _, name := range []string{
+		// RadarOnly sorts after MultiSensor by name but ranks below both passes
+		"MRMS_MultiSensor_QPE_01H_Pass2_00.00_20240501-010000.grib2",
+		"MRMS_RadarOnly_QPE_01H_00.00_20240501-010000.grib2",
+		"MRMS_MultiSensor_QPE_01H_Pass1_00.00_20240501-010000.grib2",
+		"MRMS_RadarOnly_QPE_01H_00.00_20240501-020000.grib2",
+		"MultiSensor_QPE_01H_Pass1_00.00_20240501-020000.grib2",
+		"MRMS_RadarOnly_QPE_01H_00.00_20240501-030000.grib2",
+	} {
+		if err := os.WriteFile(filepath.Join(dir, name), nil, 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	files := indexMRMSHourlyFiles(dir)
+
+	want := map[int]string{
+		1: "MultiSensor_QPE_01H_Pass2",
+		2: "MultiSensor_QPE_01H_Pass1",
+		3: "RadarOnly_QPE_01H",
+	}
+	if len(files) != len(want) {
+		t.Fatalf("indexed %d hours, want %d", len(files), len(want))
+	}
+	for hour, source := range want {
+		f, ok := files[time.Date(2024, 5, 1, hour, 0, 0, 0, time.UTC)]
+		if !ok || f.source != source {
+			t.Errorf("%02d:00 source %q, want %q", hour, f.source, source)
+		}
+	}
+}
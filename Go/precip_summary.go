@@ -0,0 +1,512 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"math"
+	"net/http"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"HMSBackend/grib2"
+	"HMSBackend/shapefile"
+	"HMSBackend/sqlcdb"
+
+	"github.com/labstack/echo/v4"
+)
+
+const (
+	// Realtime runs summarise the last 48 hours, matching downloadGRIBFiles
+	realtimeSummaryHours = 48
+	sqKmPerSqMi          = 2.589988
+	kmPerDegree          = 111.32
+)
+
+// summaryRegion is a polygon (possibly made of several shapefile records)
+// that precipitation is summarised over
+type summaryRegion struct {
+	name     string
+	kind     string
+	features []shapefile.Feature
+}
+
+func (r *summaryRegion) contains(lon, lat float64) bool {
+	for i := range r.features {
+		if r.features[i].Contains(lon, lat) {
+			return true
+		}
+	}
+	return false
+}
+
+func (r *summaryRegion) bbox() [4]float64 {
+	b := [4]float64{math.Inf(1), math.Inf(1), math.Inf(-1), math.Inf(-1)}
+	for _, f := range r.features {
+		b[0] = math.Min(b[0], f.BBox[0])
+		b[1] = math.Min(b[1], f.BBox[1])
+		b[2] = math.Max(b[2], f.BBox[2])
+		b[3] = math.Max(b[3], f.BBox[3])
+	}
+	return b
+}
+
+var (
+	summaryRegionsMu    sync.Mutex
+	summaryRegionsCache []summaryRegion
+	summaryRegionsKey   string
+)
+
+// loadSummaryRegions reads the county and subbasin shapefiles from the GIS
+// config, caching them until the configured paths or files change.
+func loadSummaryRegions() ([]summaryRegion, error) {
+	cfg := AppConfig.GIS
+	key := cfg.CountyShapefile + "|" + cfg.SubbasinShapefile + "|" + cfg.SubbasinNameField
+	for _, path := range []string{cfg.CountyShapefile, cfg.SubbasinShapefile} {
+		if info, err := os.Stat(path); err == nil {
+			key += "|" + info.ModTime().String()
+		}
+	}
+
+	summaryRegionsMu.Lock()
+	defer summaryRegionsMu.Unlock()
+	if summaryRegionsCache != nil && summaryRegionsKey == key {
+		return summaryRegionsCache, nil
+	}
+
+	var regions []summaryRegion
+	if cfg.CountyShapefile != "" {
+		features, err := shapefile.Read(cfg.CountyShapefile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read county shapefile: %w", err)
+		}
+		regions = append(regions, summaryRegion{name: cfg.CountyName, kind: "county", features: features})
+	}
+	if cfg.SubbasinShapefile != "" {
+		features, err := shapefile.Read(cfg.SubbasinShapefile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read subbasin shapefile: %w", err)
+		}
+		for i, f := range features {
+			name := attributeValue(f.Attributes, cfg.SubbasinNameField)
+			if name == "" {
+				name = fmt.Sprintf("Subbasin %d", i+1)
+			}
+			regions = append(regions, summaryRegion{name: name, kind: "subbasin", features: []shapefile.Feature{f}})
+		}
+	}
+	if len(regions) == 0 {
+		return nil, errors.New("no county or subbasin shapefile configured (gis section)")
+	}
+
+	summaryRegionsCache, summaryRegionsKey = regions, key
+	return regions, nil
+}
+
+// attributeValue looks up a .dbf field ignoring case (field names are often upper case)
+func attributeValue(attrs map[string]string, field string) string {
+	if v, ok := attrs[field]; ok {
+		return v
+	}
+	for k, v := range attrs {
+		if strings.EqualFold(k, field) {
+			return v
+		}
+	}
+	return ""
+}
+
+// summaryCell is a grid cell inside at least one region
+type summaryCell struct {
+	index    int
+	lat, lon float64
+	areaSqMi float64
+}
+
+// gridResolution returns the cell size in degrees (lat, lon), assuming the
+// MRMS 0.01 degree spacing for projected grids
+func gridResolution(grid grib2.Grid) (float64, float64) {
+	if g, ok := grid.(*grib2.LatLonGrid); ok {
+		return g.Dy, g.Dx
+	}
+	return 0.01, 0.01
+}
+
+// visitCells calls fn once for every grid cell near the bbox (west, south,
+// east, north) with the cell's centre.
+func visitCells(grid grib2.Grid, b [4]float64, fn func(k int, lat, lon float64)) {
+	dLat, dLon := gridResolution(grid)
+	seen := map[int]bool{}
+	for lat := b[1] - dLat; lat <= b[3]+dLat; lat += dLat / 2 {
+		for lon := b[0] - dLon; lon <= b[2]+dLon; lon += dLon / 2 {
+			k, ok := grid.Nearest(lat, lon)
+			if !ok || seen[k] {
+				continue
+			}
+			seen[k] = true
+			cLat, cLon := grid.LatLon(k)
+			fn(k, cLat, grib2.NormaliseLon(cLon))
+		}
+	}
+}
+
+// regionCells finds the grid cells whose centres fall inside each region.
+// It returns the shared cell list and, per region, indexes into it.
+func regionCells(grid grib2.Grid, regions []summaryRegion) ([]summaryCell, [][]int) {
+	dLat, dLon := gridResolution(grid)
+	var cells []summaryCell
+	slots := map[int]int{}
+	members := make([][]int, len(regions))
+	for r := range regions {
+		visitCells(grid, regions[r].bbox(), func(k int, lat, lon float64) {
+			if !regions[r].contains(lon, lat) {
+				return
+			}
+			slot, ok := slots[k]
+			if !ok {
+				slot = len(cells)
+				slots[k] = slot
+				area := dLat * kmPerDegree * dLon * kmPerDegree * math.Cos(lat*math.Pi/180) / sqKmPerSqMi
+				cells = append(cells, summaryCell{index: k, lat: lat, lon: lon, areaSqMi: area})
+			}
+			members[r] = append(members[r], slot)
+		})
+	}
+	return cells, members
+}
+
+// readSummaryHours decodes the hourly grids and keeps the values (inches) of
+// the summary cells. Missing files, other grids and no-coverage are NaN.
+func readSummaryHours(paths []string, nx, ny int, cells []summaryCell) [][]float32 {
+	hourly := make([][]float32, len(paths))
+	var wg sync.WaitGroup
+	jobs := make(chan int)
+	for w := 0; w < pointWorkers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for h := range jobs {
+				row := make([]float32, len(cells))
+				for i := range row {
+					row[i] = float32(math.NaN())
+				}
+				hourly[h] = row
+
+				msg, err := firstGribMessage(paths[h])
+				if err != nil || msg == nil {
+					log.Printf("Precip summary: Skipping %s: %v", paths[h], err)
+					continue
+				}
+				if x, y := msg.Grid.Size(); x != nx || y != ny {
+					log.Printf("Precip summary: Skipping %s: grid %dx%d differs from %dx%d", paths[h], x, y, nx, ny)
+					continue
+				}
+				values, err := msg.Values()
+				if err != nil {
+					log.Printf("Precip summary: Skipping %s: %v", paths[h], err)
+					continue
+				}
+				for i, c := range cells {
+					if v := values[c.index]; v >= 0 {
+						row[i] = v / mmPerInch
+					}
+				}
+			}
+		}()
+	}
+	for h := range paths {
+		jobs <- h
+	}
+	close(jobs)
+	wg.Wait()
+	return hourly
+}
+
+// summaryHours holds the region cells' hourly values of MRMS grids, read a
+// batch at a time so that runs can delete their grids as they go
+type summaryHours struct {
+	nx, ny  int
+	cells   []summaryCell
+	members [][]int
+	times   []time.Time
+	sources map[string]bool
+	hourly  [][]float32
+}
+
+// add reads the grids of files ending in (start, end]. The first grids read
+// set the cells. It returns the number of hours added.
+func (sh *summaryHours) add(files map[time.Time]mrmsHourlyFile, start, end time.Time, regions []summaryRegion) (int, error) {
+	var times []time.Time
+	for t := range files {
+		if t.After(start) && !t.After(end) {
+			times = append(times, t)
+		}
+	}
+	if len(times) == 0 {
+		return 0, nil
+	}
+	sort.Slice(times, func(i, j int) bool { return times[i].Before(times[j]) })
+
+	if sh.sources == nil {
+		sh.sources = map[string]bool{}
+	}
+	paths := make([]string, len(times))
+	for i, t := range times {
+		paths[i] = files[t].path
+		sh.sources[files[t].source] = true
+	}
+
+	if sh.cells == nil {
+		first, err := firstGribMessage(paths[len(paths)-1])
+		if err != nil {
+			return 0, err
+		}
+		if first == nil {
+			return 0, fmt.Errorf("no GRIB messages in %s", paths[len(paths)-1])
+		}
+		sh.nx, sh.ny = first.Grid.Size()
+		sh.cells, sh.members = regionCells(first.Grid, regions)
+	}
+	sh.times = append(sh.times, times...)
+	sh.hourly = append(sh.hourly, readSummaryHours(paths, sh.nx, sh.ny, sh.cells)...)
+	return len(times), nil
+}
+
+// computePrecipSummary summarises the hourly MRMS grids ending in (start, end]
+// over each region: largest 1-hour cell value, largest 24-hour cell total,
+// area-average total and the area whose 24-hour maximum reached each threshold.
+func computePrecipSummary(files map[time.Time]mrmsHourlyFile, start, end time.Time, regions []summaryRegion) (*PrecipSummary, error) {
+	var sh summaryHours
+	if _, err := sh.add(files, start, end, regions); err != nil {
+		return nil, err
+	}
+	return sh.summary(start, end, regions)
+}
+
+// summary summarises the hours read, which must have been added in time
+// order, over (start, end] as computePrecipSummary does
+func (sh *summaryHours) summary(start, end time.Time, regions []summaryRegion) (*PrecipSummary, error) {
+	if len(sh.times) == 0 {
+		return nil, fmt.Errorf("no MRMS hourly grids between %s and %s", start.Format(time.RFC3339), end.Format(time.RFC3339))
+	}
+	times, hourly, cells, members := sh.times, sh.hourly, sh.cells, sh.members
+
+	summary := &PrecipSummary{
+		WindowStart:  start.UTC(),
+		WindowEnd:    end.UTC(),
+		Units:        "inches",
+		Hours:        len(times),
+		MissingHours: max(0, int(end.Sub(start)/time.Hour)-len(times)),
+	}
+	for s := range sh.sources {
+		summary.Sources = append(summary.Sources, s)
+	}
+	sort.Strings(summary.Sources)
+
+	// Per cell: window total and the largest 24-hour running total
+	totals := make([]float64, len(cells))
+	max24 := make([]float64, len(cells))
+	max24End := make([]time.Time, len(cells))
+	for i := range cells {
+		var running float64
+		lo := 0
+		for h, t := range times {
+			if v := hourly[h][i]; !math.IsNaN(float64(v)) {
+				running += float64(v)
+				totals[i] += float64(v)
+			}
+			for !times[lo].After(t.Add(-24 * time.Hour)) {
+				if v := hourly[lo][i]; !math.IsNaN(float64(v)) {
+					running -= float64(v)
+				}
+				lo++
+			}
+			if running > max24[i] || max24End[i].IsZero() {
+				max24[i], max24End[i] = running, t
+			}
+		}
+	}
+
+	thresholds := AppConfig.Precip.SummaryThresholds
+	for r, region := range regions {
+		rs := PrecipRegionSummary{Name: region.name, Type: region.kind, Cells: len(members[r])}
+		var weighted float64
+		above := make([]float64, len(thresholds))
+		for _, i := range members[r] {
+			c := cells[i]
+			rs.AreaSqMi += c.areaSqMi
+			weighted += totals[i] * c.areaSqMi
+			for h, t := range times {
+				v := hourly[h][i]
+				if !math.IsNaN(float64(v)) && (rs.Max1Hour == nil || float64(v) > rs.Max1Hour.Value) {
+					rs.Max1Hour = &PrecipCellValue{Value: float64(v), Lat: c.lat, Lon: c.lon, Time: t}
+				}
+			}
+			if rs.Max24Hour == nil || max24[i] > rs.Max24Hour.Value {
+				rs.Max24Hour = &PrecipCellValue{Value: max24[i], Lat: c.lat, Lon: c.lon, Time: max24End[i]}
+			}
+			for k, th := range thresholds {
+				if max24[i] >= th {
+					above[k] += c.areaSqMi
+				}
+			}
+		}
+		if rs.AreaSqMi > 0 {
+			rs.MeanTotal = roundTo(weighted/rs.AreaSqMi, 100)
+		}
+		for k, th := range thresholds {
+			ta := PrecipThresholdArea{Inches: th, AreaSqMi: roundTo(above[k], 10)}
+			if rs.AreaSqMi > 0 {
+				ta.Percent = roundTo(100*above[k]/rs.AreaSqMi, 10)
+			}
+			rs.AreaAbove = append(rs.AreaAbove, ta)
+		}
+		for _, v := range []*PrecipCellValue{rs.Max1Hour, rs.Max24Hour} {
+			if v != nil {
+				v.Value = roundTo(v.Value, 100)
+				v.Lat, v.Lon = roundTo(v.Lat, 1e4), roundTo(v.Lon, 1e4)
+				v.Time = v.Time.UTC()
+			}
+		}
+		rs.AreaSqMi = roundTo(rs.AreaSqMi, 10)
+		summary.Regions = append(summary.Regions, rs)
+	}
+	return summary, nil
+}
+
+func roundTo(v, scale float64) float64 {
+	return math.Round(v*scale) / scale
+}
+
+// realtimeRunWindow is the window a realtime run covers: the last
+// realtimeSummaryHours ending at the newest MRMS grid in dir.
+func realtimeRunWindow(dir string) (time.Time, time.Time) {
+	var end time.Time
+	for t := range indexMRMSHourlyFiles(dir) {
+		if t.After(end) {
+			end = t
+		}
+	}
+	return end.Add(-realtimeSummaryHours * time.Hour), end
+}
+
+// storePrecipRunSummary summarises the MRMS grids a pipeline run used (found
+// in dirs, ending in (start, end]) and stores the result against the run.
+// Failures are logged only, so the summary never blocks the pipeline.
+func storePrecipRunSummary(ctx context.Context, queries *sqlcdb.Queries, runID int32, runType string, dirs []string, start, end time.Time) {
+	if runID == 0 {
+		return
+	}
+	files := indexMRMSHourlyFiles(dirs...)
+
+	regions, err := loadSummaryRegions()
+	if err != nil {
+		log.Printf("Warning: Precipitation summary for run %d skipped: %v", runID, err)
+		return
+	}
+	summary, err := computePrecipSummary(files, start, end, regions)
+	storePrecipSummary(ctx, queries, runID, runType, summary, err)
+}
+
+// storePrecipSummary stores the summary of a run computed by
+// computePrecipSummary or summaryHours.summary, logging err instead when set
+func storePrecipSummary(ctx context.Context, queries *sqlcdb.Queries, runID int32, runType string, summary *PrecipSummary, err error) {
+	if err != nil {
+		log.Printf("Warning: Precipitation summary for run %d failed: %v", runID, err)
+		return
+	}
+	summary.RunID, summary.RunType = runID, runType
+
+	body, err := json.Marshal(summary)
+	if err != nil {
+		log.Printf("Warning: Failed to encode precipitation summary for run %d: %v", runID, err)
+		return
+	}
+	_, err = queries.UpsertPrecipRunSummary(ctx, sqlcdb.UpsertPrecipRunSummaryParams{
+		RunID:       runID,
+		WindowStart: summary.WindowStart,
+		WindowEnd:   summary.WindowEnd,
+		Summary:     body,
+	})
+	if err != nil {
+		log.Printf("Warning: Failed to store precipitation summary for run %d: %v", runID, err)
+		return
+	}
+	log.Printf("INFO: Stored precipitation summary for run %d (%d hours, %d regions)", runID, summary.Hours, len(summary.Regions))
+}
+
+// formatPrecipSummary renders a summary as plain text for reports
+func formatPrecipSummary(s *PrecipSummary) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "Precipitation summary, %s run %d\n", s.RunType, s.RunID)
+	fmt.Fprintf(&b, "MRMS %s to %s UTC (%d hourly grids, %d missing; %s)\n\n",
+		s.WindowStart.Format("2006-01-02 15:04"), s.WindowEnd.Format("2006-01-02 15:04"),
+		s.Hours, s.MissingHours, strings.Join(s.Sources, ", "))
+	for _, r := range s.Regions {
+		fmt.Fprintf(&b, "%s (%s, %.1f sq mi)\n", r.Name, r.Type, r.AreaSqMi)
+		fmt.Fprintf(&b, "  Mean total:   %.2f in\n", r.MeanTotal)
+		if v := r.Max1Hour; v != nil {
+			fmt.Fprintf(&b, "  Max 1-hour:   %.2f in, hour ending %s UTC at %.4f, %.4f\n", v.Value, v.Time.Format("2006-01-02 15:04"), v.Lat, v.Lon)
+		}
+		if v := r.Max24Hour; v != nil {
+			fmt.Fprintf(&b, "  Max 24-hour:  %.2f in, ending %s UTC at %.4f, %.4f\n", v.Value, v.Time.Format("2006-01-02 15:04"), v.Lat, v.Lon)
+		}
+		for _, a := range r.AreaAbove {
+			fmt.Fprintf(&b, "  Area >= %g in: %.1f sq mi (%.1f%%)\n", a.Inches, a.AreaSqMi, a.Percent)
+		}
+		b.WriteString("\n")
+	}
+	return b.String()
+}
+
+// handleGetPrecipSummary serves GET /api/precip/summary?run_id=&run_type=&format=json|text.
+// Without run_id the most recent summary (optionally of one run type) is returned.
+func handleGetPrecipSummary(queries *sqlcdb.Queries) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		ctx := c.Request().Context()
+
+		var row sqlcdb.PrecipRunSummary
+		var err error
+		if idStr := c.QueryParam("run_id"); idStr != "" {
+			id, convErr := strconv.Atoi(idStr)
+			if convErr != nil || id <= 0 {
+				return respondWithError(c, http.StatusBadRequest, "run_id must be a positive integer")
+			}
+			row, err = queries.GetPrecipRunSummary(ctx, int32(id))
+		} else {
+			runType := c.QueryParam("run_type")
+			if runType != "" && runType != pipelineRunRealtime && runType != pipelineRunHistorical {
+				return respondWithError(c, http.StatusBadRequest, "run_type must be realtime or historical")
+			}
+			row, err = queries.GetLatestPrecipRunSummary(ctx, runType)
+		}
+		if errors.Is(err, sql.ErrNoRows) {
+			return respondWithError(c, http.StatusNotFound, "No precipitation summary found")
+		}
+		if err != nil {
+			log.Printf("Error loading precipitation summary: %v", err)
+			return respondWithError(c, http.StatusInternalServerError, "Failed to load precipitation summary")
+		}
+
+		var summary PrecipSummary
+		if err := json.Unmarshal(row.Summary, &summary); err != nil {
+			log.Printf("Error decoding precipitation summary %d: %v", row.ID, err)
+			return respondWithError(c, http.StatusInternalServerError, "Failed to decode precipitation summary")
+		}
+
+		switch c.QueryParam("format") {
+		case "", "json":
+			return respondWithJSON(c, http.StatusOK, summary)
+		case "text":
+			return c.String(http.StatusOK, formatPrecipSummary(&summary))
+		}
+		return respondWithError(c, http.StatusBadRequest, "format must be json or text")
+	}
+}
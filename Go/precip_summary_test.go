@@ -0,0 +1,93 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	"HMSBackend/grib2"
+	"HMSBackend/shapefile"
+)
+
+// writeTestMRMSHours writes hourly MRMS grids ending at first+1h onward,
+// hour h holding values(h) mm in every cell
+func writeTestMRMSHours(t *testing.T, dir string, first time.Time, from, to int, values func(h int) float32) {
+	t.Helper()
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	grid := grib2.NewLatLonGrid([4]float64{-99, 29, -98, 30}, 0.25)
+	for h := from; h < to; h++ {
+		valid := first.Add(time.Duration(h+1) * time.Hour)
+		msg := grib2.NewMessage(209, 161, valid, grid, grib2.Product{Category: 6, Number: 37})
+		field := make([]float32, grid.Nx*grid.Ny)
+		for k := range field {
+			field[k] = values(h)
+		}
+		if err := msg.PackPNG(field, 1); err != nil {
+			t.Fatal(err)
+		}
+		name := "MRMS_MultiSensor_QPE_01H_Pass2_00.00_" + valid.Format("20060102-150405") + ".grib2"
+		if err := grib2.WriteFile(filepath.Join(dir, name), msg); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestSummaryHoursMatchesSinglePass(t *testing.T) {
+	regions := []summaryRegion{{name: "box", kind: "test", features: []shapefile.Feature{{
+		Rings: [][][2]float64{{{-98.8, 29.2}, {-98.2, 29.2}, {-98.2, 29.8}, {-98.8, 29.8}, {-98.8, 29.2}}},
+		BBox:  [4]float64{-98.8, 29.2, -98.2, 29.8},
+	}}}}
+	first := time.Date(2024, 5, 1, 0, 0, 0, 0, time.UTC)
+	// The wettest 24 hours straddle the chunk boundary at hour 20
+	rain := func(h int) float32 {
+		if h >= 12 && h < 30 {
+			return 5
+		}
+		return 1
+	}
+	dir := t.TempDir()
+	chunkA, chunkB := filepath.Join(dir, "a"), filepath.Join(dir, "b")
+	writeTestMRMSHours(t, chunkA, first, 0, 20, rain)
+	writeTestMRMSHours(t, chunkB, first, 20, 40, rain)
+	end := first.Add(40 * time.Hour)
+
+	want, err := computePrecipSummary(indexMRMSHourlyFiles(chunkA, chunkB), first, end, regions)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var sh summaryHours
+	for _, chunk := range []struct {
+		dir        string
+		start, end time.Time
+	}{
+		{chunkA, first, first.Add(20 * time.Hour)},
+		{chunkB, first.Add(20 * time.Hour), end},
+	} {
+		n, err := sh.add(indexMRMSHourlyFiles(chunk.dir), chunk.start, chunk.end, regions)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if n != 20 {
+			t.Errorf("added %d hours from %s, want 20", n, chunk.dir)
+		}
+		// The run deletes each chunk once it has been read
+		if err := os.RemoveAll(chunk.dir); err != nil {
+			t.Fatal(err)
+		}
+	}
+	got, err := sh.summary(first, end, regions)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("chunked summary\n%+v\nwant\n%+v", got, want)
+	}
+	if max24 := got.Regions[0].Max24Hour; max24 == nil || max24.Value < 3.5 {
+		t.Errorf("24-hour maximum %+v misses the wet spell across the chunks", max24)
+	}
+}
@@ -0,0 +1,325 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"image"
+	"image/color"
+	"image/png"
+	"log"
+	"math"
+	"net/http"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+
+	"HMSBackend/cog"
+
+	"github.com/labstack/echo/v4"
+)
+
+const tileSize = 256
+
+// ColorStop maps precipitation at or above Value (inches) to a hex colour
+type ColorStop struct {
+	Value float64 `mapstructure:"value" json:"value"`
+	Color string  `mapstructure:"color" json:"color"`
+}
+
+// defaultPrecipColorRamp is the NWS QPE legend. Values below the first stop
+// (including zero) are rendered transparent.
+var defaultPrecipColorRamp = []ColorStop{
+	{0.01, "#04e9e7"},
+	{0.10, "#019ff4"},
+	{0.25, "#0300f4"},
+	{0.50, "#02fd02"},
+	{0.75, "#01c501"},
+	{1.00, "#008e00"},
+	{1.50, "#fdf802"},
+	{2.00, "#e5bc00"},
+	{2.50, "#fd9500"},
+	{3.00, "#fd0000"},
+	{4.00, "#d40000"},
+	{5.00, "#bc0000"},
+	{6.00, "#f800fd"},
+	{8.00, "#9854c6"},
+	{10.0, "#fdfdfd"},
+}
+
+type rampStop struct {
+	value float64
+	color color.NRGBA
+}
+
+var (
+	precipRampOnce sync.Once
+	precipRamp     []rampStop
+)
+
+// getPrecipRamp parses the configured colour ramp once, falling back to the
+// NWS legend when none is configured or it cannot be parsed.
+func getPrecipRamp() []rampStop {
+	precipRampOnce.Do(func() {
+		stops := AppConfig.Tiles.ColorRamp
+		if len(stops) == 0 {
+			stops = defaultPrecipColorRamp
+		}
+		ramp, err := parseColorRamp(stops)
+		if err != nil {
+			log.Printf("Tiles: Invalid color ramp (%v), using the NWS default", err)
+			ramp, _ = parseColorRamp(defaultPrecipColorRamp)
+		}
+		precipRamp = ramp
+	})
+	return precipRamp
+}
+
+func parseColorRamp(stops []ColorStop) ([]rampStop, error) {
+	ramp := make([]rampStop, 0, len(stops))
+	for i, s := range stops {
+		c, err := parseHexColor(s.Color)
+		if err != nil {
+			return nil, err
+		}
+		if i > 0 && s.Value <= stops[i-1].Value {
+			return nil, fmt.Errorf("stop values must increase (%v after %v)", s.Value, stops[i-1].Value)
+		}
+		ramp = append(ramp, rampStop{s.Value, c})
+	}
+	return ramp, nil
+}
+
+// parseHexColor accepts #rrggbb or #rrggbbaa
+func parseHexColor(s string) (color.NRGBA, error) {
+	h := strings.TrimPrefix(s, "#")
+	if len(h) != 6 && len(h) != 8 {
+		return color.NRGBA{}, fmt.Errorf("invalid color %q", s)
+	}
+	v, err := strconv.ParseUint(h, 16, 32)
+	if err != nil {
+		return color.NRGBA{}, fmt.Errorf("invalid color %q", s)
+	}
+	if len(h) == 6 {
+		v = v<<8 | 0xff
+	}
+	return color.NRGBA{R: uint8(v >> 24), G: uint8(v >> 16), B: uint8(v >> 8), A: uint8(v)}, nil
+}
+
+// rampColor returns the colour for a value; ok is false below the first stop
+func rampColor(ramp []rampStop, v float64) (color.NRGBA, bool) {
+	if len(ramp) == 0 || v < ramp[0].value {
+		return color.NRGBA{}, false
+	}
+	i := len(ramp) - 1
+	for i > 0 && v < ramp[i].value {
+		i--
+	}
+	return ramp[i].color, true
+}
+
+// tileLonLat returns the longitude/latitude of a fractional Web Mercator tile position
+func tileLonLat(z int, x, y float64) (float64, float64) {
+	n := math.Exp2(float64(z))
+	lon := x/n*360 - 180
+	lat := math.Atan(math.Sinh(math.Pi*(1-2*y/n))) * 180 / math.Pi
+	return lon, lat
+}
+
+// renderPrecipTile draws one 256x256 Web Mercator tile from a COG using nearest neighbour sampling
+func renderPrecipTile(f *cog.File, z, x, y int) ([]byte, error) {
+	// Degrees per output pixel at this zoom; pick the matching overview
+	level := f.LevelFor(360 / (tileSize * math.Exp2(float64(z))))
+	ramp := getPrecipRamp()
+
+	img := image.NewNRGBA(image.Rect(0, 0, tileSize, tileSize))
+	for py := 0; py < tileSize; py++ {
+		_, lat := tileLonLat(z, 0, float64(y)+(float64(py)+0.5)/tileSize)
+		for px := 0; px < tileSize; px++ {
+			lon, _ := tileLonLat(z, float64(x)+(float64(px)+0.5)/tileSize, 0)
+			v, ok, err := f.At(level, lon, lat)
+			if err != nil {
+				return nil, err
+			}
+			if !ok {
+				continue
+			}
+			if c, ok := rampColor(ramp, v); ok {
+				img.SetNRGBA(px, py, c)
+			}
+		}
+	}
+
+	var buf bytes.Buffer
+	enc := png.Encoder{CompressionLevel: png.BestSpeed}
+	if err := enc.Encode(&buf, img); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// cachedCog is an open COG with the number of tile requests reading it
+type cachedCog struct {
+	f       *cog.File
+	refs    int
+	evicted bool // dropped from the cache, closed by the last release
+}
+
+// openCogs keeps the most recently used COGs open between tile requests.
+// order runs from least to most recently used.
+var openCogs = struct {
+	sync.Mutex
+	files map[string]*cachedCog
+	order []string
+}{files: map[string]*cachedCog{}}
+
+const maxOpenCogs = 8
+
+// openCachedCog returns an open COG and a release func that the caller must
+// call when done reading. A COG evicted while in use is closed on release.
+func openCachedCog(path string) (*cog.File, func(), error) {
+	openCogs.Lock()
+	defer openCogs.Unlock()
+	e, ok := openCogs.files[path]
+	if ok {
+		removeCogOrder(path)
+	} else {
+		f, err := cog.Open(path)
+		if err != nil {
+			return nil, nil, err
+		}
+		for len(openCogs.order) >= maxOpenCogs {
+			evictCachedCog(openCogs.order[0])
+		}
+		e = &cachedCog{f: f}
+		openCogs.files[path] = e
+	}
+	openCogs.order = append(openCogs.order, path)
+	e.refs++
+
+	var once sync.Once
+	release := func() {
+		once.Do(func() {
+			openCogs.Lock()
+			defer openCogs.Unlock()
+			e.refs--
+			if e.evicted && e.refs == 0 {
+				e.f.Close()
+			}
+		})
+	}
+	return e.f, release, nil
+}
+
+// removeCogOrder drops path from the usage order; openCogs must be locked
+func removeCogOrder(path string) {
+	for i, p := range openCogs.order {
+		if p == path {
+			openCogs.order = append(openCogs.order[:i], openCogs.order[i+1:]...)
+			return
+		}
+	}
+}
+
+// evictCachedCog drops path from the cache, closing it unless a request is
+// still reading it; openCogs must be locked
+func evictCachedCog(path string) {
+	e, ok := openCogs.files[path]
+	if !ok {
+		return
+	}
+	delete(openCogs.files, path)
+	removeCogOrder(path)
+	e.evicted = true
+	if e.refs == 0 {
+		e.f.Close()
+	}
+}
+
+// closeCachedCog drops a COG from the open file cache, e.g. before deleting it
+func closeCachedCog(path string) {
+	openCogs.Lock()
+	defer openCogs.Unlock()
+	evictCachedCog(path)
+}
+
+// tileTimestampPattern restricts timestamps to COG base names (no path traversal)
+var tileTimestampPattern = regexp.MustCompile(`^[A-Za-z0-9_\-]+$`)
+
+// precipTileCacheDir returns the cache folder for one COG's tiles
+func precipTileCacheDir(timestamp string) string {
+	return filepath.Join(AppConfig.Tiles.CacheDir, "precip", timestamp)
+}
+
+// precipTileMaxAge is how long browsers may reuse a tile without asking again.
+// Tags are rebuilt in place (the hourly QPE is republished as new gauges
+// arrive), so tiles are kept short and revalidated against the COG's ETag.
+const precipTileMaxAge = 300
+
+// precipTileETag identifies one build of a COG by its size and modification time
+func precipTileETag(info os.FileInfo) string {
+	return fmt.Sprintf(`"%x-%x"`, info.ModTime().UnixNano(), info.Size())
+}
+
+// handleGetPrecipTile serves GET /api/tiles/precip/:timestamp/:z/:x/:y.png
+// where timestamp is the COG name without extension (as listed by
+// /api/precip/catalog). Rendered tiles are cached on disk until the COG is
+// rebuilt; responses carry an ETag from the COG so clients revalidate.
+func handleGetPrecipTile(c echo.Context) error {
+	timestamp := c.Param("timestamp")
+	if !tileTimestampPattern.MatchString(timestamp) {
+		return respondWithError(c, http.StatusBadRequest, "Invalid timestamp")
+	}
+	z, errZ := strconv.Atoi(c.Param("z"))
+	x, errX := strconv.Atoi(c.Param("x"))
+	y, errY := strconv.Atoi(strings.TrimSuffix(c.Param("y"), ".png"))
+	if errZ != nil || errX != nil || errY != nil {
+		return respondWithError(c, http.StatusBadRequest, "Invalid tile coordinates")
+	}
+	if z < 0 || z > AppConfig.Tiles.MaxZoom || x < 0 || y < 0 || x >= 1<<z || y >= 1<<z {
+		return respondWithError(c, http.StatusBadRequest, "Tile out of range")
+	}
+
+	cogPath := filepath.Join(AppConfig.Paths.StaticCogDir, timestamp+".tif")
+	cogInfo, err := os.Stat(cogPath)
+	if os.IsNotExist(err) {
+		return respondWithError(c, http.StatusNotFound, "No precipitation raster for "+timestamp)
+	} else if err != nil {
+		log.Printf("Tiles: Failed to stat %s: %v", cogPath, err)
+		return respondWithError(c, http.StatusInternalServerError, "Failed to read precipitation raster")
+	}
+	etag := precipTileETag(cogInfo)
+	c.Response().Header().Set("Cache-Control", fmt.Sprintf("public, max-age=%d", precipTileMaxAge))
+	c.Response().Header().Set("ETag", etag)
+	if c.Request().Header.Get("If-None-Match") == etag {
+		return c.NoContent(http.StatusNotModified)
+	}
+
+	// A tile rendered before the COG was rebuilt is stale
+	cachePath := filepath.Join(precipTileCacheDir(timestamp), strconv.Itoa(z), strconv.Itoa(x), strconv.Itoa(y)+".png")
+	if info, err := os.Stat(cachePath); err == nil && !info.ModTime().Before(cogInfo.ModTime()) {
+		return c.File(cachePath)
+	}
+
+	f, release, err := openCachedCog(cogPath)
+	if err != nil {
+		log.Printf("Tiles: Failed to open %s: %v", cogPath, err)
+		return respondWithError(c, http.StatusInternalServerError, "Failed to read precipitation raster")
+	}
+
+	data, err := renderPrecipTile(f, z, x, y)
+	release()
+	if err != nil {
+		log.Printf("Tiles: Failed to render %s/%d/%d/%d: %v", timestamp, z, x, y, err)
+		return respondWithError(c, http.StatusInternalServerError, "Failed to render tile")
+	}
+
+	if err := os.MkdirAll(filepath.Dir(cachePath), 0755); err == nil {
+		if err := os.WriteFile(cachePath, data, 0644); err != nil {
+			log.Printf("Tiles: Failed to cache %s: %v", cachePath, err)
+		}
+	}
+	return c.Blob(http.StatusOK, "image/png", data)
+}
@@ -0,0 +1,141 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"HMSBackend/cog"
+
+	"github.com/labstack/echo/v4"
+)
+
+func writeTestCogs(t *testing.T, n int) []string {
+	t.Helper()
+	dir := t.TempDir()
+	values := make([]float32, 32*32)
+	paths := make([]string, n)
+	for i := range paths {
+		paths[i] = filepath.Join(dir, fmt.Sprintf("%02d.tif", i))
+		if err := cog.WriteFile(paths[i], values, 32, 32, [4]float64{-99, 29, -98, 30}, cog.Options{TileSize: 16}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	t.Cleanup(func() {
+		for _, p := range paths {
+			closeCachedCog(p)
+		}
+	})
+	return paths
+}
+
+func TestOpenCachedCogEvictsLeastRecentlyUsed(t *testing.T) {
+	paths := writeTestCogs(t, maxOpenCogs+1)
+	for _, p := range paths[:maxOpenCogs] {
+		_, release, err := openCachedCog(p)
+		if err != nil {
+			t.Fatal(err)
+		}
+		release()
+	}
+	// Using the oldest COG again makes the second one the eviction candidate
+	_, release, err := openCachedCog(paths[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	release()
+	if _, release, err = openCachedCog(paths[maxOpenCogs]); err != nil {
+		t.Fatal(err)
+	}
+	release()
+
+	openCogs.Lock()
+	_, keptFirst := openCogs.files[paths[0]]
+	_, keptSecond := openCogs.files[paths[1]]
+	openCogs.Unlock()
+	if !keptFirst || keptSecond {
+		t.Errorf("after a hit on the oldest COG: first cached %v, second cached %v; want true, false", keptFirst, keptSecond)
+	}
+}
+
+func TestOpenCachedCogKeepsEvictedFileOpenUntilReleased(t *testing.T) {
+	paths := writeTestCogs(t, 1)
+	f, release, err := openCachedCog(paths[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	closeCachedCog(paths[0])
+	if _, err := f.ReadLevel(1); err != nil {
+		t.Fatalf("reading an evicted COG still in use: %v", err)
+	}
+	release()
+	release() // a second release must not close twice
+	if _, err := f.ReadLevel(0); err == nil {
+		t.Error("expected the COG to be closed after its last release")
+	}
+}
+
+func TestPrecipTileRevalidatesRebuiltCog(t *testing.T) {
+	saved := AppConfig
+	t.Cleanup(func() { AppConfig = saved })
+	AppConfig.Paths.StaticCogDir = t.TempDir()
+	AppConfig.Tiles.CacheDir = t.TempDir()
+	AppConfig.Tiles.MaxZoom = 10
+
+	cogPath := filepath.Join(AppConfig.Paths.StaticCogDir, "20240501_12Z.tif")
+	writeCog := func(value float32, modTime time.Time) {
+		t.Helper()
+		values := make([]float32, 32*32)
+		for i := range values {
+			values[i] = value
+		}
+		closeCachedCog(cogPath)
+		if err := cog.WriteFile(cogPath, values, 32, 32, [4]float64{-99, 29, -98, 30}, cog.Options{TileSize: 16}); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.Chtimes(cogPath, modTime, modTime); err != nil {
+			t.Fatal(err)
+		}
+	}
+	t.Cleanup(func() { closeCachedCog(cogPath) })
+	get := func(ifNoneMatch string) *httptest.ResponseRecorder {
+		t.Helper()
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		if ifNoneMatch != "" {
+			req.Header.Set("If-None-Match", ifNoneMatch)
+		}
+		rec := httptest.NewRecorder()
+		c := echo.New().NewContext(req, rec)
+		c.SetParamNames("timestamp", "z", "x", "y")
+		c.SetParamValues("20240501_12Z", "7", "28", "53.png")
+		if err := handleGetPrecipTile(c); err != nil {
+			t.Fatal(err)
+		}
+		return rec
+	}
+
+	built := time.Now().Add(-time.Hour)
+	writeCog(0.5, built)
+	first := get("")
+	etag := first.Header().Get("ETag")
+	if first.Code != http.StatusOK || etag == "" {
+		t.Fatalf("first request: status %d, ETag %q", first.Code, etag)
+	}
+	if rec := get(etag); rec.Code != http.StatusNotModified {
+		t.Errorf("unchanged COG: status %d, want 304", rec.Code)
+	}
+
+	// Rebuild the tag in place with different rainfall
+	writeCog(3, time.Now().Add(time.Minute))
+	rebuilt := get(etag)
+	if rebuilt.Code != http.StatusOK || rebuilt.Header().Get("ETag") == etag {
+		t.Fatalf("rebuilt COG: status %d, ETag %q; want 200 and a new ETag", rebuilt.Code, rebuilt.Header().Get("ETag"))
+	}
+	if string(rebuilt.Body.Bytes()) == string(first.Body.Bytes()) {
+		t.Error("rebuilt COG served the tile cached from the previous build")
+	}
+}
@@ -0,0 +1,283 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"HMSBackend/hms"
+	"HMSBackend/sqlcdb"
+
+	"github.com/labstack/echo/v4"
+)
+
+const (
+	defaultStageUnit     = "ft"
+	maxRatingUploadBytes = 1 << 20
+)
+
+func junctionRatingCurve(row sqlcdb.RatingCurve) (JunctionRatingCurve, error) {
+	r := JunctionRatingCurve{
+		Model:     row.Model,
+		Junction:  row.Junction,
+		StageUnit: row.StageUnit,
+		Source:    row.Source,
+		UpdatedAt: &row.UpdatedAt,
+	}
+	if err := json.Unmarshal(row.Curve, &r.RatingCurve); err != nil {
+		return r, fmt.Errorf("rating curve of junction %s is unreadable: %w", row.Junction, err)
+	}
+	return r, nil
+}
+
+// loadRatingCurves returns the rating curves of a model's junctions keyed
+// by upper-case junction name
+func loadRatingCurves(ctx context.Context, queries *sqlcdb.Queries, modelID string) (map[string]JunctionRatingCurve, error) {
+	rows, err := queries.ListRatingCurves(ctx, modelID)
+	if err != nil {
+		return nil, err
+	}
+	out := make(map[string]JunctionRatingCurve, len(rows))
+	for _, row := range rows {
+		r, err := junctionRatingCurve(row)
+		if err != nil {
+			log.Printf("Warning: %v", err)
+			continue
+		}
+		out[strings.ToUpper(r.Junction)] = r
+	}
+	return out, nil
+}
+
+// applyRatingCurves sets the stage of every flow of the junctions with a
+// rating curve. Failures are only logged so the flows are still served.
+func applyRatingCurves(ctx context.Context, queries *sqlcdb.Queries, modelID string, flows *JunctionFlowsFile) {
+	curves, err := loadRatingCurves(ctx, queries, modelID)
+	if err != nil {
+		log.Printf("Warning: Rating curves of model %s not applied: %v", modelID, err)
+		return
+	}
+	setJunctionStages(curves, flows)
+}
+
+// setJunctionStages sets the stage of every flow of the junctions in curves,
+// which is keyed by upper-case junction name. Missing flows get no stage.
+func setJunctionStages(curves map[string]JunctionRatingCurve, flows *JunctionFlowsFile) {
+	for i, s := range flows.Series {
+		r, ok := curves[strings.ToUpper(s.Name)]
+		if !ok {
+			continue
+		}
+		flows.Series[i].StageUnit = r.StageUnit
+		for j, p := range s.Data {
+			if !missingFlow(p.Value) {
+				flows.Series[i].Data[j].Stage = r.roundedStage(p.Value)
+			}
+		}
+	}
+}
+
+// roundedStage returns the stage of flow to the hundredth
+func (r JunctionRatingCurve) roundedStage(flow float64) *float64 {
+	stage := math.Round(r.Stage(flow)*100) / 100
+	return &stage
+}
+
+// loadJunctionRatingCurve returns the rating curve of one junction, nil when
+// it has none. Failures are only logged so the flows are still served.
+func loadJunctionRatingCurve(ctx context.Context, queries *sqlcdb.Queries, modelID, junction string) *JunctionRatingCurve {
+	row, err := queries.GetRatingCurve(ctx, sqlcdb.GetRatingCurveParams{
+		Model:    modelID,
+		Junction: strings.ToUpper(junction),
+	})
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil
+	}
+	if err != nil {
+		log.Printf("Warning: Rating curve of junction %s not applied: %v", junction, err)
+		return nil
+	}
+	r, err := junctionRatingCurve(row)
+	if err != nil {
+		log.Printf("Warning: %v", err)
+		return nil
+	}
+	return &r
+}
+
+// parseRatingCSV reads flow,stage rows of a rating table; a first row that
+// is not numeric is taken as a header
+func parseRatingCSV(r io.Reader) ([]hms.RatingPoint, error) {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+	rows, err := reader.ReadAll()
+	if err != nil {
+		return nil, fmt.Errorf("invalid CSV: %w", err)
+	}
+
+	var points []hms.RatingPoint
+	for i, row := range rows {
+		if len(row) < 2 {
+			return nil, fmt.Errorf("line %d: expected flow,stage", i+1)
+		}
+		flow, ferr := strconv.ParseFloat(strings.TrimSpace(row[0]), 64)
+		stage, serr := strconv.ParseFloat(strings.TrimSpace(row[1]), 64)
+		if ferr != nil || serr != nil {
+			if i == 0 {
+				continue // header
+			}
+			return nil, fmt.Errorf("line %d: flow and stage must be numbers", i+1)
+		}
+		points = append(points, hms.RatingPoint{Flow: flow, Stage: stage})
+	}
+	return points, nil
+}
+
+// handleListRatingCurves serves GET /api/rating-curves?model=
+func handleListRatingCurves(queries *sqlcdb.Queries) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		model, err := GetModel(c.QueryParam("model"))
+		if err != nil {
+			return respondWithError(c, http.StatusBadRequest, err.Error())
+		}
+		curves, err := loadRatingCurves(c.Request().Context(), queries, model.ID)
+		if err != nil {
+			log.Printf("Error listing rating curves: %v", err)
+			return respondWithError(c, http.StatusInternalServerError, "Failed to list rating curves")
+		}
+		out := make([]JunctionRatingCurve, 0, len(curves))
+		for _, r := range curves {
+			out = append(out, r)
+		}
+		sortRatingCurves(out)
+		return respondWithJSON(c, http.StatusOK, out)
+	}
+}
+
+func sortRatingCurves(curves []JunctionRatingCurve) {
+	for i := 1; i < len(curves); i++ {
+		for j := i; j > 0 && curves[j].Junction < curves[j-1].Junction; j-- {
+			curves[j], curves[j-1] = curves[j-1], curves[j]
+		}
+	}
+}
+
+// handleGetRatingCurve serves GET /api/rating-curves/:model/:junction
+func handleGetRatingCurve(queries *sqlcdb.Queries) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		model, err := GetModel(c.Param("model"))
+		if err != nil {
+			return respondWithError(c, http.StatusBadRequest, err.Error())
+		}
+		row, err := queries.GetRatingCurve(c.Request().Context(), sqlcdb.GetRatingCurveParams{
+			Model:    model.ID,
+			Junction: strings.ToUpper(c.Param("junction")),
+		})
+		if errors.Is(err, sql.ErrNoRows) {
+			return respondWithError(c, http.StatusNotFound, "Junction has no rating curve")
+		}
+		if err != nil {
+			log.Printf("Error getting rating curve: %v", err)
+			return respondWithError(c, http.StatusInternalServerError, "Failed to get rating curve")
+		}
+		r, err := junctionRatingCurve(row)
+		if err != nil {
+			log.Printf("Error reading rating curve: %v", err)
+			return respondWithError(c, http.StatusInternalServerError, "Failed to read rating curve")
+		}
+		return respondWithJSON(c, http.StatusOK, r)
+	}
+}
+
+// handlePutRatingCurve handles PUT /api/rating-curves/:model/:junction
+// (admins) with a JSON JunctionRatingCurve, or a flow,stage table CSV as
+// the multipart field "file" (form fields stage_unit and source). Junction
+// names are stored upper-case like flood thresholds.
+func handlePutRatingCurve(queries *sqlcdb.Queries) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		if ok, err := requireAdmin(c, queries); !ok {
+			return err
+		}
+		var req JunctionRatingCurve
+		if fh, err := c.FormFile("file"); err == nil {
+			f, err := fh.Open()
+			if err != nil {
+				return respondWithError(c, http.StatusBadRequest, "Failed to open uploaded file")
+			}
+			defer f.Close()
+			if req.Points, err = parseRatingCSV(io.LimitReader(f, maxRatingUploadBytes)); err != nil {
+				return respondWithError(c, http.StatusBadRequest, err.Error())
+			}
+			req.Kind = hms.RatingTable
+			req.StageUnit, req.Source = c.FormValue("stage_unit"), c.FormValue("source")
+		} else if err := c.Bind(&req); err != nil {
+			return respondWithError(c, http.StatusBadRequest, "Invalid request format")
+		}
+
+		model, err := GetModel(c.Param("model"))
+		if err != nil {
+			return respondWithError(c, http.StatusBadRequest, err.Error())
+		}
+		req.Model, req.Junction = model.ID, strings.ToUpper(c.Param("junction"))
+		if req.StageUnit == "" {
+			req.StageUnit = defaultStageUnit
+		}
+		if err := req.Validate(); err != nil {
+			return respondWithError(c, http.StatusBadRequest, err.Error())
+		}
+		curve, err := json.Marshal(req.RatingCurve)
+		if err != nil {
+			return respondWithError(c, http.StatusBadRequest, "Invalid rating curve")
+		}
+
+		row, err := queries.UpsertRatingCurve(c.Request().Context(), sqlcdb.UpsertRatingCurveParams{
+			Model:     req.Model,
+			Junction:  req.Junction,
+			Curve:     curve,
+			StageUnit: req.StageUnit,
+			Source:    req.Source,
+		})
+		if err != nil {
+			log.Printf("Error storing rating curve: %v", err)
+			return respondWithError(c, http.StatusInternalServerError, "Failed to store rating curve")
+		}
+		log.Printf("INFO: Stored %s rating curve of junction %s (model %s)", req.Kind, row.Junction, row.Model)
+		req.UpdatedAt = &row.UpdatedAt
+		return respondWithJSON(c, http.StatusOK, req)
+	}
+}
+
+// handleDeleteRatingCurve handles DELETE /api/rating-curves/:model/:junction (admins)
+func handleDeleteRatingCurve(queries *sqlcdb.Queries) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		if ok, err := requireAdmin(c, queries); !ok {
+			return err
+		}
+		model, err := GetModel(c.Param("model"))
+		if err != nil {
+			return respondWithError(c, http.StatusBadRequest, err.Error())
+		}
+		n, err := queries.DeleteRatingCurve(c.Request().Context(), sqlcdb.DeleteRatingCurveParams{
+			Model:    model.ID,
+			Junction: strings.ToUpper(c.Param("junction")),
+		})
+		if err != nil {
+			log.Printf("Error deleting rating curve: %v", err)
+			return respondWithError(c, http.StatusInternalServerError, "Failed to delete rating curve")
+		}
+		if n == 0 {
+			return respondWithError(c, http.StatusNotFound, "Junction has no rating curve")
+		}
+		return respondWithJSON(c, http.StatusOK, map[string]string{"message": "Rating curve deleted successfully"})
+	}
+}
@@ -0,0 +1,75 @@
+package main
+
+import (
+	"strings"
+	"testing"
+
+	"HMSBackend/hms"
+)
+
+func TestSetJunctionStages(t *testing.T) {
+	curves := map[string]JunctionRatingCurve{
+		"CHI-001": {
+			Junction:  "CHI-001",
+			StageUnit: "ft",
+			RatingCurve: hms.RatingCurve{Kind: hms.RatingTable, Points: []hms.RatingPoint{
+				{Flow: 0, Stage: 1},
+				{Flow: 300, Stage: 2},
+			}},
+		},
+	}
+	flows := &JunctionFlowsFile{Series: []JunctionFlowSeries{
+		{Name: "chi-001", Data: []JunctionFlowPoint{{Value: 0}, {Value: 100}, {Value: 450}, {Value: -3.402823466e38}}},
+		{Name: "OUTLET", Data: []JunctionFlowPoint{{Value: 100}}},
+	}}
+	setJunctionStages(curves, flows)
+
+	rated := flows.Series[0]
+	if rated.StageUnit != "ft" {
+		t.Errorf("stage unit %q, want ft", rated.StageUnit)
+	}
+	// Rounded to the hundredth; the last segment extends above 300
+	for i, want := range []float64{1, 1.33, 2.5} {
+		if s := rated.Data[i].Stage; s == nil || *s != want {
+			t.Errorf("stage of %g = %v, want %g", rated.Data[i].Value, s, want)
+		}
+	}
+	if s := rated.Data[3].Stage; s != nil {
+		t.Errorf("missing flow got stage %g", *s)
+	}
+	if unrated := flows.Series[1]; unrated.StageUnit != "" || unrated.Data[0].Stage != nil {
+		t.Errorf("junction without a rating curve got stages: %+v", unrated)
+	}
+}
+
+func TestParseRatingCSV(t *testing.T) {
+	tests := []struct {
+		name    string
+		csv     string
+		want    []hms.RatingPoint
+		wantErr bool
+	}{
+		{"header", "flow,stage\n0,1\n300, 2.5\n", []hms.RatingPoint{{Flow: 0, Stage: 1}, {Flow: 300, Stage: 2.5}}, false},
+		{"no header", "0,1\n300,2\n", []hms.RatingPoint{{Flow: 0, Stage: 1}, {Flow: 300, Stage: 2}}, false},
+		{"extra columns", "Q (cfs),H (ft),note\n10,1,gauged\n", []hms.RatingPoint{{Flow: 10, Stage: 1}}, false},
+		{"text after the header", "flow,stage\n0,1\nhigh,2\n", nil, true},
+		{"one column", "0\n", nil, true},
+		{"malformed", "0,\"1\n", nil, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseRatingCSV(strings.NewReader(tt.csv))
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("error %v, want error %v", err, tt.wantErr)
+			}
+			if len(got) != len(tt.want) {
+				t.Fatalf("got %v, want %v", got, tt.want)
+			}
+			for i := range got {
+				if got[i] != tt.want[i] {
+					t.Errorf("point %d = %+v, want %+v", i, got[i], tt.want[i])
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,200 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"regexp"
+	"time"
+
+	"HMSBackend/sqlcdb"
+
+	"github.com/labstack/echo/v4"
+)
+
+// scenarioNamePattern keeps scenario names usable in URLs and job directories
+var scenarioNamePattern = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9_.-]{0,99}$`)
+
+func validateScenarioName(name string) error {
+	if !scenarioNamePattern.MatchString(name) {
+		return errors.New("name must be 1-100 letters, digits, '_', '.' or '-'")
+	}
+	return nil
+}
+
+// startScenario records a scenario of model as running and computes it in the
+// background. compute receives the scenario's pipeline run id and returns the
+// junction flows stored with the scenario. A scenario with the same name is
+// replaced.
+func startScenario(queries *sqlcdb.Queries, model ModelConfig, name, kind string, definition interface{},
+	compute func(ctx context.Context, runID int32) (*JunctionFlowsFile, error)) (sqlcdb.Scenario, error) {
+	body, err := json.Marshal(definition)
+	if err != nil {
+		return sqlcdb.Scenario{}, err
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	scenario, err := queries.UpsertScenario(ctx, sqlcdb.UpsertScenarioParams{
+		Name:       name,
+		Model:      model.ID,
+		Kind:       kind,
+		Definition: body,
+	})
+	if err != nil {
+		return sqlcdb.Scenario{}, fmt.Errorf("failed to record scenario: %w", err)
+	}
+
+	go func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 2*time.Hour)
+		defer cancel()
+
+		runID := startPipelineRun(ctx, queries, kind, json.RawMessage(body))
+		if runID != 0 {
+			if err := queries.SetScenarioRun(ctx, sqlcdb.SetScenarioRunParams{
+				ID:    scenario.ID,
+				RunID: sql.NullInt32{Int32: runID, Valid: true},
+			}); err != nil {
+				log.Printf("Warning: Failed to link scenario %s to run %d: %v", name, runID, err)
+			}
+		}
+
+		flows, runErr := compute(ctx, runID)
+		finishPipelineRun(queries, runID, runErr)
+		finishScenario(queries, scenario.ID, flows, runErr)
+		if runErr != nil {
+			log.Printf("Scenario %s of model %s failed: %v", name, model.ID, runErr)
+			return
+		}
+		log.Printf("INFO: Scenario %s of model %s completed", name, model.ID)
+	}()
+
+	return scenario, nil
+}
+
+// finishScenario stores the outcome and flows of a scenario
+func finishScenario(queries *sqlcdb.Queries, id int32, flows *JunctionFlowsFile, runErr error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	params := sqlcdb.FinishScenarioParams{ID: id, Status: pipelineStatusSucceeded, Flows: json.RawMessage("{}")}
+	if runErr != nil {
+		params.Status = pipelineStatusFailed
+		params.ErrorMessage = runErr.Error()
+	} else if flows != nil {
+		body, err := json.Marshal(flows)
+		if err != nil {
+			log.Printf("Warning: Failed to encode flows of scenario %d: %v", id, err)
+		} else {
+			params.Flows = body
+		}
+	}
+	if err := queries.FinishScenario(ctx, params); err != nil {
+		log.Printf("Warning: Failed to update scenario %d: %v", id, err)
+	}
+}
+
+func scenarioSummary(name, model, kind, status, errorMessage string, runID sql.NullInt32, createdAt time.Time, finishedAt sql.NullTime) ScenarioSummary {
+	s := ScenarioSummary{
+		Name:      name,
+		Model:     model,
+		Kind:      kind,
+		Status:    status,
+		Error:     errorMessage,
+		CreatedAt: createdAt,
+	}
+	if runID.Valid {
+		s.RunID = runID.Int32
+	}
+	if finishedAt.Valid {
+		t := finishedAt.Time
+		s.FinishedAt = &t
+	}
+	return s
+}
+
+// handleListScenarios lists the scenarios, newest first, optionally of one
+// model (?model=) and kind (?kind=)
+func handleListScenarios(queries *sqlcdb.Queries) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		rows, err := queries.ListScenarios(c.Request().Context(), sqlcdb.ListScenariosParams{
+			Model: c.QueryParam("model"),
+			Kind:  c.QueryParam("kind"),
+		})
+		if err != nil {
+			log.Printf("Error listing scenarios: %v", err)
+			return respondWithError(c, http.StatusInternalServerError, "Failed to list scenarios")
+		}
+		out := make([]ScenarioSummary, len(rows))
+		for i, r := range rows {
+			out[i] = scenarioSummary(r.Name, r.Model, r.Kind, r.Status, r.ErrorMessage, r.RunID, r.CreatedAt, r.FinishedAt)
+		}
+		return respondWithJSON(c, http.StatusOK, out)
+	}
+}
+
+// handleGetScenario returns a scenario with its definition and junction flows
+func handleGetScenario(queries *sqlcdb.Queries) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		s, err := queries.GetScenario(c.Request().Context(), sqlcdb.GetScenarioParams{
+			Model: c.Param("model"),
+			Name:  c.Param("name"),
+		})
+		if errors.Is(err, sql.ErrNoRows) {
+			return respondWithError(c, http.StatusNotFound, "Scenario not found")
+		}
+		if err != nil {
+			log.Printf("Error getting scenario %s/%s: %v", c.Param("model"), c.Param("name"), err)
+			return respondWithError(c, http.StatusInternalServerError, "Failed to get scenario")
+		}
+		out := ScenarioResponse{
+			ScenarioSummary: scenarioSummary(s.Name, s.Model, s.Kind, s.Status, s.ErrorMessage, s.RunID, s.CreatedAt, s.FinishedAt),
+			Definition:      s.Definition,
+		}
+		if s.Status == pipelineStatusSucceeded {
+			out.Flows = decodeScenarioFlows(s.Flows, s.Model, s.Name)
+		}
+		out.Baseline = decodeScenarioFlows(s.Baseline, s.Model, s.Name)
+		if out.Flows != nil && out.Baseline != nil {
+			out.Comparison = compareJunctionPeaks(out.Baseline, out.Flows)
+		}
+		return respondWithJSON(c, http.StatusOK, out)
+	}
+}
+
+// decodeScenarioFlows decodes flows stored with a scenario, nil when none were
+func decodeScenarioFlows(data json.RawMessage, model, name string) *JunctionFlowsFile {
+	var flows JunctionFlowsFile
+	if err := json.Unmarshal(data, &flows); err != nil {
+		log.Printf("Warning: Failed to decode flows of scenario %s/%s: %v", model, name, err)
+		return nil
+	}
+	if flows.Series == nil {
+		return nil
+	}
+	return &flows
+}
+
+// handleDeleteScenario deletes a scenario (admins); its pipeline run is kept
+func handleDeleteScenario(queries *sqlcdb.Queries) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		if ok, err := requireAdmin(c, queries); !ok {
+			return err
+		}
+		n, err := queries.DeleteScenario(c.Request().Context(), sqlcdb.DeleteScenarioParams{
+			Model: c.Param("model"),
+			Name:  c.Param("name"),
+		})
+		if err != nil {
+			log.Printf("Error deleting scenario %s/%s: %v", c.Param("model"), c.Param("name"), err)
+			return respondWithError(c, http.StatusInternalServerError, "Failed to delete scenario")
+		}
+		if n == 0 {
+			return respondWithError(c, http.StatusNotFound, "Scenario not found")
+		}
+		return respondWithJSON(c, http.StatusOK, map[string]string{"message": "Scenario deleted successfully"})
+	}
+}
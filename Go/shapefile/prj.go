@@ -0,0 +1,272 @@
+package shapefile
+
+import (
+	"fmt"
+	"math"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// projection converts file coordinates to longitude/latitude in degrees.
+type projection interface {
+	inverse(x, y float64) (lon, lat float64)
+}
+
+type geographic struct{}
+
+func (geographic) inverse(x, y float64) (float64, float64) { return x, y }
+
+// ellipsoid parameters shared by the projected coordinate systems.
+type ellipsoid struct {
+	a, e, e2 float64
+}
+
+func newEllipsoid(a, invFlattening float64) ellipsoid {
+	f := 0.0
+	if invFlattening != 0 {
+		f = 1 / invFlattening
+	}
+	e2 := 2*f - f*f
+	return ellipsoid{a: a, e: math.Sqrt(e2), e2: e2}
+}
+
+// m and q are Snyder's (14-15) and (3-12); t is (15-9).
+func (el ellipsoid) m(phi float64) float64 {
+	s := math.Sin(phi)
+	return math.Cos(phi) / math.Sqrt(1-el.e2*s*s)
+}
+
+func (el ellipsoid) q(phi float64) float64 {
+	s := math.Sin(phi)
+	if el.e == 0 {
+		return 2 * s
+	}
+	return (1 - el.e2) * (s/(1-el.e2*s*s) - 1/(2*el.e)*math.Log((1-el.e*s)/(1+el.e*s)))
+}
+
+func (el ellipsoid) t(phi float64) float64 {
+	s := math.Sin(phi)
+	return math.Tan(math.Pi/4-phi/2) / math.Pow((1-el.e*s)/(1+el.e*s), el.e/2)
+}
+
+// projected holds the common false origin and linear unit handling.
+type projected struct {
+	falseEasting, falseNorthing float64 // metres
+	unit                        float64 // metres per file unit
+}
+
+func (p projected) metres(x, y float64) (float64, float64) {
+	return x*p.unit - p.falseEasting, y*p.unit - p.falseNorthing
+}
+
+// albers is the Albers equal-area conic projection (Snyder ch. 14).
+type albers struct {
+	projected
+	el         ellipsoid
+	lon0       float64
+	n, c, rho0 float64
+}
+
+func newAlbers(p projected, el ellipsoid, lat0, lon0, sp1, sp2 float64) *albers {
+	m1, m2 := el.m(sp1), el.m(sp2)
+	q1, q2, q0 := el.q(sp1), el.q(sp2), el.q(lat0)
+	n := math.Sin(sp1)
+	if math.Abs(sp1-sp2) > 1e-10 {
+		n = (m1*m1 - m2*m2) / (q2 - q1)
+	}
+	c := m1*m1 + n*q1
+	return &albers{projected: p, el: el, lon0: lon0, n: n, c: c, rho0: el.a * math.Sqrt(c-n*q0) / n}
+}
+
+func (p *albers) inverse(x, y float64) (float64, float64) {
+	x, y = p.metres(x, y)
+	rho0y := p.rho0 - y
+	rho := math.Hypot(x, rho0y)
+	theta := math.Atan2(x, rho0y)
+	if p.n < 0 {
+		rho, theta = -rho, math.Atan2(-x, -rho0y)
+	}
+	q := (p.c - rho*rho*p.n*p.n/(p.el.a*p.el.a)) / p.n
+	phi := math.Asin(math.Max(-1, math.Min(1, q/2)))
+	el := p.el
+	for i := 0; i < 15 && el.e > 0; i++ {
+		s := math.Sin(phi)
+		d := (1 - el.e2*s*s) * (1 - el.e2*s*s) / (2 * math.Cos(phi)) *
+			(q/(1-el.e2) - s/(1-el.e2*s*s) + 1/(2*el.e)*math.Log((1-el.e*s)/(1+el.e*s)))
+		phi += d
+		if math.Abs(d) < 1e-12 {
+			break
+		}
+	}
+	return degrees(p.lon0 + theta/p.n), degrees(phi)
+}
+
+// lambert is the Lambert conformal conic projection (Snyder ch. 15), one or
+// two standard parallels.
+type lambert struct {
+	projected
+	el            ellipsoid
+	lon0          float64
+	n, f, rho0, k float64
+}
+
+func newLambert(p projected, el ellipsoid, lat0, lon0, sp1, sp2, k0 float64) *lambert {
+	m1, m2 := el.m(sp1), el.m(sp2)
+	t1, t2, t0 := el.t(sp1), el.t(sp2), el.t(lat0)
+	n := math.Sin(sp1)
+	if math.Abs(sp1-sp2) > 1e-10 {
+		n = (math.Log(m1) - math.Log(m2)) / (math.Log(t1) - math.Log(t2))
+	}
+	f := m1 / (n * math.Pow(t1, n))
+	return &lambert{projected: p, el: el, lon0: lon0, n: n, f: f, k: k0, rho0: el.a * f * k0 * math.Pow(t0, n)}
+}
+
+func (p *lambert) inverse(x, y float64) (float64, float64) {
+	x, y = p.metres(x, y)
+	sign := 1.0
+	if p.n < 0 {
+		sign = -1
+	}
+	rho0y := p.rho0 - y
+	rho := sign * math.Hypot(x, rho0y)
+	theta := math.Atan2(sign*x, sign*rho0y)
+	t := math.Pow(rho/(p.el.a*p.f*p.k), 1/p.n)
+	phi := math.Pi/2 - 2*math.Atan(t)
+	for i := 0; i < 15; i++ {
+		s := math.Sin(phi)
+		next := math.Pi/2 - 2*math.Atan(t*math.Pow((1-p.el.e*s)/(1+p.el.e*s), p.el.e/2))
+		if math.Abs(next-phi) < 1e-12 {
+			phi = next
+			break
+		}
+		phi = next
+	}
+	return degrees(p.lon0 + theta/p.n), degrees(phi)
+}
+
+// transverseMercator follows Snyder ch. 8 (UTM and most state plane zones).
+type transverseMercator struct {
+	projected
+	el         ellipsoid
+	lat0, lon0 float64
+	k0         float64
+}
+
+// meridianArc is Snyder (3-21).
+func (el ellipsoid) meridianArc(phi float64) float64 {
+	e2 := el.e2
+	e4, e6 := e2*e2, e2*e2*e2
+	return el.a * ((1-e2/4-3*e4/64-5*e6/256)*phi -
+		(3*e2/8+3*e4/32+45*e6/1024)*math.Sin(2*phi) +
+		(15*e4/256+45*e6/1024)*math.Sin(4*phi) -
+		(35*e6/3072)*math.Sin(6*phi))
+}
+
+func (p *transverseMercator) inverse(x, y float64) (float64, float64) {
+	x, y = p.metres(x, y)
+	el := p.el
+	e2 := el.e2
+	ep2 := e2 / (1 - e2)
+	m := el.meridianArc(p.lat0) + y/p.k0
+	mu := m / (el.a * (1 - e2/4 - 3*e2*e2/64 - 5*e2*e2*e2/256))
+	e1 := (1 - math.Sqrt(1-e2)) / (1 + math.Sqrt(1-e2))
+	phi1 := mu + (3*e1/2-27*e1*e1*e1/32)*math.Sin(2*mu) +
+		(21*e1*e1/16-55*e1*e1*e1*e1/32)*math.Sin(4*mu) +
+		(151*e1*e1*e1/96)*math.Sin(6*mu) +
+		(1097*e1*e1*e1*e1/512)*math.Sin(8*mu)
+
+	s, c := math.Sin(phi1), math.Cos(phi1)
+	c1 := ep2 * c * c
+	t1 := math.Tan(phi1) * math.Tan(phi1)
+	n1 := el.a / math.Sqrt(1-e2*s*s)
+	r1 := el.a * (1 - e2) / math.Pow(1-e2*s*s, 1.5)
+	d := x / (n1 * p.k0)
+
+	phi := phi1 - (n1*math.Tan(phi1)/r1)*(d*d/2-
+		(5+3*t1+10*c1-4*c1*c1-9*ep2)*math.Pow(d, 4)/24+
+		(61+90*t1+298*c1+45*t1*t1-252*ep2-3*c1*c1)*math.Pow(d, 6)/720)
+	lon := p.lon0 + (d-(1+2*t1+c1)*d*d*d/6+
+		(5-2*c1+28*t1-3*c1*c1+8*ep2+24*t1*t1)*math.Pow(d, 5)/120)/c
+	return degrees(lon), degrees(phi)
+}
+
+func degrees(r float64) float64 { return r * 180 / math.Pi }
+func radians(d float64) float64 { return d * math.Pi / 180 }
+
+var (
+	wktProjection = regexp.MustCompile(`PROJECTION\["([^"]+)"`)
+	wktParameter  = regexp.MustCompile(`PARAMETER\["([^"]+)",\s*([-+0-9.eE]+)\]`)
+	wktSpheroid   = regexp.MustCompile(`SPHEROID\["[^"]*",\s*([-+0-9.eE]+),\s*([-+0-9.eE]+)`)
+	wktUnit       = regexp.MustCompile(`UNIT\["[^"]*",\s*([-+0-9.eE]+)`)
+)
+
+// parsePRJ builds a projection from ESRI/OGC WKT.
+func parsePRJ(wkt string) (projection, error) {
+	wkt = strings.TrimSpace(wkt)
+	if !strings.HasPrefix(wkt, "PROJCS") {
+		return geographic{}, nil
+	}
+
+	name := ""
+	if m := wktProjection.FindStringSubmatch(wkt); m != nil {
+		name = strings.ToLower(m[1])
+	}
+	params := map[string]float64{}
+	for _, m := range wktParameter.FindAllStringSubmatch(wkt, -1) {
+		v, err := strconv.ParseFloat(m[2], 64)
+		if err == nil {
+			params[strings.ToLower(m[1])] = v
+		}
+	}
+	param := func(def float64, keys ...string) float64 {
+		for _, k := range keys {
+			if v, ok := params[k]; ok {
+				return v
+			}
+		}
+		return def
+	}
+
+	el := newEllipsoid(6378137, 298.257222101) // GRS 1980
+	if m := wktSpheroid.FindStringSubmatch(wkt); m != nil {
+		a, _ := strconv.ParseFloat(m[1], 64)
+		inv, _ := strconv.ParseFloat(m[2], 64)
+		if a > 0 {
+			el = newEllipsoid(a, inv)
+		}
+	}
+
+	// The linear unit is the last UNIT of the PROJCS (the first belongs to GEOGCS)
+	unit := 1.0
+	if units := wktUnit.FindAllStringSubmatch(wkt, -1); len(units) > 1 {
+		if v, err := strconv.ParseFloat(units[len(units)-1][1], 64); err == nil && v > 0 {
+			unit = v
+		}
+	}
+
+	p := projected{
+		falseEasting:  param(0, "false_easting") * unit,
+		falseNorthing: param(0, "false_northing") * unit,
+		unit:          unit,
+	}
+	lon0 := radians(param(0, "central_meridian", "longitude_of_center", "longitude_of_origin"))
+	lat0 := radians(param(0, "latitude_of_origin", "latitude_of_center"))
+	sp1 := radians(param(lat0*180/math.Pi, "standard_parallel_1"))
+	sp2 := radians(param(sp1*180/math.Pi, "standard_parallel_2"))
+	k0 := param(1, "scale_factor")
+
+	switch {
+	case strings.Contains(name, "albers"):
+		return newAlbers(p, el, lat0, lon0, sp1, sp2), nil
+	case strings.Contains(name, "lambert_conformal_conic"):
+		if _, ok := params["standard_parallel_1"]; !ok {
+			// One standard parallel form: the origin latitude is the parallel
+			sp1, sp2 = lat0, lat0
+		}
+		return newLambert(p, el, lat0, lon0, sp1, sp2, k0), nil
+	case strings.Contains(name, "transverse_mercator"):
+		return &transverseMercator{projected: p, el: el, lat0: lat0, lon0: lon0, k0: k0}, nil
+	}
+	return nil, fmt.Errorf("unsupported projection %q", name)
+}
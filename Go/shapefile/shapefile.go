@@ -0,0 +1,214 @@
+// Package shapefile reads polygon ESRI shapefiles (.shp with its .dbf
+// attributes and optional .prj) and returns the rings in longitude/latitude.
+//
+// Projected files are converted using the .prj definition; Albers equal area,
+// Lambert conformal conic and transverse Mercator projections are supported.
+// Datum shifts (e.g. NAD83 to WGS84) are ignored as they are well below the
+// resolution of the precipitation grids this is used with.
+package shapefile
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"strings"
+)
+
+// Feature is one polygon record. Rings are closed lists of lon/lat points;
+// outer rings and holes are not distinguished, point-in-polygon uses the
+// even-odd rule so holes work naturally.
+type Feature struct {
+	Attributes map[string]string
+	Rings      [][][2]float64
+	BBox       [4]float64 // west, south, east, north
+}
+
+// Contains reports whether lon/lat falls inside the feature.
+func (f *Feature) Contains(lon, lat float64) bool {
+	if lon < f.BBox[0] || lon > f.BBox[2] || lat < f.BBox[1] || lat > f.BBox[3] {
+		return false
+	}
+	inside := false
+	for _, ring := range f.Rings {
+		for i, j := 0, len(ring)-1; i < len(ring); j, i = i, i+1 {
+			a, b := ring[i], ring[j]
+			if (a[1] > lat) != (b[1] > lat) && lon < (b[0]-a[0])*(lat-a[1])/(b[1]-a[1])+a[0] {
+				inside = !inside
+			}
+		}
+	}
+	return inside
+}
+
+// Read loads the polygons of path (the .shp file) with attributes from the
+// sibling .dbf and coordinates converted with the sibling .prj, if present.
+func Read(path string) ([]Feature, error) {
+	base := strings.TrimSuffix(path, ".shp")
+
+	var proj projection = geographic{}
+	if wkt, err := os.ReadFile(base + ".prj"); err == nil {
+		p, err := parsePRJ(string(wkt))
+		if err != nil {
+			return nil, fmt.Errorf("%s.prj: %w", base, err)
+		}
+		proj = p
+	}
+
+	shp, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer shp.Close()
+	features, err := readShapes(shp, proj)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+
+	dbf, err := os.Open(base + ".dbf")
+	if err != nil {
+		if os.IsNotExist(err) {
+			return features, nil
+		}
+		return nil, err
+	}
+	defer dbf.Close()
+	attrs, err := readDBF(dbf)
+	if err != nil {
+		return nil, fmt.Errorf("%s.dbf: %w", base, err)
+	}
+	for i := range features {
+		if i < len(attrs) {
+			features[i].Attributes = attrs[i]
+		}
+	}
+	return features, nil
+}
+
+// readShapes parses the .shp main file.
+func readShapes(r io.Reader, proj projection) ([]Feature, error) {
+	var hdr [100]byte
+	if _, err := io.ReadFull(r, hdr[:]); err != nil {
+		return nil, fmt.Errorf("reading header: %w", err)
+	}
+	if binary.BigEndian.Uint32(hdr[0:]) != 9994 {
+		return nil, errors.New("not a shapefile")
+	}
+	switch t := binary.LittleEndian.Uint32(hdr[32:]); t {
+	case 5, 15, 25: // Polygon, PolygonZ, PolygonM
+	default:
+		return nil, fmt.Errorf("shape type %d is not a polygon", t)
+	}
+
+	var features []Feature
+	var rec [8]byte
+	for {
+		if _, err := io.ReadFull(r, rec[:]); err != nil {
+			if errors.Is(err, io.EOF) {
+				return features, nil
+			}
+			return nil, fmt.Errorf("reading record header: %w", err)
+		}
+		content := make([]byte, 2*int(binary.BigEndian.Uint32(rec[4:])))
+		if _, err := io.ReadFull(r, content); err != nil {
+			return nil, fmt.Errorf("reading record %d: %w", binary.BigEndian.Uint32(rec[0:]), err)
+		}
+		f, err := parsePolygon(content, proj)
+		if err != nil {
+			return nil, fmt.Errorf("record %d: %w", binary.BigEndian.Uint32(rec[0:]), err)
+		}
+		features = append(features, f)
+	}
+}
+
+func parsePolygon(b []byte, proj projection) (Feature, error) {
+	f := Feature{BBox: [4]float64{math.Inf(1), math.Inf(1), math.Inf(-1), math.Inf(-1)}}
+	if len(b) < 4 || binary.LittleEndian.Uint32(b) == 0 {
+		return f, nil // null shape
+	}
+	if len(b) < 44 {
+		return f, errors.New("polygon record too short")
+	}
+	numParts := int(binary.LittleEndian.Uint32(b[36:]))
+	numPoints := int(binary.LittleEndian.Uint32(b[40:]))
+	pointsAt := 44 + 4*numParts
+	if numParts < 0 || numPoints < 0 || len(b) < pointsAt+16*numPoints {
+		return f, errors.New("polygon record truncated")
+	}
+	for p := 0; p < numParts; p++ {
+		start := int(binary.LittleEndian.Uint32(b[44+4*p:]))
+		end := numPoints
+		if p+1 < numParts {
+			end = int(binary.LittleEndian.Uint32(b[44+4*(p+1):]))
+		}
+		if start < 0 || end > numPoints || start > end {
+			return f, errors.New("invalid part index")
+		}
+		ring := make([][2]float64, 0, end-start)
+		for k := start; k < end; k++ {
+			o := pointsAt + 16*k
+			x := math.Float64frombits(binary.LittleEndian.Uint64(b[o:]))
+			y := math.Float64frombits(binary.LittleEndian.Uint64(b[o+8:]))
+			lon, lat := proj.inverse(x, y)
+			ring = append(ring, [2]float64{lon, lat})
+			f.BBox[0] = math.Min(f.BBox[0], lon)
+			f.BBox[1] = math.Min(f.BBox[1], lat)
+			f.BBox[2] = math.Max(f.BBox[2], lon)
+			f.BBox[3] = math.Max(f.BBox[3], lat)
+		}
+		f.Rings = append(f.Rings, ring)
+	}
+	return f, nil
+}
+
+// readDBF parses dBASE III attribute records as trimmed strings.
+func readDBF(r io.Reader) ([]map[string]string, error) {
+	var hdr [32]byte
+	if _, err := io.ReadFull(r, hdr[:]); err != nil {
+		return nil, fmt.Errorf("reading header: %w", err)
+	}
+	numRecords := int(binary.LittleEndian.Uint32(hdr[4:]))
+	headerLen := int(binary.LittleEndian.Uint16(hdr[8:]))
+	recordLen := int(binary.LittleEndian.Uint16(hdr[10:]))
+	if headerLen < 33 || recordLen < 1 {
+		return nil, errors.New("invalid header")
+	}
+
+	desc := make([]byte, headerLen-32)
+	if _, err := io.ReadFull(r, desc); err != nil {
+		return nil, fmt.Errorf("reading field descriptors: %w", err)
+	}
+	type field struct {
+		name   string
+		length int
+	}
+	var fields []field
+	for o := 0; o+32 <= len(desc) && desc[o] != 0x0D; o += 32 {
+		name := string(desc[o : o+11])
+		if i := strings.IndexByte(name, 0); i >= 0 {
+			name = name[:i]
+		}
+		fields = append(fields, field{name: name, length: int(desc[o+16])})
+	}
+
+	out := make([]map[string]string, 0, numRecords)
+	rec := make([]byte, recordLen)
+	for i := 0; i < numRecords; i++ {
+		if _, err := io.ReadFull(r, rec); err != nil {
+			return nil, fmt.Errorf("reading record %d: %w", i, err)
+		}
+		attrs := make(map[string]string, len(fields))
+		o := 1 // deletion flag
+		for _, fd := range fields {
+			if o+fd.length > len(rec) {
+				break
+			}
+			attrs[fd.name] = strings.TrimSpace(string(rec[o : o+fd.length]))
+			o += fd.length
+		}
+		out = append(out, attrs)
+	}
+	return out, nil
+}
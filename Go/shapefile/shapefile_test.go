@@ -0,0 +1,166 @@
+package shapefile
+
+import (
+	"bytes"
+	"encoding/binary"
+	"math"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// writeTestShapefile writes one polygon with a square hole and a NAME field
+func writeTestShapefile(t *testing.T, dir string) string {
+	t.Helper()
+	rings := [][][2]float64{
+		{{-99, 29}, {-99, 30}, {-98, 30}, {-98, 29}, {-99, 29}},
+		{{-98.6, 29.4}, {-98.4, 29.4}, {-98.4, 29.6}, {-98.6, 29.6}, {-98.6, 29.4}},
+	}
+
+	var content bytes.Buffer
+	le := func(v interface{}) { binary.Write(&content, binary.LittleEndian, v) }
+	le(int32(5))
+	le([4]float64{-99, 29, -98, 30})
+	le(int32(len(rings)))
+	le(int32(10))
+	le([]int32{0, 5})
+	for _, r := range rings {
+		for _, p := range r {
+			le(p)
+		}
+	}
+
+	var shp bytes.Buffer
+	header := make([]byte, 100)
+	binary.BigEndian.PutUint32(header[0:], 9994)
+	binary.BigEndian.PutUint32(header[24:], uint32(100+8+content.Len())/2)
+	binary.LittleEndian.PutUint32(header[28:], 1000)
+	binary.LittleEndian.PutUint32(header[32:], 5)
+	shp.Write(header)
+	binary.Write(&shp, binary.BigEndian, [2]int32{1, int32(content.Len() / 2)})
+	shp.Write(content.Bytes())
+
+	var dbf bytes.Buffer
+	dbfHeader := make([]byte, 32)
+	dbfHeader[0] = 3
+	binary.LittleEndian.PutUint32(dbfHeader[4:], 1)
+	binary.LittleEndian.PutUint16(dbfHeader[8:], 32+32+1)
+	binary.LittleEndian.PutUint16(dbfHeader[10:], 1+10)
+	dbf.Write(dbfHeader)
+	field := make([]byte, 32)
+	copy(field, "NAME")
+	field[11] = 'C'
+	field[16] = 10
+	dbf.Write(field)
+	dbf.WriteString("\r LeonCreek \x1a")
+
+	base := filepath.Join(dir, "test")
+	if err := os.WriteFile(base+".shp", shp.Bytes(), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(base+".dbf", dbf.Bytes(), 0644); err != nil {
+		t.Fatal(err)
+	}
+	return base + ".shp"
+}
+
+func TestReadPolygonWithHole(t *testing.T) {
+	features, err := Read(writeTestShapefile(t, t.TempDir()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(features) != 1 {
+		t.Fatalf("got %d features, want 1", len(features))
+	}
+	f := features[0]
+	if f.Attributes["NAME"] != "LeonCreek" {
+		t.Errorf("NAME = %q, want LeonCreek", f.Attributes["NAME"])
+	}
+	if f.BBox != [4]float64{-99, 29, -98, 30} {
+		t.Errorf("bbox = %v", f.BBox)
+	}
+	for _, c := range []struct {
+		lon, lat float64
+		want     bool
+	}{
+		{-98.8, 29.2, true},
+		{-98.5, 29.5, false}, // in the hole
+		{-97.5, 29.5, false},
+		{-98.2, 29.9, true},
+	} {
+		if got := f.Contains(c.lon, c.lat); got != c.want {
+			t.Errorf("Contains(%v, %v) = %v, want %v", c.lon, c.lat, got, c.want)
+		}
+	}
+}
+
+// Forward projections (Snyder) used to check the inverses round trip.
+
+func albersForward(p *albers, lon, lat float64) (float64, float64) {
+	rho := p.el.a * math.Sqrt(p.c-p.n*p.el.q(radians(lat))) / p.n
+	theta := p.n * (radians(lon) - p.lon0)
+	x, y := rho*math.Sin(theta), p.rho0-rho*math.Cos(theta)
+	return (x + p.falseEasting) / p.unit, (y + p.falseNorthing) / p.unit
+}
+
+func lambertForward(p *lambert, lon, lat float64) (float64, float64) {
+	rho := p.el.a * p.f * p.k * math.Pow(p.el.t(radians(lat)), p.n)
+	theta := p.n * (radians(lon) - p.lon0)
+	x, y := rho*math.Sin(theta), p.rho0-rho*math.Cos(theta)
+	return (x + p.falseEasting) / p.unit, (y + p.falseNorthing) / p.unit
+}
+
+func tmForward(p *transverseMercator, lon, lat float64) (float64, float64) {
+	el := p.el
+	phi := radians(lat)
+	ep2 := el.e2 / (1 - el.e2)
+	s, c := math.Sin(phi), math.Cos(phi)
+	n := el.a / math.Sqrt(1-el.e2*s*s)
+	t := math.Tan(phi) * math.Tan(phi)
+	cc := ep2 * c * c
+	a := (radians(lon) - p.lon0) * c
+	m := el.meridianArc(phi)
+	x := p.k0 * n * (a + (1-t+cc)*math.Pow(a, 3)/6 + (5-18*t+t*t+72*cc-58*ep2)*math.Pow(a, 5)/120)
+	y := p.k0 * (m - el.meridianArc(p.lat0) + n*math.Tan(phi)*(a*a/2+(5-t+9*cc+4*cc*cc)*math.Pow(a, 4)/24+
+		(61-58*t+t*t+600*cc-330*ep2)*math.Pow(a, 6)/720))
+	return (x + p.falseEasting) / p.unit, (y + p.falseNorthing) / p.unit
+}
+
+const (
+	albersPRJ  = `PROJCS["USA_Contiguous_Albers_Equal_Area_Conic_USGS_version",GEOGCS["GCS_North_American_1983",DATUM["D_North_American_1983",SPHEROID["GRS_1980",6378137.0,298.257222101]],PRIMEM["Greenwich",0.0],UNIT["Degree",0.0174532925199433]],PROJECTION["Albers"],PARAMETER["False_Easting",0.0],PARAMETER["False_Northing",0.0],PARAMETER["Central_Meridian",-96.0],PARAMETER["Standard_Parallel_1",29.5],PARAMETER["Standard_Parallel_2",45.5],PARAMETER["Latitude_Of_Origin",23.0],UNIT["Meter",1.0]]`
+	lambertPRJ = `PROJCS["NAD_1983_StatePlane_Texas_South_Central_FIPS_4204_Feet",GEOGCS["GCS_North_American_1983",DATUM["D_North_American_1983",SPHEROID["GRS_1980",6378137.0,298.257222101]],PRIMEM["Greenwich",0.0],UNIT["Degree",0.0174532925199433]],PROJECTION["Lambert_Conformal_Conic"],PARAMETER["False_Easting",1968500.0],PARAMETER["False_Northing",13123333.33333333],PARAMETER["Central_Meridian",-99.0],PARAMETER["Standard_Parallel_1",28.38333333333333],PARAMETER["Standard_Parallel_2",30.28333333333333],PARAMETER["Latitude_Of_Origin",27.83333333333333],UNIT["Foot_US",0.3048006096012192]]`
+	utmPRJ     = `PROJCS["NAD_1983_UTM_Zone_14N",GEOGCS["GCS_North_American_1983",DATUM["D_North_American_1983",SPHEROID["GRS_1980",6378137.0,298.257222101]],PRIMEM["Greenwich",0.0],UNIT["Degree",0.0174532925199433]],PROJECTION["Transverse_Mercator"],PARAMETER["False_Easting",500000.0],PARAMETER["False_Northing",0.0],PARAMETER["Central_Meridian",-99.0],PARAMETER["Scale_Factor",0.9996],PARAMETER["Latitude_Of_Origin",0.0],UNIT["Meter",1.0]]`
+)
+
+func TestProjectionsRoundTrip(t *testing.T) {
+	points := [][2]float64{{-98.49, 29.42}, {-98.81, 29.73}, {-98.12, 29.11}}
+	for _, c := range []struct {
+		name    string
+		wkt     string
+		forward func(projection, float64, float64) (float64, float64)
+	}{
+		{"albers", albersPRJ, func(p projection, lon, lat float64) (float64, float64) { return albersForward(p.(*albers), lon, lat) }},
+		{"lambert", lambertPRJ, func(p projection, lon, lat float64) (float64, float64) { return lambertForward(p.(*lambert), lon, lat) }},
+		{"utm", utmPRJ, func(p projection, lon, lat float64) (float64, float64) {
+			return tmForward(p.(*transverseMercator), lon, lat)
+		}},
+	} {
+		p, err := parsePRJ(c.wkt)
+		if err != nil {
+			t.Fatalf("%s: %v", c.name, err)
+		}
+		for _, pt := range points {
+			x, y := c.forward(p, pt[0], pt[1])
+			lon, lat := p.inverse(x, y)
+			if math.Abs(lon-pt[0]) > 1e-7 || math.Abs(lat-pt[1]) > 1e-7 {
+				t.Errorf("%s: %v -> (%.2f, %.2f) -> (%.8f, %.8f)", c.name, pt, x, y, lon, lat)
+			}
+		}
+	}
+
+	// The false origin of UTM 14N is the central meridian on the equator
+	p, _ := parsePRJ(utmPRJ)
+	if lon, lat := p.inverse(500000, 0); math.Abs(lon+99) > 1e-9 || math.Abs(lat) > 1e-9 {
+		t.Errorf("UTM inverse of the false origin = %v, %v; want -99, 0", lon, lat)
+	}
+}
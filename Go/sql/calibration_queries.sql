@@ -0,0 +1,54 @@
+-- name: CreateCalibration :one
+INSERT INTO public.calibrations (
+    name,
+    model,
+    objective,
+    definition,
+    status
+) VALUES (
+    $1, $2, $3, $4, 'running'
+)
+RETURNING *;
+
+-- name: SetCalibrationRun :exec
+UPDATE public.calibrations
+SET run_id = $2
+WHERE id = $1;
+
+-- name: FinishCalibration :exec
+UPDATE public.calibrations
+SET status = $2,
+    error_message = $3,
+    best_trial = $4,
+    finished_at = NOW()
+WHERE id = $1;
+
+-- name: GetCalibration :one
+SELECT *
+FROM public.calibrations
+WHERE id = $1;
+
+-- name: ListCalibrations :many
+SELECT id, name, model, objective, status, error_message, run_id, best_trial, created_at, finished_at
+FROM public.calibrations
+WHERE (sqlc.arg(model)::text = '' OR model = sqlc.arg(model)::text)
+ORDER BY created_at DESC;
+
+-- name: InsertCalibrationTrial :exec
+INSERT INTO public.calibration_trials (
+    calibration_id,
+    trial,
+    parameters,
+    status,
+    error_message,
+    score,
+    fits
+) VALUES (
+    $1, $2, $3, $4, $5, $6, $7
+);
+
+-- name: ListCalibrationTrials :many
+SELECT *
+FROM public.calibration_trials
+WHERE calibration_id = $1
+ORDER BY trial;
@@ -0,0 +1,42 @@
+-- name: UpsertHistoricalEvent :one
+INSERT INTO public.historical_events (
+    name,
+    model,
+    description,
+    start_time,
+    end_time,
+    run_id,
+    inputs,
+    flows,
+    artifacts_dir
+) VALUES (
+    $1, $2, $3, $4, $5, $6, $7, $8, $9
+)
+ON CONFLICT (model, name) DO UPDATE SET
+    description = EXCLUDED.description,
+    start_time = EXCLUDED.start_time,
+    end_time = EXCLUDED.end_time,
+    run_id = EXCLUDED.run_id,
+    inputs = EXCLUDED.inputs,
+    flows = EXCLUDED.flows,
+    artifacts_dir = EXCLUDED.artifacts_dir,
+    created_at = NOW()
+RETURNING *;
+
+-- name: GetHistoricalEvent :one
+SELECT *
+FROM public.historical_events
+WHERE model = $1
+  AND name = $2;
+
+-- name: ListHistoricalEvents :many
+SELECT id, name, model, description, start_time, end_time, run_id, created_at
+FROM public.historical_events
+WHERE (sqlc.arg(model)::text = '' OR model = sqlc.arg(model)::text)
+ORDER BY start_time DESC;
+
+-- name: DeleteHistoricalEvent :one
+DELETE FROM public.historical_events
+WHERE model = $1
+  AND name = $2
+RETURNING artifacts_dir;
@@ -0,0 +1,91 @@
+-- name: UpsertRainGauge :exec
+INSERT INTO public.rain_gauges (
+    station_id,
+    name,
+    lat,
+    lon,
+    source
+) VALUES (
+    $1, $2, $3, $4, $5
+)
+ON CONFLICT (station_id) DO UPDATE SET
+    name = EXCLUDED.name,
+    lat = EXCLUDED.lat,
+    lon = EXCLUDED.lon,
+    source = EXCLUDED.source,
+    updated_at = NOW();
+
+-- name: ListRainGauges :many
+SELECT *
+FROM public.rain_gauges
+ORDER BY station_id;
+
+-- name: UpsertGaugeObservation :exec
+INSERT INTO public.gauge_observations (
+    station_id,
+    valid_time,
+    precip_in,
+    source
+) VALUES (
+    $1, $2, $3, $4
+)
+ON CONFLICT (station_id, valid_time) DO UPDATE SET
+    precip_in = EXCLUDED.precip_in,
+    source = EXCLUDED.source;
+
+-- name: ListGaugeObservations :many
+SELECT *
+FROM public.gauge_observations
+WHERE station_id = sqlc.arg(station_id)
+  AND valid_time > sqlc.arg(from_time)
+  AND valid_time <= sqlc.arg(to_time)
+ORDER BY valid_time;
+
+-- name: ListGaugeTotals :many
+SELECT g.station_id,
+       g.name,
+       g.lat,
+       g.lon,
+       SUM(o.precip_in)::float8 AS total_precip,
+       COUNT(*) AS observation_count
+FROM public.gauge_observations o
+JOIN public.rain_gauges g ON g.station_id = o.station_id
+WHERE o.valid_time > sqlc.arg(from_time)
+  AND o.valid_time <= sqlc.arg(to_time)
+GROUP BY g.station_id, g.name, g.lat, g.lon
+ORDER BY g.station_id;
+
+-- name: UpsertPrecipBiasFactor :one
+INSERT INTO public.precip_bias_factors (
+    run_id,
+    method,
+    window_start,
+    window_end,
+    mean_field_factor,
+    gauges_used,
+    applied,
+    details
+) VALUES (
+    $1, $2, $3, $4, $5, $6, $7, $8
+)
+ON CONFLICT (run_id) DO UPDATE SET
+    method = EXCLUDED.method,
+    window_start = EXCLUDED.window_start,
+    window_end = EXCLUDED.window_end,
+    mean_field_factor = EXCLUDED.mean_field_factor,
+    gauges_used = EXCLUDED.gauges_used,
+    applied = EXCLUDED.applied,
+    details = EXCLUDED.details,
+    created_at = NOW()
+RETURNING *;
+
+-- name: GetPrecipBiasFactor :one
+SELECT *
+FROM public.precip_bias_factors
+WHERE run_id = $1;
+
+-- name: GetLatestPrecipBiasFactor :one
+SELECT *
+FROM public.precip_bias_factors
+ORDER BY created_at DESC
+LIMIT 1;
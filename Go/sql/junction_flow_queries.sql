@@ -0,0 +1,81 @@
+-- name: UpsertJunctionFlowRun :one
+INSERT INTO public.junction_flow_runs (
+    model,
+    run_id,
+    issue_time
+) VALUES (
+    $1, $2, $3
+)
+ON CONFLICT (model, issue_time) DO UPDATE SET
+    run_id = EXCLUDED.run_id,
+    created_at = NOW()
+RETURNING *;
+
+-- name: DeleteJunctionFlows :exec
+DELETE FROM public.junction_flows
+WHERE flow_run_id = $1;
+
+-- name: InsertJunctionFlows :exec
+INSERT INTO public.junction_flows (
+    flow_run_id,
+    junction,
+    valid_time,
+    flow,
+    observed
+)
+SELECT sqlc.arg(flow_run_id)::int,
+       unnest(sqlc.arg(junctions)::text[]),
+       unnest(sqlc.arg(valid_times)::timestamp[]),
+       unnest(sqlc.arg(flows)::float8[]),
+       unnest(sqlc.arg(observed)::boolean[]);
+
+-- name: GetJunctionFlowRun :one
+SELECT *
+FROM public.junction_flow_runs
+WHERE id = $1;
+
+-- name: ListJunctionFlowRuns :many
+SELECT *
+FROM public.junction_flow_runs
+WHERE model = sqlc.arg(model)
+  AND issue_time >= sqlc.arg(from_time)
+  AND issue_time <= sqlc.arg(to_time)
+ORDER BY issue_time DESC
+LIMIT sqlc.arg(max_rows);
+
+-- name: ListRunJunctionFlows :many
+SELECT valid_time, flow, observed
+FROM public.junction_flows
+WHERE flow_run_id = sqlc.arg(flow_run_id)
+  AND junction = sqlc.arg(junction)
+ORDER BY valid_time;
+
+-- name: ListJunctionForecasts :many
+SELECT r.id AS flow_run_id,
+       r.run_id,
+       r.issue_time,
+       f.valid_time,
+       f.flow,
+       f.observed
+FROM public.junction_flows f
+JOIN public.junction_flow_runs r ON r.id = f.flow_run_id
+WHERE r.model = sqlc.arg(model)
+  AND f.junction = sqlc.arg(junction)
+  AND r.issue_time >= sqlc.arg(from_time)
+  AND r.issue_time <= sqlc.arg(to_time)
+ORDER BY r.issue_time, f.valid_time;
+
+-- name: ListSimulatedObservedFlows :many
+SELECT DISTINCT ON (f.valid_time)
+       f.valid_time,
+       f.flow,
+       r.id AS flow_run_id,
+       r.issue_time
+FROM public.junction_flows f
+JOIN public.junction_flow_runs r ON r.id = f.flow_run_id
+WHERE r.model = sqlc.arg(model)
+  AND f.junction = sqlc.arg(junction)
+  AND f.observed
+  AND f.valid_time >= sqlc.arg(from_time)
+  AND f.valid_time <= sqlc.arg(to_time)
+ORDER BY f.valid_time, r.issue_time DESC;
@@ -0,0 +1,33 @@
+-- name: CreatePipelineRun :one
+INSERT INTO public.pipeline_runs (
+    run_type,
+    status,
+    parameters
+) VALUES (
+    $1, 'running', $2
+)
+RETURNING *;
+
+-- name: FinishPipelineRun :exec
+UPDATE public.pipeline_runs
+SET status = $2,
+    error_message = $3,
+    finished_at = NOW()
+WHERE id = $1;
+
+-- name: SetPipelineRunProgress :exec
+UPDATE public.pipeline_runs
+SET progress = $2
+WHERE id = $1;
+
+-- name: GetPipelineRun :one
+SELECT *
+FROM public.pipeline_runs
+WHERE id = $1;
+
+-- name: ListPipelineRuns :many
+SELECT *
+FROM public.pipeline_runs
+WHERE (sqlc.arg(run_type)::text = '' OR run_type = sqlc.arg(run_type)::text)
+ORDER BY started_at DESC
+LIMIT sqlc.arg(max_rows);
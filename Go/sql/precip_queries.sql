@@ -0,0 +1,114 @@
+-- name: UpsertPrecipCog :one
+INSERT INTO public.precip_cogs (
+    product,
+    kind,
+    duration_hours,
+    valid_start,
+    valid_end,
+    file_name,
+    source_grib,
+    west,
+    south,
+    east,
+    north,
+    width,
+    height,
+    units,
+    min_value,
+    max_value,
+    mean_value,
+    wet_fraction,
+    valid_cells
+) VALUES (
+    $1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16, $17, $18, $19
+)
+ON CONFLICT (file_name) DO UPDATE SET
+    product = EXCLUDED.product,
+    kind = EXCLUDED.kind,
+    duration_hours = EXCLUDED.duration_hours,
+    valid_start = EXCLUDED.valid_start,
+    valid_end = EXCLUDED.valid_end,
+    source_grib = EXCLUDED.source_grib,
+    west = EXCLUDED.west,
+    south = EXCLUDED.south,
+    east = EXCLUDED.east,
+    north = EXCLUDED.north,
+    width = EXCLUDED.width,
+    height = EXCLUDED.height,
+    units = EXCLUDED.units,
+    min_value = EXCLUDED.min_value,
+    max_value = EXCLUDED.max_value,
+    mean_value = EXCLUDED.mean_value,
+    wet_fraction = EXCLUDED.wet_fraction,
+    valid_cells = EXCLUDED.valid_cells,
+    created_at = NOW()
+RETURNING *;
+
+-- name: ListPrecipCogs :many
+SELECT *
+FROM public.precip_cogs
+WHERE valid_end >= sqlc.arg(from_time)
+  AND valid_end <= sqlc.arg(to_time)
+  AND (sqlc.arg(product)::text = '' OR product = sqlc.arg(product)::text)
+ORDER BY valid_end, product;
+
+-- name: GetLatestPrecipCog :one
+SELECT *
+FROM public.precip_cogs
+WHERE product = $1
+ORDER BY valid_end DESC
+LIMIT 1;
+
+-- name: GetPrecipCogEndingBetween :one
+SELECT *
+FROM public.precip_cogs
+WHERE product = sqlc.arg(product)
+  AND valid_end > sqlc.arg(after_time)
+  AND valid_end <= sqlc.arg(before_time)
+ORDER BY valid_end DESC
+LIMIT 1;
+
+-- name: ListPrecipCogProducts :many
+SELECT DISTINCT product
+FROM public.precip_cogs
+ORDER BY product;
+
+-- name: ListPrecipCogsEndingBefore :many
+SELECT *
+FROM public.precip_cogs
+WHERE product = $1
+  AND valid_end < $2
+ORDER BY valid_end;
+
+-- name: DeletePrecipCog :exec
+DELETE FROM public.precip_cogs
+WHERE id = $1;
+
+-- name: UpsertPrecipRunSummary :one
+INSERT INTO public.precip_run_summaries (
+    run_id,
+    window_start,
+    window_end,
+    summary
+) VALUES (
+    $1, $2, $3, $4
+)
+ON CONFLICT (run_id) DO UPDATE SET
+    window_start = EXCLUDED.window_start,
+    window_end = EXCLUDED.window_end,
+    summary = EXCLUDED.summary,
+    created_at = NOW()
+RETURNING *;
+
+-- name: GetPrecipRunSummary :one
+SELECT *
+FROM public.precip_run_summaries
+WHERE run_id = $1;
+
+-- name: GetLatestPrecipRunSummary :one
+SELECT s.*
+FROM public.precip_run_summaries s
+JOIN public.pipeline_runs r ON r.id = s.run_id
+WHERE (sqlc.arg(run_type)::text = '' OR r.run_type = sqlc.arg(run_type)::text)
+ORDER BY s.created_at DESC
+LIMIT 1;
@@ -0,0 +1,33 @@
+-- name: UpsertRatingCurve :one
+INSERT INTO public.rating_curves (
+    model,
+    junction,
+    curve,
+    stage_unit,
+    source
+) VALUES (
+    $1, $2, $3, $4, $5
+)
+ON CONFLICT (model, junction) DO UPDATE SET
+    curve = EXCLUDED.curve,
+    stage_unit = EXCLUDED.stage_unit,
+    source = EXCLUDED.source,
+    updated_at = NOW()
+RETURNING *;
+
+-- name: GetRatingCurve :one
+SELECT *
+FROM public.rating_curves
+WHERE model = $1
+  AND junction = $2;
+
+-- name: ListRatingCurves :many
+SELECT *
+FROM public.rating_curves
+WHERE model = $1
+ORDER BY junction;
+
+-- name: DeleteRatingCurve :execrows
+DELETE FROM public.rating_curves
+WHERE model = $1
+  AND junction = $2;
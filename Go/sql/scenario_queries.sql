@@ -0,0 +1,57 @@
+-- name: UpsertScenario :one
+INSERT INTO public.scenarios (
+    name,
+    model,
+    kind,
+    definition,
+    status
+) VALUES (
+    $1, $2, $3, $4, 'running'
+)
+ON CONFLICT (model, name) DO UPDATE SET
+    kind = EXCLUDED.kind,
+    definition = EXCLUDED.definition,
+    status = 'running',
+    error_message = '',
+    run_id = NULL,
+    flows = '{}',
+    baseline = '{}',
+    created_at = NOW(),
+    finished_at = NULL
+RETURNING *;
+
+-- name: SetScenarioRun :exec
+UPDATE public.scenarios
+SET run_id = $2
+WHERE id = $1;
+
+-- name: SetScenarioBaseline :exec
+UPDATE public.scenarios
+SET baseline = $2
+WHERE id = $1;
+
+-- name: FinishScenario :exec
+UPDATE public.scenarios
+SET status = $2,
+    error_message = $3,
+    flows = $4,
+    finished_at = NOW()
+WHERE id = $1;
+
+-- name: GetScenario :one
+SELECT *
+FROM public.scenarios
+WHERE model = $1
+  AND name = $2;
+
+-- name: ListScenarios :many
+SELECT id, name, model, kind, status, error_message, run_id, created_at, finished_at
+FROM public.scenarios
+WHERE (sqlc.arg(model)::text = '' OR model = sqlc.arg(model)::text)
+  AND (sqlc.arg(kind)::text = '' OR kind = sqlc.arg(kind)::text)
+ORDER BY created_at DESC;
+
+-- name: DeleteScenario :execrows
+DELETE FROM public.scenarios
+WHERE model = $1
+  AND name = $2;
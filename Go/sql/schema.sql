@@ -20,4 +20,241 @@ CREATE TABLE public.organizations
     created_at timestamp without time zone NOT NULL DEFAULT now(),
     updated_at timestamp without time zone NOT NULL DEFAULT now(),
     CONSTRAINT organizations_pkey PRIMARY KEY (id)
-);
\ No newline at end of file
+);
+
+-- Catalogo de COGs de precipitacion publicados (GET /api/precip/catalog)
+CREATE TABLE public.precip_cogs
+(
+    id SERIAL PRIMARY KEY,
+    product TEXT NOT NULL,
+    kind TEXT NOT NULL,
+    duration_hours INT NOT NULL,
+    valid_start TIMESTAMP NOT NULL,
+    valid_end TIMESTAMP NOT NULL,
+    file_name TEXT NOT NULL UNIQUE,
+    source_grib TEXT NOT NULL DEFAULT '',
+    west DOUBLE PRECISION NOT NULL,
+    south DOUBLE PRECISION NOT NULL,
+    east DOUBLE PRECISION NOT NULL,
+    north DOUBLE PRECISION NOT NULL,
+    width INT NOT NULL,
+    height INT NOT NULL,
+    units TEXT NOT NULL,
+    min_value DOUBLE PRECISION NOT NULL,
+    max_value DOUBLE PRECISION NOT NULL,
+    mean_value DOUBLE PRECISION NOT NULL,
+    wet_fraction DOUBLE PRECISION NOT NULL,
+    valid_cells INT NOT NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX precip_cogs_product_valid_end_idx ON public.precip_cogs (product, valid_end);
+
+-- Ejecuciones del pipeline HMS (tiempo real e historico)
+CREATE TABLE public.pipeline_runs
+(
+    id SERIAL PRIMARY KEY,
+    run_type TEXT NOT NULL,
+    status TEXT NOT NULL,
+    parameters JSONB NOT NULL DEFAULT '{}',
+    error_message TEXT NOT NULL DEFAULT '',
+    progress JSONB NOT NULL DEFAULT '{}',
+    started_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    finished_at TIMESTAMP
+);
+
+CREATE INDEX pipeline_runs_started_at_idx ON public.pipeline_runs (started_at);
+
+-- Resumen de precipitacion MRMS por subcuenca y condado para cada ejecucion
+CREATE TABLE public.precip_run_summaries
+(
+    id SERIAL PRIMARY KEY,
+    run_id INT NOT NULL UNIQUE REFERENCES public.pipeline_runs (id) ON DELETE CASCADE,
+    window_start TIMESTAMP NOT NULL,
+    window_end TIMESTAMP NOT NULL,
+    summary JSONB NOT NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+-- Pluviometros y sus observaciones (correccion de sesgo radar/pluviometro)
+CREATE TABLE public.rain_gauges
+(
+    station_id TEXT PRIMARY KEY,
+    name TEXT NOT NULL DEFAULT '',
+    lat DOUBLE PRECISION NOT NULL,
+    lon DOUBLE PRECISION NOT NULL,
+    source TEXT NOT NULL DEFAULT '',
+    updated_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+CREATE TABLE public.gauge_observations
+(
+    id SERIAL PRIMARY KEY,
+    station_id TEXT NOT NULL REFERENCES public.rain_gauges (station_id) ON DELETE CASCADE,
+    valid_time TIMESTAMP NOT NULL,
+    precip_in DOUBLE PRECISION NOT NULL,
+    source TEXT NOT NULL DEFAULT '',
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    UNIQUE (station_id, valid_time)
+);
+
+CREATE INDEX gauge_observations_valid_time_idx ON public.gauge_observations (valid_time);
+
+-- Factores de sesgo calculados (y aplicados) en cada ejecucion
+CREATE TABLE public.precip_bias_factors
+(
+    id SERIAL PRIMARY KEY,
+    run_id INT NOT NULL UNIQUE REFERENCES public.pipeline_runs (id) ON DELETE CASCADE,
+    method TEXT NOT NULL,
+    window_start TIMESTAMP NOT NULL,
+    window_end TIMESTAMP NOT NULL,
+    mean_field_factor DOUBLE PRECISION NOT NULL,
+    gauges_used INT NOT NULL,
+    applied BOOLEAN NOT NULL,
+    details JSONB NOT NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+-- Escenarios con nombre (tormentas de diseno, ...) y los caudales por union que produjeron
+CREATE TABLE public.scenarios
+(
+    id SERIAL PRIMARY KEY,
+    name TEXT NOT NULL,
+    model TEXT NOT NULL,
+    kind TEXT NOT NULL,
+    definition JSONB NOT NULL,
+    status TEXT NOT NULL,
+    error_message TEXT NOT NULL DEFAULT '',
+    run_id INT REFERENCES public.pipeline_runs (id) ON DELETE SET NULL,
+    flows JSONB NOT NULL DEFAULT '{}',
+    baseline JSONB NOT NULL DEFAULT '{}',
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    finished_at TIMESTAMP,
+    UNIQUE (model, name)
+);
+
+-- Calibraciones de parametros contra caudales observados y los ensayos de cada una
+CREATE TABLE public.calibrations
+(
+    id SERIAL PRIMARY KEY,
+    name TEXT NOT NULL,
+    model TEXT NOT NULL,
+    objective TEXT NOT NULL,
+    definition JSONB NOT NULL,
+    status TEXT NOT NULL,
+    error_message TEXT NOT NULL DEFAULT '',
+    run_id INT REFERENCES public.pipeline_runs (id) ON DELETE SET NULL,
+    best_trial INT,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    finished_at TIMESTAMP
+);
+
+CREATE TABLE public.calibration_trials
+(
+    id SERIAL PRIMARY KEY,
+    calibration_id INT NOT NULL REFERENCES public.calibrations (id) ON DELETE CASCADE,
+    trial INT NOT NULL,
+    parameters JSONB NOT NULL,
+    status TEXT NOT NULL,
+    error_message TEXT NOT NULL DEFAULT '',
+    score DOUBLE PRECISION,
+    fits JSONB NOT NULL DEFAULT '{}',
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    UNIQUE (calibration_id, trial)
+);
+
+-- Biblioteca de eventos historicos: cada ejecucion historica guardada con sus entradas y resultados
+CREATE TABLE public.historical_events
+(
+    id SERIAL PRIMARY KEY,
+    name TEXT NOT NULL,
+    model TEXT NOT NULL,
+    description TEXT NOT NULL DEFAULT '',
+    start_time TIMESTAMP NOT NULL,
+    end_time TIMESTAMP NOT NULL,
+    run_id INT REFERENCES public.pipeline_runs (id) ON DELETE SET NULL,
+    inputs JSONB NOT NULL,
+    flows JSONB NOT NULL,
+    artifacts_dir TEXT NOT NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    UNIQUE (model, name)
+);
+
+-- Caudales por union de cada ejecucion en tiempo real: pronostico y periodo observado simulado
+CREATE TABLE public.junction_flow_runs
+(
+    id SERIAL PRIMARY KEY,
+    model TEXT NOT NULL,
+    run_id INT REFERENCES public.pipeline_runs (id) ON DELETE SET NULL,
+    issue_time TIMESTAMP NOT NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    UNIQUE (model, issue_time)
+);
+
+CREATE TABLE public.junction_flows
+(
+    flow_run_id INT NOT NULL REFERENCES public.junction_flow_runs (id) ON DELETE CASCADE,
+    junction TEXT NOT NULL,
+    valid_time TIMESTAMP NOT NULL,
+    flow DOUBLE PRECISION NOT NULL,
+    observed BOOLEAN NOT NULL,
+    PRIMARY KEY (flow_run_id, junction, valid_time)
+);
+
+CREATE INDEX junction_flows_junction_valid_time_idx ON public.junction_flows (junction, valid_time);
+
+-- Caudales observados en las uniones (aforos subidos), referencia para la verificacion
+CREATE TABLE public.streamflow_observations
+(
+    id SERIAL PRIMARY KEY,
+    model TEXT NOT NULL,
+    junction TEXT NOT NULL,
+    valid_time TIMESTAMP NOT NULL,
+    flow DOUBLE PRECISION NOT NULL,
+    source TEXT NOT NULL DEFAULT '',
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    UNIQUE (model, junction, valid_time)
+);
+
+-- Verificaciones de los pronosticos por union y plazo
+CREATE TABLE public.forecast_verifications
+(
+    id SERIAL PRIMARY KEY,
+    model TEXT NOT NULL,
+    reference TEXT NOT NULL,
+    window_start TIMESTAMP NOT NULL,
+    window_end TIMESTAMP NOT NULL,
+    bin_hours INT NOT NULL,
+    results JSONB NOT NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX forecast_verifications_model_created_at_idx ON public.forecast_verifications (model, created_at);
+
+-- Umbrales de inundacion por union (caudales de accion, menor, moderada y mayor) y cruces bajos asociados
+CREATE TABLE public.junction_thresholds
+(
+    id SERIAL PRIMARY KEY,
+    model TEXT NOT NULL,
+    junction TEXT NOT NULL,
+    action_flow DOUBLE PRECISION,
+    minor_flow DOUBLE PRECISION,
+    moderate_flow DOUBLE PRECISION,
+    major_flow DOUBLE PRECISION,
+    crossings JSONB NOT NULL DEFAULT '[]',
+    updated_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    UNIQUE (model, junction)
+);
+
+-- Curvas de gasto por union (tabla caudal-nivel o ley potencial) para convertir caudal a nivel
+CREATE TABLE public.rating_curves
+(
+    id SERIAL PRIMARY KEY,
+    model TEXT NOT NULL,
+    junction TEXT NOT NULL,
+    curve JSONB NOT NULL,
+    stage_unit TEXT NOT NULL DEFAULT 'ft',
+    source TEXT NOT NULL DEFAULT '',
+    updated_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    UNIQUE (model, junction)
+);
@@ -0,0 +1,37 @@
+-- name: UpsertJunctionThreshold :one
+INSERT INTO public.junction_thresholds (
+    model,
+    junction,
+    action_flow,
+    minor_flow,
+    moderate_flow,
+    major_flow,
+    crossings
+) VALUES (
+    $1, $2, $3, $4, $5, $6, $7
+)
+ON CONFLICT (model, junction) DO UPDATE SET
+    action_flow = EXCLUDED.action_flow,
+    minor_flow = EXCLUDED.minor_flow,
+    moderate_flow = EXCLUDED.moderate_flow,
+    major_flow = EXCLUDED.major_flow,
+    crossings = EXCLUDED.crossings,
+    updated_at = NOW()
+RETURNING *;
+
+-- name: GetJunctionThreshold :one
+SELECT *
+FROM public.junction_thresholds
+WHERE model = $1
+  AND junction = $2;
+
+-- name: ListJunctionThresholds :many
+SELECT *
+FROM public.junction_thresholds
+WHERE model = $1
+ORDER BY junction;
+
+-- name: DeleteJunctionThreshold :execrows
+DELETE FROM public.junction_thresholds
+WHERE model = $1
+  AND junction = $2;
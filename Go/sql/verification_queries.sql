@@ -0,0 +1,75 @@
+-- name: UpsertStreamflowObservations :exec
+INSERT INTO public.streamflow_observations (
+    model,
+    junction,
+    valid_time,
+    flow,
+    source
+)
+SELECT sqlc.arg(model)::text,
+       unnest(sqlc.arg(junctions)::text[]),
+       unnest(sqlc.arg(valid_times)::timestamp[]),
+       unnest(sqlc.arg(flows)::float8[]),
+       sqlc.arg(source)::text
+ON CONFLICT (model, junction, valid_time) DO UPDATE SET
+    flow = EXCLUDED.flow,
+    source = EXCLUDED.source;
+
+-- name: ListStreamflowObservations :many
+SELECT junction, valid_time, flow
+FROM public.streamflow_observations
+WHERE model = sqlc.arg(model)
+  AND valid_time >= sqlc.arg(from_time)
+  AND valid_time <= sqlc.arg(to_time)
+ORDER BY junction, valid_time;
+
+-- name: ListModelForecastFlows :many
+SELECT r.issue_time,
+       f.junction,
+       f.valid_time,
+       f.flow
+FROM public.junction_flows f
+JOIN public.junction_flow_runs r ON r.id = f.flow_run_id
+WHERE r.model = sqlc.arg(model)
+  AND NOT f.observed
+  AND r.issue_time >= sqlc.arg(from_time)
+  AND r.issue_time <= sqlc.arg(to_time)
+ORDER BY f.junction, r.issue_time, f.valid_time;
+
+-- name: ListModelSimulatedObservedFlows :many
+SELECT DISTINCT ON (f.junction, f.valid_time)
+       f.junction,
+       f.valid_time,
+       f.flow
+FROM public.junction_flows f
+JOIN public.junction_flow_runs r ON r.id = f.flow_run_id
+WHERE r.model = sqlc.arg(model)
+  AND f.observed
+  AND f.valid_time >= sqlc.arg(from_time)
+  AND f.valid_time <= sqlc.arg(to_time)
+ORDER BY f.junction, f.valid_time, r.issue_time DESC;
+
+-- name: CreateForecastVerification :one
+INSERT INTO public.forecast_verifications (
+    model,
+    reference,
+    window_start,
+    window_end,
+    bin_hours,
+    results
+) VALUES (
+    $1, $2, $3, $4, $5, $6
+)
+RETURNING *;
+
+-- name: GetForecastVerification :one
+SELECT *
+FROM public.forecast_verifications
+WHERE id = $1;
+
+-- name: ListForecastVerifications :many
+SELECT id, model, reference, window_start, window_end, bin_hours, created_at
+FROM public.forecast_verifications
+WHERE (sqlc.arg(model)::text = '' OR model = sqlc.arg(model)::text)
+ORDER BY created_at DESC
+LIMIT sqlc.arg(max_rows);
@@ -27,9 +27,87 @@ func Prepare(ctx context.Context, db DBTX) (*Queries, error) {
 	if q.addUserStmt, err = db.PrepareContext(ctx, addUser); err != nil {
 		return nil, fmt.Errorf("error preparing query AddUser: %w", err)
 	}
+	if q.createCalibrationStmt, err = db.PrepareContext(ctx, createCalibration); err != nil {
+		return nil, fmt.Errorf("error preparing query CreateCalibration: %w", err)
+	}
+	if q.createForecastVerificationStmt, err = db.PrepareContext(ctx, createForecastVerification); err != nil {
+		return nil, fmt.Errorf("error preparing query CreateForecastVerification: %w", err)
+	}
+	if q.createPipelineRunStmt, err = db.PrepareContext(ctx, createPipelineRun); err != nil {
+		return nil, fmt.Errorf("error preparing query CreatePipelineRun: %w", err)
+	}
+	if q.deleteHistoricalEventStmt, err = db.PrepareContext(ctx, deleteHistoricalEvent); err != nil {
+		return nil, fmt.Errorf("error preparing query DeleteHistoricalEvent: %w", err)
+	}
+	if q.deleteJunctionFlowsStmt, err = db.PrepareContext(ctx, deleteJunctionFlows); err != nil {
+		return nil, fmt.Errorf("error preparing query DeleteJunctionFlows: %w", err)
+	}
+	if q.deleteJunctionThresholdStmt, err = db.PrepareContext(ctx, deleteJunctionThreshold); err != nil {
+		return nil, fmt.Errorf("error preparing query DeleteJunctionThreshold: %w", err)
+	}
+	if q.deletePrecipCogStmt, err = db.PrepareContext(ctx, deletePrecipCog); err != nil {
+		return nil, fmt.Errorf("error preparing query DeletePrecipCog: %w", err)
+	}
+	if q.deleteRatingCurveStmt, err = db.PrepareContext(ctx, deleteRatingCurve); err != nil {
+		return nil, fmt.Errorf("error preparing query DeleteRatingCurve: %w", err)
+	}
+	if q.deleteScenarioStmt, err = db.PrepareContext(ctx, deleteScenario); err != nil {
+		return nil, fmt.Errorf("error preparing query DeleteScenario: %w", err)
+	}
 	if q.deleteUserStmt, err = db.PrepareContext(ctx, deleteUser); err != nil {
 		return nil, fmt.Errorf("error preparing query DeleteUser: %w", err)
 	}
+	if q.finishCalibrationStmt, err = db.PrepareContext(ctx, finishCalibration); err != nil {
+		return nil, fmt.Errorf("error preparing query FinishCalibration: %w", err)
+	}
+	if q.finishPipelineRunStmt, err = db.PrepareContext(ctx, finishPipelineRun); err != nil {
+		return nil, fmt.Errorf("error preparing query FinishPipelineRun: %w", err)
+	}
+	if q.finishScenarioStmt, err = db.PrepareContext(ctx, finishScenario); err != nil {
+		return nil, fmt.Errorf("error preparing query FinishScenario: %w", err)
+	}
+	if q.getCalibrationStmt, err = db.PrepareContext(ctx, getCalibration); err != nil {
+		return nil, fmt.Errorf("error preparing query GetCalibration: %w", err)
+	}
+	if q.getForecastVerificationStmt, err = db.PrepareContext(ctx, getForecastVerification); err != nil {
+		return nil, fmt.Errorf("error preparing query GetForecastVerification: %w", err)
+	}
+	if q.getHistoricalEventStmt, err = db.PrepareContext(ctx, getHistoricalEvent); err != nil {
+		return nil, fmt.Errorf("error preparing query GetHistoricalEvent: %w", err)
+	}
+	if q.getJunctionFlowRunStmt, err = db.PrepareContext(ctx, getJunctionFlowRun); err != nil {
+		return nil, fmt.Errorf("error preparing query GetJunctionFlowRun: %w", err)
+	}
+	if q.getJunctionThresholdStmt, err = db.PrepareContext(ctx, getJunctionThreshold); err != nil {
+		return nil, fmt.Errorf("error preparing query GetJunctionThreshold: %w", err)
+	}
+	if q.getLatestPrecipBiasFactorStmt, err = db.PrepareContext(ctx, getLatestPrecipBiasFactor); err != nil {
+		return nil, fmt.Errorf("error preparing query GetLatestPrecipBiasFactor: %w", err)
+	}
+	if q.getLatestPrecipCogStmt, err = db.PrepareContext(ctx, getLatestPrecipCog); err != nil {
+		return nil, fmt.Errorf("error preparing query GetLatestPrecipCog: %w", err)
+	}
+	if q.getLatestPrecipRunSummaryStmt, err = db.PrepareContext(ctx, getLatestPrecipRunSummary); err != nil {
+		return nil, fmt.Errorf("error preparing query GetLatestPrecipRunSummary: %w", err)
+	}
+	if q.getPipelineRunStmt, err = db.PrepareContext(ctx, getPipelineRun); err != nil {
+		return nil, fmt.Errorf("error preparing query GetPipelineRun: %w", err)
+	}
+	if q.getPrecipBiasFactorStmt, err = db.PrepareContext(ctx, getPrecipBiasFactor); err != nil {
+		return nil, fmt.Errorf("error preparing query GetPrecipBiasFactor: %w", err)
+	}
+	if q.getPrecipCogEndingBetweenStmt, err = db.PrepareContext(ctx, getPrecipCogEndingBetween); err != nil {
+		return nil, fmt.Errorf("error preparing query GetPrecipCogEndingBetween: %w", err)
+	}
+	if q.getPrecipRunSummaryStmt, err = db.PrepareContext(ctx, getPrecipRunSummary); err != nil {
+		return nil, fmt.Errorf("error preparing query GetPrecipRunSummary: %w", err)
+	}
+	if q.getRatingCurveStmt, err = db.PrepareContext(ctx, getRatingCurve); err != nil {
+		return nil, fmt.Errorf("error preparing query GetRatingCurve: %w", err)
+	}
+	if q.getScenarioStmt, err = db.PrepareContext(ctx, getScenario); err != nil {
+		return nil, fmt.Errorf("error preparing query GetScenario: %w", err)
+	}
 	if q.getUserByEmailStmt, err = db.PrepareContext(ctx, getUserByEmail); err != nil {
 		return nil, fmt.Errorf("error preparing query GetUserByEmail: %w", err)
 	}
@@ -42,9 +120,123 @@ func Prepare(ctx context.Context, db DBTX) (*Queries, error) {
 	if q.getUsersWithRoleStmt, err = db.PrepareContext(ctx, getUsersWithRole); err != nil {
 		return nil, fmt.Errorf("error preparing query GetUsersWithRole: %w", err)
 	}
+	if q.insertCalibrationTrialStmt, err = db.PrepareContext(ctx, insertCalibrationTrial); err != nil {
+		return nil, fmt.Errorf("error preparing query InsertCalibrationTrial: %w", err)
+	}
+	if q.insertJunctionFlowsStmt, err = db.PrepareContext(ctx, insertJunctionFlows); err != nil {
+		return nil, fmt.Errorf("error preparing query InsertJunctionFlows: %w", err)
+	}
+	if q.listCalibrationTrialsStmt, err = db.PrepareContext(ctx, listCalibrationTrials); err != nil {
+		return nil, fmt.Errorf("error preparing query ListCalibrationTrials: %w", err)
+	}
+	if q.listCalibrationsStmt, err = db.PrepareContext(ctx, listCalibrations); err != nil {
+		return nil, fmt.Errorf("error preparing query ListCalibrations: %w", err)
+	}
+	if q.listForecastVerificationsStmt, err = db.PrepareContext(ctx, listForecastVerifications); err != nil {
+		return nil, fmt.Errorf("error preparing query ListForecastVerifications: %w", err)
+	}
+	if q.listGaugeObservationsStmt, err = db.PrepareContext(ctx, listGaugeObservations); err != nil {
+		return nil, fmt.Errorf("error preparing query ListGaugeObservations: %w", err)
+	}
+	if q.listGaugeTotalsStmt, err = db.PrepareContext(ctx, listGaugeTotals); err != nil {
+		return nil, fmt.Errorf("error preparing query ListGaugeTotals: %w", err)
+	}
+	if q.listHistoricalEventsStmt, err = db.PrepareContext(ctx, listHistoricalEvents); err != nil {
+		return nil, fmt.Errorf("error preparing query ListHistoricalEvents: %w", err)
+	}
+	if q.listJunctionFlowRunsStmt, err = db.PrepareContext(ctx, listJunctionFlowRuns); err != nil {
+		return nil, fmt.Errorf("error preparing query ListJunctionFlowRuns: %w", err)
+	}
+	if q.listJunctionForecastsStmt, err = db.PrepareContext(ctx, listJunctionForecasts); err != nil {
+		return nil, fmt.Errorf("error preparing query ListJunctionForecasts: %w", err)
+	}
+	if q.listJunctionThresholdsStmt, err = db.PrepareContext(ctx, listJunctionThresholds); err != nil {
+		return nil, fmt.Errorf("error preparing query ListJunctionThresholds: %w", err)
+	}
+	if q.listModelForecastFlowsStmt, err = db.PrepareContext(ctx, listModelForecastFlows); err != nil {
+		return nil, fmt.Errorf("error preparing query ListModelForecastFlows: %w", err)
+	}
+	if q.listModelSimulatedObservedFlowsStmt, err = db.PrepareContext(ctx, listModelSimulatedObservedFlows); err != nil {
+		return nil, fmt.Errorf("error preparing query ListModelSimulatedObservedFlows: %w", err)
+	}
+	if q.listPipelineRunsStmt, err = db.PrepareContext(ctx, listPipelineRuns); err != nil {
+		return nil, fmt.Errorf("error preparing query ListPipelineRuns: %w", err)
+	}
+	if q.listPrecipCogProductsStmt, err = db.PrepareContext(ctx, listPrecipCogProducts); err != nil {
+		return nil, fmt.Errorf("error preparing query ListPrecipCogProducts: %w", err)
+	}
+	if q.listPrecipCogsStmt, err = db.PrepareContext(ctx, listPrecipCogs); err != nil {
+		return nil, fmt.Errorf("error preparing query ListPrecipCogs: %w", err)
+	}
+	if q.listPrecipCogsEndingBeforeStmt, err = db.PrepareContext(ctx, listPrecipCogsEndingBefore); err != nil {
+		return nil, fmt.Errorf("error preparing query ListPrecipCogsEndingBefore: %w", err)
+	}
+	if q.listRainGaugesStmt, err = db.PrepareContext(ctx, listRainGauges); err != nil {
+		return nil, fmt.Errorf("error preparing query ListRainGauges: %w", err)
+	}
+	if q.listRatingCurvesStmt, err = db.PrepareContext(ctx, listRatingCurves); err != nil {
+		return nil, fmt.Errorf("error preparing query ListRatingCurves: %w", err)
+	}
+	if q.listRunJunctionFlowsStmt, err = db.PrepareContext(ctx, listRunJunctionFlows); err != nil {
+		return nil, fmt.Errorf("error preparing query ListRunJunctionFlows: %w", err)
+	}
+	if q.listScenariosStmt, err = db.PrepareContext(ctx, listScenarios); err != nil {
+		return nil, fmt.Errorf("error preparing query ListScenarios: %w", err)
+	}
+	if q.listSimulatedObservedFlowsStmt, err = db.PrepareContext(ctx, listSimulatedObservedFlows); err != nil {
+		return nil, fmt.Errorf("error preparing query ListSimulatedObservedFlows: %w", err)
+	}
+	if q.listStreamflowObservationsStmt, err = db.PrepareContext(ctx, listStreamflowObservations); err != nil {
+		return nil, fmt.Errorf("error preparing query ListStreamflowObservations: %w", err)
+	}
+	if q.setCalibrationRunStmt, err = db.PrepareContext(ctx, setCalibrationRun); err != nil {
+		return nil, fmt.Errorf("error preparing query SetCalibrationRun: %w", err)
+	}
+	if q.setPipelineRunProgressStmt, err = db.PrepareContext(ctx, setPipelineRunProgress); err != nil {
+		return nil, fmt.Errorf("error preparing query SetPipelineRunProgress: %w", err)
+	}
+	if q.setScenarioBaselineStmt, err = db.PrepareContext(ctx, setScenarioBaseline); err != nil {
+		return nil, fmt.Errorf("error preparing query SetScenarioBaseline: %w", err)
+	}
+	if q.setScenarioRunStmt, err = db.PrepareContext(ctx, setScenarioRun); err != nil {
+		return nil, fmt.Errorf("error preparing query SetScenarioRun: %w", err)
+	}
 	if q.updateUserStmt, err = db.PrepareContext(ctx, updateUser); err != nil {
 		return nil, fmt.Errorf("error preparing query UpdateUser: %w", err)
 	}
+	if q.upsertGaugeObservationStmt, err = db.PrepareContext(ctx, upsertGaugeObservation); err != nil {
+		return nil, fmt.Errorf("error preparing query UpsertGaugeObservation: %w", err)
+	}
+	if q.upsertHistoricalEventStmt, err = db.PrepareContext(ctx, upsertHistoricalEvent); err != nil {
+		return nil, fmt.Errorf("error preparing query UpsertHistoricalEvent: %w", err)
+	}
+	if q.upsertJunctionFlowRunStmt, err = db.PrepareContext(ctx, upsertJunctionFlowRun); err != nil {
+		return nil, fmt.Errorf("error preparing query UpsertJunctionFlowRun: %w", err)
+	}
+	if q.upsertJunctionThresholdStmt, err = db.PrepareContext(ctx, upsertJunctionThreshold); err != nil {
+		return nil, fmt.Errorf("error preparing query UpsertJunctionThreshold: %w", err)
+	}
+	if q.upsertPrecipBiasFactorStmt, err = db.PrepareContext(ctx, upsertPrecipBiasFactor); err != nil {
+		return nil, fmt.Errorf("error preparing query UpsertPrecipBiasFactor: %w", err)
+	}
+	if q.upsertPrecipCogStmt, err = db.PrepareContext(ctx, upsertPrecipCog); err != nil {
+		return nil, fmt.Errorf("error preparing query UpsertPrecipCog: %w", err)
+	}
+	if q.upsertPrecipRunSummaryStmt, err = db.PrepareContext(ctx, upsertPrecipRunSummary); err != nil {
+		return nil, fmt.Errorf("error preparing query UpsertPrecipRunSummary: %w", err)
+	}
+	if q.upsertRainGaugeStmt, err = db.PrepareContext(ctx, upsertRainGauge); err != nil {
+		return nil, fmt.Errorf("error preparing query UpsertRainGauge: %w", err)
+	}
+	if q.upsertRatingCurveStmt, err = db.PrepareContext(ctx, upsertRatingCurve); err != nil {
+		return nil, fmt.Errorf("error preparing query UpsertRatingCurve: %w", err)
+	}
+	if q.upsertScenarioStmt, err = db.PrepareContext(ctx, upsertScenario); err != nil {
+		return nil, fmt.Errorf("error preparing query UpsertScenario: %w", err)
+	}
+	if q.upsertStreamflowObservationsStmt, err = db.PrepareContext(ctx, upsertStreamflowObservations); err != nil {
+		return nil, fmt.Errorf("error preparing query UpsertStreamflowObservations: %w", err)
+	}
 	return &q, nil
 }
 
@@ -55,11 +247,141 @@ func (q *Queries) Close() error {
 			err = fmt.Errorf("error closing addUserStmt: %w", cerr)
 		}
 	}
+	if q.createCalibrationStmt != nil {
+		if cerr := q.createCalibrationStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing createCalibrationStmt: %w", cerr)
+		}
+	}
+	if q.createForecastVerificationStmt != nil {
+		if cerr := q.createForecastVerificationStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing createForecastVerificationStmt: %w", cerr)
+		}
+	}
+	if q.createPipelineRunStmt != nil {
+		if cerr := q.createPipelineRunStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing createPipelineRunStmt: %w", cerr)
+		}
+	}
+	if q.deleteHistoricalEventStmt != nil {
+		if cerr := q.deleteHistoricalEventStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing deleteHistoricalEventStmt: %w", cerr)
+		}
+	}
+	if q.deleteJunctionFlowsStmt != nil {
+		if cerr := q.deleteJunctionFlowsStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing deleteJunctionFlowsStmt: %w", cerr)
+		}
+	}
+	if q.deleteJunctionThresholdStmt != nil {
+		if cerr := q.deleteJunctionThresholdStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing deleteJunctionThresholdStmt: %w", cerr)
+		}
+	}
+	if q.deletePrecipCogStmt != nil {
+		if cerr := q.deletePrecipCogStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing deletePrecipCogStmt: %w", cerr)
+		}
+	}
+	if q.deleteRatingCurveStmt != nil {
+		if cerr := q.deleteRatingCurveStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing deleteRatingCurveStmt: %w", cerr)
+		}
+	}
+	if q.deleteScenarioStmt != nil {
+		if cerr := q.deleteScenarioStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing deleteScenarioStmt: %w", cerr)
+		}
+	}
 	if q.deleteUserStmt != nil {
 		if cerr := q.deleteUserStmt.Close(); cerr != nil {
 			err = fmt.Errorf("error closing deleteUserStmt: %w", cerr)
 		}
 	}
+	if q.finishCalibrationStmt != nil {
+		if cerr := q.finishCalibrationStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing finishCalibrationStmt: %w", cerr)
+		}
+	}
+	if q.finishPipelineRunStmt != nil {
+		if cerr := q.finishPipelineRunStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing finishPipelineRunStmt: %w", cerr)
+		}
+	}
+	if q.finishScenarioStmt != nil {
+		if cerr := q.finishScenarioStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing finishScenarioStmt: %w", cerr)
+		}
+	}
+	if q.getCalibrationStmt != nil {
+		if cerr := q.getCalibrationStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing getCalibrationStmt: %w", cerr)
+		}
+	}
+	if q.getForecastVerificationStmt != nil {
+		if cerr := q.getForecastVerificationStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing getForecastVerificationStmt: %w", cerr)
+		}
+	}
+	if q.getHistoricalEventStmt != nil {
+		if cerr := q.getHistoricalEventStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing getHistoricalEventStmt: %w", cerr)
+		}
+	}
+	if q.getJunctionFlowRunStmt != nil {
+		if cerr := q.getJunctionFlowRunStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing getJunctionFlowRunStmt: %w", cerr)
+		}
+	}
+	if q.getJunctionThresholdStmt != nil {
+		if cerr := q.getJunctionThresholdStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing getJunctionThresholdStmt: %w", cerr)
+		}
+	}
+	if q.getLatestPrecipBiasFactorStmt != nil {
+		if cerr := q.getLatestPrecipBiasFactorStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing getLatestPrecipBiasFactorStmt: %w", cerr)
+		}
+	}
+	if q.getLatestPrecipCogStmt != nil {
+		if cerr := q.getLatestPrecipCogStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing getLatestPrecipCogStmt: %w", cerr)
+		}
+	}
+	if q.getLatestPrecipRunSummaryStmt != nil {
+		if cerr := q.getLatestPrecipRunSummaryStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing getLatestPrecipRunSummaryStmt: %w", cerr)
+		}
+	}
+	if q.getPipelineRunStmt != nil {
+		if cerr := q.getPipelineRunStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing getPipelineRunStmt: %w", cerr)
+		}
+	}
+	if q.getPrecipBiasFactorStmt != nil {
+		if cerr := q.getPrecipBiasFactorStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing getPrecipBiasFactorStmt: %w", cerr)
+		}
+	}
+	if q.getPrecipCogEndingBetweenStmt != nil {
+		if cerr := q.getPrecipCogEndingBetweenStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing getPrecipCogEndingBetweenStmt: %w", cerr)
+		}
+	}
+	if q.getPrecipRunSummaryStmt != nil {
+		if cerr := q.getPrecipRunSummaryStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing getPrecipRunSummaryStmt: %w", cerr)
+		}
+	}
+	if q.getRatingCurveStmt != nil {
+		if cerr := q.getRatingCurveStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing getRatingCurveStmt: %w", cerr)
+		}
+	}
+	if q.getScenarioStmt != nil {
+		if cerr := q.getScenarioStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing getScenarioStmt: %w", cerr)
+		}
+	}
 	if q.getUserByEmailStmt != nil {
 		if cerr := q.getUserByEmailStmt.Close(); cerr != nil {
 			err = fmt.Errorf("error closing getUserByEmailStmt: %w", cerr)
@@ -80,11 +402,201 @@ func (q *Queries) Close() error {
 			err = fmt.Errorf("error closing getUsersWithRoleStmt: %w", cerr)
 		}
 	}
+	if q.insertCalibrationTrialStmt != nil {
+		if cerr := q.insertCalibrationTrialStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing insertCalibrationTrialStmt: %w", cerr)
+		}
+	}
+	if q.insertJunctionFlowsStmt != nil {
+		if cerr := q.insertJunctionFlowsStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing insertJunctionFlowsStmt: %w", cerr)
+		}
+	}
+	if q.listCalibrationTrialsStmt != nil {
+		if cerr := q.listCalibrationTrialsStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing listCalibrationTrialsStmt: %w", cerr)
+		}
+	}
+	if q.listCalibrationsStmt != nil {
+		if cerr := q.listCalibrationsStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing listCalibrationsStmt: %w", cerr)
+		}
+	}
+	if q.listForecastVerificationsStmt != nil {
+		if cerr := q.listForecastVerificationsStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing listForecastVerificationsStmt: %w", cerr)
+		}
+	}
+	if q.listGaugeObservationsStmt != nil {
+		if cerr := q.listGaugeObservationsStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing listGaugeObservationsStmt: %w", cerr)
+		}
+	}
+	if q.listGaugeTotalsStmt != nil {
+		if cerr := q.listGaugeTotalsStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing listGaugeTotalsStmt: %w", cerr)
+		}
+	}
+	if q.listHistoricalEventsStmt != nil {
+		if cerr := q.listHistoricalEventsStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing listHistoricalEventsStmt: %w", cerr)
+		}
+	}
+	if q.listJunctionFlowRunsStmt != nil {
+		if cerr := q.listJunctionFlowRunsStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing listJunctionFlowRunsStmt: %w", cerr)
+		}
+	}
+	if q.listJunctionForecastsStmt != nil {
+		if cerr := q.listJunctionForecastsStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing listJunctionForecastsStmt: %w", cerr)
+		}
+	}
+	if q.listJunctionThresholdsStmt != nil {
+		if cerr := q.listJunctionThresholdsStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing listJunctionThresholdsStmt: %w", cerr)
+		}
+	}
+	if q.listModelForecastFlowsStmt != nil {
+		if cerr := q.listModelForecastFlowsStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing listModelForecastFlowsStmt: %w", cerr)
+		}
+	}
+	if q.listModelSimulatedObservedFlowsStmt != nil {
+		if cerr := q.listModelSimulatedObservedFlowsStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing listModelSimulatedObservedFlowsStmt: %w", cerr)
+		}
+	}
+	if q.listPipelineRunsStmt != nil {
+		if cerr := q.listPipelineRunsStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing listPipelineRunsStmt: %w", cerr)
+		}
+	}
+	if q.listPrecipCogProductsStmt != nil {
+		if cerr := q.listPrecipCogProductsStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing listPrecipCogProductsStmt: %w", cerr)
+		}
+	}
+	if q.listPrecipCogsStmt != nil {
+		if cerr := q.listPrecipCogsStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing listPrecipCogsStmt: %w", cerr)
+		}
+	}
+	if q.listPrecipCogsEndingBeforeStmt != nil {
+		if cerr := q.listPrecipCogsEndingBeforeStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing listPrecipCogsEndingBeforeStmt: %w", cerr)
+		}
+	}
+	if q.listRainGaugesStmt != nil {
+		if cerr := q.listRainGaugesStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing listRainGaugesStmt: %w", cerr)
+		}
+	}
+	if q.listRatingCurvesStmt != nil {
+		if cerr := q.listRatingCurvesStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing listRatingCurvesStmt: %w", cerr)
+		}
+	}
+	if q.listRunJunctionFlowsStmt != nil {
+		if cerr := q.listRunJunctionFlowsStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing listRunJunctionFlowsStmt: %w", cerr)
+		}
+	}
+	if q.listScenariosStmt != nil {
+		if cerr := q.listScenariosStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing listScenariosStmt: %w", cerr)
+		}
+	}
+	if q.listSimulatedObservedFlowsStmt != nil {
+		if cerr := q.listSimulatedObservedFlowsStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing listSimulatedObservedFlowsStmt: %w", cerr)
+		}
+	}
+	if q.listStreamflowObservationsStmt != nil {
+		if cerr := q.listStreamflowObservationsStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing listStreamflowObservationsStmt: %w", cerr)
+		}
+	}
+	if q.setCalibrationRunStmt != nil {
+		if cerr := q.setCalibrationRunStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing setCalibrationRunStmt: %w", cerr)
+		}
+	}
+	if q.setPipelineRunProgressStmt != nil {
+		if cerr := q.setPipelineRunProgressStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing setPipelineRunProgressStmt: %w", cerr)
+		}
+	}
+	if q.setScenarioBaselineStmt != nil {
+		if cerr := q.setScenarioBaselineStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing setScenarioBaselineStmt: %w", cerr)
+		}
+	}
+	if q.setScenarioRunStmt != nil {
+		if cerr := q.setScenarioRunStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing setScenarioRunStmt: %w", cerr)
+		}
+	}
 	if q.updateUserStmt != nil {
 		if cerr := q.updateUserStmt.Close(); cerr != nil {
 			err = fmt.Errorf("error closing updateUserStmt: %w", cerr)
 		}
 	}
+	if q.upsertGaugeObservationStmt != nil {
+		if cerr := q.upsertGaugeObservationStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing upsertGaugeObservationStmt: %w", cerr)
+		}
+	}
+	if q.upsertHistoricalEventStmt != nil {
+		if cerr := q.upsertHistoricalEventStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing upsertHistoricalEventStmt: %w", cerr)
+		}
+	}
+	if q.upsertJunctionFlowRunStmt != nil {
+		if cerr := q.upsertJunctionFlowRunStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing upsertJunctionFlowRunStmt: %w", cerr)
+		}
+	}
+	if q.upsertJunctionThresholdStmt != nil {
+		if cerr := q.upsertJunctionThresholdStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing upsertJunctionThresholdStmt: %w", cerr)
+		}
+	}
+	if q.upsertPrecipBiasFactorStmt != nil {
+		if cerr := q.upsertPrecipBiasFactorStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing upsertPrecipBiasFactorStmt: %w", cerr)
+		}
+	}
+	if q.upsertPrecipCogStmt != nil {
+		if cerr := q.upsertPrecipCogStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing upsertPrecipCogStmt: %w", cerr)
+		}
+	}
+	if q.upsertPrecipRunSummaryStmt != nil {
+		if cerr := q.upsertPrecipRunSummaryStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing upsertPrecipRunSummaryStmt: %w", cerr)
+		}
+	}
+	if q.upsertRainGaugeStmt != nil {
+		if cerr := q.upsertRainGaugeStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing upsertRainGaugeStmt: %w", cerr)
+		}
+	}
+	if q.upsertRatingCurveStmt != nil {
+		if cerr := q.upsertRatingCurveStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing upsertRatingCurveStmt: %w", cerr)
+		}
+	}
+	if q.upsertScenarioStmt != nil {
+		if cerr := q.upsertScenarioStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing upsertScenarioStmt: %w", cerr)
+		}
+	}
+	if q.upsertStreamflowObservationsStmt != nil {
+		if cerr := q.upsertStreamflowObservationsStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing upsertStreamflowObservationsStmt: %w", cerr)
+		}
+	}
 	return err
 }
 
@@ -122,27 +634,155 @@ func (q *Queries) queryRow(ctx context.Context, stmt *sql.Stmt, query string, ar
 }
 
 type Queries struct {
-	db                                DBTX
-	tx                                *sql.Tx
-	addUserStmt                       *sql.Stmt
-	deleteUserStmt                    *sql.Stmt
-	getUserByEmailStmt                *sql.Stmt
-	getUsersStmt                      *sql.Stmt
-	getUsersByOrganizationAndRoleStmt *sql.Stmt
-	getUsersWithRoleStmt              *sql.Stmt
-	updateUserStmt                    *sql.Stmt
+	db                                  DBTX
+	tx                                  *sql.Tx
+	addUserStmt                         *sql.Stmt
+	createCalibrationStmt               *sql.Stmt
+	createForecastVerificationStmt      *sql.Stmt
+	createPipelineRunStmt               *sql.Stmt
+	deleteHistoricalEventStmt           *sql.Stmt
+	deleteJunctionFlowsStmt             *sql.Stmt
+	deleteJunctionThresholdStmt         *sql.Stmt
+	deletePrecipCogStmt                 *sql.Stmt
+	deleteRatingCurveStmt               *sql.Stmt
+	deleteScenarioStmt                  *sql.Stmt
+	deleteUserStmt                      *sql.Stmt
+	finishCalibrationStmt               *sql.Stmt
+	finishPipelineRunStmt               *sql.Stmt
+	finishScenarioStmt                  *sql.Stmt
+	getCalibrationStmt                  *sql.Stmt
+	getForecastVerificationStmt         *sql.Stmt
+	getHistoricalEventStmt              *sql.Stmt
+	getJunctionFlowRunStmt              *sql.Stmt
+	getJunctionThresholdStmt            *sql.Stmt
+	getLatestPrecipBiasFactorStmt       *sql.Stmt
+	getLatestPrecipCogStmt              *sql.Stmt
+	getLatestPrecipRunSummaryStmt       *sql.Stmt
+	getPipelineRunStmt                  *sql.Stmt
+	getPrecipBiasFactorStmt             *sql.Stmt
+	getPrecipCogEndingBetweenStmt       *sql.Stmt
+	getPrecipRunSummaryStmt             *sql.Stmt
+	getRatingCurveStmt                  *sql.Stmt
+	getScenarioStmt                     *sql.Stmt
+	getUserByEmailStmt                  *sql.Stmt
+	getUsersStmt                        *sql.Stmt
+	getUsersByOrganizationAndRoleStmt   *sql.Stmt
+	getUsersWithRoleStmt                *sql.Stmt
+	insertCalibrationTrialStmt          *sql.Stmt
+	insertJunctionFlowsStmt             *sql.Stmt
+	listCalibrationTrialsStmt           *sql.Stmt
+	listCalibrationsStmt                *sql.Stmt
+	listForecastVerificationsStmt       *sql.Stmt
+	listGaugeObservationsStmt           *sql.Stmt
+	listGaugeTotalsStmt                 *sql.Stmt
+	listHistoricalEventsStmt            *sql.Stmt
+	listJunctionFlowRunsStmt            *sql.Stmt
+	listJunctionForecastsStmt           *sql.Stmt
+	listJunctionThresholdsStmt          *sql.Stmt
+	listModelForecastFlowsStmt          *sql.Stmt
+	listModelSimulatedObservedFlowsStmt *sql.Stmt
+	listPipelineRunsStmt                *sql.Stmt
+	listPrecipCogProductsStmt           *sql.Stmt
+	listPrecipCogsStmt                  *sql.Stmt
+	listPrecipCogsEndingBeforeStmt      *sql.Stmt
+	listRainGaugesStmt                  *sql.Stmt
+	listRatingCurvesStmt                *sql.Stmt
+	listRunJunctionFlowsStmt            *sql.Stmt
+	listScenariosStmt                   *sql.Stmt
+	listSimulatedObservedFlowsStmt      *sql.Stmt
+	listStreamflowObservationsStmt      *sql.Stmt
+	setCalibrationRunStmt               *sql.Stmt
+	setPipelineRunProgressStmt          *sql.Stmt
+	setScenarioBaselineStmt             *sql.Stmt
+	setScenarioRunStmt                  *sql.Stmt
+	updateUserStmt                      *sql.Stmt
+	upsertGaugeObservationStmt          *sql.Stmt
+	upsertHistoricalEventStmt           *sql.Stmt
+	upsertJunctionFlowRunStmt           *sql.Stmt
+	upsertJunctionThresholdStmt         *sql.Stmt
+	upsertPrecipBiasFactorStmt          *sql.Stmt
+	upsertPrecipCogStmt                 *sql.Stmt
+	upsertPrecipRunSummaryStmt          *sql.Stmt
+	upsertRainGaugeStmt                 *sql.Stmt
+	upsertRatingCurveStmt               *sql.Stmt
+	upsertScenarioStmt                  *sql.Stmt
+	upsertStreamflowObservationsStmt    *sql.Stmt
 }
 
 func (q *Queries) WithTx(tx *sql.Tx) *Queries {
 	return &Queries{
-		db:                                tx,
-		tx:                                tx,
-		addUserStmt:                       q.addUserStmt,
-		deleteUserStmt:                    q.deleteUserStmt,
-		getUserByEmailStmt:                q.getUserByEmailStmt,
-		getUsersStmt:                      q.getUsersStmt,
-		getUsersByOrganizationAndRoleStmt: q.getUsersByOrganizationAndRoleStmt,
-		getUsersWithRoleStmt:              q.getUsersWithRoleStmt,
-		updateUserStmt:                    q.updateUserStmt,
+		db:                                  tx,
+		tx:                                  tx,
+		addUserStmt:                         q.addUserStmt,
+		createCalibrationStmt:               q.createCalibrationStmt,
+		createForecastVerificationStmt:      q.createForecastVerificationStmt,
+		createPipelineRunStmt:               q.createPipelineRunStmt,
+		deleteHistoricalEventStmt:           q.deleteHistoricalEventStmt,
+		deleteJunctionFlowsStmt:             q.deleteJunctionFlowsStmt,
+		deleteJunctionThresholdStmt:         q.deleteJunctionThresholdStmt,
+		deletePrecipCogStmt:                 q.deletePrecipCogStmt,
+		deleteRatingCurveStmt:               q.deleteRatingCurveStmt,
+		deleteScenarioStmt:                  q.deleteScenarioStmt,
+		deleteUserStmt:                      q.deleteUserStmt,
+		finishCalibrationStmt:               q.finishCalibrationStmt,
+		finishPipelineRunStmt:               q.finishPipelineRunStmt,
+		finishScenarioStmt:                  q.finishScenarioStmt,
+		getCalibrationStmt:                  q.getCalibrationStmt,
+		getForecastVerificationStmt:         q.getForecastVerificationStmt,
+		getHistoricalEventStmt:              q.getHistoricalEventStmt,
+		getJunctionFlowRunStmt:              q.getJunctionFlowRunStmt,
+		getJunctionThresholdStmt:            q.getJunctionThresholdStmt,
+		getLatestPrecipBiasFactorStmt:       q.getLatestPrecipBiasFactorStmt,
+		getLatestPrecipCogStmt:              q.getLatestPrecipCogStmt,
+		getLatestPrecipRunSummaryStmt:       q.getLatestPrecipRunSummaryStmt,
+		getPipelineRunStmt:                  q.getPipelineRunStmt,
+		getPrecipBiasFactorStmt:             q.getPrecipBiasFactorStmt,
+		getPrecipCogEndingBetweenStmt:       q.getPrecipCogEndingBetweenStmt,
+		getPrecipRunSummaryStmt:             q.getPrecipRunSummaryStmt,
+		getRatingCurveStmt:                  q.getRatingCurveStmt,
+		getScenarioStmt:                     q.getScenarioStmt,
+		getUserByEmailStmt:                  q.getUserByEmailStmt,
+		getUsersStmt:                        q.getUsersStmt,
+		getUsersByOrganizationAndRoleStmt:   q.getUsersByOrganizationAndRoleStmt,
+		getUsersWithRoleStmt:                q.getUsersWithRoleStmt,
+		insertCalibrationTrialStmt:          q.insertCalibrationTrialStmt,
+		insertJunctionFlowsStmt:             q.insertJunctionFlowsStmt,
+		listCalibrationTrialsStmt:           q.listCalibrationTrialsStmt,
+		listCalibrationsStmt:                q.listCalibrationsStmt,
+		listForecastVerificationsStmt:       q.listForecastVerificationsStmt,
+		listGaugeObservationsStmt:           q.listGaugeObservationsStmt,
+		listGaugeTotalsStmt:                 q.listGaugeTotalsStmt,
+		listHistoricalEventsStmt:            q.listHistoricalEventsStmt,
+		listJunctionFlowRunsStmt:            q.listJunctionFlowRunsStmt,
+		listJunctionForecastsStmt:           q.listJunctionForecastsStmt,
+		listJunctionThresholdsStmt:          q.listJunctionThresholdsStmt,
+		listModelForecastFlowsStmt:          q.listModelForecastFlowsStmt,
+		listModelSimulatedObservedFlowsStmt: q.listModelSimulatedObservedFlowsStmt,
+		listPipelineRunsStmt:                q.listPipelineRunsStmt,
+		listPrecipCogProductsStmt:           q.listPrecipCogProductsStmt,
+		listPrecipCogsStmt:                  q.listPrecipCogsStmt,
+		listPrecipCogsEndingBeforeStmt:      q.listPrecipCogsEndingBeforeStmt,
+		listRainGaugesStmt:                  q.listRainGaugesStmt,
+		listRatingCurvesStmt:                q.listRatingCurvesStmt,
+		listRunJunctionFlowsStmt:            q.listRunJunctionFlowsStmt,
+		listScenariosStmt:                   q.listScenariosStmt,
+		listSimulatedObservedFlowsStmt:      q.listSimulatedObservedFlowsStmt,
+		listStreamflowObservationsStmt:      q.listStreamflowObservationsStmt,
+		setCalibrationRunStmt:               q.setCalibrationRunStmt,
+		setPipelineRunProgressStmt:          q.setPipelineRunProgressStmt,
+		setScenarioBaselineStmt:             q.setScenarioBaselineStmt,
+		setScenarioRunStmt:                  q.setScenarioRunStmt,
+		updateUserStmt:                      q.updateUserStmt,
+		upsertGaugeObservationStmt:          q.upsertGaugeObservationStmt,
+		upsertHistoricalEventStmt:           q.upsertHistoricalEventStmt,
+		upsertJunctionFlowRunStmt:           q.upsertJunctionFlowRunStmt,
+		upsertJunctionThresholdStmt:         q.upsertJunctionThresholdStmt,
+		upsertPrecipBiasFactorStmt:          q.upsertPrecipBiasFactorStmt,
+		upsertPrecipCogStmt:                 q.upsertPrecipCogStmt,
+		upsertPrecipRunSummaryStmt:          q.upsertPrecipRunSummaryStmt,
+		upsertRainGaugeStmt:                 q.upsertRainGaugeStmt,
+		upsertRatingCurveStmt:               q.upsertRatingCurveStmt,
+		upsertScenarioStmt:                  q.upsertScenarioStmt,
+		upsertStreamflowObservationsStmt:    q.upsertStreamflowObservationsStmt,
 	}
 }
@@ -5,9 +5,99 @@
 package sqlcdb
 
 import (
+	"database/sql"
+	"encoding/json"
 	"time"
 )
 
+type Calibration struct {
+	ID           int32           `json:"id"`
+	Name         string          `json:"name"`
+	Model        string          `json:"model"`
+	Objective    string          `json:"objective"`
+	Definition   json.RawMessage `json:"definition"`
+	Status       string          `json:"status"`
+	ErrorMessage string          `json:"error_message"`
+	RunID        sql.NullInt32   `json:"run_id"`
+	BestTrial    sql.NullInt32   `json:"best_trial"`
+	CreatedAt    time.Time       `json:"created_at"`
+	FinishedAt   sql.NullTime    `json:"finished_at"`
+}
+
+type CalibrationTrial struct {
+	ID            int32           `json:"id"`
+	CalibrationID int32           `json:"calibration_id"`
+	Trial         int32           `json:"trial"`
+	Parameters    json.RawMessage `json:"parameters"`
+	Status        string          `json:"status"`
+	ErrorMessage  string          `json:"error_message"`
+	Score         sql.NullFloat64 `json:"score"`
+	Fits          json.RawMessage `json:"fits"`
+	CreatedAt     time.Time       `json:"created_at"`
+}
+
+type ForecastVerification struct {
+	ID          int32           `json:"id"`
+	Model       string          `json:"model"`
+	Reference   string          `json:"reference"`
+	WindowStart time.Time       `json:"window_start"`
+	WindowEnd   time.Time       `json:"window_end"`
+	BinHours    int32           `json:"bin_hours"`
+	Results     json.RawMessage `json:"results"`
+	CreatedAt   time.Time       `json:"created_at"`
+}
+
+type GaugeObservation struct {
+	ID        int32     `json:"id"`
+	StationID string    `json:"station_id"`
+	ValidTime time.Time `json:"valid_time"`
+	PrecipIn  float64   `json:"precip_in"`
+	Source    string    `json:"source"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+type HistoricalEvent struct {
+	ID           int32           `json:"id"`
+	Name         string          `json:"name"`
+	Model        string          `json:"model"`
+	Description  string          `json:"description"`
+	StartTime    time.Time       `json:"start_time"`
+	EndTime      time.Time       `json:"end_time"`
+	RunID        sql.NullInt32   `json:"run_id"`
+	Inputs       json.RawMessage `json:"inputs"`
+	Flows        json.RawMessage `json:"flows"`
+	ArtifactsDir string          `json:"artifacts_dir"`
+	CreatedAt    time.Time       `json:"created_at"`
+}
+
+type JunctionFlow struct {
+	FlowRunID int32     `json:"flow_run_id"`
+	Junction  string    `json:"junction"`
+	ValidTime time.Time `json:"valid_time"`
+	Flow      float64   `json:"flow"`
+	Observed  bool      `json:"observed"`
+}
+
+type JunctionFlowRun struct {
+	ID        int32         `json:"id"`
+	Model     string        `json:"model"`
+	RunID     sql.NullInt32 `json:"run_id"`
+	IssueTime time.Time     `json:"issue_time"`
+	CreatedAt time.Time     `json:"created_at"`
+}
+
+type JunctionThreshold struct {
+	ID           int32           `json:"id"`
+	Model        string          `json:"model"`
+	Junction     string          `json:"junction"`
+	ActionFlow   sql.NullFloat64 `json:"action_flow"`
+	MinorFlow    sql.NullFloat64 `json:"minor_flow"`
+	ModerateFlow sql.NullFloat64 `json:"moderate_flow"`
+	MajorFlow    sql.NullFloat64 `json:"major_flow"`
+	Crossings    json.RawMessage `json:"crossings"`
+	UpdatedAt    time.Time       `json:"updated_at"`
+}
+
 type Organization struct {
 	ID        int32     `json:"id"`
 	Name      string    `json:"name"`
@@ -15,6 +105,107 @@ type Organization struct {
 	UpdatedAt time.Time `json:"updated_at"`
 }
 
+type PipelineRun struct {
+	ID           int32           `json:"id"`
+	RunType      string          `json:"run_type"`
+	Status       string          `json:"status"`
+	Parameters   json.RawMessage `json:"parameters"`
+	ErrorMessage string          `json:"error_message"`
+	Progress     json.RawMessage `json:"progress"`
+	StartedAt    time.Time       `json:"started_at"`
+	FinishedAt   sql.NullTime    `json:"finished_at"`
+}
+
+type PrecipBiasFactor struct {
+	ID              int32           `json:"id"`
+	RunID           int32           `json:"run_id"`
+	Method          string          `json:"method"`
+	WindowStart     time.Time       `json:"window_start"`
+	WindowEnd       time.Time       `json:"window_end"`
+	MeanFieldFactor float64         `json:"mean_field_factor"`
+	GaugesUsed      int32           `json:"gauges_used"`
+	Applied         bool            `json:"applied"`
+	Details         json.RawMessage `json:"details"`
+	CreatedAt       time.Time       `json:"created_at"`
+}
+
+type PrecipCog struct {
+	ID            int32     `json:"id"`
+	Product       string    `json:"product"`
+	Kind          string    `json:"kind"`
+	DurationHours int32     `json:"duration_hours"`
+	ValidStart    time.Time `json:"valid_start"`
+	ValidEnd      time.Time `json:"valid_end"`
+	FileName      string    `json:"file_name"`
+	SourceGrib    string    `json:"source_grib"`
+	West          float64   `json:"west"`
+	South         float64   `json:"south"`
+	East          float64   `json:"east"`
+	North         float64   `json:"north"`
+	Width         int32     `json:"width"`
+	Height        int32     `json:"height"`
+	Units         string    `json:"units"`
+	MinValue      float64   `json:"min_value"`
+	MaxValue      float64   `json:"max_value"`
+	MeanValue     float64   `json:"mean_value"`
+	WetFraction   float64   `json:"wet_fraction"`
+	ValidCells    int32     `json:"valid_cells"`
+	CreatedAt     time.Time `json:"created_at"`
+}
+
+type PrecipRunSummary struct {
+	ID          int32           `json:"id"`
+	RunID       int32           `json:"run_id"`
+	WindowStart time.Time       `json:"window_start"`
+	WindowEnd   time.Time       `json:"window_end"`
+	Summary     json.RawMessage `json:"summary"`
+	CreatedAt   time.Time       `json:"created_at"`
+}
+
+type RainGauge struct {
+	StationID string    `json:"station_id"`
+	Name      string    `json:"name"`
+	Lat       float64   `json:"lat"`
+	Lon       float64   `json:"lon"`
+	Source    string    `json:"source"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+type RatingCurve struct {
+	ID        int32           `json:"id"`
+	Model     string          `json:"model"`
+	Junction  string          `json:"junction"`
+	Curve     json.RawMessage `json:"curve"`
+	StageUnit string          `json:"stage_unit"`
+	Source    string          `json:"source"`
+	UpdatedAt time.Time       `json:"updated_at"`
+}
+
+type Scenario struct {
+	ID           int32           `json:"id"`
+	Name         string          `json:"name"`
+	Model        string          `json:"model"`
+	Kind         string          `json:"kind"`
+	Definition   json.RawMessage `json:"definition"`
+	Status       string          `json:"status"`
+	ErrorMessage string          `json:"error_message"`
+	RunID        sql.NullInt32   `json:"run_id"`
+	Flows        json.RawMessage `json:"flows"`
+	Baseline     json.RawMessage `json:"baseline"`
+	CreatedAt    time.Time       `json:"created_at"`
+	FinishedAt   sql.NullTime    `json:"finished_at"`
+}
+
+type StreamflowObservation struct {
+	ID        int32     `json:"id"`
+	Model     string    `json:"model"`
+	Junction  string    `json:"junction"`
+	ValidTime time.Time `json:"valid_time"`
+	Flow      float64   `json:"flow"`
+	Source    string    `json:"source"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
 type User struct {
 	ID             int32     `json:"id"`
 	Username       string    `json:"username"`
@@ -3,7 +3,9 @@ package main
 import (
 	"database/sql"
 	"encoding/json"
+	"time"
 
+	"HMSBackend/hms"
 	"HMSBackend/sqlcdb"
 )
 
@@ -185,16 +187,715 @@ type PrecipMeta struct {
 	Bounds    [4]float64 `json:"bounds"`
 	Width     int        `json:"width"`
 	Height    int        `json:"height"`
+	Units     string     `json:"units_of_values,omitempty"`
 }
 
+// PrecipStats summarises the valid cells of a precipitation grid, in inches
+type PrecipStats struct {
+	Min         float64 `json:"min"`
+	Max         float64 `json:"max"`
+	Mean        float64 `json:"mean"`
+	WetFraction float64 `json:"wet_fraction"` // share of valid cells with >= 0.01 in
+	ValidCells  int     `json:"valid_cells"`
+}
+
+// PrecipAccumulation describes a published precipitation COG. Each one is
+// indexed in the precip_cogs catalog table.
+type PrecipAccumulation struct {
+	Product       string      `json:"product"` // e.g. "qpe_06h", "hrrr_12h"
+	Kind          string      `json:"kind"`    // "observed" or "forecast"
+	DurationHours int         `json:"duration_hours"`
+	Start         time.Time   `json:"start"`
+	End           time.Time   `json:"end"`
+	Meta          PrecipMeta  `json:"meta"`
+	Stats         PrecipStats `json:"stats"`
+	SourceGrib    string      `json:"source_grib"`
+	CreatedAt     time.Time   `json:"created_at"`
+}
+
+// PrecipPointValue is one hour of precipitation at a point, in inches.
+// Value is nil when no grid covers the hour.
+type PrecipPointValue struct {
+	Start  time.Time `json:"start"`
+	End    time.Time `json:"end"`
+	Value  *float64  `json:"value"`
+	Type   string    `json:"type"`   // "observed" or "forecast"
+	Source string    `json:"source"` // e.g. "MRMS MultiSensor_QPE_01H_Pass2", "HRRR t12z f03"
+}
+
+// PrecipPointSeries is the response of GET /api/precip/point
+type PrecipPointSeries struct {
+	Lat           float64            `json:"lat"`
+	Lon           float64            `json:"lon"`
+	Units         string             `json:"units"`
+	Hours         int                `json:"hours"`
+	ObservedTotal float64            `json:"observed_total"`
+	ForecastTotal float64            `json:"forecast_total"`
+	HRRRCycle     *time.Time         `json:"hrrr_cycle,omitempty"`
+	Series        []PrecipPointValue `json:"series"`
+}
+
+// PrecipCellValue is a grid cell value with its location and the end of the
+// hour (or 24-hour window) it belongs to
+type PrecipCellValue struct {
+	Value float64   `json:"value"`
+	Lat   float64   `json:"lat"`
+	Lon   float64   `json:"lon"`
+	Time  time.Time `json:"time"`
+}
+
+// PrecipThresholdArea is the area whose largest 24-hour total reached Inches
+type PrecipThresholdArea struct {
+	Inches   float64 `json:"inches"`
+	AreaSqMi float64 `json:"area_sq_mi"`
+	Percent  float64 `json:"percent"`
+}
+
+// PrecipRegionSummary summarises the MRMS grids of a run over one polygon
+type PrecipRegionSummary struct {
+	Name      string                `json:"name"`
+	Type      string                `json:"type"` // county or subbasin
+	Cells     int                   `json:"cells"`
+	AreaSqMi  float64               `json:"area_sq_mi"`
+	MeanTotal float64               `json:"mean_total"`
+	Max1Hour  *PrecipCellValue      `json:"max_1h"`
+	Max24Hour *PrecipCellValue      `json:"max_24h"`
+	AreaAbove []PrecipThresholdArea `json:"area_above"`
+}
+
+// PrecipSummary is the stored per-run summary served by GET /api/precip/summary
+type PrecipSummary struct {
+	RunID        int32                 `json:"run_id"`
+	RunType      string                `json:"run_type"`
+	WindowStart  time.Time             `json:"window_start"`
+	WindowEnd    time.Time             `json:"window_end"`
+	Units        string                `json:"units"`
+	Hours        int                   `json:"hours"`
+	MissingHours int                   `json:"missing_hours"`
+	Sources      []string              `json:"sources"`
+	Regions      []PrecipRegionSummary `json:"regions"`
+}
+
+// GaugeReading is one rain gauge observation: the precipitation (inches)
+// accumulated since the station's previous reading, ending at Time (UTC)
+type GaugeReading struct {
+	StationID string    `json:"station_id"`
+	Name      string    `json:"name,omitempty"`
+	Lat       *float64  `json:"lat,omitempty"`
+	Lon       *float64  `json:"lon,omitempty"`
+	Time      time.Time `json:"time"`
+	PrecipIn  float64   `json:"precip_in"`
+}
+
+// PrecipBiasGauge is one gauge/radar pair of a bias calculation
+type PrecipBiasGauge struct {
+	StationID  string  `json:"station_id"`
+	Name       string  `json:"name"`
+	Lat        float64 `json:"lat"`
+	Lon        float64 `json:"lon"`
+	GaugeTotal float64 `json:"gauge_total"`
+	RadarTotal float64 `json:"radar_total"`
+	Factor     float64 `json:"factor"`
+	Used       bool    `json:"used"`
+}
+
+// PrecipBiasResult holds the bias factors computed (and possibly applied) for a run
+type PrecipBiasResult struct {
+	RunID           int32             `json:"run_id"`
+	Method          string            `json:"method"`
+	WindowStart     time.Time         `json:"window_start"`
+	WindowEnd       time.Time         `json:"window_end"`
+	MeanFieldFactor float64           `json:"mean_field_factor"`
+	GaugesUsed      int               `json:"gauges_used"`
+	Applied         bool              `json:"applied"`
+	Message         string            `json:"message,omitempty"`
+	Gauges          []PrecipBiasGauge `json:"gauges"`
+
+	// Factors of each chunk of a chunked historical run, which the run-level
+	// fields combine
+	Chunks []PrecipBiasResult `json:"chunks,omitempty"`
+}
+
+// HistoricalDownloadRequest is the body of POST /api/run-hms-pipeline-historical.
+// Ranges longer than historical.chunk_days are downloaded, bias corrected and
+// merged one chunk at a time, but HMS computes the whole range as a single
+// run with no warm-started segments, so the range must fit one compute.
 type HistoricalDownloadRequest struct {
-	StartDate string `json:"start_date"` // Format: YYYYMMDD
-	EndDate   string `json:"end_date"`   // Format: YYYYMMDD
-	StartTime string `json:"start_time"` // Format: HH:MM (ignored for now)
-	EndTime   string `json:"end_time"`   // Format: HH:MM (ignored for now)
+	Model     string `json:"model,omitempty"` // model ID, default model when empty
+	StartDate string `json:"start_date"`      // Format: YYYYMMDD
+	EndDate   string `json:"end_date"`        // Format: YYYYMMDD
+	StartTime string `json:"start_time"`      // Format: HH:MM (ignored for now)
+	EndTime   string `json:"end_time"`        // Format: HH:MM (ignored for now)
+
+	// Optional gauge bias overrides (defaults come from the gauges config)
+	BiasMethod string `json:"bias_method,omitempty"` // none, mean_field or local
+	ApplyBias  *bool  `json:"apply_bias,omitempty"`
+
+	// Optional basin parameter overrides for this run's compute
+	Overrides []hms.ParameterOverride `json:"overrides,omitempty"`
+	// Keep the run's workspace (project copy) regardless of hms.keep_workspaces
+	KeepWorkspace bool `json:"keep_workspace,omitempty"`
+
+	// Name and description the run is saved under in the event library. The
+	// name defaults to <start_date>_<end_date>; saving replaces an event of
+	// the same name.
+	Event       string `json:"event,omitempty"`
+	Description string `json:"description,omitempty"`
+}
+
+// HistoricalProgress is the progress a historical run stores on its
+// pipeline run (GET /api/pipeline-runs/:id)
+type HistoricalProgress struct {
+	Step           string   `json:"step"`            // download, merge, compute, save or done
+	Chunk          int      `json:"chunk"`           // chunk being downloaded or merged, from 1
+	Chunks         int      `json:"chunks"`          // chunks of historical.chunk_days days
+	Days           int      `json:"days"`            // days in the range
+	DaysDownloaded int      `json:"days_downloaded"` // days with MRMS data so far
+	FailedDates    []string `json:"failed_dates,omitempty"`
+	Note           string   `json:"note,omitempty"` // limits of chunked runs
+}
+
+// PipelineRequest is the body of POST /api/run-hms-pipeline
+type PipelineRequest struct {
+	Model   string `json:"model,omitempty"` // model ID, default model when empty
+	Date    string `json:"date"`            // Optional date in YYYYMMDD format
+	RunHour string `json:"run_hour"`        // Optional run hour in HH format
+
+	// Optional gauge bias overrides (defaults come from the gauges config)
+	BiasMethod string `json:"bias_method,omitempty"` // none, mean_field or local
+	ApplyBias  *bool  `json:"apply_bias,omitempty"`
+
+	// Optional basin parameter overrides for this run's compute
+	Overrides []hms.ParameterOverride `json:"overrides,omitempty"`
+	// Keep the run's workspace (project copy) regardless of hms.keep_workspaces
+	KeepWorkspace bool `json:"keep_workspace,omitempty"`
 }
 
 // ExtractDSSDataRequest represents the request body for extracting DSS data
 type ExtractDSSDataRequest struct {
 	TargetBPart string `json:"b_part_junction"` // e.g., "CUL-041"
 }
+
+// ModelElement is one hydrologic element of an HMS basin model
+type ModelElement struct {
+	Name        string            `json:"name"`
+	Type        string            `json:"type"` // subbasin, reach, junction, reservoir, source, sink or diversion
+	Description string            `json:"description,omitempty"`
+	Downstream  string            `json:"downstream,omitempty"`
+	DivertTo    string            `json:"divert_to,omitempty"`
+	Upstream    []string          `json:"upstream"`       // elements draining directly into this one
+	Area        float64           `json:"area,omitempty"` // subbasins, in the model's unit system
+	Parameters  map[string]string `json:"parameters"`
+}
+
+// ModelElementsResponse is returned by GET /api/models/:model/elements
+type ModelElementsResponse struct {
+	Model      string         `json:"model"`
+	Basin      string         `json:"basin"`
+	UnitSystem string         `json:"unit_system"`
+	Elements   []ModelElement `json:"elements"`
+}
+
+// ElementGraphResponse is returned by GET /api/models/:model/elements/:element/graph
+type ElementGraphResponse struct {
+	Model             string       `json:"model"`
+	Element           ModelElement `json:"element"`
+	Upstream          []string     `json:"upstream"` // nearest first
+	UpstreamSubbasins []string     `json:"upstream_subbasins"`
+	Downstream        []string     `json:"downstream"` // in flow order to the outlet
+	DrainageArea      float64      `json:"drainage_area"`
+}
+
+// ModelSummary is one entry of GET /api/models
+type ModelSummary struct {
+	ID            string `json:"id"`
+	Name          string `json:"name"`
+	ProjectName   string `json:"project_name"`
+	MetModel      string `json:"met_model,omitempty"`
+	RealTimeRun   string `json:"realtime_run"`
+	HistoricalRun string `json:"historical_run"`
+	Default       bool   `json:"default"`
+}
+
+// JunctionFlowsFile is the junction flows JSON written by the DSS extract
+// scripts and served by GET /api/get-all-junction-flows
+type JunctionFlowsFile struct {
+	Series []JunctionFlowSeries `json:"series"`
+}
+
+// JunctionFlowSeries is the flow hydrograph of one junction
+type JunctionFlowSeries struct {
+	Name     string              `json:"name"`
+	Timezone string              `json:"timezone"`
+	Unit     string              `json:"unit"`
+	Max      *float64            `json:"max"`
+	Data     []JunctionFlowPoint `json:"data"`
+
+	// Set on API responses for junctions with flood thresholds or a rating curve
+	Flood     *JunctionFloodStatus `json:"flood,omitempty"`
+	StageUnit string               `json:"stage_unit,omitempty"`
+}
+
+// JunctionFlowPoint is one value of a flow series; Time is "02 Jan 2006T15:04"
+type JunctionFlowPoint struct {
+	Time  string   `json:"time"`
+	Value float64  `json:"value"`
+	Stage *float64 `json:"stage,omitempty"` // on API responses for junctions with a rating curve
+}
+
+// JunctionFlowStats summarises a junction's flows over a time window
+type JunctionFlowStats struct {
+	Peak            *float64   `json:"peak"`
+	PeakTime        *time.Time `json:"peak_time"`
+	TimeToPeakHours *float64   `json:"time_to_peak_hours"` // from the first value of the window
+	Volume          float64    `json:"volume_acre_ft"`     // trapezoidal integral of the flows
+	Current         *float64   `json:"current"`            // latest value at or before now
+	CurrentTime     *time.Time `json:"current_time"`
+	Trend           string     `json:"trend"`                // rising, falling or steady at the current value
+	PeakStage       *float64   `json:"peak_stage,omitempty"` // with a rating curve
+	CurrentStage    *float64   `json:"current_stage,omitempty"`
+}
+
+// JunctionSummary is one entry of GET /api/junctions
+type JunctionSummary struct {
+	Name   string               `json:"name"`
+	Unit   string               `json:"unit"`
+	Points int                  `json:"points"`
+	Start  *time.Time           `json:"start"`
+	End    *time.Time           `json:"end"`
+	Stats  JunctionFlowStats    `json:"stats"`
+	Flood  *JunctionFloodStatus `json:"flood,omitempty"`
+
+	StageUnit string `json:"stage_unit,omitempty"`
+}
+
+// JunctionFlowsResponse is returned by GET /api/junctions/:name/flows
+type JunctionFlowsResponse struct {
+	Model     string               `json:"model"`
+	Run       string               `json:"run"` // realtime or historical
+	Name      string               `json:"name"`
+	Timezone  string               `json:"timezone"`
+	Unit      string               `json:"unit"`
+	StageUnit string               `json:"stage_unit,omitempty"`
+	Interval  string               `json:"interval,omitempty"`
+	Stats     JunctionFlowStats    `json:"stats"`
+	Flood     *JunctionFloodStatus `json:"flood,omitempty"`
+	Data      []JunctionFlowPoint  `json:"data"`
+}
+
+// EnsembleRequest is the body of POST /api/run-hms-pipeline-ensemble
+type EnsembleRequest struct {
+	Model   string    `json:"model,omitempty"`  // model ID, default model when empty
+	Date    string    `json:"date"`             // Optional date of the latest HRRR cycle, YYYYMMDD
+	RunHour string    `json:"run_hour"`         // Optional hour of the latest HRRR cycle, HH
+	Cycles  int       `json:"cycles,omitempty"` // default ensemble.cycles
+	Scales  []float64 `json:"scales,omitempty"` // default ensemble.scales
+
+	// Keep the members' workspaces regardless of hms.keep_workspaces
+	KeepWorkspace bool `json:"keep_workspace,omitempty"`
+}
+
+// EnsembleMember is one precipitation scenario of an ensemble run
+type EnsembleMember struct {
+	ID     string    `json:"id"`
+	Cycle  time.Time `json:"cycle"` // HRRR cycle driving the forecast part
+	Scale  float64   `json:"scale"` // multiplier of the forecast precipitation
+	Status string    `json:"status"`
+	Error  string    `json:"error,omitempty"`
+}
+
+// EnsembleBand summarises the members' values at one time
+type EnsembleBand struct {
+	Min    float64 `json:"min"`
+	Median float64 `json:"median"`
+	Max    float64 `json:"max"`
+}
+
+// EnsembleFlowPoint is the band of a junction's flows at one time
+type EnsembleFlowPoint struct {
+	Time string `json:"time"`
+	EnsembleBand
+}
+
+// EnsembleFlowSeries is the flow band of one junction across the members
+type EnsembleFlowSeries struct {
+	Name     string              `json:"name"`
+	Timezone string              `json:"timezone"`
+	Unit     string              `json:"unit"`
+	Members  int                 `json:"members"` // members that produced this junction
+	Peak     EnsembleBand        `json:"peak"`    // band of the members' peak flows
+	Data     []EnsembleFlowPoint `json:"data"`
+}
+
+// EnsembleFlowsFile is served by GET /api/get-all-junction-flows?ensemble=true
+type EnsembleFlowsFile struct {
+	Model     string               `json:"model"`
+	RunID     int32                `json:"run_id,omitempty"`
+	Generated time.Time            `json:"generated"`
+	Members   []EnsembleMember     `json:"members"`
+	Series    []EnsembleFlowSeries `json:"series"`
+}
+
+// DesignStormRequest is the body of POST /api/scenarios/design-storm. The
+// depth comes from Depth or, when it is zero, from the return period's
+// design_storms.depths table.
+type DesignStormRequest struct {
+	Name            string    `json:"name"`                       // scenario name, unique per model
+	Model           string    `json:"model,omitempty"`            // model ID, default model when empty
+	ReturnPeriod    int       `json:"return_period,omitempty"`    // years
+	DurationHours   int       `json:"duration_hours,omitempty"`   // default 24
+	Depth           float64   `json:"depth,omitempty"`            // inches
+	Distribution    string    `json:"distribution,omitempty"`     // scs_type2, scs_type3 (default), uniform, custom or atlas14
+	Weights         []float64 `json:"weights,omitempty"`          // custom: relative depths of equal steps
+	IntervalMinutes int       `json:"interval_minutes,omitempty"` // default design_storms.interval_minutes
+	Start           time.Time `json:"start,omitempty"`            // default midnight UTC today
+
+	// Keep the workspace regardless of hms.keep_workspaces
+	KeepWorkspace bool `json:"keep_workspace,omitempty"`
+}
+
+// DesignStorm is a resolved design storm, stored as its scenario's definition
+type DesignStorm struct {
+	Distribution    string    `json:"distribution"`
+	ReturnPeriod    int       `json:"return_period,omitempty"`
+	DurationHours   int       `json:"duration_hours"`
+	IntervalMinutes int       `json:"interval_minutes"`
+	Depth           float64   `json:"depth"` // inches
+	Start           time.Time `json:"start"`
+	End             time.Time `json:"end"`        // end of the simulation, after the recession
+	Hyetograph      []float64 `json:"hyetograph"` // inches per interval from Start
+}
+
+// ScenarioSummary is one entry of GET /api/scenarios
+type ScenarioSummary struct {
+	Name       string     `json:"name"`
+	Model      string     `json:"model"`
+	Kind       string     `json:"kind"`
+	Status     string     `json:"status"`
+	Error      string     `json:"error,omitempty"`
+	RunID      int32      `json:"run_id,omitempty"`
+	CreatedAt  time.Time  `json:"created_at"`
+	FinishedAt *time.Time `json:"finished_at,omitempty"`
+}
+
+// ScenarioResponse is returned by GET /api/scenarios/:model/:name
+type ScenarioResponse struct {
+	ScenarioSummary
+	Definition json.RawMessage          `json:"definition"`
+	Flows      *JunctionFlowsFile       `json:"flows,omitempty"`
+	Baseline   *JunctionFlowsFile       `json:"baseline,omitempty"`   // flows of the run the scenario was derived from
+	Comparison []JunctionPeakComparison `json:"comparison,omitempty"` // peaks of flows against baseline
+}
+
+// PrecipAdjustment changes precipitation grids: every value becomes
+// value*Multiplier + Offset, and at least zero
+type PrecipAdjustment struct {
+	Multiplier float64 `json:"multiplier"`
+	Offset     float64 `json:"offset,omitempty"` // inches per hour of accumulation
+}
+
+// WhatIfRequest is the body of POST /api/scenarios/what-if. The model's
+// latest successful realtime run is recomputed with its precipitation
+// adjusted; multipliers default to 1.
+type WhatIfRequest struct {
+	Name               string   `json:"name"`            // scenario name, unique per model
+	Model              string   `json:"model,omitempty"` // model ID, default model when empty
+	ForecastMultiplier *float64 `json:"forecast_multiplier,omitempty"`
+	ForecastOffset     float64  `json:"forecast_offset,omitempty"` // inches per hour
+	ObservedMultiplier *float64 `json:"observed_multiplier,omitempty"`
+	ObservedOffset     float64  `json:"observed_offset,omitempty"` // inches per hour
+
+	// Keep the workspace regardless of hms.keep_workspaces
+	KeepWorkspace bool `json:"keep_workspace,omitempty"`
+}
+
+// WhatIf is a resolved what-if run, stored as its scenario's definition
+type WhatIf struct {
+	BaselineRunID int32                   `json:"baseline_run_id"`
+	Date          string                  `json:"date"`           // of the baseline's MRMS and HRRR downloads, YYYYMMDD
+	RunHour       string                  `json:"run_hour"`       // HRRR cycle, HH
+	MRMSDir       string                  `json:"mrms_dir"`       // observed grids downloaded for the baseline
+	Bias          *PrecipBiasResult       `json:"bias,omitempty"` // correction the baseline applied to them
+	Overrides     []hms.ParameterOverride `json:"overrides,omitempty"`
+	Forecast      PrecipAdjustment        `json:"forecast"`
+	Observed      PrecipAdjustment        `json:"observed"`
+	Start         time.Time               `json:"start"`
+	End           time.Time               `json:"end"`
+}
+
+// JunctionPeakComparison compares a junction's peak flow in a scenario with
+// the baseline it was derived from
+type JunctionPeakComparison struct {
+	Name          string   `json:"name"`
+	BaselinePeak  *float64 `json:"baseline_peak"`
+	ScenarioPeak  *float64 `json:"scenario_peak"`
+	Change        *float64 `json:"change,omitempty"`         // scenario - baseline
+	ChangePercent *float64 `json:"change_percent,omitempty"` // relative to a positive baseline peak
+}
+
+// CalibrationRequest is the body of POST /api/calibrations. Every
+// combination of the parameters' values is computed over the model's
+// historical project, whose rainfall must already cover start to end.
+type CalibrationRequest struct {
+	Name       string                 `json:"name"`
+	Model      string                 `json:"model,omitempty"` // model ID, default model when empty
+	Start      time.Time              `json:"start,omitempty"` // default first observed time
+	End        time.Time              `json:"end,omitempty"`   // default last observed time
+	Observed   []ObservedFlowSeries   `json:"observed"`
+	Parameters []CalibrationParameter `json:"parameters"`
+	Objective  string                 `json:"objective,omitempty"` // nse (default), kge, percent_bias or peak_error
+
+	// Keep the workspace regardless of hms.keep_workspaces
+	KeepWorkspace bool `json:"keep_workspace,omitempty"`
+}
+
+// ObservedFlowSeries is the observed flow at a junction. Times are UTC,
+// RFC3339 or "02 Jan 2006T15:04".
+type ObservedFlowSeries struct {
+	Junction string              `json:"junction"`
+	Data     []JunctionFlowPoint `json:"data"`
+}
+
+// CalibrationParameter is one dimension of a calibration's search space
+type CalibrationParameter struct {
+	Parameter string    `json:"parameter"`          // basin field, e.g. "Curve Number" or "Lag"
+	Elements  []string  `json:"elements,omitempty"` // default every element with the field
+	Mode      string    `json:"mode,omitempty"`     // "multiply" the current values (default) or "set" them
+	Values    []float64 `json:"values,omitempty"`
+	Min       float64   `json:"min,omitempty"` // with steps: evenly spaced values instead of values
+	Max       float64   `json:"max,omitempty"`
+	Steps     int       `json:"steps,omitempty"`
+}
+
+// CalibrationTrialResult is one computed parameter set of a calibration
+type CalibrationTrialResult struct {
+	Trial     int                     `json:"trial"`
+	Values    []float64               `json:"values"`    // of the parameters, in request order
+	Overrides []hms.ParameterOverride `json:"overrides"` // usable as the overrides of a pipeline run
+	Status    string                  `json:"status"`
+	Error     string                  `json:"error,omitempty"`
+	Score     *float64                `json:"score,omitempty"` // objective averaged over the junctions
+	Fits      map[string]hms.Fit      `json:"fits,omitempty"`  // by junction
+}
+
+// CalibrationSummary is one entry of GET /api/calibrations
+type CalibrationSummary struct {
+	ID         int32      `json:"id"`
+	Name       string     `json:"name"`
+	Model      string     `json:"model"`
+	Objective  string     `json:"objective"`
+	Status     string     `json:"status"`
+	Error      string     `json:"error,omitempty"`
+	RunID      int32      `json:"run_id,omitempty"`
+	BestTrial  *int       `json:"best_trial,omitempty"`
+	CreatedAt  time.Time  `json:"created_at"`
+	FinishedAt *time.Time `json:"finished_at,omitempty"`
+}
+
+// CalibrationResponse is returned by GET /api/calibrations/:id
+type CalibrationResponse struct {
+	CalibrationSummary
+	Definition json.RawMessage          `json:"definition"`
+	Best       *CalibrationTrialResult  `json:"best,omitempty"`
+	Trials     []CalibrationTrialResult `json:"trials"`
+}
+
+// HistoricalEventSummary is one entry of GET /api/historical-events
+type HistoricalEventSummary struct {
+	Name        string    `json:"name"`
+	Model       string    `json:"model"`
+	Description string    `json:"description,omitempty"`
+	Start       time.Time `json:"start"`
+	End         time.Time `json:"end"`
+	RunID       int32     `json:"run_id,omitempty"`
+	CreatedAt   time.Time `json:"created_at"`
+}
+
+// HistoricalEventResponse is returned by GET /api/historical-events/:model/:name
+type HistoricalEventResponse struct {
+	HistoricalEventSummary
+	Inputs    json.RawMessage    `json:"inputs"`
+	Flows     *JunctionFlowsFile `json:"flows,omitempty"`
+	Artifacts []string           `json:"artifacts"` // files served by .../files/:file
+}
+
+// HistoricalEventPeaks is a junction's peak flow in each compared event
+type HistoricalEventPeaks struct {
+	Name  string              `json:"name"`
+	Peaks map[string]*float64 `json:"peaks"` // by event, null when the event lacks the junction
+}
+
+// HistoricalEventComparison is returned by GET /api/historical-events/compare
+type HistoricalEventComparison struct {
+	Model     string                   `json:"model"`
+	Events    []HistoricalEventSummary `json:"events"`
+	Junctions []HistoricalEventPeaks   `json:"junctions"`
+}
+
+// JunctionFlowRunSummary is a realtime run whose junction flows are stored,
+// one entry of GET /api/junction-flow-runs
+type JunctionFlowRunSummary struct {
+	ID        int32     `json:"id"`
+	Model     string    `json:"model"`
+	RunID     int32     `json:"run_id,omitempty"` // pipeline run
+	IssueTime time.Time `json:"issue_time"`       // last observed hour; later values are forecast
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// StoredFlowPoint is one stored junction flow value of a run
+type StoredFlowPoint struct {
+	Time     time.Time `json:"time"`
+	Value    float64   `json:"value"`
+	Stage    *float64  `json:"stage,omitempty"` // with a rating curve
+	Observed bool      `json:"observed"`
+}
+
+// JunctionForecast is one run's series of a junction
+type JunctionForecast struct {
+	JunctionFlowRunSummary
+	Peak     *float64             `json:"peak"` // of the forecast values
+	PeakTime *time.Time           `json:"peak_time"`
+	Flood    *JunctionFloodStatus `json:"flood,omitempty"` // as of the issue time, with thresholds
+	Data     []StoredFlowPoint    `json:"data"`
+}
+
+// JunctionForecastsResponse is returned by GET /api/junctions/:name/forecasts
+type JunctionForecastsResponse struct {
+	Model     string             `json:"model"`
+	Name      string             `json:"name"`
+	StageUnit string             `json:"stage_unit,omitempty"`
+	Forecasts []JunctionForecast `json:"forecasts"` // by issue time
+}
+
+// ForecastObservedPoint pairs a forecast value with the simulated observed
+// value of a later run
+type ForecastObservedPoint struct {
+	Time          time.Time  `json:"time"`
+	LeadHours     float64    `json:"lead_hours"`
+	Forecast      float64    `json:"forecast"`
+	ForecastStage *float64   `json:"forecast_stage,omitempty"` // with a rating curve
+	Observed      *float64   `json:"observed"`                 // null until a later run has simulated the hour
+	ObservedStage *float64   `json:"observed_stage,omitempty"`
+	ObservedIssue *time.Time `json:"observed_issue"` // issue time of the run the observed value comes from
+	Error         *float64   `json:"error"`          // forecast - observed
+}
+
+// ForecastVsObservedResponse is returned by
+// GET /api/junctions/:name/forecast-vs-observed
+type ForecastVsObservedResponse struct {
+	Model        string                  `json:"model"`
+	Name         string                  `json:"name"`
+	Run          JunctionFlowRunSummary  `json:"run"`
+	StageUnit    string                  `json:"stage_unit,omitempty"`
+	Matched      int                     `json:"matched"`
+	MeanAbsError *float64                `json:"mean_abs_error"`
+	PeakForecast *float64                `json:"peak_forecast"`
+	PeakObserved *float64                `json:"peak_observed"`   // over the matched hours
+	Flood        *JunctionFloodStatus    `json:"flood,omitempty"` // of the run as of its issue time, with thresholds
+	Points       []ForecastObservedPoint `json:"points"`
+}
+
+// StreamflowUpload is the body of POST /api/streamflow-observations
+type StreamflowUpload struct {
+	Model  string               `json:"model,omitempty"`  // model ID, default model when empty
+	Source string               `json:"source,omitempty"` // e.g. "usgs"
+	Series []ObservedFlowSeries `json:"series"`
+}
+
+// VerificationRequest is the body of POST /api/verifications. Forecasts
+// issued between from and to (default the last verification.window_days)
+// are verified against the reference flows.
+type VerificationRequest struct {
+	Model      string             `json:"model,omitempty"`     // model ID, default model when empty
+	Reference  string             `json:"reference,omitempty"` // simulated (default): later runs' observed period; gauge: uploaded streamflow
+	From       string             `json:"from,omitempty"`      // issue times, RFC3339 or "02 Jan 2006T15:04"
+	To         string             `json:"to,omitempty"`
+	BinHours   int                `json:"bin_hours,omitempty"`  // default verification.bin_hours
+	Threshold  float64            `json:"threshold,omitempty"`  // exceedance flow of every junction
+	Thresholds map[string]float64 `json:"thresholds,omitempty"` // by junction, over threshold; without either the lowest stored flood threshold
+}
+
+// JunctionVerification holds the lead time metrics of one junction
+type JunctionVerification struct {
+	Name      string                `json:"name"`
+	Threshold *float64              `json:"threshold"`
+	Forecasts int                   `json:"forecasts"` // issued forecasts with reference values
+	Pairs     int                   `json:"pairs"`
+	Bins      []hms.LeadTimeMetrics `json:"bins"`
+}
+
+// VerificationSummary is one entry of GET /api/verifications
+type VerificationSummary struct {
+	ID          int32     `json:"id"`
+	Model       string    `json:"model"`
+	Reference   string    `json:"reference"`
+	WindowStart time.Time `json:"window_start"`
+	WindowEnd   time.Time `json:"window_end"`
+	BinHours    int32     `json:"bin_hours"`
+	CreatedAt   time.Time `json:"created_at"`
+}
+
+// VerificationResponse is returned by GET /api/verifications/:id
+type VerificationResponse struct {
+	VerificationSummary
+	Junctions []JunctionVerification `json:"junctions"`
+}
+
+// LowWaterCrossing is a road crossing flooded by a junction's flow
+type LowWaterCrossing struct {
+	Name      string   `json:"name"`
+	Lat       *float64 `json:"lat,omitempty"`
+	Lon       *float64 `json:"lon,omitempty"`
+	FloodFlow *float64 `json:"flood_flow,omitempty"` // junction flow that overtops the road
+}
+
+// JunctionThresholds are the flood flows of a junction, the body of
+// PUT /api/thresholds/:model/:junction. Any of the flows may be left out;
+// those given must increase from action to major.
+type JunctionThresholds struct {
+	Model     string             `json:"model"`
+	Junction  string             `json:"junction"`
+	Action    *float64           `json:"action"`
+	Minor     *float64           `json:"minor"`
+	Moderate  *float64           `json:"moderate"`
+	Major     *float64           `json:"major"`
+	Crossings []LowWaterCrossing `json:"crossings"`
+	UpdatedAt *time.Time         `json:"updated_at,omitempty"`
+}
+
+// FloodExceedance is when a junction's flows first reach a flood category
+type FloodExceedance struct {
+	Category  string    `json:"category"`
+	Threshold float64   `json:"threshold"`
+	Time      time.Time `json:"time"`
+}
+
+// CrossingStatus tells whether a low-water crossing is or will be flooded
+type CrossingStatus struct {
+	LowWaterCrossing
+	FloodedNow      bool `json:"flooded_now"`
+	FloodedForecast bool `json:"flooded_forecast"`
+}
+
+// JunctionFloodStatus classifies a junction's flows by its thresholds.
+// Categories are none, action, minor, moderate and major.
+type JunctionFloodStatus struct {
+	CurrentCategory      string            `json:"current_category"`
+	ForecastPeak         *float64          `json:"forecast_peak"` // after the current time
+	ForecastPeakTime     *time.Time        `json:"forecast_peak_time"`
+	ForecastPeakCategory string            `json:"forecast_peak_category"`
+	FirstExceedance      *time.Time        `json:"first_exceedance"` // of the lowest threshold
+	Exceedances          []FloodExceedance `json:"exceedances"`
+	Crossings            []CrossingStatus  `json:"crossings,omitempty"`
+}
+
+// JunctionRatingCurve converts a junction's flow to stage, the body of
+// PUT /api/rating-curves/:model/:junction
+type JunctionRatingCurve struct {
+	Model    string `json:"model"`
+	Junction string `json:"junction"`
+	hms.RatingCurve
+	StageUnit string     `json:"stage_unit"` // default ft
+	Source    string     `json:"source,omitempty"`
+	UpdatedAt *time.Time `json:"updated_at,omitempty"`
+}
@@ -0,0 +1,408 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"HMSBackend/hms"
+	"HMSBackend/sqlcdb"
+
+	"github.com/labstack/echo/v4"
+)
+
+const (
+	verificationReferenceSimulated = "simulated"
+	verificationReferenceGauge     = "gauge"
+)
+
+// errNoVerificationPairs is returned when no forecast of the window has
+// reference values yet
+var errNoVerificationPairs = errors.New("no forecast of the window has reference flows yet")
+
+// verification is a resolved VerificationRequest
+type verification struct {
+	Model      ModelConfig
+	Reference  string
+	From, To   time.Time
+	BinHours   int
+	Threshold  float64
+	Thresholds map[string]float64
+}
+
+// resolveVerification applies the defaults to a verification request
+func resolveVerification(req VerificationRequest, now time.Time) (verification, error) {
+	v := verification{
+		Reference:  req.Reference,
+		To:         now.UTC(),
+		BinHours:   req.BinHours,
+		Threshold:  req.Threshold,
+		Thresholds: req.Thresholds,
+	}
+	var err error
+	if v.Model, err = GetModel(req.Model); err != nil {
+		return v, err
+	}
+	switch v.Reference {
+	case "":
+		v.Reference = verificationReferenceSimulated
+	case verificationReferenceSimulated, verificationReferenceGauge:
+	default:
+		return v, fmt.Errorf("reference must be %s or %s", verificationReferenceSimulated, verificationReferenceGauge)
+	}
+	if req.To != "" {
+		if v.To, err = parseQueryTime(req.To); err != nil {
+			return v, errors.New("invalid to time")
+		}
+	}
+	v.From = v.To.AddDate(0, 0, -max(AppConfig.Verification.WindowDays, 1))
+	if req.From != "" {
+		if v.From, err = parseQueryTime(req.From); err != nil {
+			return v, errors.New("invalid from time")
+		}
+	}
+	if !v.From.Before(v.To) {
+		return v, errors.New("from must be before to")
+	}
+	if v.BinHours == 0 {
+		v.BinHours = max(AppConfig.Verification.BinHours, 1)
+	}
+	if v.BinHours < 1 || v.BinHours > 24 {
+		return v, errors.New("bin_hours must be between 1 and 24")
+	}
+	return v, nil
+}
+
+// threshold returns the exceedance flow of a junction, zero for none
+func (v verification) threshold(junction string) float64 {
+	for name, flow := range v.Thresholds {
+		if strings.EqualFold(name, junction) {
+			return flow
+		}
+	}
+	return v.Threshold
+}
+
+// referenceFlows loads the reference flows between from and to by junction
+// and time
+func referenceFlows(ctx context.Context, queries *sqlcdb.Queries, model ModelConfig, reference string, from, to time.Time) (map[string]map[time.Time]float64, error) {
+	out := map[string]map[time.Time]float64{}
+	add := func(junction string, t time.Time, flow float64) {
+		if out[junction] == nil {
+			out[junction] = map[time.Time]float64{}
+		}
+		out[junction][t] = flow
+	}
+	if reference == verificationReferenceGauge {
+		rows, err := queries.ListStreamflowObservations(ctx, sqlcdb.ListStreamflowObservationsParams{Model: model.ID, FromTime: from, ToTime: to})
+		if err != nil {
+			return nil, err
+		}
+		for _, r := range rows {
+			add(r.Junction, r.ValidTime, r.Flow)
+		}
+		return out, nil
+	}
+	rows, err := queries.ListModelSimulatedObservedFlows(ctx, sqlcdb.ListModelSimulatedObservedFlowsParams{Model: model.ID, FromTime: from, ToTime: to})
+	if err != nil {
+		return nil, err
+	}
+	for _, r := range rows {
+		add(r.Junction, r.ValidTime, r.Flow)
+	}
+	return out, nil
+}
+
+// pairForecasts pairs the forecast flows with the reference flows of the
+// same junction and hour, by junction
+func pairForecasts(forecasts []sqlcdb.ListModelForecastFlowsRow, reference map[string]map[time.Time]float64) map[string][]hms.ForecastPair {
+	out := map[string][]hms.ForecastPair{}
+	for _, f := range forecasts {
+		observed, ok := reference[f.Junction][f.ValidTime]
+		if !ok {
+			continue
+		}
+		out[f.Junction] = append(out[f.Junction], hms.ForecastPair{
+			Issue:    f.IssueTime,
+			Time:     f.ValidTime,
+			Forecast: f.Flow,
+			Observed: observed,
+		})
+	}
+	return out
+}
+
+// runVerification verifies the stored forecasts of a window and stores the
+// metrics
+func runVerification(ctx context.Context, queries *sqlcdb.Queries, v verification) (sqlcdb.ForecastVerification, []JunctionVerification, error) {
+	forecasts, err := queries.ListModelForecastFlows(ctx, sqlcdb.ListModelForecastFlowsParams{
+		Model:    v.Model.ID,
+		FromTime: v.From.UTC(),
+		ToTime:   v.To.UTC(),
+	})
+	if err != nil {
+		return sqlcdb.ForecastVerification{}, nil, fmt.Errorf("failed to list forecasts: %w", err)
+	}
+	if len(forecasts) == 0 {
+		return sqlcdb.ForecastVerification{}, nil, errNoVerificationPairs
+	}
+	last := forecasts[0].ValidTime
+	for _, f := range forecasts {
+		if f.ValidTime.After(last) {
+			last = f.ValidTime
+		}
+	}
+	reference, err := referenceFlows(ctx, queries, v.Model, v.Reference, v.From.UTC(), last)
+	if err != nil {
+		return sqlcdb.ForecastVerification{}, nil, fmt.Errorf("failed to list %s reference flows: %w", v.Reference, err)
+	}
+
+	// Without thresholds in the request, exceedance is of each junction's
+	// lowest flood threshold
+	if v.Threshold == 0 && len(v.Thresholds) == 0 {
+		stored, err := loadJunctionThresholds(ctx, queries, v.Model.ID)
+		if err != nil {
+			log.Printf("Warning: Flood thresholds of model %s not used for verification: %v", v.Model.ID, err)
+		}
+		v.Thresholds = map[string]float64{}
+		for _, t := range stored {
+			if lowest := t.lowest(); lowest > 0 {
+				v.Thresholds[t.Junction] = lowest
+			}
+		}
+	}
+
+	pairs := pairForecasts(forecasts, reference)
+	names := make([]string, 0, len(pairs))
+	for name := range pairs {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	junctions := make([]JunctionVerification, 0, len(names))
+	for _, name := range names {
+		j := JunctionVerification{Name: name, Pairs: len(pairs[name])}
+		threshold := v.threshold(name)
+		if threshold > 0 {
+			j.Threshold = &threshold
+		}
+		issues := map[time.Time]bool{}
+		for _, p := range pairs[name] {
+			issues[p.Issue] = true
+		}
+		j.Forecasts = len(issues)
+		j.Bins = hms.VerifyForecasts(pairs[name], v.BinHours, threshold)
+		junctions = append(junctions, j)
+	}
+	if len(junctions) == 0 {
+		return sqlcdb.ForecastVerification{}, nil, errNoVerificationPairs
+	}
+
+	body, err := json.Marshal(junctions)
+	if err != nil {
+		return sqlcdb.ForecastVerification{}, nil, err
+	}
+	row, err := queries.CreateForecastVerification(ctx, sqlcdb.CreateForecastVerificationParams{
+		Model:       v.Model.ID,
+		Reference:   v.Reference,
+		WindowStart: v.From.UTC(),
+		WindowEnd:   v.To.UTC(),
+		BinHours:    int32(v.BinHours),
+		Results:     body,
+	})
+	if err != nil {
+		return sqlcdb.ForecastVerification{}, nil, fmt.Errorf("failed to store verification: %w", err)
+	}
+	return row, junctions, nil
+}
+
+// RunScheduledVerifications verifies every model's forecasts of the last
+// verification.window_days against both references. Failures are only
+// logged.
+func RunScheduledVerifications(ctx context.Context, queries *sqlcdb.Queries) {
+	for _, id := range ModelIDs() {
+		for _, reference := range []string{verificationReferenceSimulated, verificationReferenceGauge} {
+			v, err := resolveVerification(VerificationRequest{Model: id, Reference: reference}, time.Now())
+			if err != nil {
+				log.Printf("Scheduler: Verification of model %s skipped: %v", id, err)
+				continue
+			}
+			row, junctions, err := runVerification(ctx, queries, v)
+			if errors.Is(err, errNoVerificationPairs) {
+				continue
+			}
+			if err != nil {
+				log.Printf("Scheduler: Verification of model %s against %s flows failed: %v", id, reference, err)
+				continue
+			}
+			log.Printf("Scheduler: Verification %d of model %s against %s flows covers %d junctions", row.ID, id, reference, len(junctions))
+		}
+	}
+}
+
+func verificationSummary(id int32, model, reference string, start, end time.Time, binHours int32, createdAt time.Time) VerificationSummary {
+	return VerificationSummary{
+		ID:          id,
+		Model:       model,
+		Reference:   reference,
+		WindowStart: start,
+		WindowEnd:   end,
+		BinHours:    binHours,
+		CreatedAt:   createdAt,
+	}
+}
+
+// handleRunVerification handles POST /api/verifications, verifying the
+// stored forecasts of a window now
+func handleRunVerification(queries *sqlcdb.Queries) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		var req VerificationRequest
+		if err := c.Bind(&req); err != nil {
+			return respondWithError(c, http.StatusBadRequest, "Invalid request format")
+		}
+		v, err := resolveVerification(req, time.Now())
+		if err != nil {
+			return respondWithError(c, http.StatusBadRequest, err.Error())
+		}
+
+		row, junctions, err := runVerification(c.Request().Context(), queries, v)
+		if errors.Is(err, errNoVerificationPairs) {
+			return respondWithError(c, http.StatusNotFound, err.Error())
+		}
+		if err != nil {
+			log.Printf("Error verifying forecasts of model %s: %v", v.Model.ID, err)
+			return respondWithError(c, http.StatusInternalServerError, "Failed to verify forecasts")
+		}
+		return respondWithJSON(c, http.StatusCreated, VerificationResponse{
+			VerificationSummary: verificationSummary(row.ID, row.Model, row.Reference, row.WindowStart, row.WindowEnd, row.BinHours, row.CreatedAt),
+			Junctions:           junctions,
+		})
+	}
+}
+
+// handleListVerifications serves GET /api/verifications?model=&limit=,
+// latest first
+func handleListVerifications(queries *sqlcdb.Queries) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		limit := 50
+		if v := c.QueryParam("limit"); v != "" {
+			var err error
+			if limit, err = strconv.Atoi(v); err != nil || limit < 1 {
+				return respondWithError(c, http.StatusBadRequest, "Invalid limit")
+			}
+		}
+		rows, err := queries.ListForecastVerifications(c.Request().Context(), sqlcdb.ListForecastVerificationsParams{
+			Model:   c.QueryParam("model"),
+			MaxRows: int32(limit),
+		})
+		if err != nil {
+			log.Printf("Error listing verifications: %v", err)
+			return respondWithError(c, http.StatusInternalServerError, "Failed to list verifications")
+		}
+		out := make([]VerificationSummary, len(rows))
+		for i, r := range rows {
+			out[i] = verificationSummary(r.ID, r.Model, r.Reference, r.WindowStart, r.WindowEnd, r.BinHours, r.CreatedAt)
+		}
+		return respondWithJSON(c, http.StatusOK, out)
+	}
+}
+
+// handleGetVerification serves GET /api/verifications/:id, optionally of
+// one junction (?junction=)
+func handleGetVerification(queries *sqlcdb.Queries) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		id, err := strconv.Atoi(c.Param("id"))
+		if err != nil {
+			return respondWithError(c, http.StatusBadRequest, "Invalid verification id")
+		}
+		row, err := queries.GetForecastVerification(c.Request().Context(), int32(id))
+		if errors.Is(err, sql.ErrNoRows) {
+			return respondWithError(c, http.StatusNotFound, "Verification not found")
+		}
+		if err != nil {
+			log.Printf("Error getting verification %d: %v", id, err)
+			return respondWithError(c, http.StatusInternalServerError, "Failed to get verification")
+		}
+
+		var junctions []JunctionVerification
+		if err := json.Unmarshal(row.Results, &junctions); err != nil {
+			log.Printf("Error parsing verification %d: %v", id, err)
+			return respondWithError(c, http.StatusInternalServerError, "Failed to read verification")
+		}
+		if name := c.QueryParam("junction"); name != "" {
+			var one []JunctionVerification
+			for _, j := range junctions {
+				if strings.EqualFold(j.Name, name) {
+					one = append(one, j)
+				}
+			}
+			if len(one) == 0 {
+				return respondWithError(c, http.StatusNotFound, fmt.Sprintf("Junction %s not in verification %d", name, id))
+			}
+			junctions = one
+		}
+		return respondWithJSON(c, http.StatusOK, VerificationResponse{
+			VerificationSummary: verificationSummary(row.ID, row.Model, row.Reference, row.WindowStart, row.WindowEnd, row.BinHours, row.CreatedAt),
+			Junctions:           junctions,
+		})
+	}
+}
+
+// handleUploadStreamflow handles POST /api/streamflow-observations, storing
+// observed junction flows used as the gauge verification reference
+func handleUploadStreamflow(queries *sqlcdb.Queries) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		var req StreamflowUpload
+		if err := c.Bind(&req); err != nil {
+			return respondWithError(c, http.StatusBadRequest, "Invalid request format")
+		}
+		model, err := GetModel(req.Model)
+		if err != nil {
+			return respondWithError(c, http.StatusBadRequest, err.Error())
+		}
+		if len(req.Series) == 0 {
+			return respondWithError(c, http.StatusBadRequest, "at least one series is required")
+		}
+
+		// A repeated junction and time keeps its last value; one statement
+		// cannot upsert the same row twice
+		params := sqlcdb.UpsertStreamflowObservationsParams{Model: model.ID, Source: req.Source}
+		index := map[string]int{}
+		for _, s := range req.Series {
+			if s.Junction == "" {
+				return respondWithError(c, http.StatusBadRequest, "every series needs a junction")
+			}
+			for _, p := range s.Data {
+				t, err := parseQueryTime(p.Time)
+				if err != nil {
+					return respondWithError(c, http.StatusBadRequest, fmt.Sprintf("%s: invalid time %q", s.Junction, p.Time))
+				}
+				key := s.Junction + "|" + t.UTC().Format(time.RFC3339)
+				if i, ok := index[key]; ok {
+					params.Flows[i] = p.Value
+					continue
+				}
+				index[key] = len(params.Flows)
+				params.Junctions = append(params.Junctions, s.Junction)
+				params.ValidTimes = append(params.ValidTimes, t.UTC())
+				params.Flows = append(params.Flows, p.Value)
+			}
+		}
+		if err := queries.UpsertStreamflowObservations(c.Request().Context(), params); err != nil {
+			log.Printf("Error storing streamflow observations: %v", err)
+			return respondWithError(c, http.StatusInternalServerError, "Failed to store streamflow observations")
+		}
+		return respondWithJSON(c, http.StatusOK, echo.Map{
+			"model":  model.ID,
+			"series": len(req.Series),
+			"stored": len(params.Flows),
+		})
+	}
+}
@@ -0,0 +1,284 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"math"
+	"net/http"
+	"os"
+	"path/filepath"
+	"time"
+
+	"HMSBackend/hms"
+	"HMSBackend/sqlcdb"
+
+	"github.com/labstack/echo/v4"
+)
+
+const scenarioKindWhatIf = "what_if"
+
+// errNoBaselineRun is returned when a model has no realtime run to derive a
+// what-if scenario from
+var errNoBaselineRun = errors.New("no successful realtime run of the model")
+
+// realtimeRunParams are the parameters RunProcessingPipeline records
+type realtimeRunParams struct {
+	Model     string                  `json:"model"`
+	Date      string                  `json:"date"`
+	RunHour   string                  `json:"run_hour"`
+	Overrides []hms.ParameterOverride `json:"overrides"`
+}
+
+// latestRealtimeRun returns the newest successful realtime run of model and
+// its parameters
+func latestRealtimeRun(ctx context.Context, queries *sqlcdb.Queries, model ModelConfig) (sqlcdb.PipelineRun, realtimeRunParams, error) {
+	runs, err := queries.ListPipelineRuns(ctx, sqlcdb.ListPipelineRunsParams{
+		RunType: pipelineRunRealtime,
+		MaxRows: 100,
+	})
+	if err != nil {
+		return sqlcdb.PipelineRun{}, realtimeRunParams{}, err
+	}
+	for _, run := range runs {
+		if run.Status != pipelineStatusSucceeded {
+			continue
+		}
+		var params realtimeRunParams
+		if err := json.Unmarshal(run.Parameters, &params); err != nil {
+			continue
+		}
+		if params.Model == "" {
+			params.Model = AppConfig.DefaultModel // runs recorded before the model registry
+		}
+		if params.Model == model.ID && params.Date != "" && params.RunHour != "" {
+			return run, params, nil
+		}
+	}
+	return sqlcdb.PipelineRun{}, realtimeRunParams{}, errNoBaselineRun
+}
+
+// resolveWhatIf derives a what-if run from the model's latest realtime run
+func resolveWhatIf(ctx context.Context, queries *sqlcdb.Queries, model ModelConfig, req WhatIfRequest) (WhatIf, error) {
+	w := WhatIf{
+		Forecast: PrecipAdjustment{Multiplier: 1, Offset: req.ForecastOffset},
+		Observed: PrecipAdjustment{Multiplier: 1, Offset: req.ObservedOffset},
+	}
+	if req.ForecastMultiplier != nil {
+		w.Forecast.Multiplier = *req.ForecastMultiplier
+	}
+	if req.ObservedMultiplier != nil {
+		w.Observed.Multiplier = *req.ObservedMultiplier
+	}
+	if w.Forecast.Multiplier < 0 || w.Observed.Multiplier < 0 {
+		return w, errors.New("multipliers cannot be negative")
+	}
+
+	run, params, err := latestRealtimeRun(ctx, queries, model)
+	if err != nil {
+		return w, err
+	}
+	w.BaselineRunID = run.ID
+	w.Date, w.RunHour = params.Date, params.RunHour
+	w.Overrides = params.Overrides
+	w.Start, w.End = realtimeControlWindow(run.StartedAt)
+
+	// The baseline's corrected grids went with its workspace, so a bias
+	// correction it applied is applied again from the stored factors
+	w.MRMSDir = GetGribDownloadPath(params.Date)
+	if bias, err := queries.GetPrecipBiasFactor(ctx, run.ID); err == nil && bias.Applied {
+		var result PrecipBiasResult
+		if err := json.Unmarshal(bias.Details, &result); err != nil {
+			return w, fmt.Errorf("bias factors of run %d are unreadable: %w", run.ID, err)
+		}
+		w.Bias = &result
+	}
+	return w, nil
+}
+
+// runWhatIf recomputes the baseline realtime run of a what-if scenario in its
+// own workspace with the adjusted precipitation and returns the junction flows
+func runWhatIf(ctx context.Context, model ModelConfig, w WhatIf, runID int32, keep bool) (flows *JunctionFlowsFile, err error) {
+	ws, err := newHMSWorkspaceIn(hmsJobDir(model, scenarioKindWhatIf, runID), model, pipelineRunRealtime)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create workspace: %w", err)
+	}
+	defer func() { ws.close(err != nil, keep) }()
+	m := ws.Model
+
+	mrmsDir := w.MRMSDir
+	if w.Bias != nil {
+		if mrmsDir, err = reapplyBiasCorrection(w.MRMSDir, filepath.Join(ws.Dir, biasCorrectedDir), w.Bias); err != nil {
+			return nil, fmt.Errorf("failed to apply the baseline's bias correction: %w", err)
+		}
+	}
+	if !w.Observed.identity() {
+		adjustedDir := filepath.Join(ws.Dir, "mrms")
+		// MRMS grids are hourly accumulations
+		n, err := rewriteMRMSGrids(mrmsDir, adjustedDir, func(_ int, v float64) float64 { return w.Observed.apply(v, 1) })
+		if err != nil {
+			return nil, fmt.Errorf("failed to adjust observed rainfall: %w", err)
+		}
+		log.Printf("INFO: Wrote %d adjusted MRMS grids to %s", n, adjustedDir)
+		mrmsDir = adjustedDir
+	}
+
+	cycle, err := time.Parse("2006010215", w.Date+w.RunHour)
+	if err != nil {
+		return nil, fmt.Errorf("invalid baseline date or run hour: %w", err)
+	}
+	forecastDir := filepath.Join(ws.Dir, "hrrr")
+	n, err := writeHRRRForecast(cycle, w.Forecast, forecastDir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to adjust forecast rainfall: %w", err)
+	}
+	if n == 0 {
+		return nil, fmt.Errorf("no HRRR forecast files for cycle %s", cycle.Format("2006-01-02 15Z"))
+	}
+
+	steps := []struct {
+		name  string
+		batch string
+		args  []string
+	}{
+		{"Merge GRIB Files RealTime", "MergeGRIBFilesRealTimeBatch.bat",
+			[]string{mrmsDir, m.ClipShapefile, m.RainfallPath("RainfallRealTime.dss")}},
+		{"Merge GRIB Files RealTime Pass 2", "MergeGRIBFilesRealTimePass2Batch.bat",
+			[]string{mrmsDir, m.ClipShapefile, m.RainfallPath("RainfallRealTimePass2.dss")}},
+		{"Merge GRIB Files Forcast", "MergeGRIBFilesRealTimeHRRBatch.bat",
+			[]string{forecastDir, m.ClipShapefile, m.RainfallPath("HRR.dss")}},
+	}
+	for _, step := range steps {
+		if err = executeBatchFile(ctx, GetJythonBatchScriptPath(step.batch), step.args...); err != nil {
+			return nil, fmt.Errorf("failed at %s: %w", step.name, err)
+		}
+	}
+	if err = combinePass1Pass2(ctx, m.RainfallPath("RainfallRealTime.dss"), m.RainfallPath("RainfallRealTimePass2.dss"),
+		m.RainfallPath("RainfallRealTimePass1And2.dss")); err != nil {
+		return nil, fmt.Errorf("failed at Combine DSS Records Pass1 Pass2: %w", err)
+	}
+	if err = combineObservedAndForecast(ctx, m.RainfallPath("RainfallRealTimePass1And2.dss"), m.RainfallPath("HRR.dss"),
+		m.RainfallPath("RainfallRealTimeAndForcast.dss")); err != nil {
+		return nil, fmt.Errorf("failed at Combine DSS Records Realtime Pass1 Pass2 and HRR: %w", err)
+	}
+
+	if err = setControlWindow(m.ControlFile(pipelineRunRealtime), w.Start, w.End); err != nil {
+		return nil, err
+	}
+	if err = ws.compute(ctx, w.Overrides); err != nil {
+		return nil, err
+	}
+	return ws.extractFlows(ctx)
+}
+
+// seriesPeak returns the peak of a junction's flows, nil without values
+func seriesPeak(s JunctionFlowSeries) *float64 {
+	if s.Max != nil {
+		return s.Max
+	}
+	var peak *float64
+	for i := range s.Data {
+		if peak == nil || s.Data[i].Value > *peak {
+			peak = &s.Data[i].Value
+		}
+	}
+	return peak
+}
+
+// compareJunctionPeaks pairs the junctions of a scenario with the baseline's
+// by name, in the scenario's order followed by junctions only in the baseline
+func compareJunctionPeaks(baseline, scenario *JunctionFlowsFile) []JunctionPeakComparison {
+	basePeaks := make(map[string]*float64, len(baseline.Series))
+	for _, s := range baseline.Series {
+		basePeaks[s.Name] = seriesPeak(s)
+	}
+	var out []JunctionPeakComparison
+	seen := make(map[string]bool, len(scenario.Series))
+	for _, s := range scenario.Series {
+		seen[s.Name] = true
+		c := JunctionPeakComparison{
+			Name:         s.Name,
+			BaselinePeak: basePeaks[s.Name],
+			ScenarioPeak: seriesPeak(s),
+		}
+		if c.BaselinePeak != nil && c.ScenarioPeak != nil {
+			change := *c.ScenarioPeak - *c.BaselinePeak
+			c.Change = &change
+			if *c.BaselinePeak > 0 {
+				pct := math.Round(change / *c.BaselinePeak * 1000) / 10
+				c.ChangePercent = &pct
+			}
+		}
+		out = append(out, c)
+	}
+	for _, s := range baseline.Series {
+		if !seen[s.Name] {
+			out = append(out, JunctionPeakComparison{Name: s.Name, BaselinePeak: basePeaks[s.Name]})
+		}
+	}
+	return out
+}
+
+// handleRunWhatIf recomputes the model's latest realtime run with adjusted
+// precipitation as a named scenario in the background. The realtime run's
+// junction flows are stored with it as the baseline.
+func handleRunWhatIf(queries *sqlcdb.Queries) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		var req WhatIfRequest
+		if err := c.Bind(&req); err != nil {
+			log.Printf("Error parsing what-if request body: %v", err)
+			return respondWithError(c, http.StatusBadRequest, "Invalid request format")
+		}
+		if err := validateScenarioName(req.Name); err != nil {
+			return respondWithError(c, http.StatusBadRequest, err.Error())
+		}
+		model, err := GetModel(req.Model)
+		if err != nil {
+			return respondWithError(c, http.StatusBadRequest, err.Error())
+		}
+		ctx := c.Request().Context()
+		w, err := resolveWhatIf(ctx, queries, model, req)
+		if errors.Is(err, errNoBaselineRun) {
+			return respondWithError(c, http.StatusConflict, fmt.Sprintf("%v %s", err, model.ID))
+		}
+		if err != nil {
+			log.Printf("Error resolving what-if %s: %v", req.Name, err)
+			return respondWithError(c, http.StatusBadRequest, err.Error())
+		}
+
+		// The realtime JSON holds the flows of the latest successful realtime run
+		baseline, err := os.ReadFile(GetJSONOutputPath(model.JSONOutput))
+		if err != nil {
+			log.Printf("Warning: No baseline flows for what-if %s: %v", req.Name, err)
+		}
+
+		log.Printf("Received what-if request: name=%s, model=%s, baseline run %d, forecast x%g%+g in/h, observed x%g%+g in/h",
+			req.Name, model.ID, w.BaselineRunID, w.Forecast.Multiplier, w.Forecast.Offset, w.Observed.Multiplier, w.Observed.Offset)
+
+		scenario, err := startScenario(queries, model, req.Name, scenarioKindWhatIf, w,
+			func(ctx context.Context, runID int32) (*JunctionFlowsFile, error) {
+				return runWhatIf(ctx, model, w, runID, req.KeepWorkspace)
+			})
+		if err != nil {
+			log.Printf("Error starting what-if %s: %v", req.Name, err)
+			return respondWithError(c, http.StatusInternalServerError, "Failed to start scenario")
+		}
+		if json.Valid(baseline) {
+			if err := queries.SetScenarioBaseline(ctx, sqlcdb.SetScenarioBaselineParams{
+				ID:       scenario.ID,
+				Baseline: baseline,
+			}); err != nil {
+				log.Printf("Warning: Failed to store baseline of what-if %s: %v", req.Name, err)
+			}
+		}
+
+		return respondWithJSON(c, http.StatusAccepted, map[string]interface{}{
+			"message":  "What-if scenario started",
+			"status":   "accepted",
+			"scenario": scenarioSummary(scenario.Name, scenario.Model, scenario.Kind, scenario.Status, "", scenario.RunID, scenario.CreatedAt, scenario.FinishedAt),
+			"what_if":  w,
+		})
+	}
+}
@@ -1,26 +1,29 @@
 # -*- coding: utf-8 -*-
 # combine_two_dss.py  – run with jython
 # Usage:
-#   jython combine_two_dss.py <source1.dss> <source2.dss> <dest.dss>
+#   jython combine_two_dss.py <source1.dss> <source2.dss> <dest.dss> <report.json>
 #
-# Merges every record from the two source files into <dest.dss>.
+# Merges the two source files into <dest.dss>, copying only the records the
+# backend's combine report (<dest>_combine.json) lists, each from the source
+# it names.
 # Exit code: 0 = success, 1 = failure.
 
-import sys, os, traceback
+import sys, os, json, traceback
+from java.util import Vector
 from hec.heclib.dss import HecDss     # heclib.jar must be on the classpath
 
 # ----------------------------------------------------------------------
 # 1.  Parse & validate command-line arguments
 # ----------------------------------------------------------------------
-if len(sys.argv) != 4:
+if len(sys.argv) != 5:
     print(
-        "USAGE: jython combine_two_dss.py <source1.dss> <source2.dss> <dest.dss>"
+        "USAGE: jython combine_two_dss.py <source1.dss> <source2.dss> <dest.dss> <report.json>"
     )
     sys.exit(1)
 
 src1, src2, dest = sys.argv[1], sys.argv[2], os.path.abspath(sys.argv[3])
 
-for f in (src1, src2):
+for f in (src1, src2, sys.argv[4]):
     if not os.path.exists(f):
         print("ERROR: File not found ->", f)
         sys.exit(1)
@@ -33,20 +36,24 @@ if os.path.exists(dest):
         print("ERROR: Cannot overwrite", dest, ":", e)
         sys.exit(1)
 
+with open(sys.argv[4]) as fp:
+    report = json.load(fp)
+
 # ----------------------------------------------------------------------
-# 2.  Helper to copy every pathname from one DSS into another
+# 2.  Helper to copy the chosen pathnames from one DSS into another
 # ----------------------------------------------------------------------
-def copy_all_records(source_path, target_path):
-    """Copy every pathname in source_path into target_path."""
+def copy_records(source_path, target_path, pathnames):
+    """Copy the given pathnames of source_path into target_path."""
+    if not pathnames:
+        return 0
     src = None
     try:
         src = HecDss.open(source_path, True)          # read-only
-        paths = src.getCatalogedPathnames(True)
-        if not paths:
-            print("WARNING: no records found in", source_path)
-            return 0
+        paths = Vector()
+        for p in pathnames:
+            paths.add(p)
         status = src.copyRecordsFrom(target_path, paths)
-        return len(paths) if status >= 0 else -1
+        return len(pathnames) if status >= 0 else -1
     finally:
         if src:
             src.done()
@@ -56,11 +63,16 @@ def copy_all_records(source_path, target_path):
 # ----------------------------------------------------------------------
 try:
     total = 0
-    for s in (src1, src2):
-        print("Copying from", os.path.basename(s))
-        n = copy_all_records(s, dest)
+    # Records chosen by the backend's precedence rules, by source name
+    chosen = {}
+    for series in report["series"]:
+        for record in series["records"]:
+            chosen.setdefault(record["source"], []).append(record["pathname"])
+    for name, path in report["files"].items():
+        print("Copying", len(chosen.get(name, [])), "records from", name, "(" + os.path.basename(path) + ")")
+        n = copy_records(path, dest, chosen.get(name, []))
         if n < 0:
-            raise RuntimeError("copyRecordsFrom() failed for " + s)
+            raise RuntimeError("copyRecordsFrom() failed for " + path)
         total += n
 
     # Basic sanity check
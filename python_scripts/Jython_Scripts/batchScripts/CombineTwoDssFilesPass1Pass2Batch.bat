@@ -20,9 +20,9 @@ rem ===== LIMITAR EL PARALELISMO (evita el ConcurrentImporter) ====
 set "JAVA_TOOL_OPTIONS=-Djava.util.concurrent.ForkJoinPool.common.parallelism=1"
 
 rem ===== VERIFICAR ARGUMENTOS =====================================
-if "%~3"=="" (
-    echo ERROR: Se requieren 3 argumentos
-    echo USAGE: %~nx0 ^<RainfallRealTime.dss^> ^<RainfallRealTimePass2.dss^> ^<RainfallRealTimePass1And2.dss^>
+if "%~4"=="" (
+    echo ERROR: Se requieren 4 argumentos
+    echo USAGE: %~nx0 ^<RainfallRealTime.dss^> ^<RainfallRealTimePass2.dss^> ^<RainfallRealTimePass1And2.dss^> ^<RainfallRealTimePass1And2_combine.json^>
     exit /b 1
 )
 
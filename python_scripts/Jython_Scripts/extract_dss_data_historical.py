@@ -19,6 +19,14 @@ TIMEZONE_LABEL = "UTC"           # whatever label your consumer expects
 UNITS_LABEL    = "cfs"           # adjust if your DSS units differ
 # ---------------------------------------------------------------------------
 
+# The backend passes the model's files: <dss_file> [run_token] [output_json]
+if len(sys.argv) > 1:
+    DSS_FILE_PATH = sys.argv[1]
+if len(sys.argv) > 2:
+    RUN_ID_TOKEN = sys.argv[2]
+if len(sys.argv) > 3:
+    OUTPUT_JSON = sys.argv[3]
+
 def format_ht(ht_obj):
     """
     Convert a HecTime that is already set to a Python-style